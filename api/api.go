@@ -0,0 +1,352 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: api.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: JSON REST API, version 1
+
+	In the file
+		1. RegisterRoutes - add all API routes to the router
+		2. Helpers to write JSON responses and errors
+
+	Every error response has the same object:
+		{"error": {"status": 404, "code": "dataset_not_found", "message": "..."}}
+	=============================================================================
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/pages"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Prefix of all API routes
+const apiPrefix = "/api/v1"
+
+// Images per page when request has no 'per_page' parameter
+const defaultPerPage = 20
+const maxPerPage = 500
+
+// Error details in the response
+type ErrorModel struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error response
+type ErrorResponse struct {
+	Error ErrorModel `json:"error"`
+}
+
+// Response with the page of items
+type ListResponse struct {
+	Items      interface{}           `json:"items"`
+	Pagination pages.PaginationModel `json:"pagination"`
+}
+
+/****************************************************************************************
+ *
+ * Function : RegisterRoutes
+ *
+ * Purpose : Add all API routes to the router
+ *
+ *   Input : router *httprouter.Router - server router
+ *
+ *  Return : Nothing
+ */
+func RegisterRoutes(router *httprouter.Router) {
+	// Datasets
+	router.GET(apiPrefix+"/datasets", ListDatasetsHandler)
+	router.POST(apiPrefix+"/datasets", CreateDatasetHandler)
+	router.GET(apiPrefix+"/datasets/:datasetname", GetDatasetHandler)
+	router.PATCH(apiPrefix+"/datasets/:datasetname", UpdateDatasetHandler)
+	router.DELETE(apiPrefix+"/datasets/:datasetname", DeleteDatasetHandler)
+	router.GET(apiPrefix+"/datasets/:datasetname/stats", StatsHandler)
+	router.POST(apiPrefix+"/datasets/:datasetname/clone", CloneDatasetHandler)
+	router.POST(apiPrefix+"/datasets/:datasetname/archive", ArchiveDatasetHandler)
+
+	// Archives
+	router.GET(apiPrefix+"/archives", ListArchivesHandler)
+	router.DELETE(apiPrefix+"/archives/:name", DeleteArchiveHandler)
+	router.POST(apiPrefix+"/archives/:name/restore", RestoreArchiveHandler)
+
+	// Classes
+	router.GET(apiPrefix+"/datasets/:datasetname/classes", GetClassesHandler)
+	router.PUT(apiPrefix+"/datasets/:datasetname/classes", pages.Locked(PutClassesHandler))
+	router.POST(apiPrefix+"/datasets/:datasetname/classes", pages.Locked(ChangeClassesHandler))
+
+	// Splits
+	router.GET(apiPrefix+"/datasets/:datasetname/splits", GetSplitsHandler)
+	router.POST(apiPrefix+"/datasets/:datasetname/splits", pages.Locked(SplitHandler))
+
+	// Images
+	router.GET(apiPrefix+"/datasets/:datasetname/images/:location", ListImagesHandler)
+	router.POST(apiPrefix+"/datasets/:datasetname/images/:location", pages.Locked(UploadImagesHandler))
+	router.GET(apiPrefix+"/datasets/:datasetname/images/:location/:filename", DownloadImageHandler)
+	router.DELETE(apiPrefix+"/datasets/:datasetname/images/:location/:filename", pages.Locked(DeleteImageHandler))
+	router.POST(apiPrefix+"/datasets/:datasetname/images/:location/:filename/move", pages.Locked(MoveImageHandler))
+	router.GET(apiPrefix+"/datasets/:datasetname/images/:location/:filename/metadata", GetMetadataHandler)
+	router.PATCH(apiPrefix+"/datasets/:datasetname/images/:location/:filename/metadata", pages.Locked(UpdateMetadataHandler))
+
+	// Resumable uploads
+	router.OPTIONS(apiPrefix+"/datasets/:datasetname/uploads", UploadOptionsHandler)
+	router.POST(apiPrefix+"/datasets/:datasetname/uploads", pages.Locked(CreateUploadHandler))
+	router.OPTIONS(apiPrefix+"/datasets/:datasetname/uploads/:id", UploadOptionsHandler)
+	router.HEAD(apiPrefix+"/datasets/:datasetname/uploads/:id", UploadOffsetHandler)
+	router.PATCH(apiPrefix+"/datasets/:datasetname/uploads/:id", pages.Locked(PatchUploadHandler))
+	router.DELETE(apiPrefix+"/datasets/:datasetname/uploads/:id", pages.Locked(DeleteUploadHandler))
+
+	// Trash
+	router.GET(apiPrefix+"/datasets/:datasetname/trash", ListTrashHandler)
+	router.POST(apiPrefix+"/datasets/:datasetname/trash", pages.Locked(TrashImagesHandler))
+	router.DELETE(apiPrefix+"/datasets/:datasetname/trash", pages.Locked(PurgeTrashHandler))
+	router.DELETE(apiPrefix+"/datasets/:datasetname/trash/:id", pages.Locked(DeleteTrashHandler))
+	router.POST(apiPrefix+"/datasets/:datasetname/trash/:id/restore", pages.Locked(RestoreTrashHandler))
+
+	// Bulk actions and background jobs
+	router.POST(apiPrefix+"/datasets/:datasetname/bulk", BulkHandler)
+	router.GET(apiPrefix+"/datasets/:datasetname/jobs", ListJobsHandler)
+	router.POST(apiPrefix+"/datasets/:datasetname/jobs", CreateJobHandler)
+	router.GET(apiPrefix+"/datasets/:datasetname/jobs/:id", GetJobHandler)
+	router.POST(apiPrefix+"/datasets/:datasetname/jobs/:id/cancel", CancelJobHandler)
+	router.POST(apiPrefix+"/datasets/:datasetname/jobs/:id/retry", RetryJobHandler)
+	router.GET(apiPrefix+"/datasets/:datasetname/jobs/:id/download", DownloadJobHandler)
+
+	// Live events
+	router.GET(apiPrefix+"/datasets/:datasetname/events", EventsHandler)
+
+	// Catalog
+	router.POST(apiPrefix+"/datasets/:datasetname/catalog", pages.Locked(SyncCatalogHandler))
+
+	// Labels
+	router.GET(apiPrefix+"/datasets/:datasetname/labels/:location/:filename", GetLabelsHandler)
+	router.PUT(apiPrefix+"/datasets/:datasetname/labels/:location/:filename", pages.Locked(PutLabelsHandler))
+
+	// Export
+	router.GET(apiPrefix+"/datasets/:datasetname/export", ExportHandler)
+
+	// Specification
+	router.GET("/api/openapi.json", OpenAPIHandler)
+}
+
+/****************************************************************************************
+ *
+ * Function : writeJSON
+ *
+ * Purpose : Write model as JSON response
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 status int - http status code
+ *			 model interface{} - response model
+ *
+ *  Return : Nothing
+ */
+func writeJSON(w http.ResponseWriter, status int, model interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(model); err != nil {
+		logging.Error_Log("Error write JSON response: '%v'", err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : writeError
+ *
+ * Purpose : Write error response
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 status int - http status code
+ *			 code string - machine readable error code
+ *			 message string - error description
+ *
+ *  Return : Nothing
+ */
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, ErrorResponse{Error: ErrorModel{Status: status, Code: code, Message: message}})
+}
+
+/****************************************************************************************
+ *
+ * Function : writeCoreError
+ *
+ * Purpose : Write error returned by the core methods with matching status
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 err error - error from the core
+ *
+ *  Return : Nothing
+ */
+func writeCoreError(w http.ResponseWriter, err error) {
+	status, code := pages.ErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		logging.Error_Log("API internal error: '%v'", err)
+	}
+
+	writeError(w, status, code, err.Error())
+}
+
+/****************************************************************************************
+ *
+ * Function : readJSON
+ *
+ * Purpose : Decode request body, writes error response when body is wrong
+ *			 Empty body keeps the model unchanged
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 model interface{} - pointer to the model to decode into
+ *
+ *  Return : bool - true if body decoded
+ */
+func readJSON(w http.ResponseWriter, r *http.Request, model interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(model); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "invalid_body", "Request body is not valid: "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+/****************************************************************************************
+ *
+ * Function : openDataset
+ *
+ * Purpose : Open dataset from the request parameters, writes error response on fail
+ *			 Request to the renamed dataset is redirected to the new name
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : core.Dataset - dataset
+ *			 bool - true if dataset opened
+ */
+func openDataset(w http.ResponseWriter, r *http.Request, p httprouter.Params) (core.Dataset, bool) {
+	dataset, err := core.OpenDataset(p.ByName("datasetname"))
+	if err != nil {
+		prefix := apiPrefix + "/datasets/" + p.ByName("datasetname")
+		if current, renamed := core.RenamedDataset(p.ByName("datasetname")); renamed && strings.HasPrefix(r.URL.Path, prefix) {
+			redirect := *r.URL
+			redirect.Path = apiPrefix + "/datasets/" + current + strings.TrimPrefix(r.URL.Path, prefix)
+			redirect.RawPath = ""
+			http.Redirect(w, r, redirect.String(), http.StatusPermanentRedirect)
+			return dataset, false
+		}
+		writeCoreError(w, err)
+		return dataset, false
+	}
+
+	return dataset, true
+}
+
+/****************************************************************************************
+ *
+ * Function : getPagination
+ *
+ * Purpose : Read 'page' and 'per_page' query parameters
+ *
+ *   Input : r *http.Request - request detials
+ *
+ *  Return : int64 - page number, starts from 1
+ *			 int64 - items per page
+ */
+func getPagination(r *http.Request) (int64, int64) {
+	page, err := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err := strconv.ParseInt(r.URL.Query().Get("per_page"), 10, 64)
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	return page, perPage
+}
+
+/****************************************************************************************
+ *
+ * Function : paginationModel
+ *
+ * Purpose : Make pagination metadata for the list response
+ *
+ *   Input : page int64 - current page
+ *			 totalItems int64 - total items
+ *			 perPage int64 - items per page
+ *			 url string - url of the list
+ *
+ *  Return : pages.PaginationModel - pagination metadata
+ */
+func paginationModel(page int64, totalItems int64, perPage int64, url string) pages.PaginationModel {
+	return pages.PaginationModel{
+		Page:           page,
+		Items:          totalItems,
+		ItemsPerPage:   perPage,
+		LastPageNumber: pages.GetPaginationPages(totalItems, perPage),
+		Url:            url}
+}
+
+/****************************************************************************************
+ *
+ * Function : pageBounds
+ *
+ * Purpose : Get slice bounds for the page, page after the last one is empty
+ *			 Bounds are compared before multiplying, so the huge page does not overflow
+ *
+ *   Input : page int64 - current page, starts from 1
+ *			 perPage int64 - items per page, positive
+ *			 total int - total items
+ *
+ *  Return : int, int - first and last (exclusive) index
+ */
+func pageBounds(page int64, perPage int64, total int) (int, int) {
+	if page-1 > int64(total)/perPage {
+		return total, total
+	}
+
+	start := int((page - 1) * perPage)
+	end := total
+	if int64(total-start) > perPage {
+		end = start + int(perPage)
+	}
+
+	return start, end
+}
+
+/****************************************************************************************
+ *
+ * Function : pageOffset
+ *
+ * Purpose : Get number of items before the page, huge page gives the offset
+ *			 after all items instead of the overflow
+ *
+ *   Input : page int64 - current page, starts from 1
+ *			 perPage int64 - items per page, positive
+ *
+ *  Return : int - number of items to skip
+ */
+func pageOffset(page int64, perPage int64) int {
+	if page-1 > math.MaxInt32/perPage {
+		return math.MaxInt32
+	}
+
+	return int((page - 1) * perPage)
+}
@@ -0,0 +1,140 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: api_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: Tests of the pagination of the API lists
+
+	In the file
+		1. TestPageBounds - slice bounds of the page
+		2. TestPageOffset - skipped items of the page
+		3. TestListPages - lists with the page after the last one
+	=============================================================================
+*/
+
+package api
+
+import (
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/julienschmidt/httprouter"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : TestPageBounds
+ *
+ * Purpose : Check bounds of the pages, also of the huge page which overflows int64
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    int64
+		perPage int64
+		total   int
+		start   int
+		end     int
+	}{
+		{"first page", 1, 20, 45, 0, 20},
+		{"middle page", 2, 20, 45, 20, 40},
+		{"last page", 3, 20, 45, 40, 45},
+		{"page after the last", 4, 20, 45, 45, 45},
+		{"exactly full pages", 2, 20, 40, 20, 40},
+		{"page after the full pages", 3, 20, 40, 40, 40},
+		{"empty list", 1, 20, 0, 0, 0},
+		{"maximum page", math.MaxInt64, 20, 45, 45, 45},
+		{"maximum page and items per page", math.MaxInt64, math.MaxInt64, 45, 45, 45},
+		{"overflow of the multiply", math.MaxInt64/20 + 2, 20, 45, 45, 45},
+		{"maximum items per page", 1, math.MaxInt64, 45, 0, 45},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end := pageBounds(test.page, test.perPage, test.total)
+			if start != test.start || end != test.end {
+				t.Errorf("bounds [%v:%v], expected [%v:%v]", start, end, test.start, test.end)
+			}
+		})
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestPageOffset
+ *
+ * Purpose : Check skipped items of the page, huge page is after all items
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestPageOffset(t *testing.T) {
+	tests := []struct {
+		page     int64
+		perPage  int64
+		expected int
+	}{
+		{1, 20, 0},
+		{3, 20, 40},
+		{math.MaxInt64, 20, math.MaxInt32},
+		{math.MaxInt64, math.MaxInt64, math.MaxInt32},
+	}
+
+	for _, test := range tests {
+		if offset := pageOffset(test.page, test.perPage); offset != test.expected {
+			t.Errorf("offset of page %v by %v is %v, expected %v", test.page, test.perPage, offset, test.expected)
+		}
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestListPages
+ *
+ * Purpose : Check lists answer with the empty page after the last one
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestListPages(t *testing.T) {
+	previous := core.DatasetsPath
+	core.DatasetsPath = t.TempDir()
+	defer func() {
+		core.CloseCatalogs()
+		core.DatasetsPath = previous
+	}()
+
+	if _, err := core.CreateDataset("cars", core.Descriptor{Task: core.TaskDetect, Tags: []string{}}); err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Join(core.DatasetsPath, core.ArchivesFolder), os.ModePerm)
+
+	router := httprouter.New()
+	RegisterRoutes(router)
+
+	for _, url := range []string{
+		"/api/v1/datasets?page=9223372036854775807",
+		"/api/v1/archives?page=9223372036854775807",
+		"/api/v1/datasets/cars/jobs?page=9223372036854775807",
+		"/api/v1/datasets/cars/trash?page=9223372036854775807",
+		"/api/v1/datasets/cars/images/uploaded?page=9223372036854775807&per_page=500",
+	} {
+		t.Run(url, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+			if recorder.Code != http.StatusOK {
+				t.Errorf("status %v: %v", recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: archives.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: API handlers for the archived datasets
+
+	Links:
+		1. GET /api/v1/archives
+		2. DELETE /api/v1/archives/:name
+		3. POST /api/v1/archives/:name/restore
+	=============================================================================
+*/
+
+package api
+
+import (
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+/****************************************************************************************
+ *
+ * Function : ListArchivesHandler
+ *
+ * Purpose : Response with the page of archived datasets
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func ListArchivesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	archives, err := core.ListArchives()
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	page, perPage := getPagination(r)
+	start, end := pageBounds(page, perPage, len(archives))
+
+	writeJSON(w, http.StatusOK, ListResponse{
+		Items:      archives[start:end],
+		Pagination: paginationModel(page, int64(len(archives)), perPage, apiPrefix+"/archives")})
+}
+
+/****************************************************************************************
+ *
+ * Function : RestoreArchiveHandler
+ *
+ * Purpose : Extract the archive into the dataset, the body can have the new name
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func RestoreArchiveHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	var request DatasetRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	dataset, err := core.RestoreArchive(p.ByName("name"), request.Name)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: archive '%v' restored to dataset '%v'", p.ByName("name"), dataset.Name)
+	w.Header().Set("Location", apiPrefix+"/datasets/"+dataset.Name)
+	writeJSON(w, http.StatusCreated, datasetModel(dataset))
+}
+
+/****************************************************************************************
+ *
+ * Function : DeleteArchiveHandler
+ *
+ * Purpose : Delete the archive forever
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func DeleteArchiveHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	if err := core.DeleteArchive(p.ByName("name")); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: archive '%v' deleted", p.ByName("name"))
+	w.WriteHeader(http.StatusNoContent)
+}
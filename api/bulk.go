@@ -0,0 +1,93 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: bulk.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: API handler for the bulk actions running as background jobs
+
+	Images of the bulk action are the selected 'location/name' list or all
+	images of the location matching filters in the query parameters.
+
+	Links:
+		1. POST /api/v1/datasets/:datasetname/bulk
+	=============================================================================
+*/
+
+package api
+
+import (
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/jobs"
+	"github.com/CoderSergiy/yolov8-dataset/pages"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// Request of the bulk action
+type BulkRequest struct {
+	core.BulkOperation
+	Images   []string `json:"images,omitempty"`   // Selected images as 'location/name'
+	Location string   `json:"location,omitempty"` // Location filtered by the query parameters when images are not selected
+}
+
+/****************************************************************************************
+ *
+ * Function : BulkHandler
+ *
+ * Purpose : Queue the job of the bulk action, response with the job
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func BulkHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var request BulkRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	images := []core.ImageRef{}
+	if len(request.Images) > 0 {
+		for _, value := range request.Images {
+			image, err := core.ParseImageRef(value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_name", "Image '"+value+"' is not in 'location/name' format")
+				return
+			}
+			images = append(images, image)
+		}
+	} else if request.Location != "" {
+		// Location of the body is used, 'split' parameter is ignored
+		filter := pages.GetImageFilter(r.URL.Query())
+		filter.Split = ""
+		query, err := filter.Query(request.Location)
+		if err != nil {
+			writeCoreError(w, err)
+			return
+		}
+		if images, err = dataset.SelectImages(query); err != nil {
+			writeCoreError(w, err)
+			return
+		}
+	} else {
+		writeError(w, http.StatusBadRequest, "no_images", "Request has no images or location")
+		return
+	}
+
+	job, err := jobs.StartBulk(dataset, request.BulkOperation, images)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: bulk '%v' of [%v] images of '%v' queued as job '%v'", request.Action, len(images), dataset.Name, job.Id)
+	writeJSON(w, http.StatusAccepted, job)
+}
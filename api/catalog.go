@@ -0,0 +1,133 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: catalog.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: API handlers for the images catalog of the dataset
+
+	Links:
+		1. POST /api/v1/datasets/:datasetname/catalog
+		2. GET, PATCH /api/v1/datasets/:datasetname/images/:location/:filename/metadata
+	=============================================================================
+*/
+
+package api
+
+import (
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// Request to synchronise the catalog
+type CatalogRequest struct {
+	Rebuild bool `json:"rebuild"`
+}
+
+// Request to update the image record, missing fields are not changed
+type MetadataRequest struct {
+	Tags     []string `json:"tags"`
+	Reviewed *bool    `json:"reviewed"`
+}
+
+/****************************************************************************************
+ *
+ * Function : SyncCatalogHandler
+ *
+ * Purpose : Synchronise catalog with the files, rebuild reads all images again
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func SyncCatalogHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var request CatalogRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	result, err := dataset.SyncCatalog(request.Rebuild)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: catalog of '%v' synchronised, rebuild: %v", dataset.Name, request.Rebuild)
+	writeJSON(w, http.StatusOK, result)
+}
+
+/****************************************************************************************
+ *
+ * Function : GetMetadataHandler
+ *
+ * Purpose : Response with the catalog record of the image
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func GetMetadataHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	if _, err := dataset.StatImage(p.ByName("location"), p.ByName("filename")); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	record, err := dataset.GetImageRecord(p.ByName("location"), p.ByName("filename"))
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, record)
+}
+
+/****************************************************************************************
+ *
+ * Function : UpdateMetadataHandler
+ *
+ * Purpose : Set tags and review status of the image
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func UpdateMetadataHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var request MetadataRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	if _, err := dataset.StatImage(p.ByName("location"), p.ByName("filename")); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	record, err := dataset.UpdateImageRecord(p.ByName("location"), p.ByName("filename"), request.Tags, request.Reviewed)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, record)
+}
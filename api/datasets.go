@@ -0,0 +1,625 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: datasets.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: API handlers for datasets, classes, splits and statistics
+
+	Links:
+		1. GET, POST /api/v1/datasets
+		2. GET, PATCH, DELETE /api/v1/datasets/:datasetname
+		3. GET /api/v1/datasets/:datasetname/stats
+		4. POST /api/v1/datasets/:datasetname/clone
+		5. POST /api/v1/datasets/:datasetname/archive
+		6. GET, PUT, POST /api/v1/datasets/:datasetname/classes
+		7. GET, POST /api/v1/datasets/:datasetname/splits
+	=============================================================================
+*/
+
+package api
+
+import (
+	"fmt"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/jobs"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strings"
+)
+
+// Dataset details in the response
+type DatasetModel struct {
+	Name       string           `json:"name"`
+	Classes    []string         `json:"classes"`
+	Descriptor *core.Descriptor `json:"descriptor"` // Null for the folder without the valid descriptor
+}
+
+// Request to create, rename or describe the dataset
+type DatasetRequest struct {
+	Name       string             `json:"name"`
+	Descriptor *core.Descriptor   `json:"descriptor,omitempty"` // Description, task, owner, tags and cover, times are ignored
+	Merge      *core.MergeOptions `json:"merge,omitempty"`      // Sources to merge into the new dataset by the job
+	Adopt      *core.AdoptOptions `json:"adopt,omitempty"`      // Ultralytics dataset on the server copied into the new dataset by the job
+}
+
+// Request to delete the dataset
+type DeleteRequest struct {
+	Confirm string `json:"confirm"` // Name of the dataset typed again to confirm the delete
+}
+
+// Request to clone the dataset
+type CloneRequest struct {
+	Name       string `json:"name"`        // Name of the clone
+	ImagesOnly bool   `json:"images_only"` // Copy images with labels and classes only, without versions, models and trash
+}
+
+// Classes of the dataset
+type ClassesModel struct {
+	Names []string `json:"names"`
+}
+
+/****************************************************************************************
+ *
+ * Function : ListDatasetsHandler
+ *
+ * Purpose : Response with the list of datasets
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func ListDatasetsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	names, err := core.ListDatasets()
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	page, perPage := getPagination(r)
+	start, end := pageBounds(page, perPage, len(names))
+
+	datasets := []DatasetModel{}
+	for _, name := range names[start:end] {
+		datasets = append(datasets, datasetModel(core.Dataset{Name: name, Path: core.DatasetPath(name)}))
+	}
+
+	writeJSON(w, http.StatusOK, ListResponse{
+		Items:      datasets,
+		Pagination: paginationModel(page, int64(len(names)), perPage, apiPrefix+"/datasets")})
+}
+
+/****************************************************************************************
+ *
+ * Function : CreateDatasetHandler
+ *
+ * Purpose : Create a new dataset
+ *			 Dataset with the merge or adoption is created empty and filled by the job
+ *			 Multipart form with the zip archive adopts the uploaded Ultralytics dataset
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func CreateDatasetHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		adoptArchive(w, r)
+		return
+	}
+
+	var request DatasetRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if request.Merge != nil && request.Adopt != nil {
+		writeCoreError(w, fmt.Errorf("%w: dataset cannot be merged and adopted together", core.ErrInvalidAdoption))
+		return
+	}
+	if request.Merge != nil {
+		if err := request.Merge.Validate(request.Name); err != nil {
+			writeCoreError(w, err)
+			return
+		}
+	}
+
+	descriptor := core.Descriptor{}
+	if request.Descriptor != nil {
+		descriptor = *request.Descriptor
+	}
+
+	// Source is checked before the dataset is created
+	if request.Adopt != nil {
+		plan, err := core.PlanAdoption(request.Adopt.Source)
+		if err != nil {
+			writeCoreError(w, err)
+			return
+		}
+		startAdoption(w, request.Name, descriptor, plan)
+		return
+	}
+
+	dataset, err := core.CreateDataset(request.Name, descriptor)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: dataset '%v' created", dataset.Name)
+	w.Header().Set("Location", apiPrefix+"/datasets/"+dataset.Name)
+	if request.Merge == nil {
+		writeJSON(w, http.StatusCreated, datasetModel(dataset))
+		return
+	}
+
+	job, err := jobs.Enqueue(jobs.TypeMerge, dataset.Name, request.Merge)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: job '%v' merges %v into '%v'", job.Id, request.Merge.Sources, dataset.Name)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+/****************************************************************************************
+ *
+ * Function : adoptArchive
+ *
+ * Purpose : Check the uploaded zip archive of the Ultralytics dataset and adopt it
+ *			 Form has 'name', 'description', 'task', 'owner', comma separated 'tags'
+ *			 and the 'archive' file
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *
+ *  Return : Nothing
+ */
+func adoptArchive(w http.ResponseWriter, r *http.Request) {
+	archive, _, err := r.FormFile("archive")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_form", "Form has no 'archive' file: "+err.Error())
+		return
+	}
+	defer archive.Close()
+	if r.MultipartForm != nil {
+		defer r.MultipartForm.RemoveAll()
+	}
+
+	plan, err := core.PlanAdoptionArchive(archive)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	descriptor := core.Descriptor{
+		Description: r.FormValue("description"),
+		Task:        r.FormValue("task"),
+		Owner:       r.FormValue("owner"),
+		Tags:        strings.Split(r.FormValue("tags"), ",")}
+	startAdoption(w, r.FormValue("name"), descriptor, plan)
+}
+
+/****************************************************************************************
+ *
+ * Function : startAdoption
+ *
+ * Purpose : Create the dataset and queue the job copying the checked source into it
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 name string - name of the new dataset
+ *			 descriptor core.Descriptor - descriptor of the new dataset
+ *			 plan core.AdoptPlan - checked source
+ *
+ *  Return : Nothing
+ */
+func startAdoption(w http.ResponseWriter, name string, descriptor core.Descriptor, plan core.AdoptPlan) {
+	dataset, err := core.CreateDataset(name, descriptor)
+	if err != nil {
+		plan.Discard()
+		writeCoreError(w, err)
+		return
+	}
+
+	job, err := jobs.Enqueue(jobs.TypeAdopt, dataset.Name, plan)
+	if err != nil {
+		plan.Discard()
+		writeJobError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: job '%v' adopts '%v' as dataset '%v'", job.Id, plan.Folder, dataset.Name)
+	w.Header().Set("Location", apiPrefix+"/datasets/"+dataset.Name)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+/****************************************************************************************
+ *
+ * Function : GetDatasetHandler
+ *
+ * Purpose : Response with the dataset details
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func GetDatasetHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, datasetModel(dataset))
+}
+
+/****************************************************************************************
+ *
+ * Function : UpdateDatasetHandler
+ *
+ * Purpose : Change the descriptor and rename the dataset
+ *			 Empty or the same name keeps the dataset name
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func UpdateDatasetHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var request DatasetRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	// Descriptor is changed first, the invalid one keeps the name as well
+	if request.Descriptor != nil {
+		unlock := core.LockDataset(dataset.Name)
+		_, err := dataset.SetDescriptor(*request.Descriptor)
+		unlock()
+		if err != nil {
+			writeCoreError(w, err)
+			return
+		}
+		logging.Info_Log("API: descriptor of dataset '%v' changed", dataset.Name)
+	}
+
+	if request.Name != "" && request.Name != dataset.Name {
+		var renamed core.Dataset
+		err := jobs.Exclusive(dataset.Name, func() (err error) {
+			renamed, err = core.RenameDataset(dataset.Name, request.Name)
+			return err
+		})
+		if err != nil {
+			writeCoreError(w, err)
+			return
+		}
+		logging.Info_Log("API: dataset '%v' renamed to '%v'", dataset.Name, renamed.Name)
+		dataset = renamed
+	}
+
+	writeJSON(w, http.StatusOK, datasetModel(dataset))
+}
+
+/****************************************************************************************
+ *
+ * Function : DeleteDatasetHandler
+ *
+ * Purpose : Delete the dataset with all files, name is typed again in the body to confirm
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func DeleteDatasetHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	name := p.ByName("datasetname")
+
+	var request DeleteRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if request.Confirm != name {
+		writeError(w, http.StatusBadRequest, "confirmation_required", "Type the dataset name in 'confirm' to delete it")
+		return
+	}
+
+	err := jobs.Exclusive(name, func() error {
+		return core.DeleteDataset(name)
+	})
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: dataset '%v' deleted", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/****************************************************************************************
+ *
+ * Function : CloneDatasetHandler
+ *
+ * Purpose : Copy the dataset under the new name
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func CloneDatasetHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var request CloneRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	clone, err := core.CloneDataset(dataset.Name, request.Name, request.ImagesOnly)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: dataset '%v' cloned to '%v'", dataset.Name, clone.Name)
+	w.Header().Set("Location", apiPrefix+"/datasets/"+clone.Name)
+	writeJSON(w, http.StatusCreated, datasetModel(clone))
+}
+
+/****************************************************************************************
+ *
+ * Function : ArchiveDatasetHandler
+ *
+ * Purpose : Move the dataset to the zip archive
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func ArchiveDatasetHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var archive core.ArchiveInfo
+	err := jobs.Exclusive(dataset.Name, func() (err error) {
+		archive, err = core.ArchiveDataset(dataset.Name)
+		return err
+	})
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: dataset '%v' archived", dataset.Name)
+	w.Header().Set("Location", apiPrefix+"/archives")
+	writeJSON(w, http.StatusCreated, archive)
+}
+
+/****************************************************************************************
+ *
+ * Function : StatsHandler
+ *
+ * Purpose : Response with the dataset statistics
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func StatsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	stats, err := dataset.Stats()
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+/****************************************************************************************
+ *
+ * Function : GetClassesHandler
+ *
+ * Purpose : Response with classes names from the data.yaml
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func GetClassesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	names, err := dataset.Classes()
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ClassesModel{Names: names})
+}
+
+/****************************************************************************************
+ *
+ * Function : PutClassesHandler
+ *
+ * Purpose : Replace classes names in the data.yaml
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func PutClassesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var request ClassesModel
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if request.Names == nil {
+		request.Names = []string{}
+	}
+
+	if err := dataset.SetClasses(request.Names); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, request)
+}
+
+/****************************************************************************************
+ *
+ * Function : ChangeClassesHandler
+ *
+ * Purpose : Merge, delete or reorder classes together with the label files
+ *			 Dry run responses with the changes without doing them
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func ChangeClassesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var operation core.ClassOperation
+	if !readJSON(w, r, &operation) {
+		return
+	}
+
+	report, err := dataset.ChangeClasses(operation)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	if !operation.DryRun {
+		logging.Info_Log("API: classes of '%v' changed by '%v': %v label files, %v images moved to the trash", dataset.Name, operation.Operation, report.Files, report.Images)
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+/****************************************************************************************
+ *
+ * Function : GetSplitsHandler
+ *
+ * Purpose : Response with statistics of every split
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func GetSplitsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	stats, err := dataset.Stats()
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	splits := make(map[string]core.LocationStats)
+	for _, split := range core.Splits {
+		splits[split] = stats.Locations[split]
+	}
+
+	writeJSON(w, http.StatusOK, splits)
+}
+
+/****************************************************************************************
+ *
+ * Function : SplitHandler
+ *
+ * Purpose : Move uploaded images to the splits by the ratios from the request
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func SplitHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	options := core.DefaultSplitOptions
+	if !readJSON(w, r, &options) {
+		return
+	}
+
+	moved, err := dataset.SplitUploaded(options)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "split_failed", err.Error())
+		return
+	}
+
+	logging.Info_Log("API: dataset '%v' split: %v", dataset.Name, moved)
+	writeJSON(w, http.StatusOK, moved)
+}
+
+/****************************************************************************************
+ *
+ * Function : datasetModel
+ *
+ * Purpose : Make dataset details for the response
+ *
+ *   Input : dataset core.Dataset - dataset
+ *
+ *  Return : DatasetModel - dataset details
+ */
+func datasetModel(dataset core.Dataset) DatasetModel {
+	model := DatasetModel{Name: dataset.Name, Classes: []string{}}
+	if classes, err := dataset.Classes(); err == nil {
+		model.Classes = classes
+	}
+	if descriptor, err := dataset.Descriptor(); err == nil {
+		model.Descriptor = &descriptor
+	}
+
+	return model
+}
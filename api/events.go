@@ -0,0 +1,124 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: events.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: Server-Sent Events stream of the dataset
+
+	Every event is sent with its id and type, the data is the JSON event.
+	Browser reconnects with 'Last-Event-ID' header and receives missed
+	events of the history.
+
+	Links:
+		1. GET /api/v1/datasets/:datasetname/events
+		2. https://html.spec.whatwg.org/multipage/server-sent-events.html
+	=============================================================================
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/events"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Comment sent to keep the idle connection open through the proxies
+const eventsHeartbeat = 15 * time.Second
+
+// Time for the browser to wait before reconnect
+const eventsRetry = 3 * time.Second
+
+/****************************************************************************************
+ *
+ * Function : EventsHandler
+ *
+ * Purpose : Stream events of the dataset until the client disconnects
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func EventsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "no_streaming", "Server cannot stream events")
+		return
+	}
+
+	// Browser sends the header on reconnect, 'last_id' is used by other clients
+	lastId, _ := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	if lastId == 0 {
+		lastId, _ = strconv.ParseInt(r.URL.Query().Get("last_id"), 10, 64)
+	}
+
+	channel, missed := events.Subscribe(dataset.Name, lastId)
+	defer events.Unsubscribe(dataset.Name, channel)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetry.Milliseconds())
+
+	for _, event := range missed {
+		if writeEvent(w, event) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	logging.Info_Log("API: events of '%v' streamed to '%v'", dataset.Name, r.RemoteAddr)
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, open := <-channel:
+			if !open || writeEvent(w, event) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : writeEvent
+ *
+ * Purpose : Write event in the Server-Sent Events format
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 event events.Event - event
+ *
+ *  Return : error - error if connection is closed
+ */
+func writeEvent(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+	return err
+}
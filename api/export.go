@@ -0,0 +1,70 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: export.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: API handlers for the dataset export and the API specification
+
+	Links:
+		1. GET /api/v1/datasets/:datasetname/export
+		2. GET /api/openapi.json
+	=============================================================================
+*/
+
+package api
+
+import (
+	_ "embed"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// OpenAPI 3 specification of the API
+//
+//go:embed openapi.json
+var openAPISpecification []byte
+
+/****************************************************************************************
+ *
+ * Function : ExportHandler
+ *
+ * Purpose : Response with the zip archive of the dataset ready for training
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func ExportHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+dataset.Name+".zip\"")
+
+	// Archive is streamed, so the status is already sent when error occur
+	if err := dataset.Export(w); err != nil {
+		logging.Error_Log("API: export of '%v' failed: '%v'", dataset.Name, err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : OpenAPIHandler
+ *
+ * Purpose : Response with the OpenAPI specification
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpecification)
+}
@@ -0,0 +1,306 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: images.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: API handlers for images and labels
+
+	Location is 'uploaded' or one of the splits: 'train', 'valid', 'test'
+	Images list accepts 'all' location and the filters described in pages/filters.go
+
+	Links:
+		1. GET, POST /api/v1/datasets/:datasetname/images/:location
+		2. GET, DELETE /api/v1/datasets/:datasetname/images/:location/:filename
+		3. POST /api/v1/datasets/:datasetname/images/:location/:filename/move
+		4. GET, PUT /api/v1/datasets/:datasetname/labels/:location/:filename
+
+	Uploads and label changes are published to the events of the dataset
+	=============================================================================
+*/
+
+package api
+
+import (
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/events"
+	"github.com/CoderSergiy/yolov8-dataset/pages"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// Request to move image to another location
+type MoveRequest struct {
+	To string `json:"to"`
+}
+
+// Labels of the image
+type LabelsModel struct {
+	Image  string       `json:"image"`
+	Labels []core.Label `json:"labels"`
+}
+
+/****************************************************************************************
+ *
+ * Function : ListImagesHandler
+ *
+ * Purpose : Response with the page of images in the location from the catalog
+ *			 Location 'all' lists images of all locations, filters are query parameters
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func ListImagesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	// Location of the path is used, 'split' parameter is ignored
+	filter := pages.GetImageFilter(r.URL.Query())
+	filter.Split = ""
+	query, err := filter.Query(p.ByName("location"))
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	page, perPage := getPagination(r)
+	query.Offset = pageOffset(page, perPage)
+	query.Limit = int(perPage)
+	images, total, err := dataset.QueryImages(query)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	pagination := paginationModel(page, int64(total), perPage, r.URL.Path)
+	pagination.Query = filter.Values().Encode()
+	writeJSON(w, http.StatusOK, ListResponse{Items: images, Pagination: pagination})
+}
+
+/****************************************************************************************
+ *
+ * Function : UploadImagesHandler
+ *
+ * Purpose : Stream images of the 'image' field of the multipart form to the location
+ *			 and wait until the workers make catalog records and thumbnails
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func UploadImagesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+	location := p.ByName("location")
+	if !core.IsLocation(location) {
+		writeCoreError(w, core.ErrInvalidLocation)
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_form", "Request is not a multipart form: "+err.Error())
+		return
+	}
+
+	images, err := dataset.SaveImages(location, core.MultipartImages(reader, "image"))
+	if err != nil {
+		logging.Error_Log("API: images are not uploaded to '%v/%v': '%v'", dataset.Name, location, err)
+		writeCoreError(w, err)
+		return
+	}
+
+	names := []string{}
+	for _, image := range images {
+		names = append(names, image.Name)
+	}
+	events.ImagesUploaded(dataset.Name, location, names)
+
+	logging.Info_Log("API: [%v] images uploaded to '%v/%v'", len(images), dataset.Name, location)
+	writeJSON(w, http.StatusCreated, ListResponse{
+		Items:      images,
+		Pagination: paginationModel(1, int64(len(images)), int64(len(images)), r.URL.Path)})
+}
+
+/****************************************************************************************
+ *
+ * Function : DownloadImageHandler
+ *
+ * Purpose : Response with the image file
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func DownloadImageHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	if _, err := dataset.StatImage(p.ByName("location"), p.ByName("filename")); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	imagePath, _ := dataset.ImagePath(p.ByName("location"), p.ByName("filename"))
+	http.ServeFile(w, r, imagePath)
+}
+
+/****************************************************************************************
+ *
+ * Function : DeleteImageHandler
+ *
+ * Purpose : Move the image together with the label to the trash
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func DeleteImageHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	if _, err := dataset.TrashImage(p.ByName("location"), p.ByName("filename")); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: image '%v/%v/%v' moved to the trash", dataset.Name, p.ByName("location"), p.ByName("filename"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/****************************************************************************************
+ *
+ * Function : MoveImageHandler
+ *
+ * Purpose : Move the image together with the label to another location
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func MoveImageHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var request MoveRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	if err := dataset.MoveImage(p.ByName("location"), request.To, p.ByName("filename")); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	image, err := dataset.StatImage(request.To, p.ByName("filename"))
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, image)
+}
+
+/****************************************************************************************
+ *
+ * Function : GetLabelsHandler
+ *
+ * Purpose : Response with labels of the image
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func GetLabelsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	if _, err := dataset.StatImage(p.ByName("location"), p.ByName("filename")); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	labels, err := dataset.ReadLabels(p.ByName("location"), p.ByName("filename"))
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, LabelsModel{Image: p.ByName("filename"), Labels: labels})
+}
+
+/****************************************************************************************
+ *
+ * Function : PutLabelsHandler
+ *
+ * Purpose : Replace labels of the image
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func PutLabelsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var request LabelsModel
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if request.Labels == nil {
+		request.Labels = []core.Label{}
+	}
+
+	classes, err := dataset.Classes()
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	if err := core.ValidateLabels(request.Labels, len(classes)); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	if err := dataset.WriteLabels(p.ByName("location"), p.ByName("filename"), request.Labels); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	events.LabelsChanged(dataset.Name, events.LabelsData{
+		Location: p.ByName("location"),
+		Image:    p.ByName("filename"),
+		Boxes:    len(request.Labels),
+		Client:   r.Header.Get("X-Client-Id")})
+
+	request.Image = p.ByName("filename")
+	writeJSON(w, http.StatusOK, request)
+}
@@ -0,0 +1,296 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: jobs.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: API handlers for the background jobs of the dataset
+
+	Links:
+		1. GET, POST /api/v1/datasets/:datasetname/jobs
+		2. GET /api/v1/datasets/:datasetname/jobs/:id
+		3. POST /api/v1/datasets/:datasetname/jobs/:id/cancel
+		4. POST /api/v1/datasets/:datasetname/jobs/:id/retry
+		5. GET /api/v1/datasets/:datasetname/jobs/:id/download
+	=============================================================================
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/jobs"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// Request to queue the job, bulk actions are queued by the bulk endpoint
+type JobRequest struct {
+	Type   string          `json:"type"`   // 'export', 'version', 'catalog', 'import' or 'lint'
+	Params json.RawMessage `json:"params"` // Parameters of the job type
+}
+
+/****************************************************************************************
+ *
+ * Function : ListJobsHandler
+ *
+ * Purpose : Response with the page of jobs of the dataset, the latest first
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func ListJobsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	list := jobs.List(dataset.Name)
+	page, perPage := getPagination(r)
+	start, end := pageBounds(page, perPage, len(list))
+
+	writeJSON(w, http.StatusOK, ListResponse{
+		Items:      list[start:end],
+		Pagination: paginationModel(page, int64(len(list)), perPage, r.URL.Path)})
+}
+
+/****************************************************************************************
+ *
+ * Function : CreateJobHandler
+ *
+ * Purpose : Queue the job of the dataset operation
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func CreateJobHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var request JobRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	// Parameters are checked here, the job fails later otherwise
+	var params interface{}
+	switch request.Type {
+	case jobs.TypeExport, jobs.TypeLint:
+		params = struct{}{}
+	case jobs.TypeVersion:
+		params = &core.VersionOptions{}
+	case jobs.TypeCatalog:
+		params = &jobs.CatalogParams{}
+	case jobs.TypeImport:
+		params = &jobs.ImportParams{}
+	case jobs.TypeSynthesize:
+		params = &core.SynthesizeOptions{}
+	case jobs.TypeMerge:
+		writeError(w, http.StatusBadRequest, "unknown_job_type", "Merge job is queued by creating the dataset with 'merge' parameter")
+		return
+	case jobs.TypeAdopt:
+		writeError(w, http.StatusBadRequest, "unknown_job_type", "Adopt job is queued by creating the dataset with 'adopt' parameter or zip archive")
+		return
+	default:
+		writeError(w, http.StatusBadRequest, "unknown_job_type", "Job type '"+request.Type+"' is not known, bulk actions use the bulk endpoint")
+		return
+	}
+	if _, empty := params.(struct{}); len(request.Params) > 0 && !empty {
+		if err := json.Unmarshal(request.Params, params); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_params", "Job parameters are not valid: "+err.Error())
+			return
+		}
+	}
+	if importParams, ok := params.(*jobs.ImportParams); ok && importParams.Folder == "" {
+		writeError(w, http.StatusBadRequest, "invalid_params", "Import job needs 'folder' parameter")
+		return
+	}
+	if versionParams, ok := params.(*core.VersionOptions); ok {
+		if err := versionParams.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_params", err.Error())
+			return
+		}
+	}
+	if synthesizeParams, ok := params.(*core.SynthesizeOptions); ok {
+		if _, err := synthesizeParams.Resolve(); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_params", err.Error())
+			return
+		}
+	}
+
+	job, err := jobs.Enqueue(request.Type, dataset.Name, params)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: job '%v' of type '%v' queued for '%v'", job.Id, job.Type, dataset.Name)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+/****************************************************************************************
+ *
+ * Function : GetJobHandler
+ *
+ * Purpose : Response with the job and its progress
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func GetJobHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	job, ok := datasetJob(w, r, p)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+/****************************************************************************************
+ *
+ * Function : CancelJobHandler
+ *
+ * Purpose : Cancel queued or running job
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func CancelJobHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	if _, ok := datasetJob(w, r, p); !ok {
+		return
+	}
+
+	job, err := jobs.Cancel(p.ByName("id"))
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+/****************************************************************************************
+ *
+ * Function : RetryJobHandler
+ *
+ * Purpose : Queue failed or cancelled job again
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func RetryJobHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	if _, ok := datasetJob(w, r, p); !ok {
+		return
+	}
+
+	job, err := jobs.Retry(p.ByName("id"))
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+/****************************************************************************************
+ *
+ * Function : DownloadJobHandler
+ *
+ * Purpose : Response with the output file of the finished job, e.g. zip archive
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func DownloadJobHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	if _, ok := datasetJob(w, r, p); !ok {
+		return
+	}
+
+	path, job, err := jobs.OutputFile(p.ByName("id"))
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+job.Output+"\"")
+	http.ServeFile(w, r, path)
+}
+
+/****************************************************************************************
+ *
+ * Function : datasetJob
+ *
+ * Purpose : Get job of the dataset from the request, writes error response if not found
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : jobs.Job - job
+ *			 bool - true if job found
+ */
+func datasetJob(w http.ResponseWriter, r *http.Request, p httprouter.Params) (jobs.Job, bool) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return jobs.Job{}, false
+	}
+
+	job, err := jobs.Get(p.ByName("id"))
+	if err == nil && job.Dataset != dataset.Name {
+		err = jobs.ErrJobNotFound
+	}
+	if err != nil {
+		writeJobError(w, err)
+		return jobs.Job{}, false
+	}
+
+	return job, true
+}
+
+/****************************************************************************************
+ *
+ * Function : writeJobError
+ *
+ * Purpose : Write error response for the errors of the jobs
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 err error - error of the jobs package
+ *
+ *  Return : Nothing
+ */
+func writeJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		writeError(w, http.StatusNotFound, "job_not_found", err.Error())
+	case errors.Is(err, jobs.ErrNoOutput):
+		writeError(w, http.StatusConflict, "no_output", err.Error())
+	case errors.Is(err, jobs.ErrJobFinished), errors.Is(err, jobs.ErrJobNotRetryable):
+		writeError(w, http.StatusConflict, "invalid_job_status", err.Error())
+	case errors.Is(err, jobs.ErrUnknownType):
+		writeError(w, http.StatusBadRequest, "unknown_job_type", err.Error())
+	default:
+		writeCoreError(w, err)
+	}
+}
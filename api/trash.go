@@ -0,0 +1,191 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: trash.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: API handlers for the trash of the dataset
+
+	Links:
+		1. GET, POST, DELETE /api/v1/datasets/:datasetname/trash
+		2. DELETE /api/v1/datasets/:datasetname/trash/:id
+		3. POST /api/v1/datasets/:datasetname/trash/:id/restore
+	=============================================================================
+*/
+
+package api
+
+import (
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"time"
+)
+
+// Request to move images of the location to the trash
+type TrashRequest struct {
+	Location string   `json:"location"`
+	Names    []string `json:"names"`
+}
+
+// Result of the trash purge
+type PurgeModel struct {
+	Purged int `json:"purged"`
+}
+
+/****************************************************************************************
+ *
+ * Function : ListTrashHandler
+ *
+ * Purpose : Response with the page of deleted images, the latest first
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func ListTrashHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	items, err := dataset.ListTrash()
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	page, perPage := getPagination(r)
+	start, end := pageBounds(page, perPage, len(items))
+
+	writeJSON(w, http.StatusOK, ListResponse{
+		Items:      items[start:end],
+		Pagination: paginationModel(page, int64(len(items)), perPage, r.URL.Path)})
+}
+
+/****************************************************************************************
+ *
+ * Function : TrashImagesHandler
+ *
+ * Purpose : Move images of the location with their labels to the trash
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func TrashImagesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var request TrashRequest
+	if !readJSON(w, r, &request) {
+		return
+	}
+	if len(request.Names) == 0 {
+		writeError(w, http.StatusBadRequest, "no_images", "Request has no images names")
+		return
+	}
+
+	result := dataset.TrashImages(request.Location, request.Names)
+
+	logging.Info_Log("API: [%v] images of '%v/%v' moved to the trash, [%v] failed", result.Done, dataset.Name, request.Location, len(result.Failed))
+	writeJSON(w, http.StatusOK, result)
+}
+
+/****************************************************************************************
+ *
+ * Function : RestoreTrashHandler
+ *
+ * Purpose : Move deleted image back to its location
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func RestoreTrashHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	image, err := dataset.RestoreTrash(p.ByName("id"))
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: image '%v/%v/%v' restored from the trash", dataset.Name, image.Location, image.Name)
+	writeJSON(w, http.StatusOK, image)
+}
+
+/****************************************************************************************
+ *
+ * Function : DeleteTrashHandler
+ *
+ * Purpose : Delete image from the trash forever
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func DeleteTrashHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := dataset.DeleteTrash(p.ByName("id")); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/****************************************************************************************
+ *
+ * Function : PurgeTrashHandler
+ *
+ * Purpose : Delete images kept in the trash longer than 'older_than' duration,
+ *			 all images when parameter is not set
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func PurgeTrashHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	var retention time.Duration
+	if value := r.URL.Query().Get("older_than"); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_query", "Parameter 'older_than' is not a valid duration, e.g. '720h'")
+			return
+		}
+		retention = duration
+	}
+
+	purged, err := dataset.PurgeTrash(retention)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: [%v] images of '%v' deleted from the trash", purged, dataset.Name)
+	writeJSON(w, http.StatusOK, PurgeModel{Purged: purged})
+}
@@ -0,0 +1,366 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: uploads.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: Resumable uploads of the images by the tus protocol
+
+	Upload is created with 'Upload-Length' and 'Upload-Metadata' with
+	'filename', optional 'location' ('uploaded' by default) and 'checksum'
+	of the whole image as '<algorithm> <base64 digest>'. Chunks are sent
+	by PATCH from the offset returned by HEAD, 'Upload-Checksum' of the
+	chunk is verified before the chunk is kept.
+
+	Extensions: creation, creation-with-upload, termination, checksum, expiration
+
+	Links:
+		1. OPTIONS, POST /api/v1/datasets/:datasetname/uploads
+		2. HEAD, PATCH, DELETE /api/v1/datasets/:datasetname/uploads/:id
+		3. https://tus.io/protocols/resumable-upload
+	=============================================================================
+*/
+
+package api
+
+import (
+	"encoding/base64"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/events"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Version and extensions of the tus protocol
+const tusVersion = "1.0.0"
+const tusExtensions = "creation,creation-with-upload,termination,checksum,expiration"
+
+// Content type of the chunk
+const tusContentType = "application/offset+octet-stream"
+
+/****************************************************************************************
+ *
+ * Function : UploadOptionsHandler
+ *
+ * Purpose : Response with the tus version, extensions and limits of the server
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func UploadOptionsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	setTusHeaders(w)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(core.MaxUploadSize, 10))
+	w.Header().Set("Tus-Checksum-Algorithm", strings.Join(core.ChecksumAlgorithms, ","))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/****************************************************************************************
+ *
+ * Function : CreateUploadHandler
+ *
+ * Purpose : Create the upload, the body with the first chunk is written at once
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func CreateUploadHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	setTusHeaders(w)
+	if !checkTusVersion(w, r) {
+		return
+	}
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		writeError(w, http.StatusBadRequest, "invalid_upload", "Upload-Defer-Length is not supported, Upload-Length is required")
+		return
+	}
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_upload", "Upload-Length header is required")
+		return
+	}
+
+	metadata := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	location := metadata["location"]
+	if location == "" {
+		location = core.LocationUploaded
+	}
+	if metadata["filename"] == "" {
+		writeError(w, http.StatusBadRequest, "invalid_upload", "Upload-Metadata has no 'filename'")
+		return
+	}
+
+	upload, err := dataset.CreateUpload(location, metadata["filename"], length, metadata["checksum"], r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+	logging.Info_Log("API: upload '%v' of '%v/%v/%v' with [%v] bytes created", upload.Id, dataset.Name, location, upload.Name, length)
+
+	// Creation with upload, the response has the received offset if the chunk is broken
+	// Upload is removed when all bytes are received but the image is not completed
+	if r.Header.Get("Content-Type") == tusContentType && r.ContentLength != 0 {
+		upload, err = writeUploadChunk(dataset, upload.Id, 0, r)
+		if err != nil && (isChunkRejected(err) || upload.Offset == upload.Length) {
+			writeCoreError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Location", apiPrefix+"/datasets/"+dataset.Name+"/uploads/"+upload.Id)
+	setUploadHeaders(w, upload)
+	writeJSON(w, http.StatusCreated, upload)
+}
+
+/****************************************************************************************
+ *
+ * Function : UploadOffsetHandler
+ *
+ * Purpose : Response with the received offset of the upload in the headers
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func UploadOffsetHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	setTusHeaders(w)
+	if !checkTusVersion(w, r) {
+		return
+	}
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	upload, err := dataset.GetUpload(p.ByName("id"))
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	setUploadHeaders(w, upload)
+	if upload.Metadata != "" {
+		w.Header().Set("Upload-Metadata", upload.Metadata)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+/****************************************************************************************
+ *
+ * Function : PatchUploadHandler
+ *
+ * Purpose : Append the chunk to the upload from 'Upload-Offset'
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func PatchUploadHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	setTusHeaders(w)
+	if !checkTusVersion(w, r) {
+		return
+	}
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	if r.Header.Get("Content-Type") != tusContentType {
+		writeError(w, http.StatusUnsupportedMediaType, "invalid_content_type", "Content-Type must be '"+tusContentType+"'")
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_upload", "Upload-Offset header is required")
+		return
+	}
+
+	upload, err := writeUploadChunk(dataset, p.ByName("id"), offset, r)
+	if err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	setUploadHeaders(w, upload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/****************************************************************************************
+ *
+ * Function : DeleteUploadHandler
+ *
+ * Purpose : Remove the upload with received bytes
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func DeleteUploadHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	setTusHeaders(w)
+	if !checkTusVersion(w, r) {
+		return
+	}
+	dataset, ok := openDataset(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := dataset.DeleteUpload(p.ByName("id")); err != nil {
+		writeCoreError(w, err)
+		return
+	}
+
+	logging.Info_Log("API: upload '%v' of '%v' deleted", p.ByName("id"), dataset.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/****************************************************************************************
+ *
+ * Function : writeUploadChunk
+ *
+ * Purpose : Write body of the request to the upload, publish the completed image
+ *
+ *   Input : dataset core.Dataset - dataset
+ *			 id string - upload id
+ *			 offset int64 - offset of the chunk
+ *			 r *http.Request - request with the chunk
+ *
+ *  Return : core.Upload - upload with the new offset
+ *			 error - error of the upload or the connection
+ */
+func writeUploadChunk(dataset core.Dataset, id string, offset int64, r *http.Request) (core.Upload, error) {
+	upload, err := dataset.WriteUpload(id, offset, r.Body, r.Header.Get("Upload-Checksum"))
+	if err != nil {
+		logging.Error_Log("API: chunk of upload '%v' of '%v' from [%v] is not written, received [%v] bytes: '%v'", id, dataset.Name, offset, upload.Offset, err)
+		return upload, err
+	}
+
+	if upload.Completed && offset < upload.Length {
+		events.ImagesUploaded(dataset.Name, upload.Location, []string{upload.Name})
+		logging.Info_Log("API: upload '%v' completed as '%v/%v/%v'", upload.Id, dataset.Name, upload.Location, upload.Name)
+	}
+
+	return upload, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : isChunkRejected
+ *
+ * Purpose : Check if the chunk is rejected by the upload, not by the broken connection
+ *
+ *   Input : err error - error of the chunk
+ *
+ *  Return : bool - true if the error is sent to the client
+ */
+func isChunkRejected(err error) bool {
+	switch err {
+	case core.ErrChecksumMismatch, core.ErrInvalidChecksum, core.ErrUploadTooLarge, core.ErrUploadLocked, core.ErrImageExists:
+		return true
+	}
+
+	return false
+}
+
+/****************************************************************************************
+ *
+ * Function : parseUploadMetadata
+ *
+ * Purpose : Parse 'Upload-Metadata' header, comma separated keys with base64 values
+ *
+ *   Input : header string - header value
+ *
+ *  Return : map[string]string - decoded values, key without value has empty value
+ */
+func parseUploadMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 {
+			continue
+		}
+		value := ""
+		if len(fields) > 1 {
+			decoded, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				continue
+			}
+			value = string(decoded)
+		}
+		metadata[fields[0]] = value
+	}
+
+	return metadata
+}
+
+/****************************************************************************************
+ *
+ * Function : setTusHeaders
+ *
+ * Purpose : Set headers of every tus response
+ *
+ *   Input : w http.ResponseWriter - output value
+ *
+ *  Return : Nothing
+ */
+func setTusHeaders(w http.ResponseWriter) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+/****************************************************************************************
+ *
+ * Function : setUploadHeaders
+ *
+ * Purpose : Set offset, length and expiration of the upload
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 upload core.Upload - upload
+ *
+ *  Return : Nothing
+ */
+func setUploadHeaders(w http.ResponseWriter, upload core.Upload) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	w.Header().Set("Upload-Expires", upload.Expires().UTC().Format(http.TimeFormat))
+}
+
+/****************************************************************************************
+ *
+ * Function : checkTusVersion
+ *
+ * Purpose : Check 'Tus-Resumable' header of the request, writes error response if not supported
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *
+ *  Return : bool - true if version is supported
+ */
+func checkTusVersion(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Tus-Resumable") == tusVersion {
+		return true
+	}
+
+	w.Header().Set("Tus-Version", tusVersion)
+	writeError(w, http.StatusPreconditionFailed, "unsupported_version", "Tus-Resumable header must be '"+tusVersion+"'")
+	return false
+}
@@ -0,0 +1,93 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: uploads_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/api
+	Purpose: Tests of the tus uploads
+
+	In the file
+		1. TestCreateUploadWithChunk - status of the creation with upload
+	=============================================================================
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/julienschmidt/httprouter"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : TestCreateUploadWithChunk
+ *
+ * Purpose : Check upload created with the whole image is completed, name of the stored
+ *			 image, the rejected chunk and the failed completion are answered with the error
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestCreateUploadWithChunk(t *testing.T) {
+	previous := core.DatasetsPath
+	core.DatasetsPath = t.TempDir()
+	defer func() {
+		core.CloseCatalogs()
+		core.DatasetsPath = previous
+	}()
+
+	dataset, err := core.CreateDataset("cars", core.Descriptor{Task: core.TaskDetect, Tags: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content bytes.Buffer
+	png.Encode(&content, image.NewGray(image.Rect(0, 0, 4, 4)))
+	stored, _ := dataset.ImagePath(core.LocationUploaded, "stored.png")
+	os.MkdirAll(filepath.Dir(stored), os.ModePerm)
+	os.WriteFile(stored, content.Bytes(), 0644)
+	// Folder with the thumbnail name fails the processing of the completed image
+	thumbnail, _ := dataset.ThumbnailPath(core.LocationUploaded, "blocked.png")
+	os.MkdirAll(filepath.Join(thumbnail, "blocked"), os.ModePerm)
+
+	router := httprouter.New()
+	RegisterRoutes(router)
+
+	tests := []struct {
+		name     string
+		filename string
+		checksum string
+		status   int
+	}{
+		{"completed", "a.png", "", http.StatusCreated},
+		{"stored image name", "stored.png", "", http.StatusConflict},
+		{"wrong checksum", "b.png", "sha256 " + base64.StdEncoding.EncodeToString(make([]byte, 32)), 460},
+		{"image cannot be processed", "blocked.png", "", http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/api/v1/datasets/cars/uploads", bytes.NewReader(content.Bytes()))
+			request.Header.Set("Tus-Resumable", tusVersion)
+			request.Header.Set("Content-Type", tusContentType)
+			request.Header.Set("Upload-Length", strconv.Itoa(content.Len()))
+			request.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte(test.filename)))
+			request.Header.Set("Upload-Checksum", test.checksum)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != test.status {
+				t.Fatalf("status %v, expected %v: %v", recorder.Code, test.status, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: archives.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/client
+	Purpose: Client methods for the archived datasets
+
+	Datasets are archived by Client.ArchiveDataset
+	=============================================================================
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+/****************************************************************************************
+ *
+ * Function : Client.ListArchives
+ *
+ * Purpose : Get page of archived datasets
+ *
+ *   Input : ctx context.Context - request context
+ *			 page int64 - page number, 0 for the first page
+ *			 perPage int64 - archives per page, 0 for the server default
+ *
+ *  Return : ArchiveList - page of archives
+ *			 error - error if occur
+ */
+func (client *Client) ListArchives(ctx context.Context, page int64, perPage int64) (ArchiveList, error) {
+	var list ArchiveList
+	err := client.do(ctx, http.MethodGet, "/archives", pageQuery(page, perPage), nil, &list)
+	return list, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.RestoreArchive
+ *
+ * Purpose : Extract the archive into the dataset and delete the archive
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - name of the archived dataset
+ *			 newName string - name of the restored dataset, archive name when empty
+ *
+ *  Return : Dataset - restored dataset
+ *			 error - error if occur
+ */
+func (client *Client) RestoreArchive(ctx context.Context, name string, newName string) (Dataset, error) {
+	var dataset Dataset
+	err := client.do(ctx, http.MethodPost, escape("archives", name, "restore"), nil, map[string]string{"name": newName}, &dataset)
+	return dataset, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.DeleteArchive
+ *
+ * Purpose : Delete the archive forever
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - name of the archived dataset
+ *
+ *  Return : error - error if occur
+ */
+func (client *Client) DeleteArchive(ctx context.Context, name string) error {
+	return client.do(ctx, http.MethodDelete, escape("archives", name), nil, nil, nil)
+}
@@ -0,0 +1,271 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: client.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/client
+	Purpose: Go client for the dataset API, follows api/openapi.json
+
+	In the file
+		1. Client - connection to the server
+		2. Error - error object returned by the API
+		3. Helpers to send requests and decode responses
+	=============================================================================
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Path of the API version on the server
+const apiPath = "/api/v1"
+
+// Client to the dataset server
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// Error returned by the API
+type Error struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+/****************************************************************************************
+ *
+ * Function : NewClient
+ *
+ * Purpose : Constructor for the Client
+ *
+ *   Input : baseURL string - server address, e.g. 'http://localhost:8080'
+ *
+ *  Return : *Client
+ */
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+/****************************************************************************************
+ *
+ * Function : Error.Error
+ *
+ * Purpose : Print API error
+ *
+ *   Input : Nothing
+ *
+ *  Return : string - error description
+ */
+func (err *Error) Error() string {
+	return fmt.Sprintf("api error %v %v: %v", err.Status, err.Code, err.Message)
+}
+
+/****************************************************************************************
+ *
+ * Function : IsNotFound
+ *
+ * Purpose : Check if error is the API 'not found' response
+ *
+ *   Input : err error - error returned by the client
+ *
+ *  Return : bool - true if server responded with 404
+ */
+func IsNotFound(err error) bool {
+	apiError, ok := err.(*Error)
+	return ok && apiError.Status == http.StatusNotFound
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.do
+ *
+ * Purpose : Send request and decode JSON response
+ *
+ *   Input : ctx context.Context - request context
+ *			 method string - http method
+ *			 path string - path after the API prefix, already escaped
+ *			 query url.Values - query parameters, can be nil
+ *			 request interface{} - model to send as JSON body, nil for no body
+ *			 response interface{} - pointer to decode response, nil to skip body
+ *
+ *  Return : error - error if occur, *Error for the API errors
+ */
+func (client *Client) do(ctx context.Context, method string, path string, query url.Values, request interface{}, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		content, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(content)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, method, client.url(path, query), body)
+	if err != nil {
+		return err
+	}
+	if request != nil {
+		httpRequest.Header.Set("Content-Type", "application/json")
+	}
+	httpRequest.Header.Set("Accept", "application/json")
+
+	return client.send(httpRequest, response)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.send
+ *
+ * Purpose : Send prepared request and decode JSON response
+ *
+ *   Input : httpRequest *http.Request - request to send
+ *			 response interface{} - pointer to decode response, nil to skip body
+ *
+ *  Return : error - error if occur, *Error for the API errors
+ */
+func (client *Client) send(httpRequest *http.Request, response interface{}) error {
+	httpResponse, err := client.HTTPClient.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if err := checkResponse(httpResponse); err != nil {
+		return err
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	return json.NewDecoder(httpResponse.Body).Decode(response)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.download
+ *
+ * Purpose : Send GET request and copy response body to the writer
+ *
+ *   Input : ctx context.Context - request context
+ *			 path string - path after the API prefix, already escaped
+ *			 writer io.Writer - where to copy the body
+ *
+ *  Return : int64 - copied bytes
+ *			 error - error if occur
+ */
+func (client *Client) download(ctx context.Context, path string, writer io.Writer) (int64, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, client.url(path, nil), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	httpResponse, err := client.HTTPClient.Do(httpRequest)
+	if err != nil {
+		return 0, err
+	}
+	defer httpResponse.Body.Close()
+
+	if err := checkResponse(httpResponse); err != nil {
+		return 0, err
+	}
+
+	return io.Copy(writer, httpResponse.Body)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.url
+ *
+ * Purpose : Make full url of the API endpoint
+ *
+ *   Input : path string - path after the API prefix, already escaped
+ *			 query url.Values - query parameters, can be nil
+ *
+ *  Return : string - url
+ */
+func (client *Client) url(path string, query url.Values) string {
+	fullURL := client.BaseURL + apiPath + path
+	if len(query) > 0 {
+		fullURL += "?" + query.Encode()
+	}
+
+	return fullURL
+}
+
+/****************************************************************************************
+ *
+ * Function : checkResponse
+ *
+ * Purpose : Convert error response to the *Error
+ *
+ *   Input : httpResponse *http.Response - server response
+ *
+ *  Return : error - nil for the successful status
+ */
+func checkResponse(httpResponse *http.Response) error {
+	if httpResponse.StatusCode >= 200 && httpResponse.StatusCode < 300 {
+		return nil
+	}
+
+	var errorResponse struct {
+		Error Error `json:"error"`
+	}
+	if err := json.NewDecoder(httpResponse.Body).Decode(&errorResponse); err != nil || errorResponse.Error.Code == "" {
+		return &Error{Status: httpResponse.StatusCode, Code: "http_error", Message: httpResponse.Status}
+	}
+
+	return &errorResponse.Error
+}
+
+/****************************************************************************************
+ *
+ * Function : pageQuery
+ *
+ * Purpose : Make query with pagination parameters, zero values are skipped
+ *
+ *   Input : page int64 - page number
+ *			 perPage int64 - items per page
+ *
+ *  Return : url.Values - query parameters
+ */
+func pageQuery(page int64, perPage int64) url.Values {
+	query := url.Values{}
+	if page > 0 {
+		query.Set("page", strconv.FormatInt(page, 10))
+	}
+	if perPage > 0 {
+		query.Set("per_page", strconv.FormatInt(perPage, 10))
+	}
+
+	return query
+}
+
+/****************************************************************************************
+ *
+ * Function : escape
+ *
+ * Purpose : Join escaped path segments
+ *
+ *   Input : segments ...string - path segments
+ *
+ *  Return : string - path starting with slash
+ */
+func escape(segments ...string) string {
+	var path strings.Builder
+	for _, segment := range segments {
+		path.WriteString("/" + url.PathEscape(segment))
+	}
+
+	return path.String()
+}
@@ -0,0 +1,353 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: client_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/client
+	Purpose: Tests of the client against the httptest server
+
+	Server answers the requests by the method and path, the handlers check
+	the requests sent by the client.
+
+	In the file
+		1. TestDatasets - list, create, get, rename and delete datasets
+		2. TestImages - upload, download, move and delete images
+		3. TestLabels - get and replace labels
+		4. TestExport - download the dataset archive
+		5. TestErrors - decoding of the API error object
+	=============================================================================
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : newTestServer
+ *
+ * Purpose : Start the server answering by the '<method> <path>' handlers, other
+ *			 requests are answered with the 404 API error
+ *
+ *   Input : t *testing.T - test
+ *			 handlers map[string]http.HandlerFunc - handlers by the method and the path
+ *
+ *  Return : *Client - client connected to the server
+ */
+func newTestServer(t *testing.T, handlers map[string]http.HandlerFunc) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, found := handlers[r.Method+" "+r.URL.EscapedPath()]
+		if !found {
+			writeTestJSON(w, http.StatusNotFound, map[string]Error{"error": {Status: http.StatusNotFound, Code: "not_found", Message: r.Method + " " + r.URL.Path}})
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewClient(server.URL + "/")
+}
+
+/****************************************************************************************
+ *
+ * Function : writeTestJSON
+ *
+ * Purpose : Write the model as JSON response
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 status int - http status
+ *			 model interface{} - response model
+ *
+ *  Return : Nothing
+ */
+func writeTestJSON(w http.ResponseWriter, status int, model interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model)
+}
+
+/****************************************************************************************
+ *
+ * Function : readTestJSON
+ *
+ * Purpose : Decode JSON body of the request, fails the test on the wrong content
+ *
+ *   Input : t *testing.T - test
+ *			 r *http.Request - request
+ *			 model interface{} - pointer to decode the body
+ *
+ *  Return : Nothing
+ */
+func readTestJSON(t *testing.T, r *http.Request, model interface{}) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("%v %v: content type '%v'", r.Method, r.URL.Path, contentType)
+	}
+	if err := json.NewDecoder(r.Body).Decode(model); err != nil {
+		t.Errorf("%v %v: cannot decode body: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestDatasets
+ *
+ * Purpose : Check the dataset requests and the decoded responses
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestDatasets(t *testing.T) {
+	ctx := context.Background()
+	client := newTestServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/datasets": func(w http.ResponseWriter, r *http.Request) {
+			if page, perPage := r.URL.Query().Get("page"), r.URL.Query().Get("per_page"); page != "2" || perPage != "10" {
+				t.Errorf("list: page '%v', per page '%v'", page, perPage)
+			}
+			writeTestJSON(w, http.StatusOK, DatasetList{Items: []Dataset{{Name: "cars"}}, Pagination: Pagination{Page: 2, ItemsPerPage: 10, Items: 11}})
+		},
+		"POST /api/v1/datasets": func(w http.ResponseWriter, r *http.Request) {
+			var request map[string]string
+			readTestJSON(t, r, &request)
+			writeTestJSON(w, http.StatusCreated, Dataset{Name: request["name"], Classes: []string{}})
+		},
+		"GET /api/v1/datasets/road%20signs": func(w http.ResponseWriter, r *http.Request) {
+			writeTestJSON(w, http.StatusOK, Dataset{Name: "road signs", Classes: []string{"stop", "yield"}, Descriptor: &Descriptor{Task: "detect", Layout: 5}})
+		},
+		"PATCH /api/v1/datasets/cars": func(w http.ResponseWriter, r *http.Request) {
+			var request map[string]string
+			readTestJSON(t, r, &request)
+			writeTestJSON(w, http.StatusOK, Dataset{Name: request["name"]})
+		},
+		"DELETE /api/v1/datasets/cars": func(w http.ResponseWriter, r *http.Request) {
+			var request map[string]string
+			readTestJSON(t, r, &request)
+			if request["confirm"] != "cars" {
+				writeTestJSON(w, http.StatusBadRequest, map[string]Error{"error": {Status: http.StatusBadRequest, Code: "confirmation_required"}})
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	list, err := client.ListDatasets(ctx, 2, 10)
+	if err != nil || len(list.Items) != 1 || list.Items[0].Name != "cars" || list.Pagination.Items != 11 {
+		t.Errorf("ListDatasets: %+v, %v", list, err)
+	}
+
+	created, err := client.CreateDataset(ctx, "trucks")
+	if err != nil || created.Name != "trucks" {
+		t.Errorf("CreateDataset: %+v, %v", created, err)
+	}
+
+	dataset, err := client.GetDataset(ctx, "road signs")
+	if err != nil || !reflect.DeepEqual(dataset.Classes, []string{"stop", "yield"}) || dataset.Descriptor == nil || dataset.Descriptor.Layout != 5 {
+		t.Errorf("GetDataset: %+v, %v", dataset, err)
+	}
+
+	renamed, err := client.RenameDataset(ctx, "cars", "vehicles")
+	if err != nil || renamed.Name != "vehicles" {
+		t.Errorf("RenameDataset: %+v, %v", renamed, err)
+	}
+
+	if err := client.DeleteDataset(ctx, "cars"); err != nil {
+		t.Errorf("DeleteDataset: %v", err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestImages
+ *
+ * Purpose : Check upload as multipart form, download, move and delete of the image
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestImages(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("\x89PNG image content")
+	client := newTestServer(t, map[string]http.HandlerFunc{
+		"POST /api/v1/datasets/cars/images/uploaded": func(w http.ResponseWriter, r *http.Request) {
+			file, header, err := r.FormFile("image")
+			if err != nil {
+				t.Errorf("upload: %v", err)
+				return
+			}
+			defer file.Close()
+			received, _ := io.ReadAll(file)
+			if !bytes.Equal(received, content) {
+				t.Errorf("upload: received %q", received)
+			}
+			writeTestJSON(w, http.StatusCreated, ImageList{Items: []Image{{Name: header.Filename, Location: "uploaded", Size: int64(len(received))}}})
+		},
+		"GET /api/v1/datasets/cars/images/train/a%20b.png": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(content)
+		},
+		"POST /api/v1/datasets/cars/images/uploaded/a.png/move": func(w http.ResponseWriter, r *http.Request) {
+			var request map[string]string
+			readTestJSON(t, r, &request)
+			writeTestJSON(w, http.StatusOK, Image{Name: "a.png", Location: request["to"]})
+		},
+		"DELETE /api/v1/datasets/cars/images/train/a.png": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	image, err := client.UploadImage(ctx, "cars", "uploaded", "a.png", bytes.NewReader(content))
+	if err != nil || image.Name != "a.png" || image.Size != int64(len(content)) {
+		t.Errorf("UploadImage: %+v, %v", image, err)
+	}
+
+	var downloaded bytes.Buffer
+	size, err := client.DownloadImage(ctx, "cars", "train", "a b.png", &downloaded)
+	if err != nil || size != int64(len(content)) || !bytes.Equal(downloaded.Bytes(), content) {
+		t.Errorf("DownloadImage: %v bytes, %v", size, err)
+	}
+
+	moved, err := client.MoveImage(ctx, "cars", "uploaded", "a.png", "valid")
+	if err != nil || moved.Location != "valid" {
+		t.Errorf("MoveImage: %+v, %v", moved, err)
+	}
+
+	if err := client.DeleteImage(ctx, "cars", "train", "a.png"); err != nil {
+		t.Errorf("DeleteImage: %v", err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestLabels
+ *
+ * Purpose : Check labels are decoded and sent, nil labels are sent as empty list
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestLabels(t *testing.T) {
+	ctx := context.Background()
+	labels := []Label{{Class: 1, X: 0.5, Y: 0.5, Width: 0.2, Height: 0.1}, {Class: 0, X: 0.3, Y: 0.3, Width: 0.2, Height: 0.2, Points: []float64{0.2, 0.2, 0.4, 0.2, 0.4, 0.4}}}
+	var stored []byte
+	client := newTestServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/datasets/cars/labels/train/a.png": func(w http.ResponseWriter, r *http.Request) {
+			writeTestJSON(w, http.StatusOK, Labels{Image: "a.png", Labels: labels})
+		},
+		"PUT /api/v1/datasets/cars/labels/train/a.png": func(w http.ResponseWriter, r *http.Request) {
+			stored, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		},
+	})
+
+	received, err := client.Labels(ctx, "cars", "train", "a.png")
+	if err != nil || !reflect.DeepEqual(received, labels) {
+		t.Errorf("Labels: %+v, %v", received, err)
+	}
+
+	if err := client.SetLabels(ctx, "cars", "train", "a.png", labels[:1]); err != nil {
+		t.Errorf("SetLabels: %v", err)
+	}
+	var request Labels
+	if err := json.Unmarshal(stored, &request); err != nil || !reflect.DeepEqual(request.Labels, labels[:1]) {
+		t.Errorf("SetLabels sent %s", stored)
+	}
+
+	if err := client.SetLabels(ctx, "cars", "train", "a.png", nil); err != nil {
+		t.Errorf("SetLabels without labels: %v", err)
+	}
+	if !strings.Contains(string(stored), `"labels":[]`) {
+		t.Errorf("SetLabels without labels sent %s", stored)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestExport
+ *
+ * Purpose : Check the archive is copied to the writer
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestExport(t *testing.T) {
+	archive := bytes.Repeat([]byte("PK zip content "), 1000)
+	client := newTestServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/datasets/cars/export": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/zip")
+			w.Write(archive)
+		},
+	})
+
+	var output bytes.Buffer
+	size, err := client.Export(context.Background(), "cars", &output)
+	if err != nil || size != int64(len(archive)) || !bytes.Equal(output.Bytes(), archive) {
+		t.Errorf("Export: %v bytes, %v", size, err)
+	}
+
+	if _, err := client.Export(context.Background(), "trucks", &output); !IsNotFound(err) {
+		t.Errorf("Export of the missed dataset: %v", err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestErrors
+ *
+ * Purpose : Check API error object is decoded to *Error, other error responses
+ *			 get 'http_error' code with the status
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected Error
+		notFound bool
+	}{
+		{"not found", http.StatusNotFound, `{"error": {"status": 404, "code": "dataset_not_found", "message": "Dataset is not exists"}}`, Error{http.StatusNotFound, "dataset_not_found", "Dataset is not exists"}, true},
+		{"conflict", http.StatusConflict, `{"error": {"status": 409, "code": "image_exists", "message": "Image already exists"}}`, Error{http.StatusConflict, "image_exists", "Image already exists"}, false},
+		{"bad request", http.StatusBadRequest, `{"error": {"status": 400, "code": "invalid_name", "message": "Name is not valid"}}`, Error{http.StatusBadRequest, "invalid_name", "Name is not valid"}, false},
+		{"not json", http.StatusBadGateway, `<html>Bad gateway</html>`, Error{http.StatusBadGateway, "http_error", "502 Bad Gateway"}, false},
+		{"no error code", http.StatusInternalServerError, `{"message": "failed"}`, Error{http.StatusInternalServerError, "http_error", "500 Internal Server Error"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestServer(t, map[string]http.HandlerFunc{
+				"GET /api/v1/datasets/cars": func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(test.status)
+					io.WriteString(w, test.body)
+				},
+			})
+
+			_, err := client.GetDataset(context.Background(), "cars")
+			var apiError *Error
+			if !errors.As(err, &apiError) {
+				t.Fatalf("error %v is not *Error", err)
+			}
+			if *apiError != test.expected {
+				t.Errorf("decoded %+v, expected %+v", *apiError, test.expected)
+			}
+			if IsNotFound(err) != test.notFound {
+				t.Errorf("IsNotFound is %v", IsNotFound(err))
+			}
+		})
+	}
+}
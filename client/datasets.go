@@ -0,0 +1,386 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: datasets.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/client
+	Purpose: Client methods for datasets, classes, splits and export
+
+	=============================================================================
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+/****************************************************************************************
+ *
+ * Function : Client.ListDatasets
+ *
+ * Purpose : Get page of datasets
+ *
+ *   Input : ctx context.Context - request context
+ *			 page int64 - page number, 0 for the first page
+ *			 perPage int64 - datasets per page, 0 for the server default
+ *
+ *  Return : DatasetList - page of datasets
+ *			 error - error if occur
+ */
+func (client *Client) ListDatasets(ctx context.Context, page int64, perPage int64) (DatasetList, error) {
+	var list DatasetList
+	err := client.do(ctx, http.MethodGet, "/datasets", pageQuery(page, perPage), nil, &list)
+	return list, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.CreateDataset
+ *
+ * Purpose : Create a new dataset
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *
+ *  Return : Dataset - created dataset
+ *			 error - error if occur
+ */
+func (client *Client) CreateDataset(ctx context.Context, name string) (Dataset, error) {
+	var dataset Dataset
+	err := client.do(ctx, http.MethodPost, "/datasets", nil, map[string]string{"name": name}, &dataset)
+	return dataset, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.MergeDatasets
+ *
+ * Purpose : Create a new dataset and queue the job merging the sources into it
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - name of the new dataset
+ *			 options MergeOptions - sources, class mapping and split ratios
+ *
+ *  Return : Job - queued merge job, its result has the merged classes
+ *			 error - error if occur
+ */
+func (client *Client) MergeDatasets(ctx context.Context, name string, options MergeOptions) (Job, error) {
+	var job Job
+	request := map[string]interface{}{"name": name, "merge": options}
+	err := client.do(ctx, http.MethodPost, "/datasets", nil, request, &job)
+	return job, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.AdoptDataset
+ *
+ * Purpose : Create a new dataset and queue the job copying the Ultralytics dataset
+ *			 of the server folder or zip archive into it
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - name of the new dataset
+ *			 source string - folder or zip archive on the server
+ *
+ *  Return : Job - queued adopt job
+ *			 error - error if occur
+ */
+func (client *Client) AdoptDataset(ctx context.Context, name string, source string) (Job, error) {
+	var job Job
+	request := map[string]interface{}{"name": name, "adopt": map[string]string{"source": source}}
+	err := client.do(ctx, http.MethodPost, "/datasets", nil, request, &job)
+	return job, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.AdoptArchive
+ *
+ * Purpose : Upload zip archive of the Ultralytics dataset, create a new dataset
+ *			 and queue the job copying the archive into it
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - name of the new dataset
+ *			 archive io.Reader - zip archive content
+ *
+ *  Return : Job - queued adopt job
+ *			 error - error if occur
+ */
+func (client *Client) AdoptArchive(ctx context.Context, name string, archive io.Reader) (Job, error) {
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	go func() {
+		err := form.WriteField("name", name)
+		if err == nil {
+			var part io.Writer
+			if part, err = form.CreateFormFile("archive", name+".zip"); err == nil {
+				_, err = io.Copy(part, archive)
+			}
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, client.url("/datasets", nil), reader)
+	if err != nil {
+		reader.Close()
+		return Job{}, err
+	}
+	httpRequest.Header.Set("Content-Type", form.FormDataContentType())
+	httpRequest.Header.Set("Accept", "application/json")
+
+	var job Job
+	if err := client.send(httpRequest, &job); err != nil {
+		reader.Close()
+		return Job{}, err
+	}
+
+	return job, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.GetDataset
+ *
+ * Purpose : Get dataset details
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *
+ *  Return : Dataset - dataset details
+ *			 error - error if occur
+ */
+func (client *Client) GetDataset(ctx context.Context, name string) (Dataset, error) {
+	var dataset Dataset
+	err := client.do(ctx, http.MethodGet, escape("datasets", name), nil, nil, &dataset)
+	return dataset, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.RenameDataset
+ *
+ * Purpose : Rename the dataset
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - current dataset name
+ *			 newName string - new dataset name
+ *
+ *  Return : Dataset - renamed dataset
+ *			 error - error if occur
+ */
+func (client *Client) RenameDataset(ctx context.Context, name string, newName string) (Dataset, error) {
+	var dataset Dataset
+	err := client.do(ctx, http.MethodPatch, escape("datasets", name), nil, map[string]string{"name": newName}, &dataset)
+	return dataset, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.DescribeDataset
+ *
+ * Purpose : Replace description, task, owner, tags and cover of the dataset
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *			 descriptor Descriptor - new descriptor
+ *
+ *  Return : Dataset - dataset with the changed descriptor
+ *			 error - error if occur
+ */
+func (client *Client) DescribeDataset(ctx context.Context, name string, descriptor Descriptor) (Dataset, error) {
+	var dataset Dataset
+	err := client.do(ctx, http.MethodPatch, escape("datasets", name), nil, map[string]interface{}{"descriptor": descriptor}, &dataset)
+	return dataset, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.DeleteDataset
+ *
+ * Purpose : Delete the dataset with all files, the name is sent again as the confirmation
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *
+ *  Return : error - error if occur
+ */
+func (client *Client) DeleteDataset(ctx context.Context, name string) error {
+	return client.do(ctx, http.MethodDelete, escape("datasets", name), nil, map[string]string{"confirm": name}, nil)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.CloneDataset
+ *
+ * Purpose : Copy the dataset under the new name
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *			 newName string - name of the copy
+ *			 imagesOnly bool - copy images with labels and classes only, without versions, models and trash
+ *
+ *  Return : Dataset - copy of the dataset
+ *			 error - error if occur
+ */
+func (client *Client) CloneDataset(ctx context.Context, name string, newName string, imagesOnly bool) (Dataset, error) {
+	var dataset Dataset
+	request := map[string]interface{}{"name": newName, "images_only": imagesOnly}
+	err := client.do(ctx, http.MethodPost, escape("datasets", name, "clone"), nil, request, &dataset)
+	return dataset, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.ArchiveDataset
+ *
+ * Purpose : Move the dataset to the zip archive on the server
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *
+ *  Return : Archive - written archive
+ *			 error - error if occur
+ */
+func (client *Client) ArchiveDataset(ctx context.Context, name string) (Archive, error) {
+	var archive Archive
+	err := client.do(ctx, http.MethodPost, escape("datasets", name, "archive"), nil, nil, &archive)
+	return archive, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.Stats
+ *
+ * Purpose : Get dataset statistics
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *
+ *  Return : Stats - statistics
+ *			 error - error if occur
+ */
+func (client *Client) Stats(ctx context.Context, name string) (Stats, error) {
+	var stats Stats
+	err := client.do(ctx, http.MethodGet, escape("datasets", name, "stats"), nil, nil, &stats)
+	return stats, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.Classes
+ *
+ * Purpose : Get classes names of the dataset
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *
+ *  Return : []string - classes names, index is the class id
+ *			 error - error if occur
+ */
+func (client *Client) Classes(ctx context.Context, name string) ([]string, error) {
+	var classes struct {
+		Names []string `json:"names"`
+	}
+	err := client.do(ctx, http.MethodGet, escape("datasets", name, "classes"), nil, nil, &classes)
+	return classes.Names, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.SetClasses
+ *
+ * Purpose : Replace classes names of the dataset
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *			 classes []string - classes names, index is the class id
+ *
+ *  Return : error - error if occur
+ */
+func (client *Client) SetClasses(ctx context.Context, name string, classes []string) error {
+	if classes == nil {
+		classes = []string{}
+	}
+
+	return client.do(ctx, http.MethodPut, escape("datasets", name, "classes"), nil, map[string][]string{"names": classes}, nil)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.ChangeClasses
+ *
+ * Purpose : Merge, delete or reorder classes together with the label files
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *			 operation ClassOperation - operation, dry run reports the changes only
+ *
+ *  Return : ClassReport - classes after the operation and the number of changes
+ *			 error - error if occur
+ */
+func (client *Client) ChangeClasses(ctx context.Context, name string, operation ClassOperation) (ClassReport, error) {
+	var report ClassReport
+	err := client.do(ctx, http.MethodPost, escape("datasets", name, "classes"), nil, operation, &report)
+	return report, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.Splits
+ *
+ * Purpose : Get statistics of every split
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *
+ *  Return : map[string]LocationStats - statistics by the split name
+ *			 error - error if occur
+ */
+func (client *Client) Splits(ctx context.Context, name string) (map[string]LocationStats, error) {
+	var splits map[string]LocationStats
+	err := client.do(ctx, http.MethodGet, escape("datasets", name, "splits"), nil, nil, &splits)
+	return splits, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.Split
+ *
+ * Purpose : Move uploaded images to the splits by the ratios
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *			 options SplitOptions - split ratios
+ *
+ *  Return : map[string]int - number of moved images per split
+ *			 error - error if occur
+ */
+func (client *Client) Split(ctx context.Context, name string, options SplitOptions) (map[string]int, error) {
+	var moved map[string]int
+	err := client.do(ctx, http.MethodPost, escape("datasets", name, "splits"), nil, options, &moved)
+	return moved, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.Export
+ *
+ * Purpose : Download zip archive of the dataset ready for training
+ *
+ *   Input : ctx context.Context - request context
+ *			 name string - dataset name
+ *			 writer io.Writer - where to write archive
+ *
+ *  Return : int64 - archive size
+ *			 error - error if occur
+ */
+func (client *Client) Export(ctx context.Context, name string, writer io.Writer) (int64, error) {
+	return client.download(ctx, escape("datasets", name, "export"), writer)
+}
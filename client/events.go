@@ -0,0 +1,83 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: events.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/client
+	Purpose: Client of the Server-Sent Events stream of the dataset
+	=============================================================================
+*/
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/****************************************************************************************
+ *
+ * Function : Client.Events
+ *
+ * Purpose : Receive events of the dataset until the context is cancelled,
+ *			 the handler returns error or the server closes the stream
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 lastId int64 - id of the last received event, 0 to receive the whole history
+ *			 handler func(Event) error - called for every event
+ *
+ *  Return : error - error of the handler, connection or context
+ */
+func (client *Client) Events(ctx context.Context, dataset string, lastId int64, handler func(Event) error) error {
+	query := url.Values{}
+	if lastId > 0 {
+		query.Set("last_id", strconv.FormatInt(lastId, 10))
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, client.url(escape("datasets", dataset, "events"), query), nil)
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Accept", "text/event-stream")
+
+	httpResponse, err := client.HTTPClient.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if err := checkResponse(httpResponse); err != nil {
+		return err
+	}
+
+	// Every event is 'id', 'event' and 'data' lines ended by the empty line
+	data := ""
+	scanner := bufio.NewScanner(httpResponse.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+		case line == "" && data != "":
+			var event Event
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				return err
+			}
+			data = ""
+			if err := handler(event); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
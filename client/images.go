@@ -0,0 +1,323 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: images.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/client
+	Purpose: Client methods for images and labels
+
+	Location is 'uploaded' or one of the splits: 'train', 'valid', 'test'
+	=============================================================================
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+/****************************************************************************************
+ *
+ * Function : Client.ListImages
+ *
+ * Purpose : Get page of images in the location
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - images location
+ *			 page int64 - page number, 0 for the first page
+ *			 perPage int64 - images per page, 0 for the server default
+ *
+ *  Return : ImageList - page of images
+ *			 error - error if occur
+ */
+func (client *Client) ListImages(ctx context.Context, dataset string, location string, page int64, perPage int64) (ImageList, error) {
+	var list ImageList
+	err := client.do(ctx, http.MethodGet, escape("datasets", dataset, "images", location), pageQuery(page, perPage), nil, &list)
+	return list, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.SearchImages
+ *
+ * Purpose : Get page of images matched by the filter
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - images location or 'all'
+ *			 filter ImageFilter - filters and sorting
+ *			 page int64 - page number, 0 for the first page
+ *			 perPage int64 - images per page, 0 for the server default
+ *
+ *  Return : ImageList - page of images
+ *			 error - error if occur
+ */
+func (client *Client) SearchImages(ctx context.Context, dataset string, location string, filter ImageFilter, page int64, perPage int64) (ImageList, error) {
+	query := pageQuery(page, perPage)
+	for name, value := range filter.values() {
+		query[name] = value
+	}
+
+	var list ImageList
+	err := client.do(ctx, http.MethodGet, escape("datasets", dataset, "images", location), query, nil, &list)
+	return list, err
+}
+
+/****************************************************************************************
+ *
+ * Function : ImageFilter.values
+ *
+ * Purpose : Convert filter to the query parameters
+ *
+ *   Input : Nothing
+ *
+ *  Return : url.Values - query parameters
+ */
+func (filter ImageFilter) values() url.Values {
+	query := url.Values{}
+	texts := map[string]string{"status": filter.Status, "tag": filter.Tag, "q": filter.Search, "from": filter.From, "to": filter.To, "sort": filter.Sort}
+	for name, value := range texts {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	numbers := map[string]int{"min_width": filter.MinWidth, "max_width": filter.MaxWidth, "min_height": filter.MinHeight, "max_height": filter.MaxHeight}
+	for name, value := range numbers {
+		if value > 0 {
+			query.Set(name, strconv.Itoa(value))
+		}
+	}
+
+	if filter.Class != nil {
+		query.Set("class", strconv.Itoa(*filter.Class))
+	}
+	if filter.Descending {
+		query.Set("order", "desc")
+	}
+
+	return query
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.UploadImage
+ *
+ * Purpose : Upload image, content is streamed without buffering the whole file
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - images location
+ *			 name string - image file name
+ *			 content io.Reader - image content
+ *
+ *  Return : Image - uploaded image
+ *			 error - error if occur
+ */
+func (client *Client) UploadImage(ctx context.Context, dataset string, location string, name string, content io.Reader) (Image, error) {
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	go func() {
+		part, err := form.CreateFormFile("image", name)
+		if err == nil {
+			_, err = io.Copy(part, content)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, client.url(escape("datasets", dataset, "images", location), nil), reader)
+	if err != nil {
+		reader.Close()
+		return Image{}, err
+	}
+	httpRequest.Header.Set("Content-Type", form.FormDataContentType())
+	httpRequest.Header.Set("Accept", "application/json")
+
+	var list ImageList
+	if err := client.send(httpRequest, &list); err != nil {
+		reader.Close()
+		return Image{}, err
+	}
+	if len(list.Items) == 0 {
+		return Image{}, &Error{Status: http.StatusCreated, Code: "empty_response", Message: "Server has not returned uploaded image"}
+	}
+
+	return list.Items[0], nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.DownloadImage
+ *
+ * Purpose : Download image to the writer
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - images location
+ *			 name string - image file name
+ *			 writer io.Writer - where to write the image
+ *
+ *  Return : int64 - image size
+ *			 error - error if occur
+ */
+func (client *Client) DownloadImage(ctx context.Context, dataset string, location string, name string, writer io.Writer) (int64, error) {
+	return client.download(ctx, escape("datasets", dataset, "images", location, name), writer)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.DeleteImage
+ *
+ * Purpose : Move image together with its label to the trash
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - images location
+ *			 name string - image file name
+ *
+ *  Return : error - error if occur
+ */
+func (client *Client) DeleteImage(ctx context.Context, dataset string, location string, name string) error {
+	return client.do(ctx, http.MethodDelete, escape("datasets", dataset, "images", location, name), nil, nil, nil)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.MoveImage
+ *
+ * Purpose : Move image together with its label to another location
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - current images location
+ *			 name string - image file name
+ *			 to string - new images location
+ *
+ *  Return : Image - moved image
+ *			 error - error if occur
+ */
+func (client *Client) MoveImage(ctx context.Context, dataset string, location string, name string, to string) (Image, error) {
+	var image Image
+	err := client.do(ctx, http.MethodPost, escape("datasets", dataset, "images", location, name, "move"), nil, map[string]string{"to": to}, &image)
+	return image, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.Labels
+ *
+ * Purpose : Get labels of the image
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - images location
+ *			 name string - image file name
+ *
+ *  Return : []Label - labels
+ *			 error - error if occur
+ */
+func (client *Client) Labels(ctx context.Context, dataset string, location string, name string) ([]Label, error) {
+	var labels Labels
+	err := client.do(ctx, http.MethodGet, escape("datasets", dataset, "labels", location, name), nil, nil, &labels)
+	return labels.Labels, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.SetLabels
+ *
+ * Purpose : Replace labels of the image
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - images location
+ *			 name string - image file name
+ *			 labels []Label - new labels
+ *
+ *  Return : error - error if occur
+ */
+func (client *Client) SetLabels(ctx context.Context, dataset string, location string, name string, labels []Label) error {
+	if labels == nil {
+		labels = []Label{}
+	}
+
+	return client.do(ctx, http.MethodPut, escape("datasets", dataset, "labels", location, name), nil, Labels{Labels: labels}, nil)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.ImageMetadata
+ *
+ * Purpose : Get catalog record of the image
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - images location
+ *			 name string - image file name
+ *
+ *  Return : Image - image with the catalog fields
+ *			 error - error if occur
+ */
+func (client *Client) ImageMetadata(ctx context.Context, dataset string, location string, name string) (Image, error) {
+	var image Image
+	err := client.do(ctx, http.MethodGet, escape("datasets", dataset, "images", location, name, "metadata"), nil, nil, &image)
+	return image, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.UpdateImageMetadata
+ *
+ * Purpose : Set tags and review status of the image
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - images location
+ *			 name string - image file name
+ *			 tags []string - new tags, nil to keep current tags
+ *			 reviewed *bool - new review status, nil to keep current status
+ *
+ *  Return : Image - updated image
+ *			 error - error if occur
+ */
+func (client *Client) UpdateImageMetadata(ctx context.Context, dataset string, location string, name string, tags []string, reviewed *bool) (Image, error) {
+	request := map[string]interface{}{}
+	if tags != nil {
+		request["tags"] = tags
+	}
+	if reviewed != nil {
+		request["reviewed"] = *reviewed
+	}
+
+	var image Image
+	err := client.do(ctx, http.MethodPatch, escape("datasets", dataset, "images", location, name, "metadata"), nil, request, &image)
+	return image, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.SyncCatalog
+ *
+ * Purpose : Synchronise images catalog with the files
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 rebuild bool - true to read all images again
+ *
+ *  Return : CatalogResult - number of updated and removed records
+ *			 error - error if occur
+ */
+func (client *Client) SyncCatalog(ctx context.Context, dataset string, rebuild bool) (CatalogResult, error) {
+	var result CatalogResult
+	err := client.do(ctx, http.MethodPost, escape("datasets", dataset, "catalog"), nil, map[string]bool{"rebuild": rebuild}, &result)
+	return result, err
+}
@@ -0,0 +1,150 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: jobs.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/client
+	Purpose: Client methods for the bulk actions and their background jobs
+	=============================================================================
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+/****************************************************************************************
+ *
+ * Function : Client.Bulk
+ *
+ * Purpose : Start the job of the bulk action
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 request BulkRequest - action and images
+ *			 filter ImageFilter - filters of the location when request has no images
+ *
+ *  Return : Job - started job
+ *			 error - error if occur
+ */
+func (client *Client) Bulk(ctx context.Context, dataset string, request BulkRequest, filter ImageFilter) (Job, error) {
+	var job Job
+	err := client.do(ctx, http.MethodPost, escape("datasets", dataset, "bulk"), filter.values(), request, &job)
+	return job, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.CreateJob
+ *
+ * Purpose : Queue the job of the dataset, e.g. 'export', 'version', 'catalog' or 'import'
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 request JobRequest - type and parameters of the job
+ *
+ *  Return : Job - queued job
+ *			 error - error if occur
+ */
+func (client *Client) CreateJob(ctx context.Context, dataset string, request JobRequest) (Job, error) {
+	var job Job
+	err := client.do(ctx, http.MethodPost, escape("datasets", dataset, "jobs"), nil, request, &job)
+	return job, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.Job
+ *
+ * Purpose : Get job with its progress
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 id string - job id
+ *
+ *  Return : Job - job
+ *			 error - error if occur
+ */
+func (client *Client) Job(ctx context.Context, dataset string, id string) (Job, error) {
+	var job Job
+	err := client.do(ctx, http.MethodGet, escape("datasets", dataset, "jobs", id), nil, nil, &job)
+	return job, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.ListJobs
+ *
+ * Purpose : Get page of the dataset jobs, the latest first
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 page int64 - page number, 0 for the first page
+ *			 perPage int64 - jobs per page, 0 for the server default
+ *
+ *  Return : JobList - page of jobs
+ *			 error - error if occur
+ */
+func (client *Client) ListJobs(ctx context.Context, dataset string, page int64, perPage int64) (JobList, error) {
+	var list JobList
+	err := client.do(ctx, http.MethodGet, escape("datasets", dataset, "jobs"), pageQuery(page, perPage), nil, &list)
+	return list, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.CancelJob
+ *
+ * Purpose : Cancel the queued or running job
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 id string - job id
+ *
+ *  Return : Job - job, running job is cancelled by the worker later
+ *			 error - error if occur
+ */
+func (client *Client) CancelJob(ctx context.Context, dataset string, id string) (Job, error) {
+	var job Job
+	err := client.do(ctx, http.MethodPost, escape("datasets", dataset, "jobs", id, "cancel"), nil, nil, &job)
+	return job, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.RetryJob
+ *
+ * Purpose : Queue the failed or cancelled job again
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 id string - job id
+ *
+ *  Return : Job - queued job
+ *			 error - error if occur
+ */
+func (client *Client) RetryJob(ctx context.Context, dataset string, id string) (Job, error) {
+	var job Job
+	err := client.do(ctx, http.MethodPost, escape("datasets", dataset, "jobs", id, "retry"), nil, nil, &job)
+	return job, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.DownloadJobOutput
+ *
+ * Purpose : Download output file of the finished job, e.g. zip archive
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 id string - job id
+ *			 writer io.Writer - where to write the file
+ *
+ *  Return : int64 - number of written bytes
+ *			 error - error if occur
+ */
+func (client *Client) DownloadJobOutput(ctx context.Context, dataset string, id string, writer io.Writer) (int64, error) {
+	return client.download(ctx, escape("datasets", dataset, "jobs", id, "download"), writer)
+}
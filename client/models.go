@@ -0,0 +1,261 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: models.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/client
+	Purpose: Models of the API requests and responses
+
+	=============================================================================
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Pagination metadata of the list response
+type Pagination struct {
+	Page           int64  `json:"page"`
+	ItemsPerPage   int64  `json:"per_page"`
+	Items          int64  `json:"total_items"`
+	LastPageNumber int64  `json:"last_page"`
+	Url            string `json:"url"`
+}
+
+// Dataset details
+type Dataset struct {
+	Name       string      `json:"name"`
+	Classes    []string    `json:"classes"`
+	Descriptor *Descriptor `json:"descriptor"`
+}
+
+// Descriptor of the dataset, times and layout are set by the server
+type Descriptor struct {
+	Description string    `json:"description"`
+	Task        string    `json:"task"`
+	Owner       string    `json:"owner"`
+	Tags        []string  `json:"tags"`
+	Cover       string    `json:"cover,omitempty"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+	Layout      int       `json:"layout"`
+}
+
+// Page of datasets
+type DatasetList struct {
+	Items      []Dataset  `json:"items"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// Archived dataset
+type Archive struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Archived time.Time `json:"archived"`
+}
+
+// Page of archived datasets
+type ArchiveList struct {
+	Items      []Archive  `json:"items"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// Statistics of the images location
+type LocationStats struct {
+	Images   int `json:"images"`
+	Labelled int `json:"labelled"`
+	Boxes    int `json:"boxes"`
+}
+
+// Statistics of the class
+type ClassStats struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Images int    `json:"images"`
+	Boxes  int    `json:"boxes"`
+}
+
+// Statistics of the dataset
+type Stats struct {
+	Images        int                      `json:"images"`
+	Labelled      int                      `json:"labelled"`
+	Boxes         int                      `json:"boxes"`
+	InvalidLabels int                      `json:"invalid_labels"`
+	Locations     map[string]LocationStats `json:"locations"`
+	Classes       []ClassStats             `json:"classes"`
+}
+
+// Options to split uploaded images
+type SplitOptions struct {
+	Train             float64 `json:"train"`
+	Valid             float64 `json:"valid"`
+	Test              float64 `json:"test"`
+	Seed              int64   `json:"seed"`
+	IncludeUnlabelled bool    `json:"include_unlabelled"`
+}
+
+// Sources of the new dataset, the merge runs as the job
+type MergeOptions struct {
+	Sources []string          `json:"sources"`
+	Classes map[string]string `json:"classes,omitempty"` // Class name of the sources to the merged name, empty name drops the boxes
+	Split   *SplitOptions     `json:"split,omitempty"`   // Ratios to distribute the split images again, nil keeps the splits
+}
+
+// Image details, catalog fields are empty in the upload and move responses
+type Image struct {
+	Name           string    `json:"name"`
+	Location       string    `json:"location"`
+	Size           int64     `json:"size"`
+	Modified       time.Time `json:"modified"`
+	Labelled       bool      `json:"labelled"`
+	Width          int       `json:"width"`
+	Height         int       `json:"height"`
+	SHA256         string    `json:"sha256"`
+	PerceptualHash string    `json:"perceptual_hash"`
+	Uploaded       time.Time `json:"uploaded"`
+	LabelStatus    string    `json:"label_status"`
+	Boxes          int       `json:"boxes"`
+	Classes        []int     `json:"classes"`
+	Tags           []string  `json:"tags"`
+}
+
+// Filters and sorting of the images list, empty fields are not sent
+type ImageFilter struct {
+	Status     string // 'unlabelled', 'labelled' or 'reviewed'
+	Class      *int   // Class present in the labels
+	Tag        string // Image tag
+	Search     string // Part of the file name
+	MinWidth   int    // Dimensions in pixels
+	MaxWidth   int
+	MinHeight  int
+	MaxHeight  int
+	From       string // Upload date range as 'YYYY-MM-DD', both days included
+	To         string
+	Sort       string // 'name', 'uploaded', 'size' or 'boxes'
+	Descending bool
+}
+
+// Result of the catalog synchronisation
+type CatalogResult struct {
+	Images  int `json:"images"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+}
+
+// Merge, delete or reorder of the classes applied to the label files
+type ClassOperation struct {
+	Operation    string `json:"operation"` // 'merge', 'delete' or 'reorder'
+	Class        int    `json:"class"`
+	Into         int    `json:"into"`
+	DeleteImages bool   `json:"delete_images,omitempty"` // Move images with the deleted class to the trash
+	Order        []int  `json:"order,omitempty"`         // Old ids in the new order
+	DryRun       bool   `json:"dry_run"`
+}
+
+// Changes of the class operation
+type ClassReport struct {
+	ClassOperation
+	Classes []string `json:"classes"`
+	Mapping []int    `json:"mapping"` // New id of every old class, -1 for the deleted class
+	Files   int      `json:"files"`
+	Boxes   int      `json:"boxes"`
+	Removed int      `json:"removed"`
+	Images  int      `json:"images"`
+	Trash   int      `json:"trash"`
+}
+
+// Page of images
+type ImageList struct {
+	Items      []Image    `json:"items"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// Deleted image in the trash
+type TrashItem struct {
+	Id       string    `json:"id"`
+	Name     string    `json:"name"`
+	Location string    `json:"location"`
+	Deleted  time.Time `json:"deleted"`
+	Size     int64     `json:"size"`
+	Labelled bool      `json:"labelled"`
+}
+
+// Page of deleted images
+type TrashList struct {
+	Items      []TrashItem `json:"items"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+// Result of the bulk operation, failed images with the reason
+type BulkResult struct {
+	Done   int               `json:"done"`
+	Failed map[string]string `json:"failed"`
+}
+
+// Bulk action over the selected images or all images of the location
+type BulkRequest struct {
+	Action   string   `json:"action"` // 'move', 'delete', 'tag', 'untag', 'clear_labels', 'copy' or 'download'
+	To       string   `json:"to,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Dataset  string   `json:"dataset,omitempty"`
+	Images   []string `json:"images,omitempty"`   // Selected images as 'location/name'
+	Location string   `json:"location,omitempty"` // All images of the location matching the filter when images are empty
+}
+
+// Job to queue with its parameters
+type JobRequest struct {
+	Type   string      `json:"type"`             // 'export', 'version', 'catalog', 'import' or 'lint'
+	Params interface{} `json:"params,omitempty"` // e.g. {"rebuild": true} for 'catalog', {"folder": "..."} for 'import'
+}
+
+// Background job with the progress
+type Job struct {
+	Id       string          `json:"id"`
+	Type     string          `json:"type"`
+	Dataset  string          `json:"dataset"`
+	Status   string          `json:"status"` // 'queued', 'running', 'done', 'failed' or 'cancelled'
+	Params   json.RawMessage `json:"params"`
+	Done     int             `json:"done"`
+	Total    int             `json:"total"`
+	Error    string          `json:"error"`
+	Result   json.RawMessage `json:"result"` // BulkResult for the bulk actions
+	Output   string          `json:"output"` // File name of the output, download with DownloadJobOutput
+	Attempts int             `json:"attempts"`
+	Created  time.Time       `json:"created"`
+	Started  time.Time       `json:"started"`
+	Finished time.Time       `json:"finished"`
+}
+
+// Page of jobs
+type JobList struct {
+	Items      []Job      `json:"items"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// Event of the dataset, message is empty for the progress notifications
+type Event struct {
+	Id      int64           `json:"id"`
+	Type    string          `json:"type"` // 'upload', 'labels', 'job' or 'lint'
+	Dataset string          `json:"dataset"`
+	Time    time.Time       `json:"time"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"` // Job for the 'job' events, lint report for the 'lint' events
+}
+
+// Object on the image, coordinates are normalized to [0, 1]
+type Label struct {
+	Class  int       `json:"class"`
+	X      float64   `json:"x"`
+	Y      float64   `json:"y"`
+	Width  float64   `json:"width"`
+	Height float64   `json:"height"`
+	Points []float64 `json:"points,omitempty"`
+}
+
+// Labels of the image
+type Labels struct {
+	Image  string  `json:"image,omitempty"`
+	Labels []Label `json:"labels"`
+}
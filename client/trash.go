@@ -0,0 +1,121 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: trash.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/client
+	Purpose: Client methods for the trash of the dataset
+
+	Deleted images are kept in the trash until they are purged
+	=============================================================================
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+/****************************************************************************************
+ *
+ * Function : Client.ListTrash
+ *
+ * Purpose : Get page of deleted images, the latest first
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 page int64 - page number, 0 for the first page
+ *			 perPage int64 - images per page, 0 for the server default
+ *
+ *  Return : TrashList - page of deleted images
+ *			 error - error if occur
+ */
+func (client *Client) ListTrash(ctx context.Context, dataset string, page int64, perPage int64) (TrashList, error) {
+	var list TrashList
+	err := client.do(ctx, http.MethodGet, escape("datasets", dataset, "trash"), pageQuery(page, perPage), nil, &list)
+	return list, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.TrashImages
+ *
+ * Purpose : Move several images of the location with their labels to the trash
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - images location
+ *			 names []string - images file names
+ *
+ *  Return : BulkResult - number of deleted images and failed ones
+ *			 error - error if occur
+ */
+func (client *Client) TrashImages(ctx context.Context, dataset string, location string, names []string) (BulkResult, error) {
+	var result BulkResult
+	request := map[string]interface{}{"location": location, "names": names}
+	err := client.do(ctx, http.MethodPost, escape("datasets", dataset, "trash"), nil, request, &result)
+	return result, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.RestoreTrash
+ *
+ * Purpose : Move deleted image back to its location
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 id string - id of the deleted image
+ *
+ *  Return : Image - restored image
+ *			 error - error if occur
+ */
+func (client *Client) RestoreTrash(ctx context.Context, dataset string, id string) (Image, error) {
+	var image Image
+	err := client.do(ctx, http.MethodPost, escape("datasets", dataset, "trash", id, "restore"), nil, nil, &image)
+	return image, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.DeleteTrash
+ *
+ * Purpose : Delete image from the trash forever
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 id string - id of the deleted image
+ *
+ *  Return : error - error if occur
+ */
+func (client *Client) DeleteTrash(ctx context.Context, dataset string, id string) error {
+	return client.do(ctx, http.MethodDelete, escape("datasets", dataset, "trash", id), nil, nil, nil)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.PurgeTrash
+ *
+ * Purpose : Delete images kept in the trash longer than the duration
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 olderThan time.Duration - age of the deleted images, 0 to empty the trash
+ *
+ *  Return : int - number of purged images
+ *			 error - error if occur
+ */
+func (client *Client) PurgeTrash(ctx context.Context, dataset string, olderThan time.Duration) (int, error) {
+	query := url.Values{}
+	if olderThan > 0 {
+		query.Set("older_than", olderThan.String())
+	}
+
+	var result struct {
+		Purged int `json:"purged"`
+	}
+	err := client.do(ctx, http.MethodDelete, escape("datasets", dataset, "trash"), query, nil, &result)
+	return result.Purged, err
+}
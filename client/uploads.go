@@ -0,0 +1,230 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: uploads.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/client
+	Purpose: Client of the resumable uploads by the tus protocol
+
+	UploadFile sends the image by chunks and continues from the offset
+	received by the server when the connection is lost.
+	=============================================================================
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Size of the chunk sent by UploadFile
+const UploadChunkSize = 4 << 20
+
+// Attempts to send the chunk before UploadFile fails
+const uploadRetries = 5
+
+/****************************************************************************************
+ *
+ * Function : Client.CreateUpload
+ *
+ * Purpose : Start resumable upload of the image
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *			 size int64 - size of the image
+ *			 checksum string - checksum of the whole image '<algorithm> <base64 digest>', can be empty
+ *
+ *  Return : string - upload id
+ *			 error - error if occur
+ */
+func (client *Client) CreateUpload(ctx context.Context, dataset string, location string, name string, size int64, checksum string) (string, error) {
+	metadata := "filename " + base64.StdEncoding.EncodeToString([]byte(name)) +
+		",location " + base64.StdEncoding.EncodeToString([]byte(location))
+	if checksum != "" {
+		metadata += ",checksum " + base64.StdEncoding.EncodeToString([]byte(checksum))
+	}
+
+	headers := map[string]string{"Upload-Length": strconv.FormatInt(size, 10), "Upload-Metadata": metadata}
+	httpResponse, err := client.tus(ctx, http.MethodPost, escape("datasets", dataset, "uploads"), headers, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Base(httpResponse.Header.Get("Location")), nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.UploadOffset
+ *
+ * Purpose : Get number of bytes received by the server
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 id string - upload id
+ *
+ *  Return : int64 - received bytes
+ *			 error - error if occur
+ */
+func (client *Client) UploadOffset(ctx context.Context, dataset string, id string) (int64, error) {
+	httpResponse, err := client.tus(ctx, http.MethodHead, escape("datasets", dataset, "uploads", id), nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(httpResponse.Header.Get("Upload-Offset"), 10, 64)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.UploadChunk
+ *
+ * Purpose : Send the chunk with its sha1 checksum
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 id string - upload id
+ *			 offset int64 - offset of the chunk
+ *			 chunk []byte - data
+ *
+ *  Return : int64 - new offset
+ *			 error - error if occur
+ */
+func (client *Client) UploadChunk(ctx context.Context, dataset string, id string, offset int64, chunk []byte) (int64, error) {
+	sum := sha1.Sum(chunk)
+	headers := map[string]string{
+		"Content-Type":    "application/offset+octet-stream",
+		"Upload-Offset":   strconv.FormatInt(offset, 10),
+		"Upload-Checksum": "sha1 " + base64.StdEncoding.EncodeToString(sum[:])}
+
+	httpResponse, err := client.tus(ctx, http.MethodPatch, escape("datasets", dataset, "uploads", id), headers, bytes.NewReader(chunk))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(httpResponse.Header.Get("Upload-Offset"), 10, 64)
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.DeleteUpload
+ *
+ * Purpose : Cancel the upload and remove received bytes
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 id string - upload id
+ *
+ *  Return : error - error if occur
+ */
+func (client *Client) DeleteUpload(ctx context.Context, dataset string, id string) error {
+	_, err := client.tus(ctx, http.MethodDelete, escape("datasets", dataset, "uploads", id), nil, nil)
+	return err
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.UploadFile
+ *
+ * Purpose : Upload the image file by chunks, the server checks sha256 of the whole file
+ *			 Lost connection is retried from the offset received by the server
+ *
+ *   Input : ctx context.Context - request context
+ *			 dataset string - dataset name
+ *			 location string - 'uploaded' or one of the splits
+ *			 filePath string - path to the image file
+ *
+ *  Return : string - upload id
+ *			 error - error if occur, *Error for the API errors
+ */
+func (client *Client) UploadFile(ctx context.Context, dataset string, location string, filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := client.CreateUpload(ctx, dataset, location, filepath.Base(filePath), size, "sha256 "+base64.StdEncoding.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return "", err
+	}
+
+	chunk := make([]byte, UploadChunkSize)
+	offset, retries := int64(0), 0
+	for offset < size {
+		length, err := file.ReadAt(chunk, offset)
+		if err != nil && err != io.EOF {
+			return id, err
+		}
+
+		next, err := client.UploadChunk(ctx, dataset, id, offset, chunk[:length])
+		if err == nil {
+			offset, retries = next, 0
+			continue
+		}
+
+		// API errors are final, connection errors and wrong offset are retried from the received offset
+		if apiError, isAPIError := err.(*Error); (isAPIError && apiError.Status != http.StatusConflict) || ctx.Err() != nil || retries >= uploadRetries {
+			return id, err
+		}
+		retries++
+		time.Sleep(time.Duration(retries) * time.Second)
+		if received, err := client.UploadOffset(ctx, dataset, id); err == nil {
+			offset = received
+		}
+	}
+
+	return id, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Client.tus
+ *
+ * Purpose : Send the request of the tus protocol
+ *
+ *   Input : ctx context.Context - request context
+ *			 method string - http method
+ *			 resource string - path after the API prefix
+ *			 headers map[string]string - headers of the request
+ *			 body io.Reader - body, can be nil
+ *
+ *  Return : *http.Response - response with closed body
+ *			 error - error if occur, *Error for the API errors
+ */
+func (client *Client) tus(ctx context.Context, method string, resource string, headers map[string]string, body io.Reader) (*http.Response, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, method, client.url(resource, nil), body)
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Tus-Resumable", "1.0.0")
+	for name, value := range headers {
+		httpRequest.Header.Set(name, value)
+	}
+
+	httpResponse, err := client.HTTPClient.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	return httpResponse, checkResponse(httpResponse)
+}
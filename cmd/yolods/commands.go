@@ -0,0 +1,819 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: commands.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/cmd/yolods
+	Purpose: Commands of the tool
+
+	In the file
+		1. create, adopt, import, export, split, merge
+		2. version, synthesize, classes, stats, lint, catalog, trash
+		3. migrate, serve
+	=============================================================================
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/jobs"
+	"github.com/CoderSergiy/yolov8-dataset/server"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/****************************************************************************************
+ *
+ * Function : createCommand
+ *
+ * Purpose : Create a new dataset
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func createCommand(args []string) error {
+	flags, jsonOutput := newFlags("create")
+	descriptor := descriptorFlags(flags)
+	arguments, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	dataset, err := core.CreateDataset(arguments[0], descriptor())
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, map[string]string{"name": dataset.Name, "path": dataset.Path}, func() {
+		fmt.Printf("Dataset '%v' created in '%v'\n", dataset.Name, dataset.Path)
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : adoptCommand
+ *
+ * Purpose : Create a new dataset from the Ultralytics dataset folder or zip archive
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func adoptCommand(args []string) error {
+	flags, jsonOutput := newFlags("adopt")
+	descriptor := descriptorFlags(flags)
+	arguments, err := parseFlags(flags, args, 2)
+	if err != nil {
+		return err
+	}
+
+	// Source is checked before the dataset is created
+	plan, err := core.PlanAdoption(arguments[1])
+	if err != nil {
+		return err
+	}
+
+	dataset, err := core.CreateDataset(arguments[0], descriptor())
+	if err != nil {
+		plan.Discard()
+		return err
+	}
+
+	result, err := core.AdoptDataset(context.Background(), dataset, plan, nil)
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, result, func() {
+		fmt.Printf("Adopted %v images into '%v': %v labelled, %v renamed\n", result.Images, dataset.Name, result.Labelled, result.Renamed)
+		for _, location := range core.Splits {
+			fmt.Printf("%-8v %v images\n", location, result.Locations[location])
+		}
+		fmt.Printf("Classes: %v\n", strings.Join(plan.Classes, ", "))
+		for _, invalid := range result.InvalidLabels {
+			fmt.Printf("Label is not adopted: %v\n", invalid)
+		}
+		fmt.Printf("Lint found %v errors and %v warnings\n", result.Errors, result.Warnings)
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : importCommand
+ *
+ * Purpose : Import images with labels from the folder
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func importCommand(args []string) error {
+	flags, jsonOutput := newFlags("import")
+	options := core.ImportOptions{}
+	flags.StringVar(&options.Location, "location", core.LocationUploaded, "Location to import into: "+strings.Join(core.Locations, ", "))
+	flags.BoolVar(&options.Overwrite, "overwrite", false, "Replace images with the same name")
+	arguments, err := parseFlags(flags, args, 2)
+	if err != nil {
+		return err
+	}
+
+	dataset, err := core.OpenDataset(arguments[0])
+	if err != nil {
+		return err
+	}
+
+	result, err := dataset.ImportFolder(arguments[1], options)
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, result, func() {
+		fmt.Printf("Imported %v images (%v with labels), skipped %v\n", result.Imported, result.Labelled, result.Skipped)
+		for _, invalid := range result.InvalidLabels {
+			fmt.Printf("  invalid label: %v\n", invalid)
+		}
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : exportCommand
+ *
+ * Purpose : Write dataset or version as zip archive
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func exportCommand(args []string) error {
+	flags, jsonOutput := newFlags("export")
+	version := flags.String("version", "", "Export the version instead of the current splits")
+	arguments, err := parseFlags(flags, args, 2)
+	if err != nil {
+		return err
+	}
+
+	dataset, err := core.OpenDataset(arguments[0])
+	if err != nil {
+		return err
+	}
+
+	// Archive is written next to the target and renamed when completed
+	target := arguments[1]
+	archive, err := os.CreateTemp(filepath.Dir(target), ".export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+
+	if *version != "" {
+		err = dataset.ExportVersion(*version, archive)
+	} else {
+		err = dataset.Export(archive)
+	}
+	if closeErr := archive.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(archive.Name(), target); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	result := map[string]interface{}{"dataset": dataset.Name, "version": *version, "file": target, "size": info.Size()}
+	return printResult(*jsonOutput, result, func() {
+		fmt.Printf("Exported '%v' to '%v' (%v bytes)\n", dataset.Name, target, info.Size())
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : splitCommand
+ *
+ * Purpose : Move uploaded images to the splits
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func splitCommand(args []string) error {
+	flags, jsonOutput := newFlags("split")
+	options := core.DefaultSplitOptions
+	flags.Float64Var(&options.Train, "train", options.Train, "Train split ratio")
+	flags.Float64Var(&options.Valid, "valid", options.Valid, "Valid split ratio")
+	flags.Float64Var(&options.Test, "test", options.Test, "Test split ratio")
+	flags.Int64Var(&options.Seed, "seed", 0, "Seed to shuffle images")
+	flags.BoolVar(&options.IncludeUnlabelled, "include-unlabelled", false, "Move images without labels as background images")
+	arguments, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	dataset, err := core.OpenDataset(arguments[0])
+	if err != nil {
+		return err
+	}
+
+	moved, err := dataset.SplitUploaded(options)
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, moved, func() {
+		for _, split := range core.Splits {
+			fmt.Printf("%-6v %v images\n", split, moved[split])
+		}
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : mergeCommand
+ *
+ * Purpose : Create a new dataset from the images of two or more datasets
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func mergeCommand(args []string) error {
+	flags, jsonOutput := newFlags("merge")
+	sources := flags.String("sources", "", "Comma separated datasets to merge")
+	classes := flags.String("classes", "", "Comma separated mapping 'name=merged name' of the source classes, empty merged name drops the boxes")
+	resplit := flags.Bool("resplit", false, "Distribute the split images again by the ratios")
+	split := core.DefaultSplitOptions
+	flags.Float64Var(&split.Train, "train", split.Train, "Train split ratio with --resplit")
+	flags.Float64Var(&split.Valid, "valid", split.Valid, "Valid split ratio with --resplit")
+	flags.Float64Var(&split.Test, "test", split.Test, "Test split ratio with --resplit")
+	flags.Int64Var(&split.Seed, "seed", 0, "Seed to shuffle images with --resplit")
+	arguments, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	options := core.MergeOptions{}
+	for _, source := range strings.Split(*sources, ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			options.Sources = append(options.Sources, source)
+		}
+	}
+	for _, pair := range strings.Split(*classes, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, merged, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("class mapping '%v' is not 'name=merged name'", pair)
+		}
+		if options.Classes == nil {
+			options.Classes = map[string]string{}
+		}
+		options.Classes[strings.TrimSpace(name)] = strings.TrimSpace(merged)
+	}
+	if *resplit {
+		options.Split = &split
+	}
+	if err := options.Validate(arguments[0]); err != nil {
+		return err
+	}
+
+	dataset, err := core.CreateDataset(arguments[0], core.Descriptor{})
+	if err != nil {
+		return err
+	}
+
+	result, err := core.MergeDatasets(context.Background(), dataset, options, nil)
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, result, func() {
+		fmt.Printf("Merged %v images into '%v': %v labelled, %v renamed, %v boxes dropped\n", result.Images, dataset.Name, result.Labelled, result.Renamed, result.Dropped)
+		for _, location := range core.Locations {
+			fmt.Printf("%-8v %v images\n", location, result.Locations[location])
+		}
+		fmt.Printf("Classes: %v\n", strings.Join(result.Names, ", "))
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : versionCommand
+ *
+ * Purpose : Create a version of the dataset or list versions
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func versionCommand(args []string) error {
+	flags, jsonOutput := newFlags("version")
+	options := core.VersionOptions{}
+	flags.StringVar(&options.Name, "name", "", "Version name, next 'v<number>' by default")
+	list := flags.Bool("list", false, "List versions instead of creating a new one")
+	preprocess := core.PreprocessOptions{}
+	flags.BoolVar(&preprocess.AutoOrient, "auto-orient", false, "Turn the images by their EXIF orientation")
+	flags.IntVar(&preprocess.Size, "size", 0, "Resize the images to the size in pixels, e.g. 640")
+	flags.StringVar(&preprocess.Resize, "resize", "", "Resize mode used with --size: stretch, fit or letterbox (default)")
+	flags.BoolVar(&preprocess.Grayscale, "grayscale", false, "Convert the images to grayscale")
+	tile := core.TileOptions{}
+	flags.IntVar(&tile.Size, "tile", 0, "Cut the images larger than the size into tiles, e.g. 640")
+	flags.Float64Var(&tile.Overlap, "tile-overlap", 0.2, "Part of the tile shared with the next one")
+	flags.Float64Var(&tile.MinVisible, "tile-min-visible", core.DefaultTileMinVisible, "Part of the box left in the tile to keep the label")
+	flags.Float64Var(&tile.Background, "tile-background", 0, "Part of the tiles without labels kept as background")
+	augment := core.AugmentOptions{}
+	transforms := flags.String("augment", "", "Comma separated transforms of the training images: "+strings.Join(core.AugmentTransforms, ", "))
+	flags.IntVar(&augment.Copies, "copies", 2, "Augmented copies of every training image, used with --augment")
+	flags.Int64Var(&augment.Seed, "seed", 0, "Seed of the augmentation and the background tiles, the same seed gives the same images")
+	arguments, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if preprocess != (core.PreprocessOptions{}) {
+		options.Preprocess = &preprocess
+	}
+	if tile.Size != 0 {
+		tile.Seed = augment.Seed
+		options.Tile = &tile
+	}
+	if *transforms != "" {
+		augment.Transforms = core.ParseAugmentTransforms(*transforms)
+		options.Augment = &augment
+	}
+
+	dataset, err := core.OpenDataset(arguments[0])
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		versions, err := dataset.ListVersions()
+		if err != nil {
+			return err
+		}
+		return printResult(*jsonOutput, versions, func() {
+			for _, version := range versions {
+				fmt.Printf("%-10v %v  %v images, %v boxes\n", version.Name, version.Created.Format("2006-01-02 15:04:05"), version.Images, version.Boxes)
+			}
+		})
+	}
+
+	manifest, err := dataset.CreateVersion(options)
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, manifest, func() {
+		fmt.Printf("Version '%v' created: %v images, %v boxes\n", manifest.Name, manifest.Images, manifest.Boxes)
+		for _, split := range core.Splits {
+			fmt.Printf("  %-6v %v images\n", split, manifest.Splits[split].Images)
+		}
+		if recipe := manifest.Preprocessing; recipe != nil {
+			fmt.Printf("Preprocessed %v images: %v turned by EXIF orientation, %v copied as is\n", recipe.Images, recipe.Oriented, recipe.Skipped)
+		}
+		if recipe := manifest.Tiling; recipe != nil {
+			fmt.Printf("Cut %v images into %v tiles: %v background tiles kept, %v dropped, %v labels lost\n", recipe.Images, recipe.Tiles, recipe.Empty, recipe.Skipped, recipe.Dropped)
+			if recipe.Undecoded > 0 {
+				fmt.Printf("  %v images cannot be decoded and are kept full size\n", recipe.Undecoded)
+			}
+		}
+		if recipe := manifest.Augmentation; recipe != nil {
+			fmt.Printf("Augmented %v training images: %v copies with %v boxes, %v boxes dropped\n", recipe.Source, recipe.Images, recipe.Boxes, recipe.Dropped)
+		}
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : synthesizeCommand
+ *
+ * Purpose : Write mosaic or copy-paste images of the rare classes to the split
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func synthesizeCommand(args []string) error {
+	flags, jsonOutput := newFlags("synthesize")
+	options := core.SynthesizeOptions{}
+	flags.StringVar(&options.Method, "method", core.SynthesizeMosaic, "Method: mosaic or copy_paste")
+	flags.IntVar(&options.Count, "count", 10, "Number of the new images")
+	classes := flags.String("classes", "", "Comma separated rare classes, needed by copy_paste")
+	flags.StringVar(&options.Source, "source", "", "Location of the source images, the target by default")
+	flags.StringVar(&options.Target, "target", core.SplitTrain, "Split of the new images")
+	flags.IntVar(&options.Size, "size", core.DefaultMosaicSize, "Side of the mosaic image")
+	flags.IntVar(&options.Objects, "objects", 3, "Largest number of the objects pasted on one image")
+	flags.Int64Var(&options.Seed, "seed", 0, "Seed of the random choices")
+	arguments, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	for _, value := range strings.Split(*classes, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		class, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("class '%v' is not a number", value)
+		}
+		options.Classes = append(options.Classes, class)
+	}
+
+	dataset, err := core.OpenDataset(arguments[0])
+	if err != nil {
+		return err
+	}
+
+	result, err := dataset.Synthesize(context.Background(), options, nil)
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, result, func() {
+		fmt.Printf("Synthesized %v images in '%v': %v boxes, %v pasted objects, %v labels dropped\n", result.Images, result.Target, result.Boxes, result.Pasted, result.Dropped)
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : classesCommand
+ *
+ * Purpose : List classes or merge, delete and reorder them with the label files
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func classesCommand(args []string) error {
+	flags, jsonOutput := newFlags("classes")
+	merge := flags.String("merge", "", "Class to merge, id or name")
+	into := flags.String("into", "", "Class which gets the boxes of the merged class")
+	remove := flags.String("delete", "", "Class to delete with its boxes, id or name")
+	deleteImages := flags.Bool("delete-images", false, "Move images with the deleted class to the trash instead of removing the boxes")
+	reorder := flags.String("reorder", "", "Comma separated classes in the new order, ids or names")
+	dryRun := flags.Bool("dry-run", false, "Report the changes without changing the files")
+	arguments, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	dataset, err := core.OpenDataset(arguments[0])
+	if err != nil {
+		return err
+	}
+	names, err := dataset.Classes()
+	if err != nil {
+		return err
+	}
+
+	// Class is given by the id or the name
+	classId := func(value string) (int, error) {
+		value = strings.TrimSpace(value)
+		for id, name := range names {
+			if name == value {
+				return id, nil
+			}
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("class '%v' is not known", value)
+		}
+		return id, nil
+	}
+
+	operation := core.ClassOperation{DeleteImages: *deleteImages, DryRun: *dryRun}
+	switch {
+	case *merge != "":
+		operation.Operation = core.ClassMerge
+		if operation.Class, err = classId(*merge); err == nil {
+			operation.Into, err = classId(*into)
+		}
+	case *remove != "":
+		operation.Operation = core.ClassDelete
+		operation.Class, err = classId(*remove)
+	case *reorder != "":
+		operation.Operation = core.ClassReorder
+		for _, value := range strings.Split(*reorder, ",") {
+			var id int
+			if id, err = classId(value); err != nil {
+				break
+			}
+			operation.Order = append(operation.Order, id)
+		}
+	default:
+		return printResult(*jsonOutput, names, func() {
+			for id, name := range names {
+				fmt.Printf("%3v  %v\n", id, name)
+			}
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := dataset.ChangeClasses(operation)
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, report, func() {
+		prefix := "Changed"
+		if report.DryRun {
+			prefix = "Dry run, would change"
+		}
+		fmt.Printf("%v %v label files: %v boxes get the new class, %v boxes removed, %v images moved to the trash, %v label files in the trash\n",
+			prefix, report.Files, report.Boxes, report.Removed, report.Images, report.Trash)
+		fmt.Printf("Classes: %v\n", strings.Join(report.Classes, ", "))
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : statsCommand
+ *
+ * Purpose : Print dataset statistics
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func statsCommand(args []string) error {
+	flags, jsonOutput := newFlags("stats")
+	arguments, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	dataset, err := core.OpenDataset(arguments[0])
+	if err != nil {
+		return err
+	}
+
+	stats, err := dataset.Stats()
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, stats, func() {
+		fmt.Printf("Images: %v, labelled: %v, boxes: %v\n", stats.Images, stats.Labelled, stats.Boxes)
+		for _, location := range core.Locations {
+			locationStats := stats.Locations[location]
+			fmt.Printf("  %-8v %6v images %6v labelled %6v boxes\n", location, locationStats.Images, locationStats.Labelled, locationStats.Boxes)
+		}
+		fmt.Println("Classes:")
+		for _, class := range stats.Classes {
+			fmt.Printf("  %3v %-20v %6v images %6v boxes\n", class.Id, class.Name, class.Images, class.Boxes)
+		}
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : lintCommand
+ *
+ * Purpose : Check dataset for problems, fails when errors found
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func lintCommand(args []string) error {
+	flags, jsonOutput := newFlags("lint")
+	arguments, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	dataset, err := core.OpenDataset(arguments[0])
+	if err != nil {
+		return err
+	}
+
+	report, err := dataset.Lint()
+	if err != nil {
+		return err
+	}
+
+	err = printResult(*jsonOutput, report, func() {
+		for _, issue := range report.Issues {
+			fmt.Printf("%-7v %v: %v\n", issue.Severity, filepath.Join(issue.Location, issue.File), issue.Message)
+		}
+		fmt.Printf("Checked %v images: %v errors, %v warnings\n", report.Images, report.Errors, report.Warnings)
+	})
+	if err != nil {
+		return err
+	}
+
+	if report.Errors > 0 {
+		return exitError{}
+	}
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : catalogCommand
+ *
+ * Purpose : Synchronise images catalog with the files or rebuild it
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func catalogCommand(args []string) error {
+	flags, jsonOutput := newFlags("catalog")
+	rebuild := flags.Bool("rebuild", false, "Read all images again instead of the changed ones")
+	arguments, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	dataset, err := core.OpenDataset(arguments[0])
+	if err != nil {
+		return err
+	}
+
+	result, err := dataset.SyncCatalog(*rebuild)
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, result, func() {
+		fmt.Printf("Catalog of '%v': %v images, %v updated, %v removed\n", dataset.Name, result.Images, result.Updated, result.Removed)
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : trashCommand
+ *
+ * Purpose : List deleted images, restore one of them or purge the trash
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func trashCommand(args []string) error {
+	flags, jsonOutput := newFlags("trash")
+	restore := flags.String("restore", "", "Id of the deleted image to restore")
+	purge := flags.Bool("purge", false, "Delete images from the trash forever")
+	olderThan := flags.Duration("older-than", 0, "Purge only images deleted earlier than the duration ago")
+	arguments, err := parseFlags(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	dataset, err := core.OpenDataset(arguments[0])
+	if err != nil {
+		return err
+	}
+
+	if *restore != "" {
+		image, err := dataset.RestoreTrash(*restore)
+		if err != nil {
+			return err
+		}
+		return printResult(*jsonOutput, image, func() {
+			fmt.Printf("Restored '%v/%v'\n", image.Location, image.Name)
+		})
+	}
+
+	if *purge {
+		purged, err := dataset.PurgeTrash(*olderThan)
+		if err != nil {
+			return err
+		}
+		return printResult(*jsonOutput, map[string]int{"purged": purged}, func() {
+			fmt.Printf("Purged %v images from the trash of '%v'\n", purged, dataset.Name)
+		})
+	}
+
+	items, err := dataset.ListTrash()
+	if err != nil {
+		return err
+	}
+
+	return printResult(*jsonOutput, items, func() {
+		for _, item := range items {
+			fmt.Printf("%-16v %-8v %v (deleted %v)\n", item.Id, item.Location, item.Name, item.Deleted.Format("2006-01-02 15:04"))
+		}
+		fmt.Printf("%v images in the trash of '%v'\n", len(items), dataset.Name)
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : migrateCommand
+ *
+ * Purpose : Upgrade one or all datasets to the latest layout
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - error if occur
+ */
+func migrateCommand(args []string) error {
+	flags, jsonOutput := newFlags("migrate")
+	name := flags.String("dataset", "", "Migrate only the dataset, all datasets by default")
+	options := core.MigrationOptions{}
+	flags.BoolVar(&options.DryRun, "dry-run", false, "Print the steps without doing them")
+	noBackup := flags.Bool("no-backup", false, "Do not zip the dataset to the '"+core.BackupsFolder+"' folder first")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	options.Backup = !*noBackup
+
+	var results []core.MigrationResult
+	if *name != "" {
+		dataset, err := core.OpenDataset(*name)
+		if err != nil {
+			return err
+		}
+		result, err := dataset.MigrateLayout(options)
+		if err != nil {
+			return err
+		}
+		results = append(results, result)
+	} else {
+		var err error
+		if results, err = core.MigrateDatasets(options); err != nil {
+			return err
+		}
+	}
+
+	failed, migrated := false, 0
+	for _, result := range results {
+		failed = failed || result.Error != ""
+		if len(result.Steps) > 0 {
+			migrated++
+		}
+	}
+
+	err := printResult(*jsonOutput, results, func() {
+		for _, result := range results {
+			switch {
+			case result.Error != "":
+				fmt.Printf("'%v': stopped at layout %v: %v\n", result.Dataset, result.To, result.Error)
+			case len(result.Steps) == 0:
+				fmt.Printf("'%v': layout %v is the latest\n", result.Dataset, result.From)
+			default:
+				fmt.Printf("'%v': layout %v to %v\n", result.Dataset, result.From, result.To)
+			}
+			for _, step := range result.Steps {
+				fmt.Printf("  - %v\n", step)
+			}
+			if result.Backup != "" {
+				fmt.Printf("  backup %v\n", result.Backup)
+			}
+		}
+		if options.DryRun {
+			fmt.Printf("%v datasets to migrate to layout %v\n", migrated, core.LayoutVersion)
+		} else {
+			fmt.Printf("%v datasets migrated to layout %v\n", migrated, core.LayoutVersion)
+		}
+	})
+	if err == nil && failed {
+		return exitError{}
+	}
+
+	return err
+}
+
+/****************************************************************************************
+ *
+ * Function : serveCommand
+ *
+ * Purpose : Run the web server
+ *
+ *   Input : args []string - command line arguments
+ *
+ *  Return : error - reason why server stopped
+ */
+func serveCommand(args []string) error {
+	flags, _ := newFlags("serve")
+	options := server.Options{}
+	flags.StringVar(&options.Address, "address", ":8080", "Address to listen")
+	flags.BoolVar(&options.Dev, "dev", false, "Load templates and assets from the disk for live editing")
+	flags.StringVar(&options.WebPath, "web", "web", "Path to the 'web' folder, used with --dev")
+	flags.BoolVar(&options.Reload, "reload", false, "Reload templates when files changed, used with --dev")
+	flags.DurationVar(&options.TrashRetention, "trash-retention", core.DefaultTrashRetention, "Time to keep deleted images in the trash, 0 to keep forever")
+	flags.IntVar(&options.Workers, "workers", jobs.DefaultWorkers, "Number of background jobs running at the same time")
+	flags.IntVar(&options.ProcessWorkers, "process-workers", core.DefaultProcessWorkers, "Number of uploaded images processed at the same time")
+	flags.BoolVar(&options.Migrate, "migrate", true, "Upgrade datasets to the latest layout before the start")
+	flags.BoolVar(&options.MigrationBackup, "migrate-backup", true, "Zip every migrated dataset to the '"+core.BackupsFolder+"' folder first")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	return server.Run(options)
+}
@@ -0,0 +1,215 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: main.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/cmd/yolods
+	Purpose: Command line tool for the headless dataset operations
+
+	Usage: yolods <command> [flags] [arguments]
+		All commands accept --json flag to print machine readable result
+		Exit code is 1 on error, lint exits with 1 when errors found
+	=============================================================================
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Command of the tool
+type command struct {
+	usage       string
+	description string
+	run         func(args []string) error
+}
+
+// Commands by the name, filled in init to let commands print own usage
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"create":     {"create [--description text] [--task detect] [--owner name] [--tags a,b] <dataset>", "Create a new dataset with the descriptor", createCommand},
+		"adopt":      {"adopt [--description text] [--task detect] [--owner name] [--tags a,b] <dataset> <folder|file.zip>", "Create a new dataset from the Ultralytics dataset with data.yaml", adoptCommand},
+		"import":     {"import [--location uploaded] [--overwrite] <dataset> <folder>", "Import images with labels from the folder", importCommand},
+		"export":     {"export [--version <name>] <dataset> <file.zip>", "Export dataset or version as zip archive", exportCommand},
+		"split":      {"split [--train 0.7] [--valid 0.2] [--test 0.1] [--seed 0] [--include-unlabelled] <dataset>", "Move uploaded images to the splits", splitCommand},
+		"merge":      {"merge --sources <dataset>,<dataset> [--classes car=vehicle,truck=vehicle] [--resplit] [--train 0.7] [--valid 0.2] [--test 0.1] [--seed 0] <dataset>", "Create a new dataset from the images of other datasets", mergeCommand},
+		"version":    {"version [--name <name>] [--list] [--auto-orient] [--size 640] [--resize letterbox] [--grayscale] [--tile 640] [--tile-overlap 0.2] [--tile-background 0] [--augment flip_horizontal,crop] [--copies 2] [--seed 0] <dataset>", "Create a version of the dataset or list versions", versionCommand},
+		"synthesize": {"synthesize [--method mosaic] [--count 10] [--classes 3,5] [--source train] [--target train] [--size 640] [--objects 3] [--seed 0] <dataset>", "Write mosaic or copy-paste images of the rare classes", synthesizeCommand},
+		"classes":    {"classes [--merge <class> --into <class>] [--delete <class> [--delete-images]] [--reorder 2,0,1] [--dry-run] <dataset>", "List, merge, delete or reorder classes with the label files", classesCommand},
+		"stats":      {"stats <dataset>", "Print dataset statistics", statsCommand},
+		"lint":       {"lint <dataset>", "Check dataset for problems", lintCommand},
+		"catalog":    {"catalog [--rebuild] <dataset>", "Synchronise images catalog with the files or rebuild it", catalogCommand},
+		"trash":      {"trash [--restore <id>] [--purge] [--older-than 720h] <dataset>", "List, restore or purge deleted images", trashCommand},
+		"migrate":    {"migrate [--dataset <name>] [--dry-run] [--no-backup]", "Upgrade datasets to the latest folder layout", migrateCommand},
+		"serve":      {"serve [--address :8080] [--dev] [--web web] [--reload] [--trash-retention 720h] [--workers 2] [--process-workers 4] [--migrate=false] [--migrate-backup=false]", "Run the web server", serveCommand},
+	}
+}
+
+// Error to exit with code 1 without printing anything more
+type exitError struct{}
+
+func (exitError) Error() string { return "" }
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		printUsage()
+		return
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command '%v'\n\n", os.Args[1])
+		printUsage()
+		os.Exit(2)
+	}
+
+	err := cmd.run(os.Args[2:])
+	core.CloseCatalogs()
+	if err != nil {
+		if _, silent := err.(exitError); !silent {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : printUsage
+ *
+ * Purpose : Print list of the commands
+ *
+ *   Input : Nothing
+ *
+ *  Return : Nothing
+ */
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: yolods <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10v %v\n", name, commands[name].description)
+		fmt.Fprintf(os.Stderr, "  %-10v yolods %v\n", "", commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "All commands accept --json flag to print result as JSON and --datasets flag with the datasets folder")
+}
+
+/****************************************************************************************
+ *
+ * Function : descriptorFlags
+ *
+ * Purpose : Add flags of the dataset descriptor
+ *
+ *   Input : flags *flag.FlagSet - flags of the command
+ *
+ *  Return : func() core.Descriptor - descriptor of the parsed flags
+ */
+func descriptorFlags(flags *flag.FlagSet) func() core.Descriptor {
+	descriptor := core.Descriptor{}
+	flags.StringVar(&descriptor.Description, "description", "", "Description of the dataset")
+	flags.StringVar(&descriptor.Task, "task", core.TaskDetect, "Yolov8 task: "+strings.Join(core.Tasks, ", "))
+	flags.StringVar(&descriptor.Owner, "owner", "", "Person or team responsible for the dataset")
+	tags := flags.String("tags", "", "Comma separated tags")
+
+	return func() core.Descriptor {
+		descriptor.Tags = strings.Split(*tags, ",")
+		return descriptor
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : newFlags
+ *
+ * Purpose : Create flag set of the command with the common --json and --datasets flags
+ *
+ *   Input : name string - command name
+ *
+ *  Return : *flag.FlagSet - flags of the command
+ *			 *bool - value of the --json flag
+ */
+func newFlags(name string) (*flag.FlagSet, *bool) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	jsonOutput := flags.Bool("json", false, "Print result as JSON")
+	flags.StringVar(&core.DatasetsPath, "datasets", core.DatasetsPath, "Folder with all datasets")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: yolods %v\n", commands[name].usage)
+		flags.PrintDefaults()
+	}
+
+	return flags, jsonOutput
+}
+
+/****************************************************************************************
+ *
+ * Function : parseFlags
+ *
+ * Purpose : Parse flags placed before and after the arguments
+ *
+ *   Input : flags *flag.FlagSet - flags of the command
+ *			 args []string - command line arguments
+ *			 expected int - number of required arguments
+ *
+ *  Return : []string - arguments without flags
+ *			 error - error if flags are wrong or arguments are missing
+ */
+func parseFlags(flags *flag.FlagSet, args []string, expected int) ([]string, error) {
+	var arguments []string
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, exitError{}
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		arguments = append(arguments, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if len(arguments) != expected {
+		flags.Usage()
+		return nil, exitError{}
+	}
+
+	return arguments, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : printResult
+ *
+ * Purpose : Print result as JSON or as text
+ *
+ *   Input : jsonOutput bool - true to print JSON
+ *			 result interface{} - result to print as JSON
+ *			 text func() - prints result as text
+ *
+ *  Return : error - error if occur
+ */
+func printResult(jsonOutput bool, result interface{}, text func()) error {
+	if !jsonOutput {
+		text()
+		return nil
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
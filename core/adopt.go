@@ -0,0 +1,599 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: adopt.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Adopt the Ultralytics dataset folder or zip archive as the new dataset
+
+	Source is the folder on the server or the zip archive with data.yaml in
+	the root or in the only subfolder. Splits of the data.yaml are folders
+	or list files, one path or the list of them, relative to 'path:' or to
+	the data.yaml folder. Roboflow '../train/images' is found next to the
+	data.yaml as well. Label of the image is found as Ultralytics does it,
+	the last 'images' folder of the path replaced by 'labels', or next to
+	the image. Source is checked before the dataset is created, every label
+	file is checked when it is copied, image with the invalid label is
+	adopted without the label. Paths of the extracted archive must stay
+	inside it, symbolic links are not followed.
+
+	In the file
+		1. PlanAdoption, PlanAdoptionArchive - check the source and count images
+		2. AdoptPlan.Discard - remove the extracted archive which is not adopted
+		3. AdoptDataset - copy images and labels of the plan into the dataset
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix of the hidden folders with the extracted archives
+const adoptFolderPrefix = ".adopt-"
+
+// Biggest uploaded zip archive of the adoption
+var MaxAdoptArchiveSize int64 = 8 << 30
+
+// Biggest size of the extracted files of the adopted archive
+var MaxAdoptExtractedSize int64 = 32 << 30
+
+// Most entries of the adopted archive
+var MaxAdoptArchiveEntries = 1000000
+
+// Keys of the data.yaml splits for every split of the dataset
+var adoptSplitKeys = map[string]string{SplitTrain: "train", SplitValid: "val", SplitTest: "test"}
+
+// Settings of the adoption
+type AdoptOptions struct {
+	Source string `json:"source"` // Folder or zip archive on the server
+}
+
+// Checked source of the adoption
+type AdoptPlan struct {
+	Folder    string              `json:"folder"`              // Folder with the data.yaml
+	Classes   []string            `json:"classes"`             // Classes of the data.yaml
+	Sources   map[string][]string `json:"sources"`             // Image folders and list files of every split
+	Images    map[string]int      `json:"images"`              // Images found in every split
+	Temporary string              `json:"temporary,omitempty"` // Folder of the extracted archive, removed after the adoption
+}
+
+// Result of the adoption
+type AdoptResult struct {
+	Images        int            `json:"images"`         // Copied images
+	Labelled      int            `json:"labelled"`       // Copied images with labels
+	Renamed       int            `json:"renamed"`        // Images which got the new name
+	Locations     map[string]int `json:"locations"`      // Images of every split
+	InvalidLabels []string       `json:"invalid_labels"` // Images adopted without the invalid label
+	Errors        int            `json:"errors"`         // Errors found by the lint of the adopted dataset
+	Warnings      int            `json:"warnings"`       // Warnings found by the lint of the adopted dataset
+}
+
+/****************************************************************************************
+ *
+ * Function : PlanAdoption
+ *
+ * Purpose : Find the data.yaml, resolve the splits and count images
+ *			 Zip archive is extracted to the hidden folder of the datasets
+ *
+ *   Input : source string - folder or zip archive on the server
+ *
+ *  Return : AdoptPlan - checked source
+ *			 error - ErrInvalidAdoption if source is not the Ultralytics dataset
+ */
+func PlanAdoption(source string) (AdoptPlan, error) {
+	plan := AdoptPlan{Sources: map[string][]string{}, Images: map[string]int{}}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return plan, fmt.Errorf("%w: %v", ErrInvalidAdoption, err)
+	}
+
+	root := source
+	if !info.IsDir() {
+		if !strings.EqualFold(filepath.Ext(source), archiveExtension) {
+			return plan, fmt.Errorf("%w: source is neither folder nor zip archive", ErrInvalidAdoption)
+		}
+		plan.Temporary = filepath.Join(DatasetsPath, adoptFolderPrefix+strconv.FormatInt(time.Now().UnixNano(), 36))
+		if err := extractArchive(source, plan.Temporary, MaxAdoptArchiveEntries, MaxAdoptExtractedSize); err != nil {
+			os.RemoveAll(plan.Temporary)
+			return plan, fmt.Errorf("%w: %v", ErrInvalidAdoption, err)
+		}
+		root = plan.Temporary
+	}
+
+	if err := plan.check(root); err != nil {
+		plan.Discard()
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : PlanAdoptionArchive
+ *
+ * Purpose : Save the uploaded zip archive and check it, the archive is removed
+ *			 when it is extracted
+ *
+ *   Input : archive io.Reader - content of the zip archive
+ *
+ *  Return : AdoptPlan - checked source
+ *			 error - ErrInvalidAdoption if archive is not the Ultralytics dataset,
+ *			 ErrUploadTooLarge if archive is larger than MaxAdoptArchiveSize
+ */
+func PlanAdoptionArchive(archive io.Reader) (AdoptPlan, error) {
+	path := filepath.Join(DatasetsPath, adoptFolderPrefix+strconv.FormatInt(time.Now().UnixNano(), 36)+archiveExtension)
+	if err := writeFile(path, &sizeLimitReader{source: archive, left: MaxAdoptArchiveSize}); err != nil {
+		os.Remove(path)
+		return AdoptPlan{}, err
+	}
+	defer os.Remove(path)
+
+	return PlanAdoption(path)
+}
+
+/****************************************************************************************
+ *
+ * Function : AdoptPlan.Discard
+ *
+ * Purpose : Remove the extracted archive of the plan which is not adopted
+ *
+ *   Input : Nothing
+ *
+ *  Return : Nothing
+ */
+func (plan AdoptPlan) Discard() {
+	if plan.Temporary != "" {
+		os.RemoveAll(plan.Temporary)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : AdoptDataset
+ *
+ * Purpose : Copy images with labels of the plan into the dataset and lint it
+ *			 Classes of data.yaml are replaced by the classes of the source
+ *
+ *   Input : ctx context.Context - context, cancelled context stops the adoption
+ *			 dataset Dataset - new dataset
+ *			 plan AdoptPlan - checked source
+ *			 progress BulkProgress - called after every image, can be nil
+ *
+ *  Return : AdoptResult - number of copied images
+ *			 error - error if occur
+ */
+func AdoptDataset(ctx context.Context, dataset Dataset, plan AdoptPlan, progress BulkProgress) (AdoptResult, error) {
+	result := AdoptResult{Locations: map[string]int{}, InvalidLabels: []string{}}
+	defer plan.Discard()
+
+	if err := dataset.SetClasses(plan.Classes); err != nil {
+		return result, err
+	}
+
+	total := 0
+	for _, count := range plan.Images {
+		total += count
+	}
+
+	used := map[string]bool{}
+	for _, location := range Splits {
+		images, err := plan.images(location)
+		if err != nil {
+			return result, err
+		}
+
+		for _, image := range images {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+
+			name := filepath.Base(image)
+			if used[name] || !IsValidName(name) {
+				name = dataset.freeImageName(adoptBaseName(name), strings.ToLower(filepath.Ext(name)))
+				result.Renamed++
+			}
+			used[name] = true
+
+			if err := dataset.adoptImage(image, location, name, len(plan.Classes), &result); err != nil {
+				return result, fmt.Errorf("image '%v': %w", image, err)
+			}
+			if progress != nil {
+				progress(result.Images, total)
+			}
+		}
+	}
+
+	report, err := dataset.Lint()
+	if err != nil {
+		return result, err
+	}
+	result.Errors, result.Warnings = report.Errors, report.Warnings
+
+	return result, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.adoptImage
+ *
+ * Purpose : Copy image with the valid label into the split
+ *
+ *   Input : image string - path to the image of the source
+ *			 location string - split of the dataset
+ *			 name string - image name in the dataset
+ *			 classes int - number of classes
+ *			 result *AdoptResult - result to count the image
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) adoptImage(image string, location string, name string, classes int, result *AdoptResult) error {
+	imagePath, err := dataset.ImagePath(location, name)
+	if err != nil {
+		return err
+	}
+	if err := copyFileAtomic(image, imagePath); err != nil {
+		return err
+	}
+	result.Images++
+	result.Locations[location]++
+
+	labelled := false
+	if content, err := os.ReadFile(adoptLabelPath(image)); err == nil {
+		labels, err := ParseLabels(content)
+		if err == nil {
+			err = ValidateLabels(labels, classes)
+		}
+		if err != nil {
+			result.InvalidLabels = append(result.InvalidLabels, location+"/"+name+": "+err.Error())
+		} else if len(labels) > 0 {
+			if err := dataset.WriteLabels(location, name, labels); err != nil {
+				return err
+			}
+			labelled = true
+			result.Labelled++
+		}
+	}
+
+	if !labelled {
+		dataset.refreshCatalog(location, name)
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : AdoptPlan.check
+ *
+ * Purpose : Parse the data.yaml of the folder, resolve splits and count images
+ *
+ *   Input : root string - folder with the data.yaml or with the only subfolder having it
+ *
+ *  Return : error - ErrInvalidAdoption if source is not valid
+ */
+func (plan *AdoptPlan) check(root string) error {
+	folder, err := findDataFileFolder(root)
+	if err != nil {
+		return err
+	}
+	plan.Folder = folder
+
+	content, err := os.ReadFile(filepath.Join(folder, DataFileName))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAdoption, err)
+	}
+	dataFile, err := ParseDataFile(content)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAdoption, err)
+	}
+	if len(dataFile.Names) == 0 {
+		return fmt.Errorf("%w: data.yaml has no classes", ErrInvalidAdoption)
+	}
+	if len(dataFile.Train) == 0 {
+		return fmt.Errorf("%w: data.yaml has no 'train' split", ErrInvalidAdoption)
+	}
+	plan.Classes = dataFile.Names
+
+	// Relative 'path:' is used when it is found, absolute one can be from another machine
+	base := folder
+	if dataFile.Path != "" {
+		candidate := dataFile.Path
+		if !filepath.IsAbs(candidate) {
+			candidate = filepath.Join(folder, candidate)
+		}
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			if err := plan.checkPath(candidate); err != nil {
+				return fmt.Errorf("%w: 'path' of the data.yaml: %v", ErrInvalidAdoption, err)
+			}
+			base = candidate
+		}
+	}
+
+	entries := map[string][]string{SplitTrain: dataFile.Train, SplitValid: dataFile.Val, SplitTest: dataFile.Test}
+	images := 0
+	for _, location := range Splits {
+		for _, entry := range entries[location] {
+			resolved, err := plan.resolveSplitEntry(folder, base, entry)
+			if err != nil {
+				return fmt.Errorf("%w: '%v' of the '%v' split: %v", ErrInvalidAdoption, entry, adoptSplitKeys[location], err)
+			}
+			plan.Sources[location] = append(plan.Sources[location], resolved)
+		}
+
+		found, err := plan.images(location)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAdoption, err)
+		}
+		plan.Images[location] = len(found)
+		images += len(found)
+	}
+	if images == 0 {
+		return fmt.Errorf("%w: splits have no images", ErrInvalidAdoption)
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : AdoptPlan.images
+ *
+ * Purpose : Get paths of the images of the split from folders and list files
+ *
+ *   Input : location string - split of the dataset
+ *
+ *  Return : []string - paths to the images
+ *			 error - error if occur
+ */
+func (plan AdoptPlan) images(location string) ([]string, error) {
+	images := []string{}
+	for _, source := range plan.Sources[location] {
+		info, err := os.Stat(source)
+		if err != nil {
+			return nil, err
+		}
+
+		// Folder is searched for the images in all subfolders as Ultralytics does
+		if info.IsDir() {
+			err := filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+				if err == nil && !entry.IsDir() && IsImageFile(entry.Name()) {
+					if err := plan.checkPath(path); err != nil {
+						return err
+					}
+					images = append(images, path)
+				}
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		listed, err := plan.readImageList(source)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, listed...)
+	}
+
+	return images, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : findDataFileFolder
+ *
+ * Purpose : Find the folder with the data.yaml, archives often have the dataset
+ *			 in the only subfolder
+ *
+ *   Input : root string - source folder
+ *
+ *  Return : string - folder with the data.yaml
+ *			 error - ErrInvalidAdoption if it is not found
+ */
+func findDataFileFolder(root string) (string, error) {
+	if _, err := os.Stat(filepath.Join(root, DataFileName)); err == nil {
+		return root, nil
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidAdoption, err)
+	}
+	found := []string{}
+	for _, entry := range entries {
+		if _, err := os.Stat(filepath.Join(root, entry.Name(), DataFileName)); entry.IsDir() && err == nil {
+			found = append(found, filepath.Join(root, entry.Name()))
+		}
+	}
+	if len(found) != 1 {
+		return "", fmt.Errorf("%w: %v folders have data.yaml, the root or the only subfolder must have it", ErrInvalidAdoption, len(found))
+	}
+
+	return found[0], nil
+}
+
+/****************************************************************************************
+ *
+ * Function : AdoptPlan.resolveSplitEntry
+ *
+ * Purpose : Find the folder or list file of the split
+ *			 Path outside of the extracted archive is not used
+ *
+ *   Input : folder string - folder of the data.yaml
+ *			 base string - 'path:' of the data.yaml or the data.yaml folder
+ *			 entry string - path of the split in the data.yaml
+ *
+ *  Return : string - existing path
+ *			 error - error if path is not found or is outside of the archive
+ */
+func (plan AdoptPlan) resolveSplitEntry(folder string, base string, entry string) (string, error) {
+	candidates := []string{entry}
+	if !filepath.IsAbs(entry) {
+		candidates = []string{
+			filepath.Join(base, entry),
+			filepath.Join(folder, entry),
+			filepath.Join(folder, strings.TrimPrefix(filepath.ToSlash(entry), "../"))}
+	}
+
+	notFound := fmt.Errorf("folder or list file is not found")
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || !info.IsDir() && !strings.HasSuffix(candidate, ".txt") {
+			continue
+		}
+		if err := plan.checkPath(candidate); err != nil {
+			notFound = err
+			continue
+		}
+		return candidate, nil
+	}
+
+	return "", notFound
+}
+
+/****************************************************************************************
+ *
+ * Function : AdoptPlan.readImageList
+ *
+ * Purpose : Read the list file with one image path per line
+ *			 Relative paths are relative to the folder of the list file
+ *
+ *   Input : path string - path to the list file
+ *
+ *  Return : []string - existing images
+ *			 error - error if image is not found or is outside of the archive
+ */
+func (plan AdoptPlan) readImageList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	images := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		image := strings.TrimSpace(scanner.Text())
+		if image == "" || !IsImageFile(image) {
+			continue
+		}
+		if !filepath.IsAbs(image) {
+			image = filepath.Join(filepath.Dir(path), image)
+		}
+		if _, err := os.Stat(image); err != nil {
+			return nil, fmt.Errorf("image of the list '%v' is not found: %v", filepath.Base(path), err)
+		}
+		if err := plan.checkPath(image); err != nil {
+			return nil, fmt.Errorf("image of the list '%v': %v", filepath.Base(path), err)
+		}
+		images = append(images, image)
+	}
+
+	return images, scanner.Err()
+}
+
+/****************************************************************************************
+ *
+ * Function : AdoptPlan.checkPath
+ *
+ * Purpose : Check the path of the extracted archive stays inside of it, data.yaml
+ *			 and list files of the uploaded archive must not reach other files
+ *			 of the server. Folder source on the server is not limited
+ *
+ *   Input : path string - existing path of the source
+ *
+ *  Return : error - error if path is the symbolic link or is outside of the archive
+ */
+func (plan AdoptPlan) checkPath(path string) error {
+	if plan.Temporary == "" {
+		return nil
+	}
+
+	if info, err := os.Lstat(path); err != nil {
+		return err
+	} else if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("'%v' is the symbolic link", filepath.Base(path))
+	}
+
+	root, err := filepath.EvalSymlinks(plan.Temporary)
+	if err != nil {
+		return err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+
+	relative, err := filepath.Rel(root, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("'%v' is outside of the archive", filepath.Base(path))
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : adoptLabelPath
+ *
+ * Purpose : Get path to the label of the image, the last 'images' folder of the path
+ *			 replaced by 'labels', or the label next to the image
+ *
+ *   Input : image string - path to the image
+ *
+ *  Return : string - path to the label file
+ */
+func adoptLabelPath(image string) string {
+	separator := string(filepath.Separator)
+	folder, name := filepath.Dir(image)+separator, LabelFileName(filepath.Base(image))
+
+	if index := strings.LastIndex(folder, separator+ImagesFolder+separator); index >= 0 {
+		label := filepath.Join(folder[:index], LabelsFolder, folder[index+len(ImagesFolder)+2:], name)
+		if _, err := os.Stat(label); err == nil {
+			return label
+		}
+	}
+
+	return filepath.Join(folder, name)
+}
+
+/****************************************************************************************
+ *
+ * Function : adoptBaseName
+ *
+ * Purpose : Make the valid beginning of the image name from the source name
+ *
+ *   Input : name string - image file name of the source
+ *
+ *  Return : string - name without extension, not allowed characters replaced by '_'
+ */
+func adoptBaseName(name string) string {
+	base := []rune(strings.TrimSuffix(name, filepath.Ext(name)))
+	for index, character := range base {
+		if !IsValidName(string(character)) && !strings.ContainsRune(" _.-", character) {
+			base[index] = '_'
+		}
+	}
+	if len(base) == 0 || !IsValidName(string(base[0])) {
+		base = append([]rune("image"), base...)
+	}
+	if len(base) > 200 {
+		base = base[:200]
+	}
+
+	return string(base)
+}
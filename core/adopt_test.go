@@ -0,0 +1,238 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: adopt_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the adoption of the zip archives
+
+	In the file
+		1. writeTestArchive - zip archive with the files
+		2. TestPlanAdoptionArchive - paths stay inside the archive, limits of the archive
+	=============================================================================
+*/
+
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : writeTestArchive
+ *
+ * Purpose : Write the zip archive with the files, '<image>' content is the png image
+ *
+ *   Input : t *testing.T - test
+ *			 path string - path to the archive
+ *			 files map[string]string - content by the path in the archive
+ *
+ *  Return : Nothing
+ */
+func writeTestArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	var content bytes.Buffer
+	writer := zip.NewWriter(&content)
+	for name, text := range files {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text == "<image>" {
+			file.Write(testImage())
+		} else {
+			file.Write([]byte(text))
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestPlanAdoptionArchive
+ *
+ * Purpose : Check the archive is adopted only with the paths inside of it and
+ *			 within the limits, refused archive leaves no extracted folder
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestPlanAdoptionArchive(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		limit  func() func() // Change the limits, returns restore of them
+		images int
+		err    error
+	}{
+		{
+			name: "dataset in the root",
+			files: map[string]string{
+				"data.yaml":          "train: images/train\nval: images/val\nnames: ['car']\n",
+				"images/train/a.png": "<image>",
+				"labels/train/a.txt": "0 0.5 0.5 0.2 0.2\n",
+				"images/val/b.png":   "<image>",
+			},
+			images: 2,
+		},
+		{
+			name: "dataset in the subfolder with the download script",
+			files: map[string]string{
+				"cars/data.yaml":    "path: .\ntrain: train.txt\nnames:\n  0: car\ndownload: |\n  import zipfile\n  print('done')\n",
+				"cars/train.txt":    "./images/a.png\nimages/b.png\n",
+				"cars/images/a.png": "<image>",
+				"cars/images/b.png": "<image>",
+			},
+			images: 2,
+		},
+		{
+			name: "entry outside of the archive",
+			files: map[string]string{
+				"data.yaml":    "train: images\nnames: ['car']\n",
+				"images/a.png": "<image>",
+				"../evil.png":  "<image>",
+			},
+			err: ErrInvalidAdoption,
+		},
+		{
+			name: "absolute split",
+			files: map[string]string{
+				"data.yaml":    "train: <outside>/images\nnames: ['car']\n",
+				"images/a.png": "<image>",
+			},
+			err: ErrInvalidAdoption,
+		},
+		{
+			name: "path outside of the archive",
+			files: map[string]string{
+				"data.yaml":    "path: ../outside\ntrain: images\nnames: ['car']\n",
+				"images/a.png": "<image>",
+			},
+			err: ErrInvalidAdoption,
+		},
+		{
+			name: "relative split outside of the archive",
+			files: map[string]string{
+				"data.yaml":    "train: ../outside/images\nnames: ['car']\n",
+				"images/a.png": "<image>",
+			},
+			err: ErrInvalidAdoption,
+		},
+		{
+			name: "list file line outside of the archive",
+			files: map[string]string{
+				"data.yaml":    "train: train.txt\nnames: ['car']\n",
+				"train.txt":    "images/a.png\n../outside/images/a.png\n",
+				"images/a.png": "<image>",
+			},
+			err: ErrInvalidAdoption,
+		},
+		{
+			name: "too many entries",
+			files: map[string]string{
+				"data.yaml":    "train: images\nnames: ['car']\n",
+				"images/a.png": "<image>",
+				"images/b.png": "<image>",
+			},
+			limit: func() func() {
+				previous := MaxAdoptArchiveEntries
+				MaxAdoptArchiveEntries = 2
+				return func() { MaxAdoptArchiveEntries = previous }
+			},
+			err: ErrInvalidAdoption,
+		},
+		{
+			name: "too large extracted",
+			files: map[string]string{
+				"data.yaml":    "train: images\nnames: ['car']\n",
+				"images/a.png": strings.Repeat("0", 1000),
+			},
+			limit: func() func() {
+				previous := MaxAdoptExtractedSize
+				MaxAdoptExtractedSize = 500
+				return func() { MaxAdoptExtractedSize = previous }
+			},
+			err: ErrInvalidAdoption,
+		},
+		{
+			name: "too large uploaded",
+			files: map[string]string{
+				"data.yaml":    "train: images\nnames: ['car']\n",
+				"images/a.png": "<image>",
+			},
+			limit: func() func() {
+				previous := MaxAdoptArchiveSize
+				MaxAdoptArchiveSize = 100
+				return func() { MaxAdoptArchiveSize = previous }
+			},
+			err: ErrUploadTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := useTestDatasets(t)
+
+			// Images of the server outside of the archive
+			outside := filepath.Join(root, "outside")
+			os.MkdirAll(filepath.Join(outside, "images"), os.ModePerm)
+			os.WriteFile(filepath.Join(outside, "images", "a.png"), testImage(), 0644)
+
+			files := map[string]string{}
+			for name, content := range test.files {
+				files[name] = strings.ReplaceAll(content, "<outside>", outside)
+			}
+			archivePath := filepath.Join(t.TempDir(), "cars.zip")
+			writeTestArchive(t, archivePath, files)
+			if test.limit != nil {
+				defer test.limit()()
+			}
+
+			archive, _ := os.Open(archivePath)
+			defer archive.Close()
+			plan, err := PlanAdoptionArchive(archive)
+			defer plan.Discard()
+
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Errorf("error %v, expected %v", err, test.err)
+				}
+				// Paths are refused as outside, not as missed
+				containment := strings.Contains(test.name, "outside") || strings.HasPrefix(test.name, "absolute")
+				if containment && (err == nil || !strings.Contains(err.Error(), "outside")) {
+					t.Errorf("error %v is not about the path outside of the archive", err)
+				}
+				entries, _ := os.ReadDir(root)
+				for _, entry := range entries {
+					if strings.HasPrefix(entry.Name(), adoptFolderPrefix) {
+						t.Errorf("refused archive left '%v'", entry.Name())
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if images := plan.Images[SplitTrain] + plan.Images[SplitValid] + plan.Images[SplitTest]; images != test.images {
+				t.Errorf("%v images found, expected %v", images, test.images)
+			}
+			if !reflect.DeepEqual(plan.Classes, []string{"car"}) {
+				t.Errorf("classes %v", plan.Classes)
+			}
+		})
+	}
+}
@@ -0,0 +1,673 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: augment.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Offline augmentation of the training images of the version
+
+	Every training image gets 'copies' augmented copies '<name>_aug<n>'.
+	Every transform of the recipe is applied to the copy with the chance
+	of 1/2, at least one of them always. Geometric transforms move box and
+	polygon labels with the pixels, labels mostly left out of the image are
+	dropped. The same seed gives the same copies.
+
+	In the file
+		1. AugmentOptions.Resolve - check the recipe and fill the defaults
+		2. augmentImage - make the copy of the image with its labels
+		3. Geometric transforms: flip, rotate90, crop, scale
+		4. Pixel transforms: brightness and contrast, blur, noise
+	=============================================================================
+*/
+
+package core
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Transforms of the augmentation
+const AugmentFlipHorizontal = "flip_horizontal"
+const AugmentFlipVertical = "flip_vertical"
+const AugmentRotate90 = "rotate90"
+const AugmentCrop = "crop"
+const AugmentScale = "scale"
+const AugmentBrightness = "brightness"
+const AugmentBlur = "blur"
+const AugmentNoise = "noise"
+
+// All transforms in the order they are applied
+var AugmentTransforms = []string{AugmentFlipHorizontal, AugmentFlipVertical, AugmentRotate90, AugmentCrop,
+	AugmentScale, AugmentBrightness, AugmentBlur, AugmentNoise}
+
+// Largest number of the copies of every image
+const MaxAugmentCopies = 10
+
+// Label is dropped when less of its box stays in the image
+const augmentMinVisible = 0.25
+
+// Colour of the area outside of the scaled down image
+var augmentFill = color.RGBA{R: 114, G: 114, B: 114, A: 255}
+
+// Recipe of the augmentation, zero limits are replaced by the defaults
+type AugmentOptions struct {
+	Copies     int      `json:"copies"`               // Augmented copies of every training image
+	Transforms []string `json:"transforms"`           // Transforms chosen at random for every copy
+	Seed       int64    `json:"seed"`                 // Seed of the random choices
+	CropMin    float64  `json:"crop_min,omitempty"`   // Smallest side of the crop as part of the image side
+	ScaleMin   float64  `json:"scale_min,omitempty"`  // Smallest zoom, below 1 zooms out with the grey border
+	ScaleMax   float64  `json:"scale_max,omitempty"`  // Largest zoom, above 1 zooms in
+	Brightness float64  `json:"brightness,omitempty"` // Largest change of the brightness as part of the full range
+	Contrast   float64  `json:"contrast,omitempty"`   // Largest change of the contrast as part of the contrast
+	Blur       float64  `json:"blur,omitempty"`       // Largest blur radius in pixels
+	Noise      float64  `json:"noise,omitempty"`      // Largest standard deviation of the noise as part of the full range
+}
+
+// Default limits of the transforms
+var DefaultAugmentOptions = AugmentOptions{CropMin: 0.7, ScaleMin: 0.8, ScaleMax: 1.2, Brightness: 0.25, Contrast: 0.25, Blur: 2, Noise: 0.05}
+
+// Result of the augmentation in the version manifest
+type AugmentRecipe struct {
+	AugmentOptions
+	Source  int `json:"source"`  // Training images used for the copies
+	Images  int `json:"images"`  // Augmented images
+	Boxes   int `json:"boxes"`   // Labels of the augmented images
+	Dropped int `json:"dropped"` // Labels left out of the augmented images
+}
+
+// Geometric change of the copy, coordinates are normalized
+type pointTransform func(x float64, y float64) (float64, float64)
+
+/****************************************************************************************
+ *
+ * Function : AugmentOptions.Resolve
+ *
+ * Purpose : Check the recipe and fill zero limits with the defaults
+ *
+ *  Return : AugmentOptions - recipe with all limits
+ *			 error - ErrInvalidAugmentation if recipe is not valid
+ */
+func (options AugmentOptions) Resolve() (AugmentOptions, error) {
+	if options.Copies < 1 || options.Copies > MaxAugmentCopies {
+		return options, fmt.Errorf("%w: copies must be from 1 to %v", ErrInvalidAugmentation, MaxAugmentCopies)
+	}
+	if len(options.Transforms) == 0 {
+		return options, fmt.Errorf("%w: no transforms", ErrInvalidAugmentation)
+	}
+
+	// Transforms are kept in the order they are applied
+	transforms := []string{}
+	for _, known := range AugmentTransforms {
+		for _, transform := range options.Transforms {
+			if transform == known {
+				transforms = append(transforms, known)
+				break
+			}
+		}
+	}
+	for _, transform := range options.Transforms {
+		if !isAugmentTransform(transform) {
+			return options, fmt.Errorf("%w: transform '%v' is not known", ErrInvalidAugmentation, transform)
+		}
+	}
+	options.Transforms = transforms
+
+	defaults := DefaultAugmentOptions
+	if options.CropMin == 0 {
+		options.CropMin = defaults.CropMin
+	}
+	if options.ScaleMin == 0 {
+		options.ScaleMin = defaults.ScaleMin
+	}
+	if options.ScaleMax == 0 {
+		options.ScaleMax = defaults.ScaleMax
+	}
+	if options.Brightness == 0 {
+		options.Brightness = defaults.Brightness
+	}
+	if options.Contrast == 0 {
+		options.Contrast = defaults.Contrast
+	}
+	if options.Blur == 0 {
+		options.Blur = defaults.Blur
+	}
+	if options.Noise == 0 {
+		options.Noise = defaults.Noise
+	}
+
+	switch {
+	case options.CropMin <= 0 || options.CropMin > 1:
+		return options, fmt.Errorf("%w: crop_min must be above 0 and up to 1", ErrInvalidAugmentation)
+	case options.ScaleMin <= 0 || options.ScaleMin > options.ScaleMax:
+		return options, fmt.Errorf("%w: scale_min must be above 0 and up to scale_max", ErrInvalidAugmentation)
+	case options.Brightness < 0 || options.Brightness > 1 || options.Contrast < 0 || options.Contrast > 1:
+		return options, fmt.Errorf("%w: brightness and contrast must be from 0 to 1", ErrInvalidAugmentation)
+	case options.Blur < 0 || options.Noise < 0 || options.Noise > 1:
+		return options, fmt.Errorf("%w: blur must be positive, noise from 0 to 1", ErrInvalidAugmentation)
+	}
+
+	return options, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : ParseAugmentTransforms
+ *
+ * Purpose : Split comma separated list of the transforms
+ *
+ *   Input : list string - e.g. 'flip_horizontal,rotate90'
+ *
+ *  Return : []string - transforms, empty for empty list
+ */
+func ParseAugmentTransforms(list string) []string {
+	transforms := []string{}
+	for _, transform := range strings.Split(list, ",") {
+		if transform = strings.TrimSpace(transform); transform != "" {
+			transforms = append(transforms, transform)
+		}
+	}
+
+	return transforms
+}
+
+/****************************************************************************************
+ *
+ * Function : augmentImage
+ *
+ * Purpose : Make the augmented copy of the image with its labels
+ *
+ *   Input : source image.Image - decoded image
+ *			 labels []Label - labels of the image
+ *			 options AugmentOptions - resolved recipe
+ *			 random *rand.Rand - random choices of the version
+ *
+ *  Return : image.Image - augmented image
+ *			 []Label - moved labels
+ *			 int - number of dropped labels
+ */
+func augmentImage(source image.Image, labels []Label, options AugmentOptions, random *rand.Rand) (image.Image, []Label, int) {
+	// At least one transform is applied, so the copy is never the same as the image
+	chosen, applied := map[string]bool{}, false
+	for _, transform := range options.Transforms {
+		chosen[transform] = random.Intn(2) == 0
+		applied = applied || chosen[transform]
+	}
+	if !applied {
+		chosen[options.Transforms[random.Intn(len(options.Transforms))]] = true
+	}
+
+	picture := toRGBA(source)
+	labels = append([]Label{}, labels...)
+	dropped := 0
+
+	move := func(transform pointTransform, clip bool) {
+		minVisible := 0.0
+		if clip {
+			minVisible = augmentMinVisible
+		}
+		var lost int
+		labels, lost = moveLabels(labels, transform, minVisible)
+		dropped += lost
+	}
+
+	for _, transform := range options.Transforms {
+		if !chosen[transform] {
+			continue
+		}
+
+		switch transform {
+		case AugmentFlipHorizontal:
+			picture = flipImage(picture, true)
+			move(func(x float64, y float64) (float64, float64) { return 1 - x, y }, false)
+		case AugmentFlipVertical:
+			picture = flipImage(picture, false)
+			move(func(x float64, y float64) (float64, float64) { return x, 1 - y }, false)
+		case AugmentRotate90:
+			for turns := 1 + random.Intn(3); turns > 0; turns-- {
+				picture = rotateImage(picture)
+				move(func(x float64, y float64) (float64, float64) { return 1 - y, x }, false)
+			}
+		case AugmentCrop:
+			area := cropArea(picture.Rect, options.CropMin, random)
+			pictureWidth, pictureHeight := float64(picture.Rect.Dx()), float64(picture.Rect.Dy())
+			left, top := float64(area.Min.X)/pictureWidth, float64(area.Min.Y)/pictureHeight
+			width, height := float64(area.Dx())/pictureWidth, float64(area.Dy())/pictureHeight
+			picture = cropImage(picture, area)
+			move(func(x float64, y float64) (float64, float64) { return (x - left) / width, (y - top) / height }, true)
+		case AugmentScale:
+			scale := options.ScaleMin + random.Float64()*(options.ScaleMax-options.ScaleMin)
+			picture = scaleImage(picture, scale)
+			move(func(x float64, y float64) (float64, float64) { return (x-0.5)*scale + 0.5, (y-0.5)*scale + 0.5 }, true)
+		case AugmentBrightness:
+			brightness := (random.Float64()*2 - 1) * options.Brightness
+			contrast := 1 + (random.Float64()*2-1)*options.Contrast
+			adjustColors(picture, brightness, contrast)
+		case AugmentBlur:
+			picture = blurImage(picture, int(math.Round(random.Float64()*options.Blur)))
+		case AugmentNoise:
+			addNoise(picture, random.Float64()*options.Noise, random)
+		}
+	}
+
+	return picture, labels, dropped
+}
+
+/****************************************************************************************
+ *
+ * Function : writeAugmentedImage
+ *
+ * Purpose : Store the augmented copy and its labels, png stays png, others become jpeg
+ *
+ *   Input : picture image.Image - augmented image
+ *			 labels []Label - labels of the copy
+ *			 name string - name of the source image
+ *			 number int - number of the copy from 1
+ *			 target string - path to the split folder of the version
+ *
+ *  Return : error - error if occur
+ */
+func writeAugmentedImage(picture image.Image, labels []Label, name string, number int, target string) error {
+	copyName := strings.TrimSuffix(name, filepath.Ext(name)) + "_aug" + strconv.Itoa(number) + filepath.Ext(encodedName(name))
+	if err := writeImageFile(filepath.Join(target, ImagesFolder, copyName), picture); err != nil {
+		return err
+	}
+
+	if len(labels) == 0 {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(target, LabelsFolder, LabelFileName(copyName)), FormatLabels(labels), 0644)
+}
+
+/****************************************************************************************
+ *
+ * Function : moveLabels
+ *
+ * Purpose : Move labels by the transform, clip them by the image if needed
+ *			 Box is moved by its corners, polygon by its points
+ *
+ *   Input : labels []Label - labels of the image
+ *			 transform pointTransform - change of the coordinates
+ *			 minVisible float64 - part of the box left in the image to keep the label,
+ *								  0 if labels cannot leave the image and are not clipped
+ *
+ *  Return : []Label - moved labels
+ *			 int - number of dropped labels
+ */
+func moveLabels(labels []Label, transform pointTransform, minVisible float64) ([]Label, int) {
+	moved := []Label{}
+	dropped := 0
+
+	for _, label := range labels {
+		points := label.Points
+		if len(points) == 0 {
+			left, top := label.X-label.Width/2, label.Y-label.Height/2
+			right, bottom := label.X+label.Width/2, label.Y+label.Height/2
+			points = []float64{left, top, right, top, right, bottom, left, bottom}
+		}
+
+		result := make([]float64, 0, len(points))
+		for index := 0; index+1 < len(points); index += 2 {
+			x, y := transform(points[index], points[index+1])
+			result = append(result, x, y)
+		}
+		_, _, fullWidth, fullHeight := polygonBox(result)
+
+		if minVisible > 0 {
+			result = clipPolygon(result)
+			if len(result) < 6 {
+				dropped++
+				continue
+			}
+			_, _, width, height := polygonBox(result)
+			if width*height < minVisible*fullWidth*fullHeight || width <= 0 || height <= 0 {
+				dropped++
+				continue
+			}
+		}
+
+		movedLabel := Label{Class: label.Class}
+		movedLabel.X, movedLabel.Y, movedLabel.Width, movedLabel.Height = polygonBox(result)
+		if len(label.Points) > 0 {
+			movedLabel.Points = result
+		}
+		moved = append(moved, movedLabel)
+	}
+
+	return moved, dropped
+}
+
+/****************************************************************************************
+ *
+ * Function : clipPolygon
+ *
+ * Purpose : Clip polygon by the image square [0, 1] with Sutherland-Hodgman algorithm
+ *
+ *   Input : points []float64 - x, y pairs
+ *
+ *  Return : []float64 - points inside of the image
+ */
+func clipPolygon(points []float64) []float64 {
+	// Edges of the image: inside check and the crossing point on the edge
+	edges := []struct {
+		inside func(x float64, y float64) bool
+		cross  func(x1 float64, y1 float64, x2 float64, y2 float64) (float64, float64)
+	}{
+		{func(x, y float64) bool { return x >= 0 }, func(x1, y1, x2, y2 float64) (float64, float64) { return 0, y1 + (y2-y1)*(0-x1)/(x2-x1) }},
+		{func(x, y float64) bool { return x <= 1 }, func(x1, y1, x2, y2 float64) (float64, float64) { return 1, y1 + (y2-y1)*(1-x1)/(x2-x1) }},
+		{func(x, y float64) bool { return y >= 0 }, func(x1, y1, x2, y2 float64) (float64, float64) { return x1 + (x2-x1)*(0-y1)/(y2-y1), 0 }},
+		{func(x, y float64) bool { return y <= 1 }, func(x1, y1, x2, y2 float64) (float64, float64) { return x1 + (x2-x1)*(1-y1)/(y2-y1), 1 }},
+	}
+
+	for _, edge := range edges {
+		if len(points) < 6 {
+			return nil
+		}
+
+		clipped := []float64{}
+		for index := 0; index+1 < len(points); index += 2 {
+			x, y := points[index], points[index+1]
+			previousX, previousY := points[(index+len(points)-2)%len(points)], points[(index+len(points)-1)%len(points)]
+
+			if edge.inside(x, y) {
+				if !edge.inside(previousX, previousY) {
+					crossX, crossY := edge.cross(previousX, previousY, x, y)
+					clipped = append(clipped, crossX, crossY)
+				}
+				clipped = append(clipped, x, y)
+			} else if edge.inside(previousX, previousY) {
+				crossX, crossY := edge.cross(previousX, previousY, x, y)
+				clipped = append(clipped, crossX, crossY)
+			}
+		}
+		points = clipped
+	}
+
+	return points
+}
+
+/****************************************************************************************
+ *
+ * Function : toRGBA
+ *
+ * Purpose : Copy image to RGBA pixels
+ *
+ *   Input : source image.Image - decoded image
+ *
+ *  Return : *image.RGBA - pixels from (0, 0)
+ */
+func toRGBA(source image.Image) *image.RGBA {
+	bounds := source.Bounds()
+	picture := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(picture, picture.Bounds(), source, bounds.Min, draw.Src)
+
+	return picture
+}
+
+/****************************************************************************************
+ *
+ * Function : flipImage
+ *
+ * Purpose : Mirror the image
+ *
+ *   Input : picture *image.RGBA - image
+ *			 horizontal bool - true to mirror left to right, false top to bottom
+ *
+ *  Return : *image.RGBA - mirrored image
+ */
+func flipImage(picture *image.RGBA, horizontal bool) *image.RGBA {
+	width, height := picture.Rect.Dx(), picture.Rect.Dy()
+	result := image.NewRGBA(picture.Rect)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sourceX, sourceY := x, height-1-y
+			if horizontal {
+				sourceX, sourceY = width-1-x, y
+			}
+			copy(result.Pix[y*result.Stride+x*4:y*result.Stride+x*4+4], picture.Pix[sourceY*picture.Stride+sourceX*4:sourceY*picture.Stride+sourceX*4+4])
+		}
+	}
+
+	return result
+}
+
+/****************************************************************************************
+ *
+ * Function : rotateImage
+ *
+ * Purpose : Rotate the image by 90 degrees clockwise
+ *
+ *   Input : picture *image.RGBA - image
+ *
+ *  Return : *image.RGBA - rotated image, width and height are swapped
+ */
+func rotateImage(picture *image.RGBA) *image.RGBA {
+	width, height := picture.Rect.Dx(), picture.Rect.Dy()
+	result := image.NewRGBA(image.Rect(0, 0, height, width))
+
+	// Pixel (x, y) moves to (height - 1 - y, x)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			targetX, targetY := height-1-y, x
+			copy(result.Pix[targetY*result.Stride+targetX*4:targetY*result.Stride+targetX*4+4], picture.Pix[y*picture.Stride+x*4:y*picture.Stride+x*4+4])
+		}
+	}
+
+	return result
+}
+
+/****************************************************************************************
+ *
+ * Function : cropArea
+ *
+ * Purpose : Choose the random part of the image
+ *
+ *   Input : bounds image.Rectangle - image from (0, 0)
+ *			 minimum float64 - smallest side of the part as part of the image side
+ *			 random *rand.Rand - random values
+ *
+ *  Return : image.Rectangle - part of the image in pixels, at least 1x1
+ */
+func cropArea(bounds image.Rectangle, minimum float64, random *rand.Rand) image.Rectangle {
+	side := func(length int) (int, int) {
+		size := int(math.Round(float64(length) * (minimum + random.Float64()*(1-minimum))))
+		if size < 1 {
+			size = 1
+		}
+		if size > length {
+			size = length
+		}
+		return random.Intn(length - size + 1), size
+	}
+
+	left, width := side(bounds.Dx())
+	top, height := side(bounds.Dy())
+	return image.Rect(left, top, left+width, top+height)
+}
+
+/****************************************************************************************
+ *
+ * Function : cropImage
+ *
+ * Purpose : Cut the part of the image
+ *
+ *   Input : picture *image.RGBA - image
+ *			 area image.Rectangle - part of the image in pixels
+ *
+ *  Return : *image.RGBA - cut image
+ */
+func cropImage(picture *image.RGBA, area image.Rectangle) *image.RGBA {
+	result := image.NewRGBA(image.Rect(0, 0, area.Dx(), area.Dy()))
+	draw.Draw(result, result.Bounds(), picture, area.Min, draw.Src)
+
+	return result
+}
+
+/****************************************************************************************
+ *
+ * Function : scaleImage
+ *
+ * Purpose : Zoom the image around the centre keeping its size
+ *
+ *   Input : picture *image.RGBA - image
+ *			 scale float64 - above 1 zooms in, below 1 zooms out with the grey border
+ *
+ *  Return : *image.RGBA - zoomed image
+ */
+func scaleImage(picture *image.RGBA, scale float64) *image.RGBA {
+	width, height := picture.Rect.Dx(), picture.Rect.Dy()
+	result := image.NewRGBA(picture.Rect)
+
+	for y := 0; y < height; y++ {
+		sourceY := int(math.Floor((float64(y)+0.5-float64(height)/2)/scale + float64(height)/2))
+		for x := 0; x < width; x++ {
+			sourceX := int(math.Floor((float64(x)+0.5-float64(width)/2)/scale + float64(width)/2))
+			if sourceX < 0 || sourceY < 0 || sourceX >= width || sourceY >= height {
+				result.SetRGBA(x, y, augmentFill)
+				continue
+			}
+			copy(result.Pix[y*result.Stride+x*4:y*result.Stride+x*4+4], picture.Pix[sourceY*picture.Stride+sourceX*4:sourceY*picture.Stride+sourceX*4+4])
+		}
+	}
+
+	return result
+}
+
+/****************************************************************************************
+ *
+ * Function : adjustColors
+ *
+ * Purpose : Change brightness and contrast of the image in place
+ *
+ *   Input : picture *image.RGBA - image
+ *			 brightness float64 - change as part of the full range, from -1 to 1
+ *			 contrast float64 - multiplier of the difference from the middle grey
+ *
+ *  Return : Nothing
+ */
+func adjustColors(picture *image.RGBA, brightness float64, contrast float64) {
+	for index := 0; index < len(picture.Pix); index += 4 {
+		for channel := 0; channel < 3; channel++ {
+			value := (float64(picture.Pix[index+channel])-128)*contrast + 128 + brightness*255
+			picture.Pix[index+channel] = clampByte(value)
+		}
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : blurImage
+ *
+ * Purpose : Box blur of the image, horizontal and vertical passes
+ *
+ *   Input : picture *image.RGBA - image
+ *			 radius int - blur radius in pixels, 0 keeps the image
+ *
+ *  Return : *image.RGBA - blurred image
+ */
+func blurImage(picture *image.RGBA, radius int) *image.RGBA {
+	if radius < 1 {
+		return picture
+	}
+
+	width, height := picture.Rect.Dx(), picture.Rect.Dy()
+	pass := func(source *image.RGBA, horizontal bool) *image.RGBA {
+		result := image.NewRGBA(source.Rect)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				var sum [4]int
+				count := 0
+				for shift := -radius; shift <= radius; shift++ {
+					sourceX, sourceY := x, y+shift
+					if horizontal {
+						sourceX, sourceY = x+shift, y
+					}
+					if sourceX < 0 || sourceY < 0 || sourceX >= width || sourceY >= height {
+						continue
+					}
+					offset := sourceY*source.Stride + sourceX*4
+					for channel := 0; channel < 4; channel++ {
+						sum[channel] += int(source.Pix[offset+channel])
+					}
+					count++
+				}
+				offset := y*result.Stride + x*4
+				for channel := 0; channel < 4; channel++ {
+					result.Pix[offset+channel] = uint8(sum[channel] / count)
+				}
+			}
+		}
+		return result
+	}
+
+	return pass(pass(picture, true), false)
+}
+
+/****************************************************************************************
+ *
+ * Function : addNoise
+ *
+ * Purpose : Add gaussian noise to the image in place
+ *
+ *   Input : picture *image.RGBA - image
+ *			 deviation float64 - standard deviation as part of the full range
+ *			 random *rand.Rand - random values
+ *
+ *  Return : Nothing
+ */
+func addNoise(picture *image.RGBA, deviation float64, random *rand.Rand) {
+	for index := 0; index < len(picture.Pix); index += 4 {
+		for channel := 0; channel < 3; channel++ {
+			picture.Pix[index+channel] = clampByte(float64(picture.Pix[index+channel]) + random.NormFloat64()*deviation*255)
+		}
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : clampByte
+ *
+ * Purpose : Round the value to the byte range
+ *
+ *   Input : value float64 - colour value
+ *
+ *  Return : uint8 - value from 0 to 255
+ */
+func clampByte(value float64) uint8 {
+	if value < 0 {
+		return 0
+	}
+	if value > 255 {
+		return 255
+	}
+
+	return uint8(math.Round(value))
+}
+
+/****************************************************************************************
+ *
+ * Function : isAugmentTransform
+ *
+ * Purpose : Check if transform is known
+ *
+ *   Input : transform string - transform name
+ *
+ *  Return : bool - true if transform is known
+ */
+func isAugmentTransform(transform string) bool {
+	for _, known := range AugmentTransforms {
+		if transform == known {
+			return true
+		}
+	}
+
+	return false
+}
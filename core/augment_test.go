@@ -0,0 +1,120 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: augment_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the labels moved by the augmentation
+
+	In the file
+		1. TestMoveLabels - boxes and polygons moved, clipped and dropped
+	=============================================================================
+*/
+
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : equalLabels
+ *
+ * Purpose : Compare labels with the tolerance of the normalized coordinates
+ *
+ *   Input : actual []Label - received labels
+ *			 expected []Label - expected labels
+ *
+ *  Return : bool - true if labels are equal
+ */
+func equalLabels(actual []Label, expected []Label) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+
+	near := func(a float64, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for index := range actual {
+		a, e := actual[index], expected[index]
+		if a.Class != e.Class || !near(a.X, e.X) || !near(a.Y, e.Y) || !near(a.Width, e.Width) || !near(a.Height, e.Height) || len(a.Points) != len(e.Points) {
+			return false
+		}
+		for point := range a.Points {
+			if !near(a.Points[point], e.Points[point]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+/****************************************************************************************
+ *
+ * Function : TestMoveLabels
+ *
+ * Purpose : Check labels moved by the transform, clipped by the image and dropped
+ *			 when less than the visible part is left
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestMoveLabels(t *testing.T) {
+	flip := func(x float64, y float64) (float64, float64) { return 1 - x, y }
+	shift := func(x float64, y float64) (float64, float64) { return x + 0.5, y }
+
+	tests := []struct {
+		name       string
+		labels     []Label
+		transform  pointTransform
+		minVisible float64
+		expected   []Label
+		dropped    int
+	}{
+		{
+			name:      "flipped box",
+			labels:    []Label{{Class: 2, X: 0.3, Y: 0.4, Width: 0.2, Height: 0.1}},
+			transform: flip,
+			expected:  []Label{{Class: 2, X: 0.7, Y: 0.4, Width: 0.2, Height: 0.1}},
+		},
+		{
+			name:      "flipped polygon keeps points",
+			labels:    []Label{{Class: 1, X: 0.3, Y: 0.3, Width: 0.2, Height: 0.2, Points: []float64{0.2, 0.2, 0.4, 0.2, 0.4, 0.4}}},
+			transform: flip,
+			expected:  []Label{{Class: 1, X: 0.7, Y: 0.3, Width: 0.2, Height: 0.2, Points: []float64{0.8, 0.2, 0.6, 0.2, 0.6, 0.4}}},
+		},
+		{
+			name:       "box clipped by the edge",
+			labels:     []Label{{Class: 0, X: 0.4, Y: 0.5, Width: 0.4, Height: 0.2}},
+			transform:  shift,
+			minVisible: 0.3,
+			expected:   []Label{{Class: 0, X: 0.85, Y: 0.5, Width: 0.3, Height: 0.2}},
+		},
+		{
+			name:       "box mostly outside is dropped",
+			labels:     []Label{{Class: 0, X: 0.7, Y: 0.5, Width: 0.4, Height: 0.2}, {Class: 1, X: 0.2, Y: 0.5, Width: 0.2, Height: 0.2}},
+			transform:  shift,
+			minVisible: 0.3,
+			expected:   []Label{{Class: 1, X: 0.7, Y: 0.5, Width: 0.2, Height: 0.2}},
+			dropped:    1,
+		},
+		{
+			name:       "box outside of the image is dropped",
+			labels:     []Label{{Class: 0, X: 0.8, Y: 0.5, Width: 0.2, Height: 0.2}},
+			transform:  shift,
+			minVisible: 0.1,
+			expected:   []Label{},
+			dropped:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			moved, dropped := moveLabels(test.labels, test.transform, test.minVisible)
+			if !equalLabels(moved, test.expected) || dropped != test.dropped {
+				t.Errorf("moved %+v, dropped %v, expected %+v, dropped %v", moved, dropped, test.expected, test.dropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,425 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: bulk.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Bulk actions over the selected images of the dataset
+
+	Images are selected by the gallery as 'location/name' or by the query
+	of the catalog. Every image is processed separately, failed images are
+	reported in the result and do not stop the action.
+
+	In the file
+		1. BulkOperation - action with its arguments
+		2. SelectImages - images matching the query
+		3. RunBulk - move, delete, tag, untag, clear labels or copy images
+		4. ZipImages - write images with labels to the zip archive
+	=============================================================================
+*/
+
+package core
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"strings"
+)
+
+// Bulk actions
+const BulkMove = "move"
+const BulkDelete = "delete"
+const BulkTag = "tag"
+const BulkUntag = "untag"
+const BulkClearLabels = "clear_labels"
+const BulkCopy = "copy"
+const BulkDownload = "download"
+
+// All bulk actions
+var BulkActions = []string{BulkMove, BulkDelete, BulkTag, BulkUntag, BulkClearLabels, BulkCopy, BulkDownload}
+
+// Image selected for the bulk action
+type ImageRef struct {
+	Location string `json:"location"`
+	Name     string `json:"name"`
+}
+
+// Bulk action with its arguments
+type BulkOperation struct {
+	Action  string   `json:"action"`
+	To      string   `json:"to,omitempty"`      // Location to move or copy images, copy keeps the location when empty
+	Tags    []string `json:"tags,omitempty"`    // Tags to add or remove
+	Dataset string   `json:"dataset,omitempty"` // Dataset to copy images
+}
+
+// Result of the bulk operation, failed images with the reason
+type BulkResult struct {
+	Done   int               `json:"done"`
+	Failed map[string]string `json:"failed"`
+}
+
+// Progress of the bulk operation, called after every image
+type BulkProgress func(done int, total int)
+
+/****************************************************************************************
+ *
+ * Function : ParseImageRef
+ *
+ * Purpose : Parse selected image in 'location/name' format
+ *
+ *   Input : value string - selected image
+ *
+ *  Return : ImageRef - image
+ *			 error - ErrInvalidName if value has no location
+ */
+func ParseImageRef(value string) (ImageRef, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 || !IsLocation(parts[0]) || parts[1] == "" {
+		return ImageRef{}, ErrInvalidName
+	}
+
+	return ImageRef{Location: parts[0], Name: parts[1]}, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : ImageRef.String
+ *
+ * Purpose : Format image as 'location/name'
+ *
+ *   Input : Nothing
+ *
+ *  Return : string - image
+ */
+func (image ImageRef) String() string {
+	return image.Location + "/" + image.Name
+}
+
+/****************************************************************************************
+ *
+ * Function : BulkOperation.Validate
+ *
+ * Purpose : Check action and its arguments
+ *
+ *   Input : Nothing
+ *
+ *  Return : error - ErrInvalidQuery, ErrInvalidLocation or ErrInvalidName if not valid
+ */
+func (operation BulkOperation) Validate() error {
+	switch operation.Action {
+	case BulkMove:
+		if !IsLocation(operation.To) {
+			return ErrInvalidLocation
+		}
+	case BulkTag, BulkUntag:
+		if len(normaliseTags(operation.Tags)) == 0 {
+			return ErrInvalidQuery
+		}
+	case BulkCopy:
+		if !IsValidName(operation.Dataset) {
+			return ErrInvalidName
+		}
+		if operation.To != "" && !IsLocation(operation.To) {
+			return ErrInvalidLocation
+		}
+	case BulkDelete, BulkClearLabels, BulkDownload:
+	default:
+		return ErrInvalidQuery
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.SelectImages
+ *
+ * Purpose : Get all images matching the query, page of the query is not used
+ *
+ *   Input : query ImageQuery - filters of the images
+ *
+ *  Return : []ImageRef - matching images
+ *			 error - error if occur
+ */
+func (dataset Dataset) SelectImages(query ImageQuery) ([]ImageRef, error) {
+	query.Offset, query.Limit = 0, 0
+
+	records, _, err := dataset.QueryImages(query)
+	if err != nil {
+		return nil, err
+	}
+
+	images := []ImageRef{}
+	for _, record := range records {
+		images = append(images, ImageRef{Location: record.Location, Name: record.Name})
+	}
+
+	return images, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.RunBulk
+ *
+ * Purpose : Apply the action to every image, download is made by ZipImages
+ *			 Cancelled context stops the action before the next image
+ *
+ *   Input : ctx context.Context - context of the action
+ *			 operation BulkOperation - action with its arguments
+ *			 images []ImageRef - selected images
+ *			 progress BulkProgress - called after every image, can be nil
+ *
+ *  Return : BulkResult - number of processed images and failed ones
+ *			 error - error if the action cannot be started or context error
+ */
+func (dataset Dataset) RunBulk(ctx context.Context, operation BulkOperation, images []ImageRef, progress BulkProgress) (BulkResult, error) {
+	if err := operation.Validate(); err != nil {
+		return BulkResult{}, err
+	}
+
+	var apply func(image ImageRef) error
+	switch operation.Action {
+	case BulkMove:
+		apply = func(image ImageRef) error {
+			return dataset.MoveImage(image.Location, operation.To, image.Name)
+		}
+	case BulkDelete:
+		apply = func(image ImageRef) error {
+			_, err := dataset.TrashImage(image.Location, image.Name)
+			return err
+		}
+	case BulkTag, BulkUntag:
+		apply = func(image ImageRef) error {
+			return dataset.changeTags(image, operation.Tags, operation.Action == BulkTag)
+		}
+	case BulkClearLabels:
+		apply = dataset.clearLabels
+	case BulkCopy:
+		target, err := OpenDataset(operation.Dataset)
+		if err != nil {
+			return BulkResult{}, err
+		}
+		apply = func(image ImageRef) error {
+			return dataset.copyImage(target, image, operation.To)
+		}
+	default:
+		return BulkResult{}, ErrInvalidQuery
+	}
+
+	result := BulkResult{Failed: make(map[string]string)}
+	for index, image := range images {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := apply(image); err != nil {
+			result.Failed[image.String()] = err.Error()
+		} else {
+			result.Done++
+		}
+		if progress != nil {
+			progress(index+1, len(images))
+		}
+	}
+
+	return result, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ZipImages
+ *
+ * Purpose : Write images with labels and data.yaml to the zip archive
+ *			 with the same folders as the dataset export, '<location>/images/<name>'
+ *
+ *   Input : ctx context.Context - context, cancelled context stops the archive
+ *			 images []ImageRef - selected images
+ *			 writer io.Writer - output of the archive
+ *			 progress BulkProgress - called after every image, can be nil
+ *
+ *  Return : BulkResult - number of archived images and failed ones
+ *			 error - error if archive cannot be written
+ */
+func (dataset Dataset) ZipImages(ctx context.Context, images []ImageRef, writer io.Writer, progress BulkProgress) (BulkResult, error) {
+	archive := zip.NewWriter(writer)
+	result := BulkResult{Failed: make(map[string]string)}
+
+	if err := addZipFile(archive, dataset.DataFilePath(), DataFileName); err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+
+	for index, image := range images {
+		if err := ctx.Err(); err != nil {
+			archive.Close()
+			return result, err
+		}
+		imagePath, err := dataset.ImagePath(image.Location, image.Name)
+		if err == nil {
+			err = addZipFile(archive, imagePath, image.Location+"/"+ImagesFolder+"/"+image.Name)
+		}
+		if err == nil {
+			labelPath, _ := dataset.LabelPath(image.Location, image.Name)
+			if labelErr := addZipFile(archive, labelPath, image.Location+"/"+LabelsFolder+"/"+LabelFileName(image.Name)); labelErr != nil && !os.IsNotExist(labelErr) {
+				err = labelErr
+			}
+		}
+
+		if os.IsNotExist(err) {
+			result.Failed[image.String()] = ErrImageNotFound.Error()
+		} else if err != nil {
+			result.Failed[image.String()] = err.Error()
+		} else {
+			result.Done++
+		}
+		if progress != nil {
+			progress(index+1, len(images))
+		}
+	}
+
+	return result, archive.Close()
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.changeTags
+ *
+ * Purpose : Add tags to the image or remove them
+ *
+ *   Input : image ImageRef - image
+ *			 tags []string - tags
+ *			 add bool - true to add tags, false to remove
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) changeTags(image ImageRef, tags []string, add bool) error {
+	record, err := dataset.GetImageRecord(image.Location, image.Name)
+	if err != nil {
+		return err
+	}
+
+	if add {
+		_, err = dataset.UpdateImageRecord(image.Location, image.Name, append(record.Tags, tags...), nil)
+		return err
+	}
+
+	removed := make(map[string]bool)
+	for _, tag := range normaliseTags(tags) {
+		removed[tag] = true
+	}
+	kept := []string{}
+	for _, tag := range record.Tags {
+		if !removed[tag] {
+			kept = append(kept, tag)
+		}
+	}
+
+	_, err = dataset.UpdateImageRecord(image.Location, image.Name, kept, nil)
+	return err
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.clearLabels
+ *
+ * Purpose : Delete label file of the image
+ *
+ *   Input : image ImageRef - image
+ *
+ *  Return : error - ErrImageNotFound if image is not exists
+ */
+func (dataset Dataset) clearLabels(image ImageRef) error {
+	if _, err := dataset.StatImage(image.Location, image.Name); err != nil {
+		return err
+	}
+
+	labelPath, _ := dataset.LabelPath(image.Location, image.Name)
+	if err := os.Remove(labelPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	dataset.refreshCatalog(image.Location, image.Name)
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.copyImage
+ *
+ * Purpose : Copy image with the label and tags to another dataset
+ *
+ *   Input : target Dataset - dataset to copy image
+ *			 image ImageRef - image
+ *			 location string - location in the target dataset, image location when empty
+ *
+ *  Return : error - ErrImageExists if target has image with the same name
+ */
+func (dataset Dataset) copyImage(target Dataset, image ImageRef, location string) error {
+	if location == "" {
+		location = image.Location
+	}
+
+	sourceImage, err := dataset.ImagePath(image.Location, image.Name)
+	if err != nil {
+		return err
+	}
+	targetImage, err := target.ImagePath(location, image.Name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(sourceImage); err != nil {
+		return ErrImageNotFound
+	}
+	if _, err := os.Stat(targetImage); err == nil {
+		return ErrImageExists
+	}
+
+	sourceLabel, _ := dataset.LabelPath(image.Location, image.Name)
+	targetLabel, _ := target.LabelPath(location, image.Name)
+	if _, err := os.Stat(sourceLabel); err == nil {
+		if err := copyFileAtomic(sourceLabel, targetLabel); err != nil {
+			return err
+		}
+	}
+	if err := copyFileAtomic(sourceImage, targetImage); err != nil {
+		return err
+	}
+	target.refreshCatalog(location, image.Name)
+
+	if record, err := dataset.GetImageRecord(image.Location, image.Name); err == nil && len(record.Tags) > 0 {
+		target.UpdateImageRecord(location, image.Name, record.Tags, nil)
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : addZipFile
+ *
+ * Purpose : Add file to the zip archive
+ *
+ *   Input : archive *zip.Writer - archive
+ *			 path string - path of the file on the drive
+ *			 name string - name of the file in the archive
+ *
+ *  Return : error - error if occur, os.IsNotExist error when file is missing
+ */
+func addZipFile(archive *zip.Writer, path string, name string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: info.ModTime()})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(entry, source)
+	return err
+}
@@ -0,0 +1,845 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: catalog.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Images catalog of the dataset kept in the embedded bbolt database
+
+	Catalog is stored in '<dataset>/catalog.db' and has one record per image
+	with the key '<location>/<name>', label status, tags and upload time are
+	indexed (catalogindex.go). Records are refreshed by every change made
+	through the core, catalog is synchronised with the drive when it is
+	opened the first time and can be rebuilt from the files at any time.
+	Catalog is created only in the folder of the dataset.
+
+	In the file
+		1. ImageRecord - catalog record of the image
+		2. Dataset.Catalog, CloseCatalogs - shared database per dataset
+		3. Dataset.QueryImages, CountImages - filtered and sorted page of the images
+		4. Dataset.SyncCatalog - refresh changed records or rebuild all of them
+		5. Dataset.UpdateImageRecord - set tags and review status
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/CoderSergiy/golib/logging"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bucket with the images records
+var imagesBucket = []byte("images")
+
+// Time to wait for the catalog locked by another process
+var CatalogOpenTimeout = time.Second
+
+// Catalog record of the image
+type ImageRecord struct {
+	ImageFile
+	Width          int       `json:"width"`
+	Height         int       `json:"height"`
+	SHA256         string    `json:"sha256"`
+	PerceptualHash string    `json:"perceptual_hash"`
+	Uploaded       time.Time `json:"uploaded"`
+	LabelStatus    string    `json:"label_status"`
+	Boxes          int       `json:"boxes"`
+	Classes        []int     `json:"classes"`
+	Tags           []string  `json:"tags"`
+}
+
+// Record as stored in the database with the label file state to find changes
+type catalogEntry struct {
+	Record        ImageRecord `json:"record"`
+	LabelSize     int64       `json:"label_size"`
+	LabelModified time.Time   `json:"label_modified"`
+}
+
+// Result of the catalog synchronisation
+type CatalogResult struct {
+	Images  int `json:"images"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+}
+
+// Images catalog of the dataset
+type Catalog struct {
+	db *bbolt.DB
+}
+
+// Opened catalogs by the dataset path, bbolt allows one open database per file
+// Catalogs of the folders locked by lockDatasetFolder are not opened
+var catalogs = struct {
+	sync.Mutex
+	open   map[string]*Catalog
+	closed map[string]bool
+}{open: make(map[string]*Catalog), closed: make(map[string]bool)}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.CatalogPath
+ *
+ * Purpose : Get path to the catalog database
+ *
+ *   Input : Nothing
+ *
+ *  Return : string - path to the catalog file
+ */
+func (dataset Dataset) CatalogPath() string {
+	return filepath.Join(dataset.Path, CatalogFileName)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.Catalog
+ *
+ * Purpose : Get opened catalog of the dataset, catalog opened the first time
+ *			 is synchronised with the files on the drive
+ *			 New catalog is created only in the folder with the descriptor or data.yaml
+ *
+ *   Input : Nothing
+ *
+ *  Return : *Catalog - catalog shared by the process
+ *			 error - ErrDatasetNotFound if folder is not the dataset,
+ *					 ErrDatasetBusy while the folder is archived or moved,
+ *					 error if database cannot be opened
+ */
+func (dataset Dataset) Catalog() (*Catalog, error) {
+	catalogs.Lock()
+	if catalog, ok := catalogs.open[dataset.Path]; ok {
+		catalogs.Unlock()
+		return catalog, nil
+	}
+	if catalogs.closed[dataset.Path] {
+		catalogs.Unlock()
+		return nil, ErrDatasetBusy
+	}
+
+	if _, err := os.Stat(dataset.CatalogPath()); os.IsNotExist(err) && !dataset.isDatasetFolder() {
+		catalogs.Unlock()
+		return nil, ErrDatasetNotFound
+	}
+
+	db, err := bbolt.Open(dataset.CatalogPath(), 0644, &bbolt.Options{Timeout: CatalogOpenTimeout})
+	if err == bbolt.ErrTimeout {
+		err = ErrCatalogLocked
+	}
+	if err != nil {
+		catalogs.Unlock()
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(imagesBucket); err != nil {
+			return err
+		}
+		return buildIndexes(tx)
+	})
+	if err != nil {
+		db.Close()
+		catalogs.Unlock()
+		return nil, err
+	}
+
+	catalog := &Catalog{db: db}
+	catalogs.open[dataset.Path] = catalog
+	catalogs.Unlock()
+
+	if _, err := dataset.SyncCatalog(false); err != nil {
+		logging.Error_Log("Cannot synchronise catalog of the dataset '%v': '%v'", dataset.Name, err)
+	}
+
+	return catalog, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : CloseCatalogs
+ *
+ * Purpose : Close all opened catalogs
+ *
+ *   Input : Nothing
+ *
+ *  Return : Nothing
+ */
+func CloseCatalogs() {
+	catalogs.Lock()
+	defer catalogs.Unlock()
+
+	for path, catalog := range catalogs.open {
+		catalog.db.Close()
+		delete(catalogs.open, path)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : closeCatalog
+ *
+ * Purpose : Close catalog of the dataset before folder is renamed or deleted
+ *
+ *   Input : path string - path to the dataset
+ *
+ *  Return : Nothing
+ */
+func closeCatalog(path string) {
+	catalogs.Lock()
+	defer catalogs.Unlock()
+
+	if catalog, ok := catalogs.open[path]; ok {
+		catalog.db.Close()
+		delete(catalogs.open, path)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.QueryImages
+ *
+ * Purpose : Get page of the images matched by the query, indexes of the catalog
+ *			 are used to read the records of the page only
+ *			 Directory is read when catalog cannot be opened
+ *
+ *   Input : query ImageQuery - filters, sorting and page
+ *
+ *  Return : []ImageRecord - images of the page
+ *			 int - total number of the matched images
+ *			 error - error if occur
+ */
+func (dataset Dataset) QueryImages(query ImageQuery) ([]ImageRecord, int, error) {
+	if err := query.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	catalog, err := dataset.Catalog()
+	if err != nil {
+		logging.Error_Log("Catalog of the dataset '%v' is not available, read the directory: '%v'", dataset.Name, err)
+		records, err := dataset.readDirectoryRecords(query.locations())
+		if err != nil {
+			return nil, 0, err
+		}
+		images, total := query.page(records)
+		return images, total, nil
+	}
+
+	return catalog.query(query)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.CountImages
+ *
+ * Purpose : Get number of images in every location
+ *
+ *   Input : Nothing
+ *
+ *  Return : map[string]int - number of images by the location
+ *			 error - error if occur
+ */
+func (dataset Dataset) CountImages() (map[string]int, error) {
+	counts := make(map[string]int)
+
+	catalog, err := dataset.Catalog()
+	if err != nil {
+		for _, location := range Locations {
+			images, err := dataset.ListImages(location)
+			if err != nil {
+				return nil, err
+			}
+			counts[location] = len(images)
+		}
+		return counts, nil
+	}
+
+	err = catalog.db.View(func(tx *bbolt.Tx) error {
+		cursor := tx.Bucket(imagesBucket).Cursor()
+		for _, location := range Locations {
+			prefix := []byte(location + "/")
+			for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+				counts[location]++
+			}
+		}
+		return nil
+	})
+
+	return counts, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.readDirectoryRecords
+ *
+ * Purpose : Get records of the images from the directories without hashes and dimensions
+ *
+ *   Input : locations []string - locations to read
+ *
+ *  Return : []ImageRecord - records of the images
+ *			 error - error if occur
+ */
+func (dataset Dataset) readDirectoryRecords(locations []string) ([]ImageRecord, error) {
+	records := []ImageRecord{}
+	for _, location := range locations {
+		images, err := dataset.ListImages(location)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, image := range images {
+			records = append(records, ImageRecord{ImageFile: image, Uploaded: image.Modified, LabelStatus: labelStatus(image.Labelled), Classes: []int{}, Tags: []string{}})
+		}
+	}
+
+	return records, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.GetImageRecord
+ *
+ * Purpose : Get catalog record of the image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : ImageRecord - catalog record
+ *			 error - ErrImageNotFound if image is not in the catalog
+ */
+func (dataset Dataset) GetImageRecord(location string, name string) (ImageRecord, error) {
+	catalog, err := dataset.Catalog()
+	if err != nil {
+		return ImageRecord{}, err
+	}
+
+	entry, found, err := catalog.get(location, name)
+	if err != nil {
+		return ImageRecord{}, err
+	}
+	if !found {
+		return ImageRecord{}, ErrImageNotFound
+	}
+
+	return entry.Record, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.UpdateImageRecord
+ *
+ * Purpose : Set tags and review status of the image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *			 tags []string - new tags, nil to keep current tags
+ *			 reviewed *bool - new review status, nil to keep current status
+ *
+ *  Return : ImageRecord - updated record
+ *			 error - error if occur
+ */
+func (dataset Dataset) UpdateImageRecord(location string, name string, tags []string, reviewed *bool) (ImageRecord, error) {
+	catalog, err := dataset.Catalog()
+	if err != nil {
+		return ImageRecord{}, err
+	}
+
+	entry, found, err := catalog.get(location, name)
+	if err != nil {
+		return ImageRecord{}, err
+	}
+	if !found {
+		return ImageRecord{}, ErrImageNotFound
+	}
+
+	if tags != nil {
+		entry.Record.Tags = normaliseTags(tags)
+	}
+	if reviewed != nil {
+		if *reviewed {
+			entry.Record.LabelStatus = LabelReviewed
+		} else {
+			entry.Record.LabelStatus = labelStatus(entry.Record.Labelled)
+		}
+	}
+
+	return entry.Record, catalog.put(entry)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.SyncCatalog
+ *
+ * Purpose : Synchronise catalog with the files on the drive
+ *			 Rebuild reads all files again, tags and upload time are kept
+ *
+ *   Input : rebuild bool - true to read all images, false to read changed only
+ *
+ *  Return : CatalogResult - number of updated and removed records
+ *			 error - error if occur
+ */
+func (dataset Dataset) SyncCatalog(rebuild bool) (CatalogResult, error) {
+	result := CatalogResult{}
+
+	catalog, err := dataset.Catalog()
+	if err != nil {
+		return result, err
+	}
+
+	entries := make(map[string]catalogEntry)
+	err = catalog.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(imagesBucket).ForEach(func(key []byte, value []byte) error {
+			entry := catalogEntry{}
+			if err := json.Unmarshal(value, &entry); err != nil {
+				// Broken record is replaced by the new one
+				return nil
+			}
+			entries[string(key)] = entry
+			return nil
+		})
+	})
+	if err != nil {
+		return result, err
+	}
+
+	updated := []catalogEntry{}
+	found := make(map[string]bool)
+	for _, location := range Locations {
+		images, err := dataset.ListImages(location)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+
+		for _, image := range images {
+			key := catalogKey(location, image.Name)
+			found[key] = true
+
+			previous, exists := entries[key]
+			if exists && !rebuild && !dataset.isEntryChanged(previous, image) {
+				continue
+			}
+
+			var previousEntry *catalogEntry
+			if exists {
+				previousEntry = &previous
+			}
+			entry, err := dataset.readCatalogEntry(location, image.Name, previousEntry, rebuild)
+			if err != nil {
+				logging.Error_Log("Cannot read image '%v' for the catalog: '%v'", key, err)
+				continue
+			}
+			updated = append(updated, entry)
+		}
+	}
+	result.Images = len(found)
+	result.Updated = len(updated)
+
+	err = catalog.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(imagesBucket)
+		for _, entry := range updated {
+			if err := putRecord(tx, entry); err != nil {
+				return err
+			}
+		}
+
+		// Records of the images removed from the drive
+		var stale [][]byte
+		bucket.ForEach(func(key []byte, value []byte) error {
+			if !found[string(key)] {
+				stale = append(stale, append([]byte{}, key...))
+			}
+			return nil
+		})
+		for _, key := range stale {
+			if err := deleteRecord(tx, key); err != nil {
+				return err
+			}
+		}
+		result.Removed = len(stale)
+
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.isEntryChanged
+ *
+ * Purpose : Check if image or label file was changed after the record was created
+ *
+ *   Input : entry catalogEntry - catalog record
+ *			 image ImageFile - image file on the drive
+ *
+ *  Return : bool - true if record must be refreshed
+ */
+func (dataset Dataset) isEntryChanged(entry catalogEntry, image ImageFile) bool {
+	if entry.Record.Size != image.Size || !entry.Record.Modified.Equal(image.Modified) {
+		return true
+	}
+
+	labelSize, labelModified := dataset.labelFileState(image.Location, image.Name)
+	return entry.LabelSize != labelSize || !entry.LabelModified.Equal(labelModified)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.readCatalogEntry
+ *
+ * Purpose : Create catalog record from the image and label files
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *			 previous *catalogEntry - current record to keep tags and upload time, can be nil
+ *			 rehash bool - true to read the image even if it is not changed
+ *
+ *  Return : catalogEntry - new record
+ *			 error - error if image cannot be read
+ */
+func (dataset Dataset) readCatalogEntry(location string, name string, previous *catalogEntry, rehash bool) (catalogEntry, error) {
+	image, err := dataset.StatImage(location, name)
+	if err != nil {
+		return catalogEntry{}, err
+	}
+
+	entry := catalogEntry{Record: ImageRecord{ImageFile: image, Uploaded: image.Modified, Classes: []int{}, Tags: []string{}}}
+	entry.LabelSize, entry.LabelModified = dataset.labelFileState(location, name)
+
+	// Hashes are calculated again only when image file is changed
+	if !rehash && previous != nil && previous.Record.Size == image.Size && previous.Record.Modified.Equal(image.Modified) && previous.Record.SHA256 != "" {
+		entry.Record.Width = previous.Record.Width
+		entry.Record.Height = previous.Record.Height
+		entry.Record.SHA256 = previous.Record.SHA256
+		entry.Record.PerceptualHash = previous.Record.PerceptualHash
+	} else {
+		imagePath, _ := dataset.ImagePath(location, name)
+		info, err := ReadImageInfo(imagePath)
+		if err != nil {
+			return catalogEntry{}, err
+		}
+		entry.Record.Width = info.Width
+		entry.Record.Height = info.Height
+		entry.Record.SHA256 = info.SHA256
+		entry.Record.PerceptualHash = info.PerceptualHash
+	}
+
+	classes := make(map[int]bool)
+	if labels, err := dataset.ReadLabels(location, name); err == nil {
+		entry.Record.Boxes = len(labels)
+		for _, label := range labels {
+			classes[label.Class] = true
+		}
+	}
+	for class := range classes {
+		entry.Record.Classes = append(entry.Record.Classes, class)
+	}
+	sort.Ints(entry.Record.Classes)
+
+	entry.Record.LabelStatus = labelStatus(image.Labelled)
+	if previous != nil {
+		entry.Record.Uploaded = previous.Record.Uploaded
+		entry.Record.Tags = previous.Record.Tags
+		// Review is kept until the labels are changed
+		if previous.Record.LabelStatus == LabelReviewed && previous.LabelSize == entry.LabelSize && previous.LabelModified.Equal(entry.LabelModified) {
+			entry.Record.LabelStatus = LabelReviewed
+		}
+	}
+
+	return entry, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.labelFileState
+ *
+ * Purpose : Get size and modification time of the label file
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : int64 - size of the label file, 0 if not exists
+ *			 time.Time - modification time, zero if not exists
+ */
+func (dataset Dataset) labelFileState(location string, name string) (int64, time.Time) {
+	labelPath, err := dataset.LabelPath(location, name)
+	if err != nil {
+		return 0, time.Time{}
+	}
+
+	info, err := os.Stat(labelPath)
+	if err != nil {
+		return 0, time.Time{}
+	}
+
+	return info.Size(), info.ModTime()
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.refreshCatalog
+ *
+ * Purpose : Refresh records of the images after change, errors are logged only
+ *			 as the catalog is restored by the next synchronisation
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 names ...string - image file names
+ *
+ *  Return : Nothing
+ */
+func (dataset Dataset) refreshCatalog(location string, names ...string) {
+	catalog, err := dataset.Catalog()
+	if err != nil {
+		logging.Error_Log("Catalog of the dataset '%v' is not updated: '%v'", dataset.Name, err)
+		return
+	}
+
+	for _, name := range names {
+		if err := dataset.refreshRecord(catalog, location, name, location); err != nil {
+			logging.Error_Log("Catalog record '%v' is not updated: '%v'", catalogKey(location, name), err)
+		}
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.moveCatalogRecord
+ *
+ * Purpose : Move record of the image to another location with tags and upload time
+ *
+ *   Input : from string - previous location
+ *			 to string - new location
+ *			 name string - image file name
+ *
+ *  Return : Nothing
+ */
+func (dataset Dataset) moveCatalogRecord(from string, to string, name string) {
+	catalog, err := dataset.Catalog()
+	if err != nil {
+		logging.Error_Log("Catalog of the dataset '%v' is not updated: '%v'", dataset.Name, err)
+		return
+	}
+
+	if err := dataset.refreshRecord(catalog, to, name, from); err != nil {
+		logging.Error_Log("Catalog record '%v' is not updated: '%v'", catalogKey(to, name), err)
+	}
+	if err := catalog.delete(from, name); err != nil {
+		logging.Error_Log("Catalog record '%v' is not removed: '%v'", catalogKey(from, name), err)
+	}
+	dataset.removeThumbnail(from, name)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.restoreCatalogRecord
+ *
+ * Purpose : Store record of the restored image with its tags, upload time and review
+ *
+ *   Input : record ImageRecord - record of the image before it was deleted
+ *
+ *  Return : Nothing
+ */
+func (dataset Dataset) restoreCatalogRecord(record ImageRecord) {
+	catalog, err := dataset.Catalog()
+	if err != nil {
+		logging.Error_Log("Catalog of the dataset '%v' is not updated: '%v'", dataset.Name, err)
+		return
+	}
+
+	previous := catalogEntry{Record: record}
+	previous.LabelSize, previous.LabelModified = dataset.labelFileState(record.Location, record.Name)
+	entry, err := dataset.readCatalogEntry(record.Location, record.Name, &previous, false)
+	if err == nil {
+		err = catalog.put(entry)
+	}
+	if err != nil {
+		logging.Error_Log("Catalog record '%v' is not restored: '%v'", catalogKey(record.Location, record.Name), err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.refreshRecord
+ *
+ * Purpose : Store new record of the image or remove it if image is not exists
+ *
+ *   Input : catalog *Catalog - catalog of the dataset
+ *			 location string - current location of the image
+ *			 name string - image file name
+ *			 previousLocation string - location of the previous record
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) refreshRecord(catalog *Catalog, location string, name string, previousLocation string) error {
+	if _, err := dataset.StatImage(location, name); err == ErrImageNotFound {
+		dataset.removeThumbnail(location, name)
+		return catalog.delete(location, name)
+	}
+
+	previous, found, err := catalog.get(previousLocation, name)
+	if err != nil {
+		return err
+	}
+
+	var previousEntry *catalogEntry
+	if found {
+		previousEntry = &previous
+	}
+	entry, err := dataset.readCatalogEntry(location, name, previousEntry, false)
+	if err != nil {
+		return err
+	}
+
+	return catalog.put(entry)
+}
+
+/****************************************************************************************
+ *
+ * Function : Catalog.get
+ *
+ * Purpose : Read record of the image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : catalogEntry - record
+ *			 bool - true if record exists
+ *			 error - error if occur
+ */
+func (catalog *Catalog) get(location string, name string) (catalogEntry, bool, error) {
+	entry := catalogEntry{}
+	found := false
+
+	err := catalog.db.View(func(tx *bbolt.Tx) error {
+		value := tx.Bucket(imagesBucket).Get([]byte(catalogKey(location, name)))
+		if value == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(value, &entry)
+	})
+
+	return entry, found, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Catalog.put
+ *
+ * Purpose : Store record of the image
+ *
+ *   Input : entry catalogEntry - record
+ *
+ *  Return : error - error if occur
+ */
+func (catalog *Catalog) put(entry catalogEntry) error {
+	return catalog.db.Update(func(tx *bbolt.Tx) error {
+		return putRecord(tx, entry)
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : Catalog.delete
+ *
+ * Purpose : Remove record of the image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : error - error if occur
+ */
+func (catalog *Catalog) delete(location string, name string) error {
+	return catalog.db.Update(func(tx *bbolt.Tx) error {
+		return deleteRecord(tx, []byte(catalogKey(location, name)))
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.isDatasetFolder
+ *
+ * Purpose : Check the folder has the descriptor or data.yaml, the dataset made
+ *			 before the descriptor has data.yaml from the first migration step
+ *
+ *   Input : Nothing
+ *
+ *  Return : bool - true if folder is the dataset
+ */
+func (dataset Dataset) isDatasetFolder() bool {
+	for _, path := range []string{dataset.DescriptorPath(), dataset.DataFilePath()} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+/****************************************************************************************
+ *
+ * Function : catalogKey
+ *
+ * Purpose : Get key of the image record, records are sorted by location and name
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : string - record key
+ */
+func catalogKey(location string, name string) string {
+	return location + "/" + name
+}
+
+/****************************************************************************************
+ *
+ * Function : labelStatus
+ *
+ * Purpose : Get label status by the label file
+ *
+ *   Input : labelled bool - true if image has not empty label file
+ *
+ *  Return : string - LabelLabelled or LabelUnlabelled
+ */
+func labelStatus(labelled bool) string {
+	if labelled {
+		return LabelLabelled
+	}
+	return LabelUnlabelled
+}
+
+/****************************************************************************************
+ *
+ * Function : normaliseTags
+ *
+ * Purpose : Trim tags, remove empty and duplicated ones and sort them
+ *
+ *   Input : tags []string - tags
+ *
+ *  Return : []string - normalised tags
+ */
+func normaliseTags(tags []string) []string {
+	unique := make(map[string]bool)
+	result := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || unique[tag] {
+			continue
+		}
+		unique[tag] = true
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,247 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: catalog_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the images catalog with the indexes
+
+	In the file
+		1. TestQueryImages - pages by the indexes equal to the sorted records
+		2. TestCatalogIndexes - indexes follow changed, moved and removed records
+		3. TestCatalogOnlyInDataset - catalog is not created in other folders
+	=============================================================================
+*/
+
+package core
+
+import (
+	"fmt"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/****************************************************************************************
+ *
+ * Function : recordNames
+ *
+ * Purpose : Get '<location>/<name>' of the records to compare the pages
+ *
+ *   Input : records []ImageRecord - records
+ *
+ *  Return : []string - keys of the records
+ */
+func recordNames(records []ImageRecord) []string {
+	names := []string{}
+	for _, record := range records {
+		names = append(names, catalogKey(record.Location, record.Name))
+	}
+	return names
+}
+
+/****************************************************************************************
+ *
+ * Function : TestQueryImages
+ *
+ * Purpose : Check pages read by the indexes are the same as the pages of all
+ *			 records filtered and sorted in memory
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestQueryImages(t *testing.T) {
+	useTestDatasets(t)
+	dataset := createTestDataset(t, "cars", "car", "truck")
+
+	// Images with the same upload time check the order by the name and location
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for index := 0; index < 12; index++ {
+		location := []string{LocationUploaded, SplitTrain, SplitValid}[index%3]
+		name := fmt.Sprintf("img%02d.png", 11-index)
+		label := ""
+		if index%2 == 0 {
+			label = fmt.Sprintf("%v 0.5 0.5 0.2 0.2\n", index%4/2)
+		}
+		writeTestImage(t, dataset, location, name, label)
+		path, _ := dataset.ImagePath(location, name)
+		modified := base.Add(time.Duration(index/2) * time.Hour)
+		os.Chtimes(path, modified, modified)
+	}
+	if _, err := dataset.SyncCatalog(true); err != nil {
+		t.Fatal(err)
+	}
+	reviewed := true
+	dataset.UpdateImageRecord(SplitTrain, "img10.png", []string{"night", "rain"}, &reviewed)
+	dataset.UpdateImageRecord(LocationUploaded, "img05.png", []string{"night"}, nil)
+	dataset.UpdateImageRecord(SplitTrain, "img07.png", []string{"night"}, nil)
+
+	all, _, err := dataset.QueryImages(ImageQuery{Location: LocationAll})
+	if err != nil || len(all) != 12 {
+		t.Fatalf("all images %v: %v", len(all), err)
+	}
+
+	labelled := true
+	class := 1
+	tests := []struct {
+		name  string
+		query ImageQuery
+	}{
+		{"location by name", ImageQuery{Location: SplitTrain}},
+		{"location by name descending", ImageQuery{Location: SplitTrain, Descending: true}},
+		{"location page", ImageQuery{Location: LocationUploaded, Offset: 1, Limit: 2}},
+		{"page after the last", ImageQuery{Location: LocationUploaded, Offset: 10, Limit: 2}},
+		{"label status", ImageQuery{Location: SplitTrain, LabelStatus: LabelLabelled}},
+		{"label status descending page", ImageQuery{Location: SplitTrain, LabelStatus: LabelUnlabelled, Descending: true, Limit: 1}},
+		{"reviewed", ImageQuery{Location: SplitTrain, LabelStatus: LabelReviewed}},
+		{"tag", ImageQuery{Location: SplitTrain, Tag: "night"}},
+		{"tag and label status", ImageQuery{Location: SplitTrain, Tag: "night", LabelStatus: LabelReviewed}},
+		{"tag and search", ImageQuery{Location: SplitTrain, Tag: "night", Search: "07"}},
+		{"class", ImageQuery{Location: LocationUploaded, Class: &class}},
+		{"all by name", ImageQuery{Location: LocationAll, Limit: 5}},
+		{"all by tag", ImageQuery{Location: LocationAll, Tag: "night"}},
+		{"all by label status", ImageQuery{Location: LocationAll, LabelStatus: LabelUnlabelled, Sort: SortSize}},
+		{"uploaded", ImageQuery{Location: LocationAll, Sort: SortUploaded}},
+		{"uploaded descending page", ImageQuery{Location: LocationAll, Sort: SortUploaded, Descending: true, Offset: 3, Limit: 4}},
+		{"uploaded in location", ImageQuery{Location: SplitValid, Sort: SortUploaded, Descending: true}},
+		{"uploaded labelled", ImageQuery{Location: LocationAll, Sort: SortUploaded, Labelled: &labelled, Limit: 3}},
+		{"uploaded with tag", ImageQuery{Location: LocationAll, Sort: SortUploaded, Tag: "night", Offset: 1}},
+		{"uploaded range", ImageQuery{Location: LocationAll, Sort: SortUploaded, UploadedFrom: base.Add(time.Hour), UploadedTo: base.Add(3 * time.Hour)}},
+		{"boxes", ImageQuery{Location: LocationAll, Sort: SortBoxes, Descending: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			images, total, err := dataset.QueryImages(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scope := test.query
+			scope.Offset, scope.Limit = 0, 0
+			inLocations := []ImageRecord{}
+			for _, record := range all {
+				if scope.Location == LocationAll || record.Location == scope.Location {
+					inLocations = append(inLocations, record)
+				}
+			}
+			expected, expectedTotal := test.query.page(inLocations)
+
+			if !reflect.DeepEqual(recordNames(images), recordNames(expected)) || total != expectedTotal {
+				t.Errorf("page %v of %v, expected %v of %v", recordNames(images), total, recordNames(expected), expectedTotal)
+			}
+		})
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestCatalogIndexes
+ *
+ * Purpose : Check index keys are replaced with the record and built for the catalog
+ *			 made before the indexes
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestCatalogIndexes(t *testing.T) {
+	useTestDatasets(t)
+	dataset := createTestDataset(t, "cars", "car")
+	writeTestImage(t, dataset, LocationUploaded, "a.png", "")
+	writeTestImage(t, dataset, LocationUploaded, "b.png", "0 0.5 0.5 0.2 0.2\n")
+	dataset.SyncCatalog(false)
+	dataset.UpdateImageRecord(LocationUploaded, "a.png", []string{"night"}, nil)
+
+	count := func(query ImageQuery) int {
+		_, total, err := dataset.QueryImages(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return total
+	}
+	check := func(step string, tagged int, unlabelled int, labelled int) {
+		t.Helper()
+		if got := count(ImageQuery{Location: LocationUploaded, Tag: "night"}); got != tagged {
+			t.Errorf("%v: %v images tagged, expected %v", step, got, tagged)
+		}
+		if got := count(ImageQuery{Location: LocationUploaded, LabelStatus: LabelUnlabelled}); got != unlabelled {
+			t.Errorf("%v: %v images unlabelled, expected %v", step, got, unlabelled)
+		}
+		if got := count(ImageQuery{Location: LocationUploaded, LabelStatus: LabelLabelled}); got != labelled {
+			t.Errorf("%v: %v images labelled, expected %v", step, got, labelled)
+		}
+	}
+	check("stored", 1, 1, 1)
+
+	// Changed tags and labels replace the index keys
+	dataset.UpdateImageRecord(LocationUploaded, "a.png", []string{"day"}, nil)
+	path, _ := dataset.LabelPath(LocationUploaded, "a.png")
+	os.WriteFile(path, []byte("0 0.5 0.5 0.2 0.2\n"), 0644)
+	dataset.refreshCatalog(LocationUploaded, "a.png")
+	check("changed", 0, 0, 2)
+
+	// Removed image is removed from the indexes
+	imagePath, _ := dataset.ImagePath(LocationUploaded, "b.png")
+	os.Remove(imagePath)
+	dataset.SyncCatalog(false)
+	check("removed", 0, 0, 1)
+
+	// Catalog made before the indexes gets them when it is opened
+	catalog, _ := dataset.Catalog()
+	catalog.db.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket(tagIndexBucket)
+	})
+	closeCatalog(dataset.Path)
+	if got := count(ImageQuery{Location: LocationUploaded, Tag: "day"}); got != 1 {
+		t.Errorf("rebuilt: %v images tagged, expected 1", got)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestCatalogOnlyInDataset
+ *
+ * Purpose : Check catalog is created in the dataset and in the folder made before
+ *			 the descriptor, but not in the other folders
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestCatalogOnlyInDataset(t *testing.T) {
+	root := useTestDatasets(t)
+	createTestDataset(t, "cars")
+	os.MkdirAll(filepath.Join(root, "legacy", DatasetFolder), os.ModePerm)
+	os.WriteFile(filepath.Join(root, "legacy", DatasetFolder, DataFileName), []byte("names: []\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "notes"), os.ModePerm)
+
+	tests := []struct {
+		name    string
+		catalog bool
+	}{
+		{"cars", true},
+		{"legacy", true},
+		{"notes", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dataset, err := OpenDataset(test.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = dataset.Catalog()
+			_, statErr := os.Stat(dataset.CatalogPath())
+			if test.catalog && (err != nil || statErr != nil) {
+				t.Errorf("catalog is not created: %v", err)
+			}
+			if !test.catalog && (err != ErrDatasetNotFound || statErr == nil) {
+				t.Errorf("catalog of the folder: %v", err)
+			}
+		})
+	}
+}
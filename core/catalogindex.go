@@ -0,0 +1,453 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: catalogindex.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Secondary indexes of the images catalog and the queries using them
+
+	Every index is the bucket of the catalog database, the index key is made
+	of the indexed value and the image, the value is the key of the record
+	in the images bucket. Indexes are changed in the same transaction as the
+	record and are built from the records when the catalog has none of them.
+
+	Indexes:
+		1. Label status - '<status>/<location>/<name>'
+		2. Tag - '<tag>\x00<location>/<name>'
+		3. Upload time - '<time>' + '<name>\x00<location>', in the order of
+		   sortRecords by the upload time
+
+	Query sorted by the name in one location, or by the upload time, walks
+	the index with the cursor in the order of the page. Records are read only
+	for the page when the rest of the filters are answered by the index.
+
+	In the file
+		1. putRecord, deleteRecord - record with the indexes
+		2. buildIndexes - indexes of the catalog made before them
+		3. Catalog.query - page of the images by the index
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"go.etcd.io/bbolt"
+)
+
+// Index of the label status
+var statusIndexBucket = []byte("index_status")
+
+// Index of the tags
+var tagIndexBucket = []byte("index_tag")
+
+// Index of the upload time
+var uploadedIndexBucket = []byte("index_uploaded")
+
+// All index buckets of the catalog
+var indexBuckets = [][]byte{statusIndexBucket, tagIndexBucket, uploadedIndexBucket}
+
+// Ordered walk over the part of the bucket
+type indexScan struct {
+	bucket   []byte
+	prefix   []byte
+	location string // Location of the image, empty for all locations
+}
+
+/****************************************************************************************
+ *
+ * Function : indexKeys
+ *
+ * Purpose : Get keys of the record in every index bucket
+ *
+ *   Input : record ImageRecord - image record
+ *
+ *  Return : map[string][][]byte - keys by the index bucket name
+ */
+func indexKeys(record ImageRecord) map[string][][]byte {
+	key := catalogKey(record.Location, record.Name)
+
+	keys := map[string][][]byte{
+		string(statusIndexBucket):   {[]byte(record.LabelStatus + "/" + key)},
+		string(uploadedIndexBucket): {uploadedIndexKey(record)},
+	}
+	for _, tag := range record.Tags {
+		keys[string(tagIndexBucket)] = append(keys[string(tagIndexBucket)], []byte(tag+"\x00"+key))
+	}
+
+	return keys
+}
+
+/****************************************************************************************
+ *
+ * Function : uploadedIndexKey
+ *
+ * Purpose : Get key of the upload time index, keys are sorted by the time, name
+ *			 and location as sortRecords does it
+ *
+ *   Input : record ImageRecord - image record
+ *
+ *  Return : []byte - index key
+ */
+func uploadedIndexKey(record ImageRecord) []byte {
+	key := make([]byte, 8, 8+len(record.Name)+1+len(record.Location))
+	// Sign bit is flipped to keep the times before 1970 in order
+	binary.BigEndian.PutUint64(key, uint64(record.Uploaded.UnixNano())^(1<<63))
+	key = append(key, record.Name...)
+	key = append(key, 0)
+	return append(key, record.Location...)
+}
+
+/****************************************************************************************
+ *
+ * Function : putIndexes
+ *
+ * Purpose : Add or remove keys of the record in the index buckets
+ *
+ *   Input : tx *bbolt.Tx - write transaction
+ *			 record ImageRecord - image record
+ *			 add bool - true to add the keys, false to remove them
+ *
+ *  Return : error - error if occur
+ */
+func putIndexes(tx *bbolt.Tx, record ImageRecord, add bool) error {
+	value := []byte(catalogKey(record.Location, record.Name))
+	for name, keys := range indexKeys(record) {
+		bucket := tx.Bucket([]byte(name))
+		for _, key := range keys {
+			var err error
+			if add {
+				err = bucket.Put(key, value)
+			} else {
+				err = bucket.Delete(key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : putRecord
+ *
+ * Purpose : Store the record and replace its index keys
+ *
+ *   Input : tx *bbolt.Tx - write transaction
+ *			 entry catalogEntry - record
+ *
+ *  Return : error - error if occur
+ */
+func putRecord(tx *bbolt.Tx, entry catalogEntry) error {
+	key := []byte(catalogKey(entry.Record.Location, entry.Record.Name))
+	if err := deleteRecord(tx, key); err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(imagesBucket).Put(key, value); err != nil {
+		return err
+	}
+
+	return putIndexes(tx, entry.Record, true)
+}
+
+/****************************************************************************************
+ *
+ * Function : deleteRecord
+ *
+ * Purpose : Remove the record with its index keys, broken record is removed
+ *			 without the index keys as they were never written
+ *
+ *   Input : tx *bbolt.Tx - write transaction
+ *			 key []byte - key of the record
+ *
+ *  Return : error - error if occur
+ */
+func deleteRecord(tx *bbolt.Tx, key []byte) error {
+	bucket := tx.Bucket(imagesBucket)
+	value := bucket.Get(key)
+	if value == nil {
+		return nil
+	}
+
+	previous := catalogEntry{}
+	if err := json.Unmarshal(value, &previous); err == nil {
+		if err := putIndexes(tx, previous.Record, false); err != nil {
+			return err
+		}
+	}
+
+	return bucket.Delete(key)
+}
+
+/****************************************************************************************
+ *
+ * Function : buildIndexes
+ *
+ * Purpose : Create index buckets and fill them from the records when the catalog
+ *			 was made before the indexes
+ *
+ *   Input : tx *bbolt.Tx - write transaction
+ *
+ *  Return : error - error if occur
+ */
+func buildIndexes(tx *bbolt.Tx) error {
+	missed := false
+	for _, name := range indexBuckets {
+		if tx.Bucket(name) == nil {
+			missed = true
+		}
+	}
+	if !missed {
+		return nil
+	}
+
+	for _, name := range indexBuckets {
+		if tx.Bucket(name) != nil {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Bucket(imagesBucket).ForEach(func(key []byte, value []byte) error {
+		entry := catalogEntry{}
+		if err := json.Unmarshal(value, &entry); err != nil {
+			// Broken record is replaced by the next synchronisation
+			return nil
+		}
+		return putIndexes(tx, entry.Record, true)
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : ImageQuery.indexScans
+ *
+ * Purpose : Choose the index walked in the order of the page
+ *
+ *   Input : Nothing
+ *
+ *  Return : []indexScan - walks in the order of the page, nil if the query is sorted in memory
+ *			 bool - true if the filters are answered by the index keys only
+ */
+func (query ImageQuery) indexScans() ([]indexScan, bool) {
+	keysOnly := query.Labelled == nil && query.Class == nil && query.Search == "" &&
+		query.MinWidth == 0 && query.MaxWidth == 0 && query.MinHeight == 0 && query.MaxHeight == 0 &&
+		query.UploadedFrom.IsZero() && query.UploadedTo.IsZero()
+	location := query.Location + "/"
+
+	switch query.Sort {
+	case "", SortName:
+		if query.Location == LocationAll {
+			return nil, false
+		}
+		if query.Tag != "" {
+			return []indexScan{{bucket: tagIndexBucket, prefix: []byte(query.Tag + "\x00" + location)}}, keysOnly && query.LabelStatus == ""
+		}
+		if query.LabelStatus != "" {
+			return []indexScan{{bucket: statusIndexBucket, prefix: []byte(query.LabelStatus + "/" + location)}}, keysOnly
+		}
+		return []indexScan{{bucket: imagesBucket, prefix: []byte(location)}}, keysOnly
+	case SortUploaded:
+		scan := indexScan{bucket: uploadedIndexBucket, prefix: []byte{}}
+		if query.Location != LocationAll {
+			scan.location = query.Location
+		}
+		return []indexScan{scan}, keysOnly && query.Tag == "" && query.LabelStatus == ""
+	}
+
+	return nil, false
+}
+
+/****************************************************************************************
+ *
+ * Function : ImageQuery.candidateScans
+ *
+ * Purpose : Choose the smallest part of the catalog having all matched images,
+ *			 used when the query is sorted in memory
+ *
+ *   Input : Nothing
+ *
+ *  Return : []indexScan - walks over the images of the query locations
+ */
+func (query ImageQuery) candidateScans() []indexScan {
+	scans := []indexScan{}
+	for _, location := range query.locations() {
+		switch {
+		case query.Tag != "":
+			scans = append(scans, indexScan{bucket: tagIndexBucket, prefix: []byte(query.Tag + "\x00" + location + "/")})
+		case query.LabelStatus != "":
+			scans = append(scans, indexScan{bucket: statusIndexBucket, prefix: []byte(query.LabelStatus + "/" + location + "/")})
+		default:
+			scans = append(scans, indexScan{bucket: imagesBucket, prefix: []byte(location + "/")})
+		}
+	}
+
+	return scans
+}
+
+/****************************************************************************************
+ *
+ * Function : walk
+ *
+ * Purpose : Call visit for every record key of the scan in the order of the keys
+ *
+ *   Input : tx *bbolt.Tx - read transaction
+ *			 scan indexScan - bucket and prefix
+ *			 descending bool - walk from the last key
+ *			 visit func([]byte) error - called with the key of the record in the images bucket
+ *
+ *  Return : error - error of the visit
+ */
+func walk(tx *bbolt.Tx, scan indexScan, descending bool, visit func(key []byte) error) error {
+	cursor := tx.Bucket(scan.bucket).Cursor()
+	recordKey := func(key []byte, value []byte) []byte {
+		if bytes.Equal(scan.bucket, imagesBucket) {
+			return key
+		}
+		return value
+	}
+	location := []byte(scan.location + "/")
+
+	key, value := cursor.Seek(scan.prefix)
+	next := cursor.Next
+	if descending {
+		// Last key of the prefix is before the first key after it
+		key, value = seekAfterPrefix(cursor, scan.prefix)
+		next = cursor.Prev
+	}
+
+	for ; key != nil && bytes.HasPrefix(key, scan.prefix); key, value = next() {
+		record := recordKey(key, value)
+		if scan.location != "" && !bytes.HasPrefix(record, location) {
+			continue
+		}
+		if err := visit(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : seekAfterPrefix
+ *
+ * Purpose : Move the cursor to the last key having the prefix
+ *
+ *   Input : cursor *bbolt.Cursor - cursor of the bucket
+ *			 prefix []byte - prefix of the keys, empty for the whole bucket
+ *
+ *  Return : []byte, []byte - key and value, the key can be without the prefix
+ */
+func seekAfterPrefix(cursor *bbolt.Cursor, prefix []byte) ([]byte, []byte) {
+	// Prefix increased by one is the first key after all keys with the prefix
+	end := append([]byte{}, prefix...)
+	for len(end) > 0 && end[len(end)-1] == 0xff {
+		end = end[:len(end)-1]
+	}
+	if len(end) == 0 {
+		return cursor.Last()
+	}
+	end[len(end)-1]++
+
+	if key, _ := cursor.Seek(end); key == nil {
+		return cursor.Last()
+	}
+	return cursor.Prev()
+}
+
+/****************************************************************************************
+ *
+ * Function : Catalog.query
+ *
+ * Purpose : Get page of the images matched by the query using the indexes
+ *
+ *   Input : query ImageQuery - filters, sorting and page
+ *
+ *  Return : []ImageRecord - images of the page
+ *			 int - total number of the matched images
+ *			 error - error if occur
+ */
+func (catalog *Catalog) query(query ImageQuery) ([]ImageRecord, int, error) {
+	images := []ImageRecord{}
+	total := 0
+
+	err := catalog.db.View(func(tx *bbolt.Tx) error {
+		records := tx.Bucket(imagesBucket)
+		read := func(key []byte) (ImageRecord, bool, error) {
+			value := records.Get(key)
+			if value == nil {
+				return ImageRecord{}, false, nil
+			}
+			entry := catalogEntry{}
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return ImageRecord{}, false, err
+			}
+			return entry.Record, true, nil
+		}
+		inPage := func(index int) bool {
+			return index >= query.Offset && (query.Limit == 0 || index < query.Offset+query.Limit)
+		}
+
+		// Index in the order of the page, records are read for the page only when possible
+		if scans, keysOnly := query.indexScans(); scans != nil {
+			for _, scan := range scans {
+				err := walk(tx, scan, query.Descending, func(key []byte) error {
+					if keysOnly && !inPage(total) {
+						total++
+						return nil
+					}
+					record, found, err := read(key)
+					if err != nil || !found || !query.Match(record) {
+						return err
+					}
+					if inPage(total) {
+						images = append(images, record)
+					}
+					total++
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		// Candidates of the index are filtered and sorted in memory
+		matched := []ImageRecord{}
+		for _, scan := range query.candidateScans() {
+			err := walk(tx, scan, false, func(key []byte) error {
+				record, found, err := read(key)
+				if err == nil && found {
+					matched = append(matched, record)
+				}
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+		images, total = query.page(matched)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return images, total, nil
+}
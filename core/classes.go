@@ -0,0 +1,402 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: classes.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Operations on the classes of data.yaml applied to the label files
+
+	Operations:
+		merge   - boxes of the class get the other class, the class is removed
+		delete  - boxes of the class are removed, or images with them are
+		          moved to the trash
+		reorder - classes get the new ids in the given order
+
+	Ids after the removed class are shifted down. Label files of the
+	uploaded images, splits and trash are changed, versions keep their own
+	data.yaml and are not changed. New label files are written next to the
+	old ones first and renamed into place when all of them are ready,
+	data.yaml is written last. Only the class of the changed line is
+	replaced, coordinates are kept as they are.
+
+	In the file
+		1. ClassOperation.Validate - check the operation against the classes
+		2. ChangeClasses - apply the operation or report the changes (dry run)
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operations on the classes
+const ClassMerge = "merge"
+const ClassDelete = "delete"
+const ClassReorder = "reorder"
+
+// Operation on the classes of data.yaml
+type ClassOperation struct {
+	Operation    string `json:"operation"`               // 'merge', 'delete' or 'reorder'
+	Class        int    `json:"class"`                   // Class merged into another one or deleted
+	Into         int    `json:"into"`                    // Class which gets the boxes of the merged class
+	DeleteImages bool   `json:"delete_images,omitempty"` // Move images with the deleted class to the trash instead of removing the boxes
+	Order        []int  `json:"order,omitempty"`         // Old ids in the new order for reorder
+	DryRun       bool   `json:"dry_run"`                 // Report the changes without changing the files
+}
+
+// Report of the class operation
+type ClassReport struct {
+	ClassOperation
+	Classes []string `json:"classes"` // Classes names after the operation
+	Mapping []int    `json:"mapping"` // New id of every old class, -1 for the deleted class
+	Files   int      `json:"files"`   // Changed label files of the uploaded images and splits
+	Boxes   int      `json:"boxes"`   // Boxes which get the new class
+	Removed int      `json:"removed"` // Removed boxes
+	Images  int      `json:"images"`  // Images moved to the trash
+	Trash   int      `json:"trash"`   // Changed label files of the images in the trash
+}
+
+// Label file waiting to be renamed into place
+type stagedLabels struct {
+	path     string
+	tempPath string
+	location string
+	image    string // Image of the label file, empty if there is no image
+}
+
+/****************************************************************************************
+ *
+ * Function : ClassOperation.Validate
+ *
+ * Purpose : Check the operation and get the new classes
+ *
+ *   Input : names []string - current classes names
+ *
+ *  Return : []string - classes names after the operation
+ *			 []int - new id of every old class, -1 for the deleted class
+ *			 error - ErrInvalidClassOperation if operation is not valid
+ */
+func (operation ClassOperation) Validate(names []string) ([]string, []int, error) {
+	isClass := func(class int) bool { return class >= 0 && class < len(names) }
+
+	mapping := make([]int, len(names))
+	switch operation.Operation {
+	case ClassMerge, ClassDelete:
+		if !isClass(operation.Class) {
+			return nil, nil, fmt.Errorf("%w: class %v is not in the data file", ErrInvalidClassOperation, operation.Class)
+		}
+		if operation.Operation == ClassMerge && (!isClass(operation.Into) || operation.Into == operation.Class) {
+			return nil, nil, fmt.Errorf("%w: class %v cannot be merged into %v", ErrInvalidClassOperation, operation.Class, operation.Into)
+		}
+
+		for class := range names {
+			mapping[class] = shiftClass(class, operation.Class)
+		}
+		mapping[operation.Class] = -1
+		if operation.Operation == ClassMerge {
+			mapping[operation.Class] = shiftClass(operation.Into, operation.Class)
+		}
+		result := append(append([]string{}, names[:operation.Class]...), names[operation.Class+1:]...)
+		return result, mapping, nil
+
+	case ClassReorder:
+		if len(operation.Order) != len(names) {
+			return nil, nil, fmt.Errorf("%w: order must have all %v classes", ErrInvalidClassOperation, len(names))
+		}
+		result := make([]string, len(names))
+		used := make([]bool, len(names))
+		for index, class := range operation.Order {
+			if !isClass(class) || used[class] {
+				return nil, nil, fmt.Errorf("%w: class %v of the order is not known or repeated", ErrInvalidClassOperation, class)
+			}
+			used[class] = true
+			result[index] = names[class]
+			mapping[class] = index
+		}
+		return result, mapping, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: operation '%v' is not known, use '%v', '%v' or '%v'", ErrInvalidClassOperation, operation.Operation, ClassMerge, ClassDelete, ClassReorder)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ChangeClasses
+ *
+ * Purpose : Apply the class operation to data.yaml and all label files
+ *			 Dry run only reports the changes
+ *
+ *   Input : operation ClassOperation - operation on the classes
+ *
+ *  Return : ClassReport - classes after the operation and the number of changes
+ *			 error - ErrInvalidClassOperation if operation is not valid
+ */
+func (dataset Dataset) ChangeClasses(operation ClassOperation) (ClassReport, error) {
+	report := ClassReport{ClassOperation: operation}
+
+	dataFile, err := dataset.ReadDataFile()
+	if err != nil {
+		return report, err
+	}
+	if report.Classes, report.Mapping, err = operation.Validate(dataFile.Names); err != nil {
+		return report, err
+	}
+
+	// Classes above the data file are shifted by the removed class or kept
+	remap := func(class int) int {
+		if class < len(report.Mapping) {
+			return report.Mapping[class]
+		}
+		if operation.Operation == ClassReorder {
+			return class
+		}
+		return shiftClass(class, operation.Class)
+	}
+
+	staged := []stagedLabels{}
+	removeStaged := func() {
+		for _, file := range staged {
+			os.Remove(file.tempPath)
+		}
+	}
+	trash := map[string][]string{}
+
+	for _, location := range Locations {
+		labelsPath, _ := dataset.LabelsPath(location)
+		entries, err := os.ReadDir(labelsPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			removeStaged()
+			return report, err
+		}
+
+		images, err := dataset.ListImages(location)
+		if err != nil {
+			removeStaged()
+			return report, err
+		}
+		imageNames := map[string]string{}
+		for _, image := range images {
+			imageNames[LabelFileName(image.Name)] = image.Name
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".txt" {
+				continue
+			}
+			labelPath := filepath.Join(labelsPath, entry.Name())
+			content, err := os.ReadFile(labelPath)
+			if err != nil {
+				removeStaged()
+				return report, err
+			}
+
+			changed, boxes, removed := remapLabelFile(content, remap)
+			if boxes == 0 && removed == 0 {
+				continue
+			}
+			image := imageNames[entry.Name()]
+			if operation.Operation == ClassDelete && operation.DeleteImages && removed > 0 && image != "" {
+				trash[location] = append(trash[location], image)
+				report.Images++
+				continue
+			}
+
+			report.Files++
+			report.Boxes += boxes
+			report.Removed += removed
+			if operation.DryRun {
+				continue
+			}
+			tempPath, err := writeTempFile(labelPath, bytes.NewReader(changed))
+			if err != nil {
+				removeStaged()
+				return report, err
+			}
+			staged = append(staged, stagedLabels{path: labelPath, tempPath: tempPath, location: location, image: image})
+		}
+	}
+
+	if operation.DryRun {
+		report.Trash, err = dataset.remapTrashLabels(remap, true)
+		return report, err
+	}
+
+	for _, location := range Locations {
+		for _, name := range trash[location] {
+			if _, err := dataset.TrashImage(location, name); err != nil {
+				removeStaged()
+				return report, err
+			}
+		}
+	}
+
+	for index, file := range staged {
+		if err := os.Rename(file.tempPath, file.path); err != nil {
+			for _, left := range staged[index:] {
+				os.Remove(left.tempPath)
+			}
+			return report, err
+		}
+	}
+	folders := map[string]bool{}
+	for _, file := range staged {
+		if !folders[filepath.Dir(file.path)] {
+			folders[filepath.Dir(file.path)] = true
+			syncFolder(filepath.Dir(file.path))
+		}
+		if file.image != "" {
+			dataset.refreshCatalog(file.location, file.image)
+		}
+	}
+
+	if report.Trash, err = dataset.remapTrashLabels(remap, false); err != nil {
+		return report, err
+	}
+
+	dataFile.Names = report.Classes
+	return report, dataset.WriteDataFile(dataFile)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.remapTrashLabels
+ *
+ * Purpose : Change classes of the label files and catalog records in the trash,
+ *			 so the restored images have the current classes
+ *
+ *   Input : remap func(int) int - new id of the class, -1 to remove the box
+ *			 dryRun bool - true to count the changed files only
+ *
+ *  Return : int - number of changed label files
+ *			 error - error if occur
+ */
+func (dataset Dataset) remapTrashLabels(remap func(int) int, dryRun bool) (int, error) {
+	items, err := dataset.ListTrash()
+	if err != nil {
+		return 0, err
+	}
+
+	changedFiles := 0
+	for _, item := range items {
+		labelPath := filepath.Join(dataset.TrashPath(item.Id), LabelFileName(item.Name))
+		content, err := os.ReadFile(labelPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return changedFiles, err
+		}
+
+		changed, boxes, removed := remapLabelFile(content, remap)
+		if boxes == 0 && removed == 0 {
+			continue
+		}
+		changedFiles++
+		if dryRun {
+			continue
+		}
+
+		if err := writeFileAtomic(labelPath, bytes.NewReader(changed)); err != nil {
+			return changedFiles, err
+		}
+		if item.Record == nil {
+			continue
+		}
+		classes := []int{}
+		for _, class := range item.Record.Classes {
+			if class = remap(class); class >= 0 && !isClassOf(class, classes) {
+				classes = append(classes, class)
+			}
+		}
+		sort.Ints(classes)
+		item.Record.Classes = classes
+		item.Record.Boxes -= removed
+
+		itemContent, err := json.MarshalIndent(item, "", "  ")
+		if err != nil {
+			return changedFiles, err
+		}
+		if err := writeFileAtomic(filepath.Join(dataset.TrashPath(item.Id), TrashItemFileName), bytes.NewReader(itemContent)); err != nil {
+			return changedFiles, err
+		}
+	}
+
+	return changedFiles, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : remapLabelFile
+ *
+ * Purpose : Change classes of the label file line by line, the rest of the line is kept
+ *			 Line which cannot be parsed is kept as is
+ *
+ *   Input : content []byte - label file content
+ *			 remap func(int) int - new id of the class, -1 to remove the box
+ *
+ *  Return : []byte - new content
+ *			 int - number of boxes with the changed class
+ *			 int - number of removed boxes
+ */
+func remapLabelFile(content []byte, remap func(int) int) ([]byte, int, int) {
+	changed, removed := 0, 0
+
+	lines := strings.Split(string(content), "\n")
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		fields := strings.Fields(trimmed)
+		if len(fields) == 0 {
+			result = append(result, line)
+			continue
+		}
+		class, err := strconv.Atoi(fields[0])
+		if err != nil || class < 0 {
+			result = append(result, line)
+			continue
+		}
+
+		newClass := remap(class)
+		switch {
+		case newClass < 0:
+			removed++
+		case newClass != class:
+			changed++
+			result = append(result, line[:len(line)-len(trimmed)]+strconv.Itoa(newClass)+trimmed[len(fields[0]):])
+		default:
+			result = append(result, line)
+		}
+	}
+
+	return []byte(strings.Join(result, "\n")), changed, removed
+}
+
+/****************************************************************************************
+ *
+ * Function : shiftClass
+ *
+ * Purpose : Get id of the class after the removed class
+ *
+ *   Input : class int - class id
+ *			 removed int - id of the removed class
+ *
+ *  Return : int - id shifted down if the class is after the removed one
+ */
+func shiftClass(class int, removed int) int {
+	if class > removed {
+		return class - 1
+	}
+
+	return class
+}
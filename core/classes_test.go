@@ -0,0 +1,102 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: classes_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the classes changed in the label files
+
+	In the file
+		1. TestRemapLabelFile - classes changed and boxes removed line by line
+		2. TestShiftClass - ids after the deleted class
+	=============================================================================
+*/
+
+package core
+
+import (
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : TestRemapLabelFile
+ *
+ * Purpose : Check classes are changed, boxes are removed and the rest of the lines
+ *			 with the unparsed lines are kept as is
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestRemapLabelFile(t *testing.T) {
+	mergeTruck := func(class int) int {
+		if class == 2 {
+			return 0
+		}
+		return class
+	}
+	deleteFirst := func(class int) int {
+		if class == 0 {
+			return -1
+		}
+		return shiftClass(class, 0)
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		remap    func(int) int
+		expected string
+		changed  int
+		removed  int
+	}{
+		{"nothing to change", "0 0.5 0.5 0.2 0.2\n1 0.1 0.1 0.1 0.1\n", mergeTruck, "0 0.5 0.5 0.2 0.2\n1 0.1 0.1 0.1 0.1\n", 0, 0},
+		{"merged class", "2 0.5 0.5 0.2 0.2\n1 0.1 0.1 0.1 0.1\n2 0.3 0.3 0.1 0.1", mergeTruck, "0 0.5 0.5 0.2 0.2\n1 0.1 0.1 0.1 0.1\n0 0.3 0.3 0.1 0.1", 2, 0},
+		{"deleted class shifts the next ones", "0 0.5 0.5 0.2 0.2\n1 0.1 0.1 0.1 0.1\n3 0.2 0.2 0.1 0.1\n", deleteFirst, "0 0.1 0.1 0.1 0.1\n2 0.2 0.2 0.1 0.1\n", 2, 1},
+		{"polygon points are kept", "2 0.1 0.1 0.3 0.1 0.3 0.3\n", mergeTruck, "0 0.1 0.1 0.3 0.1 0.3 0.3\n", 1, 0},
+		{"indent and spacing are kept", "  2\t0.5  0.5 0.2 0.2\n", mergeTruck, "  0\t0.5  0.5 0.2 0.2\n", 1, 0},
+		{"unparsed lines are kept", "# comment\n\nx 0.5 0.5 0.2 0.2\n-1 0.5 0.5 0.2 0.2\n2 0.5 0.5 0.2 0.2\n", mergeTruck, "# comment\n\nx 0.5 0.5 0.2 0.2\n-1 0.5 0.5 0.2 0.2\n0 0.5 0.5 0.2 0.2\n", 1, 0},
+		{"windows line endings", "2 0.5 0.5 0.2 0.2\r\n1 0.1 0.1 0.1 0.1\r\n", mergeTruck, "0 0.5 0.5 0.2 0.2\r\n1 0.1 0.1 0.1 0.1\r\n", 1, 0},
+		{"empty file", "", deleteFirst, "", 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content, changed, removed := remapLabelFile([]byte(test.content), test.remap)
+			if string(content) != test.expected || changed != test.changed || removed != test.removed {
+				t.Errorf("content %q, changed %v, removed %v, expected %q, changed %v, removed %v", content, changed, removed, test.expected, test.changed, test.removed)
+			}
+		})
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestShiftClass
+ *
+ * Purpose : Check only the classes after the deleted one are moved down
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestShiftClass(t *testing.T) {
+	tests := []struct {
+		class    int
+		removed  int
+		expected int
+	}{
+		{0, 2, 0},
+		{1, 2, 1},
+		{2, 2, 2},
+		{3, 2, 2},
+		{7, 0, 6},
+		{0, 0, 0},
+	}
+
+	for _, test := range tests {
+		if shifted := shiftClass(test.class, test.removed); shifted != test.expected {
+			t.Errorf("class %v after removed %v is %v, expected %v", test.class, test.removed, shifted, test.expected)
+		}
+	}
+}
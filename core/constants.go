@@ -0,0 +1,65 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: constants.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Keep constants for the datasets structure
+
+	In the file
+		1. Root folder for all datasets
+		2. Folders and files inside the dataset
+		3. Images locations: uploaded folder and dataset splits
+		4. Tasks of the dataset descriptor
+	=============================================================================
+*/
+
+package core
+
+// Root folder with all datasets, changed by the -datasets flag
+var DatasetsPath = "/datasets"
+
+// Folders and files inside the dataset
+const UploadedFolder = "uploaded"
+const DatasetFolder = "dataset"
+const VersionsFolder = "versions"
+const ModelsFolder = "models"
+const ImagesFolder = "images"
+const LabelsFolder = "labels"
+const DataFileName = "data.yaml"
+const DescriptorFileName = "dataset.json"
+
+// Folder of the background jobs written by the jobs package
+const JobsFolder = "jobs"
+
+// Images locations. Uploaded images waiting to be split, the rest are dataset splits
+const LocationUploaded = "uploaded"
+const SplitTrain = "train"
+const SplitValid = "valid"
+const SplitTest = "test"
+
+// Dataset splits in the order used by data.yaml
+var Splits = []string{SplitTrain, SplitValid, SplitTest}
+
+// All images locations
+var Locations = []string{LocationUploaded, SplitTrain, SplitValid, SplitTest}
+
+// Image files extensions accepted by Yolov8
+var ImageExtensions = []string{".bmp", ".jpeg", ".jpg", ".png", ".tif", ".tiff", ".webp"}
+
+// Yolov8 tasks the dataset is made for
+const TaskDetect = "detect"
+const TaskSegment = "segment"
+const TaskClassify = "classify"
+const TaskPose = "pose"
+const TaskOBB = "obb"
+
+// All tasks of the dataset descriptor
+var Tasks = []string{TaskDetect, TaskSegment, TaskClassify, TaskPose, TaskOBB}
+
+// Embedded metadata store of the dataset with the images catalog
+const CatalogFileName = "catalog.db"
+
+// Label status of the image in the catalog
+const LabelUnlabelled = "unlabelled"
+const LabelLabelled = "labelled"
+const LabelReviewed = "reviewed"
@@ -0,0 +1,497 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: datafile.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Methods to read and write the data.yaml file
+
+	Parser supports subset of the yaml used by Ultralytics data files:
+		1. Scalar values: 'train: ../train/images'
+		2. Flow lists: "names: ['cat', 'dog']"
+		3. Block lists: 'names:' followed by '  - cat' lines
+		4. Block maps with class ids: 'names:' followed by '  0: cat' lines
+	Nested lines of other keys are skipped, as the 'download: |' script.
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Content of the data.yaml file
+type DataFile struct {
+	Path  string   `json:"path,omitempty"`
+	Train []string `json:"train"`
+	Val   []string `json:"val"`
+	Test  []string `json:"test"`
+	Names []string `json:"names"`
+}
+
+/****************************************************************************************
+ *
+ * Function : ParseDataFile
+ *
+ * Purpose : Parse data.yaml file content
+ *
+ *   Input : content []byte - file content
+ *
+ *  Return : DataFile - parsed file
+ *			 error - ErrInvalidDataFile if file cannot be parsed
+ */
+func ParseDataFile(content []byte) (DataFile, error) {
+	dataFile := DataFile{}
+	values := make(map[string][]string)
+	namesById := make(map[int]string)
+	classesNumber := -1
+
+	currentKey := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := stripYamlComment(scanner.Text())
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		nested := line[0] == ' ' || line[0] == '\t'
+
+		// Nested lines of unknown keys are ignored, also the block scalars as 'download: |'
+		if nested && !isDataFileKey(currentKey) {
+			continue
+		}
+
+		// Block list item of the current key
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			if currentKey == "" {
+				return dataFile, fmt.Errorf("%w: line %v has list item without key", ErrInvalidDataFile, lineNumber)
+			}
+			values[currentKey] = append(values[currentKey], unquoteYaml(strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))))
+			continue
+		}
+
+		separator := strings.Index(trimmed, ":")
+		if separator < 0 {
+			return dataFile, fmt.Errorf("%w: line %v has no key", ErrInvalidDataFile, lineNumber)
+		}
+		key := unquoteYaml(strings.TrimSpace(trimmed[:separator]))
+		value := strings.TrimSpace(trimmed[separator+1:])
+
+		// Block map item of the current key, only class names use it
+		if nested && currentKey == "names" {
+			id, err := strconv.Atoi(key)
+			if err != nil {
+				return dataFile, fmt.Errorf("%w: line %v has wrong class id '%v'", ErrInvalidDataFile, lineNumber, key)
+			}
+			namesById[id] = unquoteYaml(value)
+			continue
+		}
+		if nested {
+			// Nested maps of the known keys are not used
+			continue
+		}
+
+		currentKey = key
+		switch {
+		case value == "":
+			values[key] = []string{}
+		case strings.HasPrefix(value, "["):
+			items, err := parseYamlFlowList(value)
+			if err != nil {
+				return dataFile, fmt.Errorf("%w: line %v: %v", ErrInvalidDataFile, lineNumber, err)
+			}
+			values[key] = items
+		case strings.HasPrefix(value, "{"):
+			items, err := parseYamlFlowMap(value)
+			if err != nil {
+				return dataFile, fmt.Errorf("%w: line %v: %v", ErrInvalidDataFile, lineNumber, err)
+			}
+			if key == "names" {
+				for id, name := range items {
+					namesById[id] = name
+				}
+			}
+		default:
+			values[key] = []string{unquoteYaml(value)}
+		}
+
+		if key == "nc" && len(values[key]) == 1 {
+			number, err := strconv.Atoi(values[key][0])
+			if err != nil {
+				return dataFile, fmt.Errorf("%w: 'nc' is not a number", ErrInvalidDataFile)
+			}
+			classesNumber = number
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return dataFile, err
+	}
+
+	if len(values["path"]) > 0 {
+		dataFile.Path = values["path"][0]
+	}
+	dataFile.Train = values["train"]
+	dataFile.Val = values["val"]
+	dataFile.Test = values["test"]
+	dataFile.Names = values["names"]
+
+	if len(namesById) > 0 {
+		ids := []int{}
+		for id := range namesById {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for index, id := range ids {
+			if id != index {
+				return dataFile, fmt.Errorf("%w: class ids are not sequential", ErrInvalidDataFile)
+			}
+			dataFile.Names = append(dataFile.Names, namesById[id])
+		}
+	}
+	if dataFile.Names == nil {
+		dataFile.Names = []string{}
+	}
+
+	if classesNumber >= 0 && classesNumber != len(dataFile.Names) {
+		return dataFile, fmt.Errorf("%w: 'nc' is %v, but %v names", ErrInvalidDataFile, classesNumber, len(dataFile.Names))
+	}
+
+	return dataFile, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : DataFile.Bytes
+ *
+ * Purpose : Make data.yaml file content
+ *
+ *   Input : Nothing
+ *
+ *  Return : []byte - file content
+ */
+func (dataFile DataFile) Bytes() []byte {
+	var content bytes.Buffer
+
+	if dataFile.Path != "" {
+		content.WriteString("path: " + quoteYaml(dataFile.Path) + "\n")
+	}
+	content.WriteString("train: " + formatYamlPaths(dataFile.Train) + "\n")
+	content.WriteString("val: " + formatYamlPaths(dataFile.Val) + "\n")
+	content.WriteString("test: " + formatYamlPaths(dataFile.Test) + "\n")
+	content.WriteString("\n")
+	content.WriteString("nc: " + strconv.Itoa(len(dataFile.Names)) + "\n")
+
+	var names []string
+	for _, name := range dataFile.Names {
+		names = append(names, quoteYaml(name))
+	}
+	content.WriteString("names: [" + strings.Join(names, ", ") + "]\n")
+	content.WriteString("\n")
+
+	return content.Bytes()
+}
+
+/****************************************************************************************
+ *
+ * Function : ReadDataFile
+ *
+ * Purpose : Read and parse data.yaml file
+ *
+ *   Input : path string - path to the file
+ *
+ *  Return : DataFile - parsed file
+ *			 error - error if occur
+ */
+func ReadDataFile(path string) (DataFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return DataFile{}, err
+	}
+
+	return ParseDataFile(content)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ReadDataFile
+ *
+ * Purpose : Read data.yaml file of the dataset
+ *
+ *   Input : Nothing
+ *
+ *  Return : DataFile - parsed file
+ *			 error - error if occur
+ */
+func (dataset Dataset) ReadDataFile() (DataFile, error) {
+	return ReadDataFile(dataset.DataFilePath())
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.WriteDataFile
+ *
+ * Purpose : Replace data.yaml file of the dataset
+ *
+ *   Input : dataFile DataFile - new content
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) WriteDataFile(dataFile DataFile) error {
+	return writeFileAtomic(dataset.DataFilePath(), bytes.NewReader(dataFile.Bytes()))
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.Classes
+ *
+ * Purpose : Get classes names from the data.yaml file
+ *
+ *   Input : Nothing
+ *
+ *  Return : []string - classes names, index is the class id
+ *			 error - error if occur
+ */
+func (dataset Dataset) Classes() ([]string, error) {
+	dataFile, err := dataset.ReadDataFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return dataFile.Names, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.SetClasses
+ *
+ * Purpose : Replace classes names in the data.yaml file
+ *
+ *   Input : names []string - classes names, index is the class id
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) SetClasses(names []string) error {
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" || seen[name] {
+			return fmt.Errorf("%w: class name '%v' is empty or duplicated", ErrInvalidName, name)
+		}
+		seen[name] = true
+	}
+
+	dataFile, err := dataset.ReadDataFile()
+	if err != nil {
+		return err
+	}
+	dataFile.Names = names
+
+	return dataset.WriteDataFile(dataFile)
+}
+
+/****************************************************************************************
+ *
+ * Function : isDataFileKey
+ *
+ * Purpose : Check if the key of data.yaml is read by the parser
+ *
+ *   Input : key string - top level key
+ *
+ *  Return : bool - true for 'path', 'train', 'val', 'test', 'nc' and 'names'
+ */
+func isDataFileKey(key string) bool {
+	switch key {
+	case "path", "train", "val", "test", "nc", "names":
+		return true
+	}
+
+	return false
+}
+
+/****************************************************************************************
+ *
+ * Function : stripYamlComment
+ *
+ * Purpose : Remove comment from the yaml line, '#' inside the quotes is kept
+ *
+ *   Input : line string - yaml line
+ *
+ *  Return : string - line without comment
+ */
+func stripYamlComment(line string) string {
+	var quote rune
+	for index, char := range line {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"':
+			quote = char
+		case char == '#' && (index == 0 || line[index-1] == ' ' || line[index-1] == '\t'):
+			return strings.TrimRight(line[:index], " \t")
+		}
+	}
+
+	return strings.TrimRight(line, " \t\r")
+}
+
+/****************************************************************************************
+ *
+ * Function : parseYamlFlowList
+ *
+ * Purpose : Parse flow list, e.g. "['cat', dog]"
+ *
+ *   Input : value string - yaml value
+ *
+ *  Return : []string - list items
+ *			 error - error if list is not closed
+ */
+func parseYamlFlowList(value string) ([]string, error) {
+	if !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("list is not closed")
+	}
+
+	items := []string{}
+	for _, item := range splitYamlFlow(value[1 : len(value)-1]) {
+		items = append(items, unquoteYaml(item))
+	}
+
+	return items, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : parseYamlFlowMap
+ *
+ * Purpose : Parse flow map with numeric keys, e.g. "{0: cat, 1: dog}"
+ *
+ *   Input : value string - yaml value
+ *
+ *  Return : map[int]string - map items
+ *			 error - error if map is not valid
+ */
+func parseYamlFlowMap(value string) (map[int]string, error) {
+	if !strings.HasSuffix(value, "}") {
+		return nil, fmt.Errorf("map is not closed")
+	}
+
+	items := make(map[int]string)
+	for _, item := range splitYamlFlow(value[1 : len(value)-1]) {
+		separator := strings.Index(item, ":")
+		if separator < 0 {
+			return nil, fmt.Errorf("map item '%v' has no key", item)
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(item[:separator]))
+		if err != nil {
+			return nil, fmt.Errorf("map key '%v' is not a number", item[:separator])
+		}
+		items[id] = unquoteYaml(strings.TrimSpace(item[separator+1:]))
+	}
+
+	return items, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : splitYamlFlow
+ *
+ * Purpose : Split flow collection content by commas outside the quotes
+ *
+ *   Input : content string - content between brackets
+ *
+ *  Return : []string - trimmed items
+ */
+func splitYamlFlow(content string) []string {
+	var items []string
+	var quote rune
+	start := 0
+
+	for index, char := range content {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"':
+			quote = char
+		case char == ',':
+			items = append(items, strings.TrimSpace(content[start:index]))
+			start = index + 1
+		}
+	}
+	if last := strings.TrimSpace(content[start:]); last != "" {
+		items = append(items, last)
+	}
+
+	return items
+}
+
+/****************************************************************************************
+ *
+ * Function : unquoteYaml
+ *
+ * Purpose : Remove quotes around the yaml scalar
+ *
+ *   Input : value string - yaml scalar
+ *
+ *  Return : string - value without quotes
+ */
+func unquoteYaml(value string) string {
+	if len(value) >= 2 {
+		if value[0] == '\'' && value[len(value)-1] == '\'' {
+			return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+		if value[0] == '"' && value[len(value)-1] == '"' {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				return unquoted
+			}
+			return value[1 : len(value)-1]
+		}
+	}
+
+	return value
+}
+
+/****************************************************************************************
+ *
+ * Function : quoteYaml
+ *
+ * Purpose : Put yaml scalar into single quotes
+ *
+ *   Input : value string - scalar
+ *
+ *  Return : string - quoted value
+ */
+func quoteYaml(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+/****************************************************************************************
+ *
+ * Function : formatYamlPaths
+ *
+ * Purpose : Print split paths as scalar for single path and as list for many
+ *
+ *   Input : paths []string - split paths
+ *
+ *  Return : string - yaml value
+ */
+func formatYamlPaths(paths []string) string {
+	if len(paths) == 1 {
+		return paths[0]
+	}
+
+	var quoted []string
+	for _, path := range paths {
+		quoted = append(quoted, quoteYaml(path))
+	}
+
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
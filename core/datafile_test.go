@@ -0,0 +1,107 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: datafile_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the data.yaml parser
+
+	In the file
+		1. TestParseDataFile - forms of the classes, splits and skipped keys
+	=============================================================================
+*/
+
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : TestParseDataFile
+ *
+ * Purpose : Check classes and splits of the data.yaml forms used by Ultralytics,
+ *			 nested lines of other keys are skipped
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestParseDataFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected DataFile
+		err      error
+	}{
+		{
+			name:     "flow list",
+			content:  "train: ../train/images\nval: ../valid/images\nnc: 2\nnames: ['cat', 'dog']\n",
+			expected: DataFile{Train: []string{"../train/images"}, Val: []string{"../valid/images"}, Names: []string{"cat", "dog"}},
+		},
+		{
+			name:     "block list of splits",
+			content:  "path: ../datasets/coco\ntrain:\n  - images/train2017\n  - images/extra\nnames:\n  - cat\n",
+			expected: DataFile{Path: "../datasets/coco", Train: []string{"images/train2017", "images/extra"}, Names: []string{"cat"}},
+		},
+		{
+			name: "block map with the download script",
+			content: `# Ultralytics YOLO, coco8 dataset
+path: ../datasets/coco8 # dataset root dir
+train: images/train
+val: images/val
+test: # test images (optional)
+
+# Classes
+names:
+  0: person
+  1: bicycle
+
+# Download script/URL (optional)
+download: |
+  from pathlib import Path
+  import zipfile
+
+  dir = Path(yaml['path'])  # dataset root dir
+  urls = ['https://ultralytics.com/assets/coco8.zip']
+  for url in urls:
+      print(url)
+  - not a list item
+extra:
+  nested:
+    value
+`,
+			expected: DataFile{Path: "../datasets/coco8", Train: []string{"images/train"}, Val: []string{"images/val"}, Test: []string{}, Names: []string{"person", "bicycle"}},
+		},
+		{
+			name:    "line without key",
+			content: "train: images/train\nnames: ['cat']\nnot a key\n",
+			err:     ErrInvalidDataFile,
+		},
+		{
+			name:    "wrong class id",
+			content: "names:\n  zero: cat\n",
+			err:     ErrInvalidDataFile,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dataFile, err := ParseDataFile([]byte(test.content))
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Errorf("error %v, expected %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dataFile, test.expected) {
+				t.Errorf("data file %+v, expected %+v", dataFile, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: dataset.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Methods to find, list, rename and delete datasets
+
+	In the file
+		1. Dataset model and paths to the folders inside the dataset
+		2. ListDatasets, OpenDataset, CreateDataset, RenameDataset, DeleteDataset
+	=============================================================================
+*/
+
+package core
+
+import (
+	"github.com/CoderSergiy/golib/file"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/golib/tools"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Dataset and image names allowed to use as a part of the path
+var validNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.\-]*$`)
+
+// Model of the dataset on the drive
+type Dataset struct {
+	Name string
+	Path string
+}
+
+/****************************************************************************************
+ *
+ * Function : IsValidName
+ *
+ * Purpose : Check if name can be used as dataset or file name
+ *
+ *   Input : name string - name to check
+ *
+ *  Return : bool - true if name is valid
+ */
+func IsValidName(name string) bool {
+	return len(name) <= 255 && validNameRegexp.MatchString(name)
+}
+
+/****************************************************************************************
+ *
+ * Function : DatasetPath
+ *
+ * Purpose : Get path to the dataset folder
+ *
+ *   Input : name string - dataset name
+ *
+ *  Return : string - path on drive
+ */
+func DatasetPath(name string) string {
+	return tools.EnsureSlashInEnd(DatasetsPath) + name
+}
+
+/****************************************************************************************
+ *
+ * Function : ListDatasets
+ *
+ * Purpose : Get names of all datasets, folders without the valid descriptor are skipped
+ *
+ *   Input : Nothing
+ *
+ *  Return : []string - datasets names sorted by name
+ *			 error - error if occur
+ */
+func ListDatasets() ([]string, error) {
+	entries, err := os.ReadDir(DatasetsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		// Hidden folders of the archives, clones and restores are not datasets
+		if !entry.IsDir() || !IsValidName(entry.Name()) {
+			continue
+		}
+		if _, err := readDescriptor(DatasetPath(entry.Name())); err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : OpenDataset
+ *
+ * Purpose : Get existing dataset by the name
+ *
+ *   Input : name string - dataset name
+ *
+ *  Return : Dataset - dataset model
+ *			 error - ErrDatasetNotFound if folder is not exists
+ */
+func OpenDataset(name string) (Dataset, error) {
+	if !IsValidName(name) {
+		return Dataset{}, ErrInvalidName
+	}
+
+	dataset := Dataset{Name: name, Path: DatasetPath(name)}
+	if success, _ := file.IsFolderExists(dataset.Path); !success {
+		return Dataset{}, ErrDatasetNotFound
+	}
+
+	return dataset, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : CreateDataset
+ *
+ * Purpose : Create folder with all required files for the new dataset
+ *
+ *   Input : name string - dataset name
+ *			 descriptor Descriptor - description, task, owner and tags of the dataset
+ *
+ *  Return : Dataset - dataset model
+ *			 error - error if occur
+ */
+func CreateDataset(name string, descriptor Descriptor) (Dataset, error) {
+	if !IsValidName(name) {
+		return Dataset{}, ErrInvalidName
+	}
+	if err := descriptor.Validate(); err != nil {
+		return Dataset{}, err
+	}
+
+	dataset := Dataset{Name: name, Path: DatasetPath(name)}
+	if success, _ := file.IsFolderExists(dataset.Path); success {
+		return Dataset{}, ErrDatasetExists
+	}
+
+	if err := os.MkdirAll(dataset.Path, os.ModePerm); err != nil {
+		return Dataset{}, err
+	}
+
+	if err := CreateNewDataset(dataset.Path, descriptor); err != nil {
+		os.RemoveAll(dataset.Path)
+		return Dataset{}, err
+	}
+
+	return dataset, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : RenameDataset
+ *
+ * Purpose : Rename dataset folder, the old name is redirected to the new one
+ *
+ *   Input : name string - current dataset name
+ *			 newName string - new dataset name
+ *
+ *  Return : Dataset - renamed dataset model
+ *			 error - error if occur
+ */
+func RenameDataset(name string, newName string) (Dataset, error) {
+	dataset, err := OpenDataset(name)
+	if err != nil {
+		return Dataset{}, err
+	}
+
+	if !IsValidName(newName) {
+		return Dataset{}, ErrInvalidName
+	}
+
+	renamed := Dataset{Name: newName, Path: DatasetPath(newName)}
+	if _, err := os.Stat(renamed.Path); err == nil {
+		return Dataset{}, ErrDatasetExists
+	}
+
+	// Catalog database is opened again by the new path
+	unlock := lockDatasetFolder(dataset)
+	defer unlock()
+	if err := os.Rename(dataset.Path, renamed.Path); err != nil {
+		return Dataset{}, err
+	}
+
+	// Pages of the old name are redirected to the new one
+	if err := recordRename(dataset.Name, renamed.Name); err != nil {
+		logging.Error_Log("Cannot keep redirect of the renamed dataset '%v': '%v'", dataset.Name, err)
+	}
+
+	return renamed, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : DeleteDataset
+ *
+ * Purpose : Delete dataset folder with all files
+ *
+ *   Input : name string - dataset name
+ *
+ *  Return : error - error if occur
+ */
+func DeleteDataset(name string) error {
+	dataset, err := OpenDataset(name)
+	if err != nil {
+		return err
+	}
+
+	unlock := lockDatasetFolder(dataset)
+	defer unlock()
+	return os.RemoveAll(dataset.Path)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.LocationPath
+ *
+ * Purpose : Get path to the images location folder
+ *			 Uploaded images are in 'uploaded', splits are in 'dataset/<split>'
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *
+ *  Return : string - path to the location folder
+ *			 error - ErrInvalidLocation if location is unknown
+ */
+func (dataset Dataset) LocationPath(location string) (string, error) {
+	switch location {
+	case LocationUploaded:
+		return filepath.Join(dataset.Path, UploadedFolder), nil
+	case SplitTrain, SplitValid, SplitTest:
+		return filepath.Join(dataset.Path, DatasetFolder, location), nil
+	}
+
+	return "", ErrInvalidLocation
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ImagesPath
+ *
+ * Purpose : Get path to the images folder of the location
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *
+ *  Return : string - path to the images folder
+ *			 error - error if occur
+ */
+func (dataset Dataset) ImagesPath(location string) (string, error) {
+	path, err := dataset.LocationPath(location)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(path, ImagesFolder), nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.LabelsPath
+ *
+ * Purpose : Get path to the labels folder of the location
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *
+ *  Return : string - path to the labels folder
+ *			 error - error if occur
+ */
+func (dataset Dataset) LabelsPath(location string) (string, error) {
+	path, err := dataset.LocationPath(location)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(path, LabelsFolder), nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.DataFilePath
+ *
+ * Purpose : Get path to the data.yaml file
+ *
+ *   Input : Nothing
+ *
+ *  Return : string - path to the file
+ */
+func (dataset Dataset) DataFilePath() string {
+	return filepath.Join(dataset.Path, DatasetFolder, DataFileName)
+}
+
+/****************************************************************************************
+ *
+ * Function : IsLocation
+ *
+ * Purpose : Check if name is one of the images locations
+ *
+ *   Input : location string - location name
+ *
+ *  Return : bool - true if location is known
+ */
+func IsLocation(location string) bool {
+	for _, known := range Locations {
+		if known == location {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,160 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: dataset_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Helpers of the tests with the datasets on the drive and tests of the listing
+
+	In the file
+		1. useTestDatasets - temporary datasets folder of the test
+		2. createTestDataset, writeTestImage - dataset and images of the test
+		3. TestListDatasets - only folders with the valid descriptor are listed
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : useTestDatasets
+ *
+ * Purpose : Point the datasets folder to the temporary folder of the test,
+ *			 catalogs are closed and the folder is restored when the test ends
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : string - temporary datasets folder
+ */
+func useTestDatasets(t *testing.T) string {
+	t.Helper()
+
+	previous := DatasetsPath
+	DatasetsPath = t.TempDir()
+	t.Cleanup(func() {
+		CloseCatalogs()
+		DatasetsPath = previous
+	})
+
+	return DatasetsPath
+}
+
+/****************************************************************************************
+ *
+ * Function : createTestDataset
+ *
+ * Purpose : Create the dataset with the classes, fails the test on error
+ *
+ *   Input : t *testing.T - test
+ *			 name string - dataset name
+ *			 classes []string - classes of the data.yaml
+ *
+ *  Return : Dataset - created dataset
+ */
+func createTestDataset(t *testing.T, name string, classes ...string) Dataset {
+	t.Helper()
+
+	dataset, err := CreateDataset(name, Descriptor{Task: TaskDetect, Tags: []string{}})
+	if err != nil {
+		t.Fatalf("create dataset '%v': %v", name, err)
+	}
+	if len(classes) > 0 {
+		if err := dataset.SetClasses(classes); err != nil {
+			t.Fatalf("classes of the dataset '%v': %v", name, err)
+		}
+	}
+
+	return dataset
+}
+
+/****************************************************************************************
+ *
+ * Function : testImage
+ *
+ * Purpose : Make the content of the small png image
+ *
+ *   Input : Nothing
+ *
+ *  Return : []byte - png file content
+ */
+func testImage() []byte {
+	var content bytes.Buffer
+	png.Encode(&content, image.NewGray(image.Rect(0, 0, 4, 4)))
+	return content.Bytes()
+}
+
+/****************************************************************************************
+ *
+ * Function : writeTestImage
+ *
+ * Purpose : Write the image and the optional label to the location of the dataset
+ *
+ *   Input : t *testing.T - test
+ *			 dataset Dataset - dataset of the image
+ *			 location string - uploaded or the split
+ *			 name string - image file name
+ *			 label string - content of the label file, no label file when empty
+ *
+ *  Return : Nothing
+ */
+func writeTestImage(t *testing.T, dataset Dataset, location string, name string, label string) {
+	t.Helper()
+
+	path, err := dataset.LocationPath(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, ImagesFolder, name), testImage(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if label != "" {
+		if err := os.WriteFile(filepath.Join(path, LabelsFolder, LabelFileName(name)), []byte(label), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestListDatasets
+ *
+ * Purpose : Check folders without the valid descriptor and hidden folders are skipped
+ *			 and the listing writes nothing
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestListDatasets(t *testing.T) {
+	root := useTestDatasets(t)
+	createTestDataset(t, "cars")
+	createTestDataset(t, "bikes")
+
+	// Folder made before the descriptor, stray folder and hidden folder
+	os.MkdirAll(filepath.Join(root, "legacy", DatasetFolder), os.ModePerm)
+	os.WriteFile(filepath.Join(root, "legacy", DatasetFolder, DataFileName), []byte("names: []\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "notes"), os.ModePerm)
+	os.MkdirAll(filepath.Join(root, ".archives"), os.ModePerm)
+	os.MkdirAll(filepath.Join(root, "broken"), os.ModePerm)
+	os.WriteFile(filepath.Join(root, "broken", DescriptorFileName), []byte("{"), 0644)
+
+	names, err := ListDatasets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"bikes", "cars"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("datasets %v, expected %v", names, expected)
+	}
+	if _, err := os.Stat(filepath.Join(root, "legacy", DescriptorFileName)); !os.IsNotExist(err) {
+		t.Errorf("listing wrote the descriptor of the legacy folder")
+	}
+}
@@ -0,0 +1,263 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: descriptor.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Descriptor file of the dataset and its summary for the listing
+
+	Descriptor is stored in '<dataset>/dataset.json' and is written when the
+	dataset is created. Only folders with the valid descriptor are datasets,
+	folders created before the descriptor was introduced get it from the
+	layout migration.
+
+	In the file
+		1. Descriptor - description, task, owner, tags, cover, times and layout
+		2. Dataset.Descriptor, Dataset.SetDescriptor - read and change the descriptor
+		3. Dataset.Summary - counts, classes, last activity and cover for the cards
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Maximum length of the description
+const descriptorDescriptionLimit = 2000
+
+// Descriptor of the dataset
+type Descriptor struct {
+	Description string    `json:"description"`
+	Task        string    `json:"task"`            // One of Tasks
+	Owner       string    `json:"owner"`           // Person or team responsible for the dataset
+	Tags        []string  `json:"tags"`            // Tags to find the dataset
+	Cover       string    `json:"cover,omitempty"` // '<location>/<name>' of the image shown on the card, newest image when empty
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+	Layout      int       `json:"layout"` // Version of the folder layout, changed only by the migration
+}
+
+// Summary of the dataset shown on the landing page
+type DatasetSummary struct {
+	Name         string         `json:"name"`
+	Descriptor   Descriptor     `json:"descriptor"`
+	Images       int            `json:"images"`        // Images in all locations
+	Labelled     int            `json:"labelled"`      // Images with labels
+	Locations    map[string]int `json:"locations"`     // Images by the location
+	Classes      int            `json:"classes"`       // Classes in the data.yaml
+	LastActivity time.Time      `json:"last_activity"` // Latest change of the images, classes or descriptor
+	Cover        string         `json:"cover"`         // '<location>/<name>' of the cover image, empty for the dataset without images
+}
+
+/****************************************************************************************
+ *
+ * Function : Descriptor.Validate
+ *
+ * Purpose : Check the task and the cover, empty task is set to 'detect'
+ *			 Text fields are trimmed and tags are normalised
+ *
+ *   Input : Nothing
+ *
+ *  Return : error - ErrInvalidDescriptor if descriptor is not valid
+ */
+func (descriptor *Descriptor) Validate() error {
+	descriptor.Description = strings.TrimSpace(descriptor.Description)
+	descriptor.Owner = strings.TrimSpace(descriptor.Owner)
+	descriptor.Task = strings.TrimSpace(descriptor.Task)
+	descriptor.Cover = strings.TrimSpace(descriptor.Cover)
+	descriptor.Tags = normaliseTags(descriptor.Tags)
+
+	if descriptor.Task == "" {
+		descriptor.Task = TaskDetect
+	}
+	known := false
+	for _, task := range Tasks {
+		known = known || task == descriptor.Task
+	}
+	if !known {
+		return fmt.Errorf("%w: task '%v' is not one of %v", ErrInvalidDescriptor, descriptor.Task, strings.Join(Tasks, ", "))
+	}
+
+	if len(descriptor.Description) > descriptorDescriptionLimit {
+		return fmt.Errorf("%w: description is longer than %v characters", ErrInvalidDescriptor, descriptorDescriptionLimit)
+	}
+
+	if descriptor.Cover != "" {
+		location, name, _ := strings.Cut(descriptor.Cover, "/")
+		if !IsLocation(location) || !IsValidName(name) {
+			return fmt.Errorf("%w: cover '%v' is not '<location>/<image name>'", ErrInvalidDescriptor, descriptor.Cover)
+		}
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.DescriptorPath
+ *
+ * Purpose : Get path to the dataset.json file
+ *
+ *   Input : Nothing
+ *
+ *  Return : string - path to the file
+ */
+func (dataset Dataset) DescriptorPath() string {
+	return filepath.Join(dataset.Path, DescriptorFileName)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.Descriptor
+ *
+ * Purpose : Read the descriptor of the dataset
+ *
+ *   Input : Nothing
+ *
+ *  Return : Descriptor - dataset descriptor
+ *			 error - ErrInvalidDescriptor if file is not valid
+ */
+func (dataset Dataset) Descriptor() (Descriptor, error) {
+	return readDescriptor(dataset.Path)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.SetDescriptor
+ *
+ * Purpose : Replace the descriptor, created time and layout are kept, updated time is set to now
+ *
+ *   Input : descriptor Descriptor - new descriptor
+ *
+ *  Return : Descriptor - written descriptor
+ *			 error - ErrInvalidDescriptor if descriptor is not valid
+ */
+func (dataset Dataset) SetDescriptor(descriptor Descriptor) (Descriptor, error) {
+	if err := descriptor.Validate(); err != nil {
+		return descriptor, err
+	}
+
+	descriptor.Created = time.Now().UTC()
+	descriptor.Layout = 0
+	if current, err := dataset.Descriptor(); err == nil {
+		descriptor.Created = current.Created
+		descriptor.Layout = current.Layout
+	}
+	descriptor.Updated = time.Now().UTC()
+
+	return descriptor, writeDescriptor(dataset.Path, descriptor)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.Summary
+ *
+ * Purpose : Count images by the catalog, find the cover and the last activity
+ *
+ *   Input : Nothing
+ *
+ *  Return : DatasetSummary - summary for the card of the dataset
+ *			 error - error if occur
+ */
+func (dataset Dataset) Summary() (DatasetSummary, error) {
+	summary := DatasetSummary{Name: dataset.Name, Locations: map[string]int{}}
+
+	descriptor, err := dataset.Descriptor()
+	if err != nil {
+		return summary, err
+	}
+	summary.Descriptor = descriptor
+	summary.LastActivity = descriptor.Updated
+
+	if classes, err := dataset.Classes(); err == nil {
+		summary.Classes = len(classes)
+	}
+	if info, err := os.Stat(dataset.DataFilePath()); err == nil && info.ModTime().After(summary.LastActivity) {
+		summary.LastActivity = info.ModTime()
+	}
+
+	// Newest uploaded image is the cover when it is not chosen
+	records, _, err := dataset.QueryImages(ImageQuery{Location: LocationAll, Sort: SortUploaded, Descending: true})
+	if err != nil {
+		return summary, err
+	}
+	for _, record := range records {
+		summary.Images++
+		summary.Locations[record.Location]++
+		if record.Labelled {
+			summary.Labelled++
+		}
+		if record.Modified.After(summary.LastActivity) {
+			summary.LastActivity = record.Modified
+		}
+		if record.Uploaded.After(summary.LastActivity) {
+			summary.LastActivity = record.Uploaded
+		}
+		if record.Location+"/"+record.Name == descriptor.Cover {
+			summary.Cover = descriptor.Cover
+		}
+	}
+	if summary.Cover == "" && len(records) > 0 {
+		summary.Cover = records[0].Location + "/" + records[0].Name
+	}
+
+	return summary, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : readDescriptor
+ *
+ * Purpose : Read and check the descriptor in the dataset folder
+ *
+ *   Input : path string - path to the dataset folder
+ *
+ *  Return : Descriptor - dataset descriptor
+ *			 error - ErrInvalidDescriptor if file is missed or not valid
+ */
+func readDescriptor(path string) (Descriptor, error) {
+	descriptor := Descriptor{}
+
+	content, err := os.ReadFile(filepath.Join(path, DescriptorFileName))
+	if err != nil {
+		return descriptor, fmt.Errorf("%w: %v", ErrInvalidDescriptor, err)
+	}
+	if err := json.Unmarshal(content, &descriptor); err != nil {
+		return descriptor, fmt.Errorf("%w: %v", ErrInvalidDescriptor, err)
+	}
+	if err := descriptor.Validate(); err != nil {
+		return descriptor, err
+	}
+	if descriptor.Created.IsZero() {
+		return descriptor, fmt.Errorf("%w: created time is missed", ErrInvalidDescriptor)
+	}
+
+	return descriptor, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : writeDescriptor
+ *
+ * Purpose : Write the descriptor to the dataset folder
+ *
+ *   Input : path string - path to the dataset folder
+ *			 descriptor Descriptor - descriptor to write
+ *
+ *  Return : error - error if occur
+ */
+func writeDescriptor(path string, descriptor Descriptor) error {
+	content, err := json.MarshalIndent(descriptor, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(path, DescriptorFileName), bytes.NewReader(append(content, '\n')))
+}
@@ -0,0 +1,48 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: errors.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Errors returned by the core methods
+
+	=============================================================================
+*/
+
+package core
+
+import (
+	"errors"
+)
+
+var ErrDatasetNotFound = errors.New("Dataset is not exists")
+var ErrDatasetExists = errors.New("Dataset already exists")
+var ErrInvalidName = errors.New("Name is not valid")
+var ErrInvalidLocation = errors.New("Location is not valid")
+var ErrImageNotFound = errors.New("Image is not exists")
+var ErrImageExists = errors.New("Image already exists")
+var ErrNotImage = errors.New("File is not an image")
+var ErrInvalidLabel = errors.New("Label is not valid")
+var ErrInvalidDataFile = errors.New("Data file is not valid")
+var ErrCatalogLocked = errors.New("Catalog is used by another process")
+var ErrInvalidQuery = errors.New("Query is not valid")
+var ErrUploadNotFound = errors.New("Upload is not exists")
+var ErrUploadOffset = errors.New("Upload offset is not equal to the received bytes")
+var ErrUploadLocked = errors.New("Upload is receiving another chunk")
+var ErrUploadTooLarge = errors.New("Upload is larger than allowed")
+var ErrInvalidUploadLength = errors.New("Upload length is not valid")
+var ErrInvalidChecksum = errors.New("Checksum is not valid")
+var ErrChecksumMismatch = errors.New("Checksum is not equal to the received data")
+var ErrNoImages = errors.New("Request has no image files")
+var ErrInvalidForm = errors.New("Form is not valid")
+var ErrInvalidAugmentation = errors.New("Augmentation is not valid")
+var ErrInvalidPreprocess = errors.New("Preprocessing is not valid")
+var ErrInvalidSynthesis = errors.New("Synthesis is not valid")
+var ErrInvalidTiling = errors.New("Tiling is not valid")
+var ErrInvalidClassOperation = errors.New("Class operation is not valid")
+var ErrInvalidMerge = errors.New("Merge is not valid")
+var ErrArchiveNotFound = errors.New("Archive is not exists")
+var ErrArchiveExists = errors.New("Archive already exists")
+var ErrInvalidDescriptor = errors.New("Dataset descriptor is not valid")
+var ErrInvalidAdoption = errors.New("Source is not the Ultralytics dataset")
+var ErrLayoutTooNew = errors.New("Dataset layout is newer than supported")
+var ErrDatasetBusy = errors.New("Dataset has queued or running jobs")
@@ -0,0 +1,96 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: export.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Export dataset as zip archive ready for the Yolov8 training
+
+	=============================================================================
+*/
+
+package core
+
+import (
+	"archive/zip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+/****************************************************************************************
+ *
+ * Function : Dataset.Export
+ *
+ * Purpose : Write 'dataset' folder with data.yaml and all splits as zip archive
+ *
+ *   Input : writer io.Writer - where to write archive
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) Export(writer io.Writer) error {
+	return ZipFolder(filepath.Join(dataset.Path, DatasetFolder), writer)
+}
+
+/****************************************************************************************
+ *
+ * Function : ZipFolder
+ *
+ * Purpose : Write all files of the folder as zip archive, paths are relative to the folder
+ *
+ *   Input : root string - folder to archive
+ *			 writer io.Writer - where to write archive
+ *
+ *  Return : error - error if occur
+ */
+func ZipFolder(root string, writer io.Writer) error {
+	archive := zip.NewWriter(writer)
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(root, path)
+		if err != nil || relativePath == "." {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+
+		if entry.IsDir() {
+			_, err := archive.Create(relativePath + "/")
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = relativePath
+		header.Method = zip.Deflate
+
+		target, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		source, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+
+		_, err = io.Copy(target, source)
+		return err
+	})
+	if err != nil {
+		archive.Close()
+		return err
+	}
+
+	return archive.Close()
+}
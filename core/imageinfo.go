@@ -0,0 +1,201 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: imageinfo.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Read image dimensions and calculate hashes
+
+	In the file
+		1. ReadImageInfo - dimensions, sha256 and perceptual hash of the image
+		2. decodeImage - decode the image file of any supported format
+		3. differenceHash - 64 bits dHash to find similar images
+		4. HashDistance - number of different bits of two perceptual hashes
+	=============================================================================
+*/
+
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math/bits"
+	"os"
+	"strconv"
+)
+
+// Image details calculated from the file content
+type ImageInfo struct {
+	Width          int
+	Height         int
+	SHA256         string
+	PerceptualHash string
+}
+
+/****************************************************************************************
+ *
+ * Function : ReadImageInfo
+ *
+ * Purpose : Decode image to get dimensions and hashes
+ *			 Image which cannot be decoded returns only sha256
+ *
+ *   Input : path string - path to the image
+ *
+ *  Return : ImageInfo - image details
+ *			 error - error if file cannot be read
+ */
+func ReadImageInfo(path string) (ImageInfo, error) {
+	info := ImageInfo{}
+
+	imageFile, err := os.Open(path)
+	if err != nil {
+		return info, err
+	}
+	defer imageFile.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, imageFile); err != nil {
+		return info, err
+	}
+	info.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	if _, err := imageFile.Seek(0, io.SeekStart); err != nil {
+		return info, err
+	}
+	decoded, _, err := image.Decode(imageFile)
+	if err != nil {
+		return info, nil
+	}
+
+	info.Width = decoded.Bounds().Dx()
+	info.Height = decoded.Bounds().Dy()
+	info.PerceptualHash = differenceHash(decoded)
+
+	return info, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : decodeImage
+ *
+ * Purpose : Read and decode the image file
+ *
+ *   Input : path string - path to the image
+ *
+ *  Return : image.Image - decoded image
+ *			 error - error if occur
+ */
+func decodeImage(path string) (image.Image, error) {
+	imageFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer imageFile.Close()
+
+	decoded, _, err := image.Decode(imageFile)
+	return decoded, err
+}
+
+/****************************************************************************************
+ *
+ * Function : differenceHash
+ *
+ * Purpose : Calculate dHash: image scaled to 9x8 grayscale,
+ *			 every bit shows if pixel is brighter than the right neighbour
+ *
+ *   Input : source image.Image - decoded image
+ *
+ *  Return : string - 16 hex digits
+ */
+func differenceHash(source image.Image) string {
+	const width, height = 9, 8
+	bounds := source.Bounds()
+
+	var pixels [height][width]float64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Average of the area covered by the scaled pixel
+			minX := bounds.Min.X + x*bounds.Dx()/width
+			maxX := bounds.Min.X + (x+1)*bounds.Dx()/width
+			minY := bounds.Min.Y + y*bounds.Dy()/height
+			maxY := bounds.Min.Y + (y+1)*bounds.Dy()/height
+			if maxX <= minX {
+				maxX = minX + 1
+			}
+			if maxY <= minY {
+				maxY = minY + 1
+			}
+
+			sum, count := 0.0, 0.0
+			stepX, stepY := (maxX-minX)/8+1, (maxY-minY)/8+1
+			for sourceY := minY; sourceY < maxY; sourceY += stepY {
+				for sourceX := minX; sourceX < maxX; sourceX += stepX {
+					sum += luminance(source.At(sourceX, sourceY))
+					count++
+				}
+			}
+			pixels[y][x] = sum / count
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			hash <<= 1
+			if pixels[y][x] > pixels[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return fmt.Sprintf("%016x", hash)
+}
+
+/****************************************************************************************
+ *
+ * Function : luminance
+ *
+ * Purpose : Get brightness of the pixel
+ *
+ *   Input : pixel color.Color - pixel color
+ *
+ *  Return : float64 - brightness from 0 to 65535
+ */
+func luminance(pixel color.Color) float64 {
+	r, g, b, _ := pixel.RGBA()
+	return 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+}
+
+/****************************************************************************************
+ *
+ * Function : HashDistance
+ *
+ * Purpose : Count different bits of two perceptual hashes,
+ *			 similar images have small distance
+ *
+ *   Input : first string - perceptual hash
+ *			 second string - perceptual hash
+ *
+ *  Return : int - number of different bits, -1 if hash is not valid
+ */
+func HashDistance(first string, second string) int {
+	firstHash, err := strconv.ParseUint(first, 16, 64)
+	if err != nil {
+		return -1
+	}
+	secondHash, err := strconv.ParseUint(second, 16, 64)
+	if err != nil {
+		return -1
+	}
+
+	return bits.OnesCount64(firstHash ^ secondHash)
+}
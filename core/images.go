@@ -0,0 +1,391 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: images.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Methods to work with images of the dataset
+
+	In the file
+		1. ListImages - images of the location
+		2. SaveImage - store image to the location
+		3. MoveImage - together with the label file
+		Changes are reflected in the images catalog of the dataset
+	=============================================================================
+*/
+
+package core
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Model of the image file in the dataset
+type ImageFile struct {
+	Name     string    `json:"name"`
+	Location string    `json:"location"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Labelled bool      `json:"labelled"`
+}
+
+/****************************************************************************************
+ *
+ * Function : IsImageFile
+ *
+ * Purpose : Check by the extension if file is an image
+ *
+ *   Input : name string - file name
+ *
+ *  Return : bool - true if file is an image
+ */
+func IsImageFile(name string) bool {
+	extension := strings.ToLower(filepath.Ext(name))
+	for _, imageExtension := range ImageExtensions {
+		if extension == imageExtension {
+			return true
+		}
+	}
+
+	return false
+}
+
+/****************************************************************************************
+ *
+ * Function : LabelFileName
+ *
+ * Purpose : Get label file name for the image
+ *
+ *   Input : imageName string - image file name
+ *
+ *  Return : string - label file name
+ */
+func LabelFileName(imageName string) string {
+	return strings.TrimSuffix(imageName, filepath.Ext(imageName)) + ".txt"
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ListImages
+ *
+ * Purpose : Get all images of the location sorted by name
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *
+ *  Return : []ImageFile - images
+ *			 error - error if occur
+ */
+func (dataset Dataset) ListImages(location string) ([]ImageFile, error) {
+	imagesPath, err := dataset.ImagesPath(location)
+	if err != nil {
+		return nil, err
+	}
+	labelsPath, _ := dataset.LabelsPath(location)
+
+	entries, err := os.ReadDir(imagesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	images := []ImageFile{}
+	for _, entry := range entries {
+		if entry.IsDir() || !IsImageFile(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		image := ImageFile{Name: entry.Name(), Location: location, Size: info.Size(), Modified: info.ModTime()}
+		if labelInfo, err := os.Stat(filepath.Join(labelsPath, LabelFileName(entry.Name()))); err == nil && labelInfo.Size() > 0 {
+			image.Labelled = true
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ImagePath
+ *
+ * Purpose : Get path to the image file
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : string - path to the image
+ *			 error - error if name or location is not valid
+ */
+func (dataset Dataset) ImagePath(location string, name string) (string, error) {
+	if !IsValidName(name) {
+		return "", ErrInvalidName
+	}
+	if !IsImageFile(name) {
+		return "", ErrNotImage
+	}
+
+	imagesPath, err := dataset.ImagesPath(location)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(imagesPath, name), nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.LabelPath
+ *
+ * Purpose : Get path to the label file of the image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : string - path to the label file
+ *			 error - error if name or location is not valid
+ */
+func (dataset Dataset) LabelPath(location string, name string) (string, error) {
+	if !IsValidName(name) {
+		return "", ErrInvalidName
+	}
+
+	labelsPath, err := dataset.LabelsPath(location)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(labelsPath, LabelFileName(name)), nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.StatImage
+ *
+ * Purpose : Get details of the image file
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : ImageFile - image details
+ *			 error - ErrImageNotFound if image is not exists
+ */
+func (dataset Dataset) StatImage(location string, name string) (ImageFile, error) {
+	imagePath, err := dataset.ImagePath(location, name)
+	if err != nil {
+		return ImageFile{}, err
+	}
+
+	info, err := os.Stat(imagePath)
+	if err != nil || info.IsDir() {
+		return ImageFile{}, ErrImageNotFound
+	}
+
+	image := ImageFile{Name: name, Location: location, Size: info.Size(), Modified: info.ModTime()}
+	labelPath, _ := dataset.LabelPath(location, name)
+	if labelInfo, err := os.Stat(labelPath); err == nil && labelInfo.Size() > 0 {
+		image.Labelled = true
+	}
+
+	return image, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.SaveImage
+ *
+ * Purpose : Store image to the location, existing image with the same name is not replaced
+ *			 File written to the temporary file first and renamed when completed,
+ *			 catalog record and thumbnail are made by the processing workers
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *			 source io.Reader - image content
+ *
+ *  Return : ImageFile - stored image details
+ *			 error - ErrImageExists if location has the image with the same name
+ */
+func (dataset Dataset) SaveImage(location string, name string, source io.Reader) (ImageFile, error) {
+	if err := dataset.writeImage(location, name, source); err != nil {
+		return ImageFile{}, err
+	}
+	if err := dataset.ProcessImages(location, name); err != nil {
+		dataset.removeImages(location, []string{name})
+		return ImageFile{}, err
+	}
+
+	return dataset.StatImage(location, name)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.MoveImage
+ *
+ * Purpose : Move image together with the label file to another location
+ *
+ *   Input : from string - current location
+ *			 to string - new location
+ *			 name string - image file name
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) MoveImage(from string, to string, name string) error {
+	if from == to {
+		return nil
+	}
+
+	sourceImage, err := dataset.ImagePath(from, name)
+	if err != nil {
+		return err
+	}
+	targetImage, err := dataset.ImagePath(to, name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(sourceImage); err != nil {
+		return ErrImageNotFound
+	}
+	if _, err := os.Stat(targetImage); err == nil {
+		return ErrImageExists
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetImage), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Rename(sourceImage, targetImage); err != nil {
+		return err
+	}
+	defer dataset.moveCatalogRecord(from, to, name)
+
+	sourceLabel, _ := dataset.LabelPath(from, name)
+	targetLabel, _ := dataset.LabelPath(to, name)
+	if _, err := os.Stat(sourceLabel); err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(targetLabel), os.ModePerm); err != nil {
+		return err
+	}
+
+	return os.Rename(sourceLabel, targetLabel)
+}
+
+/****************************************************************************************
+ *
+ * Function : writeFileAtomic
+ *
+ * Purpose : Write content to the temporary file in the same folder,
+ *			 sync it to the drive and rename into place, the folder is synced
+ *			 to keep the new name after the power loss
+ *
+ *   Input : path string - path to the file
+ *			 source io.Reader - file content
+ *
+ *  Return : error - error if occur
+ */
+func writeFileAtomic(path string, source io.Reader) error {
+	tempPath, err := writeTempFile(path, source)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	return syncFolder(filepath.Dir(path))
+}
+
+/****************************************************************************************
+ *
+ * Function : publishFile
+ *
+ * Purpose : Link the synced temporary file to the new name and remove the temporary
+ *			 name, unlike rename the existing file is never replaced
+ *
+ *   Input : tempPath string - path to the temporary file
+ *			 path string - path to the file
+ *
+ *  Return : error - error if occur, os.IsExist if the file exists
+ */
+func publishFile(tempPath string, path string) error {
+	err := os.Link(tempPath, path)
+	os.Remove(tempPath)
+	if err != nil {
+		return err
+	}
+
+	return syncFolder(filepath.Dir(path))
+}
+
+/****************************************************************************************
+ *
+ * Function : writeTempFile
+ *
+ * Purpose : Write synced temporary file next to the file to rename it later
+ *
+ *   Input : path string - path to the file
+ *			 source io.Reader - file content
+ *
+ *  Return : string - path to the temporary file
+ *			 error - error if occur, temporary file is removed
+ */
+func writeTempFile(path string, source io.Reader) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return "", err
+	}
+	tempPath := tempFile.Name()
+
+	if _, err := io.Copy(tempFile, source); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return "", err
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return "", err
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return "", err
+	}
+	if err := os.Chmod(tempPath, 0644); err != nil {
+		os.Remove(tempPath)
+		return "", err
+	}
+
+	return tempPath, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : syncFolder
+ *
+ * Purpose : Sync entries of the folder to the drive
+ *
+ *   Input : path string - path to the folder
+ *
+ *  Return : error - error if occur
+ */
+func syncFolder(path string) error {
+	folder, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer folder.Close()
+
+	return folder.Sync()
+}
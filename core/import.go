@@ -0,0 +1,135 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: import.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Import images with labels from the folder on the drive
+
+	Source folder can have one of the layouts:
+		1. 'images' and 'labels' subfolders
+		2. Images with label files next to them
+	=============================================================================
+*/
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Options to import images
+type ImportOptions struct {
+	Location  string `json:"location"`  // Location to import into, 'uploaded' by default
+	Overwrite bool   `json:"overwrite"` // Replace images with the same name
+}
+
+// Result of the import
+type ImportResult struct {
+	Imported      int      `json:"imported"`
+	Labelled      int      `json:"labelled"`
+	Skipped       int      `json:"skipped"`
+	InvalidLabels []string `json:"invalid_labels"`
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ImportFolder
+ *
+ * Purpose : Copy images and valid labels from the folder into the dataset
+ *			 Image with invalid label is imported without the label
+ *
+ *   Input : source string - path to the folder with images
+ *			 options ImportOptions - import options
+ *
+ *  Return : ImportResult - number of imported and skipped images
+ *			 error - error if occur
+ */
+func (dataset Dataset) ImportFolder(source string, options ImportOptions) (ImportResult, error) {
+	result := ImportResult{InvalidLabels: []string{}}
+
+	if options.Location == "" {
+		options.Location = LocationUploaded
+	}
+	if !IsLocation(options.Location) {
+		return result, ErrInvalidLocation
+	}
+
+	imagesSource, labelsSource := source, source
+	if info, err := os.Stat(filepath.Join(source, ImagesFolder)); err == nil && info.IsDir() {
+		imagesSource = filepath.Join(source, ImagesFolder)
+		labelsSource = filepath.Join(source, LabelsFolder)
+	}
+
+	entries, err := os.ReadDir(imagesSource)
+	if err != nil {
+		return result, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !IsImageFile(entry.Name()) {
+			continue
+		}
+
+		imagePath, err := dataset.ImagePath(options.Location, entry.Name())
+		if err != nil {
+			result.Skipped++
+			continue
+		}
+		if _, err := os.Stat(imagePath); err == nil && !options.Overwrite {
+			result.Skipped++
+			continue
+		}
+
+		if err := copyFileAtomic(filepath.Join(imagesSource, entry.Name()), imagePath); err != nil {
+			return result, err
+		}
+		result.Imported++
+
+		// Previous label of the overwritten image is not valid anymore
+		labelPath, _ := dataset.LabelPath(options.Location, entry.Name())
+		os.Remove(labelPath)
+		dataset.refreshCatalog(options.Location, entry.Name())
+
+		content, err := os.ReadFile(filepath.Join(labelsSource, LabelFileName(entry.Name())))
+		if err != nil {
+			continue
+		}
+		labels, err := ParseLabels(content)
+		if err == nil {
+			err = ValidateLabels(labels, 0)
+		}
+		if err != nil {
+			result.InvalidLabels = append(result.InvalidLabels, entry.Name()+": "+err.Error())
+			continue
+		}
+
+		if err := dataset.WriteLabels(options.Location, entry.Name(), labels); err != nil {
+			return result, err
+		}
+		result.Labelled++
+	}
+
+	return result, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : copyFileAtomic
+ *
+ * Purpose : Copy file through the temporary file renamed into place
+ *
+ *   Input : source string - path to the source file
+ *			 target string - path to the new file
+ *
+ *  Return : error - error if occur
+ */
+func copyFileAtomic(source string, target string) error {
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	return writeFileAtomic(target, sourceFile)
+}
@@ -0,0 +1,258 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: labels.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Methods to read and write Yolov8 label files
+
+	Label file has a line per object, all coordinates normalized to [0, 1]:
+		Box: 	 <class> <x center> <y center> <width> <height>
+		Polygon: <class> <x1> <y1> <x2> <y2> ... <xn> <yn>
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Object on the image
+type Label struct {
+	Class  int       `json:"class"`
+	X      float64   `json:"x"`
+	Y      float64   `json:"y"`
+	Width  float64   `json:"width"`
+	Height float64   `json:"height"`
+	Points []float64 `json:"points,omitempty"` // Polygon points for segmentation labels
+}
+
+/****************************************************************************************
+ *
+ * Function : ParseLabels
+ *
+ * Purpose : Parse content of the label file
+ *			 Box of the polygon label is calculated from the points
+ *
+ *   Input : content []byte - label file content
+ *
+ *  Return : []Label - labels
+ *			 error - ErrInvalidLabel if any line cannot be parsed
+ */
+func ParseLabels(content []byte) ([]Label, error) {
+	labels := []Label{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		class, err := strconv.Atoi(fields[0])
+		if err != nil || class < 0 {
+			return nil, fmt.Errorf("%w: line %v has wrong class '%v'", ErrInvalidLabel, lineNumber, fields[0])
+		}
+
+		var values []float64
+		for _, field := range fields[1:] {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %v has wrong value '%v'", ErrInvalidLabel, lineNumber, field)
+			}
+			values = append(values, value)
+		}
+
+		label := Label{Class: class}
+		if len(values) == 4 {
+			label.X, label.Y, label.Width, label.Height = values[0], values[1], values[2], values[3]
+		} else if len(values) >= 6 && len(values)%2 == 0 {
+			label.Points = values
+			label.X, label.Y, label.Width, label.Height = polygonBox(values)
+		} else {
+			return nil, fmt.Errorf("%w: line %v has %v values", ErrInvalidLabel, lineNumber, len(values))
+		}
+
+		labels = append(labels, label)
+	}
+
+	return labels, scanner.Err()
+}
+
+/****************************************************************************************
+ *
+ * Function : FormatLabels
+ *
+ * Purpose : Make label file content
+ *
+ *   Input : labels []Label - labels
+ *
+ *  Return : []byte - label file content
+ */
+func FormatLabels(labels []Label) []byte {
+	var content bytes.Buffer
+
+	for _, label := range labels {
+		content.WriteString(strconv.Itoa(label.Class))
+		if len(label.Points) > 0 {
+			for _, point := range label.Points {
+				content.WriteString(" " + formatCoordinate(point))
+			}
+		} else {
+			content.WriteString(" " + formatCoordinate(label.X) + " " + formatCoordinate(label.Y) +
+				" " + formatCoordinate(label.Width) + " " + formatCoordinate(label.Height))
+		}
+		content.WriteString("\n")
+	}
+
+	return content.Bytes()
+}
+
+/****************************************************************************************
+ *
+ * Function : ValidateLabels
+ *
+ * Purpose : Check that all coordinates are normalized and classes are known
+ *
+ *   Input : labels []Label - labels to check
+ *			 classes int - number of the classes in dataset, 0 to skip classes check
+ *
+ *  Return : error - ErrInvalidLabel with details
+ */
+func ValidateLabels(labels []Label, classes int) error {
+	for index, label := range labels {
+		if label.Class < 0 || (classes > 0 && label.Class >= classes) {
+			return fmt.Errorf("%w: label %v has unknown class %v", ErrInvalidLabel, index, label.Class)
+		}
+
+		if len(label.Points) > 0 {
+			if len(label.Points) < 6 || len(label.Points)%2 != 0 {
+				return fmt.Errorf("%w: label %v polygon has %v values", ErrInvalidLabel, index, len(label.Points))
+			}
+			for _, point := range label.Points {
+				if point < 0 || point > 1 {
+					return fmt.Errorf("%w: label %v polygon is out of the image", ErrInvalidLabel, index)
+				}
+			}
+			continue
+		}
+
+		if label.Width <= 0 || label.Height <= 0 || label.Width > 1 || label.Height > 1 {
+			return fmt.Errorf("%w: label %v has wrong size", ErrInvalidLabel, index)
+		}
+		if label.X-label.Width/2 < -0.001 || label.X+label.Width/2 > 1.001 ||
+			label.Y-label.Height/2 < -0.001 || label.Y+label.Height/2 > 1.001 {
+			return fmt.Errorf("%w: label %v is out of the image", ErrInvalidLabel, index)
+		}
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ReadLabels
+ *
+ * Purpose : Read labels of the image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : []Label - labels, empty if image has no label file
+ *			 error - error if occur
+ */
+func (dataset Dataset) ReadLabels(location string, name string) ([]Label, error) {
+	labelPath, err := dataset.LabelPath(location, name)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(labelPath)
+	if os.IsNotExist(err) {
+		return []Label{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseLabels(content)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.WriteLabels
+ *
+ * Purpose : Replace labels of the image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *			 labels []Label - new labels
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) WriteLabels(location string, name string, labels []Label) error {
+	if _, err := dataset.StatImage(location, name); err != nil {
+		return err
+	}
+
+	labelPath, err := dataset.LabelPath(location, name)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(labelPath, bytes.NewReader(FormatLabels(labels))); err != nil {
+		return err
+	}
+	dataset.refreshCatalog(location, name)
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : polygonBox
+ *
+ * Purpose : Calculate box around the polygon
+ *
+ *   Input : points []float64 - polygon points x1, y1, ..., xn, yn
+ *
+ *  Return : x, y, width, height float64 - box center and size
+ */
+func polygonBox(points []float64) (float64, float64, float64, float64) {
+	minX, minY, maxX, maxY := points[0], points[1], points[0], points[1]
+	for index := 0; index+1 < len(points); index += 2 {
+		if points[index] < minX {
+			minX = points[index]
+		}
+		if points[index] > maxX {
+			maxX = points[index]
+		}
+		if points[index+1] < minY {
+			minY = points[index+1]
+		}
+		if points[index+1] > maxY {
+			maxY = points[index+1]
+		}
+	}
+
+	return (minX + maxX) / 2, (minY + maxY) / 2, maxX - minX, maxY - minY
+}
+
+/****************************************************************************************
+ *
+ * Function : formatCoordinate
+ *
+ * Purpose : Print normalized coordinate with 6 digits precision
+ *
+ *   Input : value float64 - coordinate
+ *
+ *  Return : string - formatted coordinate
+ */
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', 6, 64)
+}
@@ -0,0 +1,560 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: lifecycle.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Clone, archive and restore of the datasets, redirects of the renamed ones
+
+	Archives are zip files '.archives/<name>.zip' in the datasets folder and
+	renamed datasets are kept in '.redirects.json' as the old name to the new
+	one, names starting with the dot are not datasets. Clone and restore are
+	made in the hidden folder and renamed into place when they are ready.
+	Jobs and unfinished uploads belong to the source dataset and are not
+	cloned, the catalog is copied by the database, so tags are kept.
+
+	In the file
+		1. CloneDataset - full copy or images with labels and classes only
+		2. ArchiveDataset, ListArchives, RestoreArchive, DeleteArchive
+		3. RenamedDataset - current name of the renamed dataset
+	=============================================================================
+*/
+
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go.etcd.io/bbolt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Folder of the archives and file of the redirects in the datasets folder
+const ArchivesFolder = ".archives"
+const RedirectsFileName = ".redirects.json"
+
+// Extension of the archive file
+const archiveExtension = ".zip"
+
+// Renames change the redirects file one by one
+var redirectsMutex sync.Mutex
+
+// Archived dataset
+type ArchiveInfo struct {
+	Name     string    `json:"name"`     // Name of the archived dataset
+	Size     int64     `json:"size"`     // Size of the archive file
+	Archived time.Time `json:"archived"` // Time of the archive
+}
+
+/****************************************************************************************
+ *
+ * Function : CloneDataset
+ *
+ * Purpose : Copy the dataset under the new name
+ *			 Images only clone has the images with labels, classes and catalog,
+ *			 but no versions, models, trash and thumbnails
+ *
+ *   Input : name string - dataset to clone
+ *			 newName string - name of the clone
+ *			 imagesOnly bool - true to copy the images with labels only
+ *
+ *  Return : Dataset - clone
+ *			 error - ErrDatasetExists if dataset with the new name exists
+ */
+func CloneDataset(name string, newName string, imagesOnly bool) (Dataset, error) {
+	dataset, err := OpenDataset(name)
+	if err != nil {
+		return Dataset{}, err
+	}
+	if !IsValidName(newName) {
+		return Dataset{}, ErrInvalidName
+	}
+	clone := Dataset{Name: newName, Path: DatasetPath(newName)}
+	if _, err := os.Stat(clone.Path); err == nil {
+		return Dataset{}, ErrDatasetExists
+	}
+
+	// Clone is made in the hidden folder, so the half copied dataset is not listed
+	temporary := filepath.Join(DatasetsPath, "."+newName+".clone")
+	os.RemoveAll(temporary)
+
+	skip := func(relativePath string) bool {
+		top := strings.Split(relativePath, string(filepath.Separator))[0]
+		if relativePath == CatalogFileName || top == UploadsFolder || top == JobsFolder {
+			return true
+		}
+		return imagesOnly && top != UploadedFolder && top != DatasetFolder && relativePath != DescriptorFileName
+	}
+	if err := copyFolder(dataset.Path, temporary, skip); err != nil {
+		os.RemoveAll(temporary)
+		return Dataset{}, err
+	}
+	for _, folder := range []string{VersionsFolder, ModelsFolder, ThumbnailsFolder} {
+		if err := os.MkdirAll(filepath.Join(temporary, folder), os.ModePerm); err != nil {
+			os.RemoveAll(temporary)
+			return Dataset{}, err
+		}
+	}
+
+	// Catalog is copied by the read transaction to get the consistent file
+	catalog, err := dataset.Catalog()
+	if err == nil {
+		err = catalog.db.View(func(tx *bbolt.Tx) error {
+			return tx.CopyFile(filepath.Join(temporary, CatalogFileName), 0644)
+		})
+	}
+	if err != nil {
+		os.RemoveAll(temporary)
+		return Dataset{}, err
+	}
+
+	// Clone is the new dataset, the descriptor gets the time of the copy
+	if descriptor, err := readDescriptor(temporary); err == nil {
+		descriptor.Created = time.Now().UTC()
+		descriptor.Updated = descriptor.Created
+		if err := writeDescriptor(temporary, descriptor); err != nil {
+			os.RemoveAll(temporary)
+			return Dataset{}, err
+		}
+	}
+
+	if err := os.Rename(temporary, clone.Path); err != nil {
+		os.RemoveAll(temporary)
+		return Dataset{}, err
+	}
+
+	return clone, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : ArchiveDataset
+ *
+ * Purpose : Write the dataset folder to the zip archive and delete the dataset
+ *
+ *   Input : name string - dataset name
+ *
+ *  Return : ArchiveInfo - written archive
+ *			 error - ErrArchiveExists if dataset with the same name is archived
+ */
+func ArchiveDataset(name string) (ArchiveInfo, error) {
+	dataset, err := OpenDataset(name)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+
+	archivePath := filepath.Join(DatasetsPath, ArchivesFolder, name+archiveExtension)
+	if _, err := os.Stat(archivePath); err == nil {
+		return ArchiveInfo{}, ErrArchiveExists
+	}
+	if err := os.MkdirAll(filepath.Dir(archivePath), os.ModePerm); err != nil {
+		return ArchiveInfo{}, err
+	}
+
+	output, err := os.CreateTemp(filepath.Dir(archivePath), "."+name+".*.tmp")
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+
+	// Catalog database is written to the archive closed, changes wait until
+	// the folder is removed
+	unlock := lockDatasetFolder(dataset)
+	defer unlock()
+	err = ZipFolder(dataset.Path, output)
+	if err == nil {
+		err = output.Sync()
+	}
+	output.Close()
+	if err == nil {
+		err = os.Rename(output.Name(), archivePath)
+	}
+	if err != nil {
+		os.Remove(output.Name())
+		return ArchiveInfo{}, err
+	}
+	syncFolder(filepath.Dir(archivePath))
+
+	if err := os.RemoveAll(dataset.Path); err != nil {
+		return ArchiveInfo{}, err
+	}
+
+	return readArchiveInfo(archivePath)
+}
+
+/****************************************************************************************
+ *
+ * Function : ListArchives
+ *
+ * Purpose : Get archived datasets sorted by name
+ *
+ *   Input : Nothing
+ *
+ *  Return : []ArchiveInfo - archives
+ *			 error - error if occur
+ */
+func ListArchives() ([]ArchiveInfo, error) {
+	archives := []ArchiveInfo{}
+
+	entries, err := os.ReadDir(filepath.Join(DatasetsPath, ArchivesFolder))
+	if os.IsNotExist(err) {
+		return archives, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), archiveExtension)
+		if entry.IsDir() || filepath.Ext(entry.Name()) != archiveExtension || !IsValidName(name) {
+			continue
+		}
+		if archive, err := readArchiveInfo(filepath.Join(DatasetsPath, ArchivesFolder, entry.Name())); err == nil {
+			archives = append(archives, archive)
+		}
+	}
+	sort.Slice(archives, func(i, j int) bool { return archives[i].Name < archives[j].Name })
+
+	return archives, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : RestoreArchive
+ *
+ * Purpose : Extract the archive into the dataset folder and delete the archive
+ *
+ *   Input : name string - name of the archived dataset
+ *			 newName string - name of the restored dataset, archive name when empty
+ *
+ *  Return : Dataset - restored dataset
+ *			 error - ErrArchiveNotFound, ErrDatasetExists if dataset with the name exists
+ */
+func RestoreArchive(name string, newName string) (Dataset, error) {
+	archivePath, err := findArchive(name)
+	if err != nil {
+		return Dataset{}, err
+	}
+	if newName == "" {
+		newName = name
+	}
+	if !IsValidName(newName) {
+		return Dataset{}, ErrInvalidName
+	}
+	dataset := Dataset{Name: newName, Path: DatasetPath(newName)}
+	if _, err := os.Stat(dataset.Path); err == nil {
+		return Dataset{}, ErrDatasetExists
+	}
+
+	temporary := filepath.Join(DatasetsPath, "."+newName+".restore")
+	os.RemoveAll(temporary)
+	if err := extractArchive(archivePath, temporary, 0, 0); err != nil {
+		os.RemoveAll(temporary)
+		return Dataset{}, err
+	}
+	if err := os.Rename(temporary, dataset.Path); err != nil {
+		os.RemoveAll(temporary)
+		return Dataset{}, err
+	}
+
+	return dataset, os.Remove(archivePath)
+}
+
+/****************************************************************************************
+ *
+ * Function : DeleteArchive
+ *
+ * Purpose : Delete the archive forever
+ *
+ *   Input : name string - name of the archived dataset
+ *
+ *  Return : error - ErrArchiveNotFound if archive is not exists
+ */
+func DeleteArchive(name string) error {
+	archivePath, err := findArchive(name)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(archivePath)
+}
+
+/****************************************************************************************
+ *
+ * Function : RenamedDataset
+ *
+ * Purpose : Get the current name of the dataset renamed before
+ *
+ *   Input : name string - old dataset name
+ *
+ *  Return : string - current name
+ *			 bool - true if dataset was renamed and exists with the current name
+ */
+func RenamedDataset(name string) (string, bool) {
+	redirects := readRedirects()
+
+	current, found := redirects[name]
+	if !found {
+		return "", false
+	}
+	if _, err := OpenDataset(current); err != nil {
+		return "", false
+	}
+
+	return current, true
+}
+
+/****************************************************************************************
+ *
+ * Function : recordRename
+ *
+ * Purpose : Keep the redirect from the old name, redirects to the old name
+ *			 are moved to the new one
+ *
+ *   Input : name string - old dataset name
+ *			 newName string - new dataset name
+ *
+ *  Return : error - error if occur
+ */
+func recordRename(name string, newName string) error {
+	redirectsMutex.Lock()
+	defer redirectsMutex.Unlock()
+
+	redirects := readRedirects()
+
+	for old, current := range redirects {
+		if current == name {
+			redirects[old] = newName
+		}
+	}
+	redirects[name] = newName
+	delete(redirects, newName)
+
+	content, err := json.MarshalIndent(redirects, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(DatasetsPath, RedirectsFileName), bytes.NewReader(content))
+}
+
+/****************************************************************************************
+ *
+ * Function : readRedirects
+ *
+ * Purpose : Read redirects of the renamed datasets
+ *
+ *   Input : Nothing
+ *
+ *  Return : map[string]string - old name to the current name, empty if file is missing
+ */
+func readRedirects() map[string]string {
+	redirects := map[string]string{}
+
+	if content, err := os.ReadFile(filepath.Join(DatasetsPath, RedirectsFileName)); err == nil {
+		json.Unmarshal(content, &redirects)
+	}
+
+	return redirects
+}
+
+/****************************************************************************************
+ *
+ * Function : findArchive
+ *
+ * Purpose : Get path to the existing archive
+ *
+ *   Input : name string - name of the archived dataset
+ *
+ *  Return : string - path to the archive file
+ *			 error - ErrInvalidName, ErrArchiveNotFound if archive is not exists
+ */
+func findArchive(name string) (string, error) {
+	if !IsValidName(name) {
+		return "", ErrInvalidName
+	}
+
+	path := filepath.Join(DatasetsPath, ArchivesFolder, name+archiveExtension)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrArchiveNotFound
+	}
+
+	return path, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : readArchiveInfo
+ *
+ * Purpose : Get details of the archive file
+ *
+ *   Input : path string - path to the archive
+ *
+ *  Return : ArchiveInfo - archive details
+ *			 error - error if occur
+ */
+func readArchiveInfo(path string) (ArchiveInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ArchiveInfo{}, err
+	}
+
+	return ArchiveInfo{Name: strings.TrimSuffix(filepath.Base(path), archiveExtension), Size: info.Size(), Archived: info.ModTime()}, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : extractArchive
+ *
+ * Purpose : Extract zip archive into the folder, modification times are kept
+ *			 Extraction stops when the archive has more entries or extracted bytes
+ *			 than allowed, sizes in the archive headers are not trusted
+ *
+ *   Input : path string - path to the archive
+ *			 target string - folder to extract into
+ *			 maxEntries int - most entries of the archive, 0 for no limit
+ *			 maxSize int64 - most bytes of the extracted files, 0 for no limit
+ *
+ *  Return : error - error if occur, archive has paths outside of the folder
+ *			 or is over the limits
+ */
+func extractArchive(path string, target string, maxEntries int, maxSize int64) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	if maxEntries > 0 && len(archive.File) > maxEntries {
+		return fmt.Errorf("archive has more than %v entries", maxEntries)
+	}
+	left := maxSize
+	if maxSize <= 0 {
+		left = math.MaxInt64 - 1
+	}
+
+	for _, entry := range archive.File {
+		name := filepath.Clean(filepath.FromSlash(entry.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("archive has path '%v' outside of the dataset", entry.Name)
+		}
+		entryPath := filepath.Join(target, name)
+
+		if strings.HasSuffix(entry.Name, "/") {
+			if err := os.MkdirAll(entryPath, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(entryPath), os.ModePerm); err != nil {
+			return err
+		}
+
+		source, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		// One byte more than left is read to find the archive over the limit
+		limited := &io.LimitedReader{R: source, N: left + 1}
+		err = writeFile(entryPath, limited)
+		source.Close()
+		if err != nil {
+			return err
+		}
+		left -= left + 1 - limited.N
+		if left < 0 {
+			return fmt.Errorf("archive has more than %v bytes extracted", maxSize)
+		}
+		os.Chtimes(entryPath, entry.Modified, entry.Modified)
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : copyFolder
+ *
+ * Purpose : Copy files of the folder, modification times are kept, so the catalog
+ *			 and thumbnails of the copy are not made again
+ *
+ *   Input : source string - folder to copy
+ *			 target string - new folder
+ *			 skip func(string) bool - true for the path relative to the source to skip
+ *
+ *  Return : error - error if occur
+ */
+func copyFolder(source string, target string, skip func(string) bool) error {
+	return filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		if relativePath != "." && skip(relativePath) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		targetPath := filepath.Join(target, relativePath)
+		if entry.IsDir() {
+			return os.MkdirAll(targetPath, os.ModePerm)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		sourceFile, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = writeFile(targetPath, sourceFile)
+		sourceFile.Close()
+		if err != nil {
+			return err
+		}
+
+		return os.Chtimes(targetPath, info.ModTime(), info.ModTime())
+	})
+}
+
+/****************************************************************************************
+ *
+ * Function : writeFile
+ *
+ * Purpose : Write the new file from the reader
+ *
+ *   Input : path string - path to the file
+ *			 source io.Reader - content
+ *
+ *  Return : error - error if occur
+ */
+func writeFile(path string, source io.Reader) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, source); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,184 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: lint.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Check dataset for the problems before training
+
+	Checks:
+		1. data.yaml can be parsed and has classes
+		2. Label files can be parsed, classes are known, boxes are inside the image
+		3. Label files without images
+		4. Images without labels in the splits
+		5. Same image name in the different locations
+		6. Duplicated boxes in the label file
+		7. Empty train or valid split
+	=============================================================================
+*/
+
+package core
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Severity of the lint issue
+const LintError = "error"
+const LintWarning = "warning"
+
+// Problem found by the lint
+type LintIssue struct {
+	Severity string `json:"severity"`
+	Location string `json:"location,omitempty"`
+	File     string `json:"file,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Result of the lint
+type LintReport struct {
+	Images   int         `json:"images"`
+	Errors   int         `json:"errors"`
+	Warnings int         `json:"warnings"`
+	Issues   []LintIssue `json:"issues"`
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.Lint
+ *
+ * Purpose : Check all images and labels of the dataset
+ *
+ *   Input : Nothing
+ *
+ *  Return : LintReport - found issues
+ *			 error - error if dataset cannot be read
+ */
+func (dataset Dataset) Lint() (LintReport, error) {
+	report := LintReport{Issues: []LintIssue{}}
+
+	classes := 0
+	if dataFile, err := dataset.ReadDataFile(); err != nil {
+		report.add(LintError, "", DataFileName, err.Error())
+	} else {
+		classes = len(dataFile.Names)
+	}
+
+	labelled := 0
+	imageLocations := make(map[string][]string)
+	for _, location := range Locations {
+		images, err := dataset.ListImages(location)
+		if err != nil {
+			return report, err
+		}
+		report.Images += len(images)
+
+		imageNames := make(map[string]bool)
+		for _, image := range images {
+			imageNames[LabelFileName(image.Name)] = true
+			imageLocations[image.Name] = append(imageLocations[image.Name], location)
+
+			if !image.Labelled {
+				if location != LocationUploaded {
+					report.add(LintWarning, location, image.Name, "image has no labels, it is used as background")
+				}
+				continue
+			}
+
+			labelled++
+			dataset.lintLabels(&report, location, image.Name, classes)
+		}
+
+		// Label files left without images
+		labelsPath, _ := dataset.LabelsPath(location)
+		entries, err := os.ReadDir(labelsPath)
+		if err != nil {
+			return report, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".txt") && !imageNames[entry.Name()] {
+				report.add(LintWarning, location, entry.Name(), "label file has no image")
+			}
+		}
+
+		if (location == SplitTrain || location == SplitValid) && len(images) == 0 {
+			report.add(LintWarning, location, "", "split has no images")
+		}
+	}
+
+	if classes == 0 && labelled > 0 {
+		report.add(LintError, "", DataFileName, "data.yaml has no classes, but there are labelled images")
+	}
+
+	var names []string
+	for name, locations := range imageLocations {
+		if len(locations) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.add(LintWarning, "", name, "image is in several locations: "+strings.Join(imageLocations[name], ", "))
+	}
+
+	return report, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.lintLabels
+ *
+ * Purpose : Check label file of the image
+ *
+ *   Input : report *LintReport - report to add issues
+ *			 location string - images location
+ *			 name string - image file name
+ *			 classes int - number of classes in data.yaml
+ *
+ *  Return : Nothing
+ */
+func (dataset Dataset) lintLabels(report *LintReport, location string, name string, classes int) {
+	labels, err := dataset.ReadLabels(location, name)
+	if err != nil {
+		report.add(LintError, location, name, err.Error())
+		return
+	}
+
+	if err := ValidateLabels(labels, classes); err != nil {
+		report.add(LintError, location, name, err.Error())
+	}
+
+	lines := make(map[string]bool)
+	for _, label := range labels {
+		line := string(FormatLabels([]Label{label}))
+		if lines[line] {
+			report.add(LintWarning, location, name, "label has duplicated boxes")
+			return
+		}
+		lines[line] = true
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : LintReport.add
+ *
+ * Purpose : Add issue to the report and count it
+ *
+ *   Input : severity string - LintError or LintWarning
+ *			 location string - images location, can be empty
+ *			 file string - file name, can be empty
+ *			 message string - issue description
+ *
+ *  Return : Nothing
+ */
+func (report *LintReport) add(severity string, location string, file string, message string) {
+	report.Issues = append(report.Issues, LintIssue{Severity: severity, Location: location, File: file, Message: message})
+
+	if severity == LintError {
+		report.Errors++
+	} else {
+		report.Warnings++
+	}
+}
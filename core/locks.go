@@ -0,0 +1,102 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: locks.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Write locks of the datasets in the process
+
+	Requests changing the files of the dataset hold the shared lock and run
+	together. Archive, backup, rename and delete of the dataset hold the
+	exclusive lock for the whole copy and removal of the folder, the catalog
+	stays closed until they end.
+
+	In the file
+		1. LockDataset - shared lock of the changes
+		2. lockDatasetFolder - exclusive lock of the whole folder
+	=============================================================================
+*/
+
+package core
+
+import (
+	"sync"
+)
+
+// Write locks by the dataset name
+var datasetLocks = struct {
+	sync.Mutex
+	locks map[string]*sync.RWMutex
+}{locks: make(map[string]*sync.RWMutex)}
+
+/****************************************************************************************
+ *
+ * Function : datasetLock
+ *
+ * Purpose : Get the write lock of the dataset, created on the first use
+ *
+ *   Input : name string - dataset name
+ *
+ *  Return : *sync.RWMutex - lock of the dataset
+ */
+func datasetLock(name string) *sync.RWMutex {
+	datasetLocks.Lock()
+	defer datasetLocks.Unlock()
+
+	lock, found := datasetLocks.locks[name]
+	if !found {
+		lock = &sync.RWMutex{}
+		datasetLocks.locks[name] = lock
+	}
+
+	return lock
+}
+
+/****************************************************************************************
+ *
+ * Function : LockDataset
+ *
+ * Purpose : Hold the shared lock while the files of the dataset are changed,
+ *			 waits for the archive, backup, rename or delete of the dataset
+ *
+ *   Input : name string - dataset name
+ *
+ *  Return : func() - releases the lock
+ */
+func LockDataset(name string) func() {
+	lock := datasetLock(name)
+	lock.RLock()
+
+	return lock.RUnlock
+}
+
+/****************************************************************************************
+ *
+ * Function : lockDatasetFolder
+ *
+ * Purpose : Hold the exclusive lock while the whole folder is copied, moved or
+ *			 removed, the catalog is closed and is not opened until the lock is released
+ *
+ *   Input : dataset Dataset - dataset
+ *
+ *  Return : func() - releases the lock
+ */
+func lockDatasetFolder(dataset Dataset) func() {
+	lock := datasetLock(dataset.Name)
+	lock.Lock()
+
+	catalogs.Lock()
+	if catalog, ok := catalogs.open[dataset.Path]; ok {
+		catalog.db.Close()
+		delete(catalogs.open, dataset.Path)
+	}
+	catalogs.closed[dataset.Path] = true
+	catalogs.Unlock()
+
+	return func() {
+		catalogs.Lock()
+		delete(catalogs.closed, dataset.Path)
+		catalogs.Unlock()
+
+		lock.Unlock()
+	}
+}
@@ -0,0 +1,108 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: locks_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the write locks of the datasets
+
+	In the file
+		1. TestArchiveWaitsForChanges - archive starts after the change ends
+		2. TestRecordRenames - parallel renames keep all redirects
+	=============================================================================
+*/
+
+package core
+
+import (
+	"archive/zip"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+/****************************************************************************************
+ *
+ * Function : TestArchiveWaitsForChanges
+ *
+ * Purpose : Check archive waits for the change holding the lock, the image stored
+ *			 by the change is in the archive
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestArchiveWaitsForChanges(t *testing.T) {
+	useTestDatasets(t)
+	dataset := createTestDataset(t, "cars", "car")
+	writeTestImage(t, dataset, LocationUploaded, "a.png", "")
+
+	unlock := LockDataset(dataset.Name)
+	archived := make(chan error)
+	go func() {
+		_, err := ArchiveDataset(dataset.Name)
+		archived <- err
+	}()
+
+	select {
+	case err := <-archived:
+		t.Fatalf("archive did not wait for the change: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	writeTestImage(t, dataset, LocationUploaded, "b.png", "")
+	unlock()
+
+	if err := <-archived; err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.OpenReader(filepath.Join(DatasetsPath, ArchivesFolder, "cars"+archiveExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	found := map[string]bool{}
+	for _, file := range archive.File {
+		found[filepath.Base(file.Name)] = true
+	}
+	if !found["a.png"] || !found["b.png"] {
+		t.Errorf("archive has no images of the change: %v", found)
+	}
+	if _, err := OpenDataset(dataset.Name); err != ErrDatasetNotFound {
+		t.Errorf("archived dataset is kept: %v", err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestRecordRenames
+ *
+ * Purpose : Check parallel renames of the datasets keep redirects of all of them
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestRecordRenames(t *testing.T) {
+	useTestDatasets(t)
+
+	var wait sync.WaitGroup
+	for index := 0; index < 20; index++ {
+		wait.Add(1)
+		go func(index int) {
+			defer wait.Done()
+			if err := recordRename(fmt.Sprintf("old%v", index), fmt.Sprintf("new%v", index)); err != nil {
+				t.Error(err)
+			}
+		}(index)
+	}
+	wait.Wait()
+
+	redirects := readRedirects()
+	for index := 0; index < 20; index++ {
+		if redirects[fmt.Sprintf("old%v", index)] != fmt.Sprintf("new%v", index) {
+			t.Errorf("redirect of 'old%v' is lost: %v", index, redirects)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: merge.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Merge of two or more datasets into the new one
+
+	Classes are unified by name in the order they are found in the sources,
+	the mapping renames classes of the sources before that or drops their
+	boxes. Label ids are changed to the merged classes, boxes of the classes
+	which are not in the data file of the source are dropped. Image with the
+	name used by the images merged before, also with another extension, gets
+	the source dataset name as the prefix. Images keep their location, or the split images of all sources
+	are distributed again by the ratios. Trash and versions of the sources
+	are not merged, tags and review status are kept.
+
+	In the file
+		1. MergeOptions.Validate - check the sources and the mapping
+		2. MergeDatasets - copy images of the sources into the dataset
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Settings of the merge
+type MergeOptions struct {
+	Sources []string          `json:"sources"`           // Names of the merged datasets
+	Classes map[string]string `json:"classes,omitempty"` // Class name of the sources to the merged name, empty name drops the boxes
+	Split   *SplitOptions     `json:"split,omitempty"`   // Ratios to distribute the split images again, nil keeps the split of every image
+}
+
+// Result of the merge
+type MergeResult struct {
+	MergeOptions
+	Names     []string         `json:"names"`     // Classes of the merged dataset
+	Mapping   map[string][]int `json:"mapping"`   // New id of every class of the source, -1 for the dropped class
+	Images    int              `json:"images"`    // Copied images
+	Labelled  int              `json:"labelled"`  // Copied images with labels
+	Renamed   int              `json:"renamed"`   // Images which got the prefix
+	Dropped   int              `json:"dropped"`   // Dropped boxes
+	Locations map[string]int   `json:"locations"` // Images of every location of the merged dataset
+}
+
+// Image of the source dataset to merge
+type mergeImage struct {
+	source   int
+	location string
+	name     string
+}
+
+/****************************************************************************************
+ *
+ * Function : MergeOptions.Validate
+ *
+ * Purpose : Check the sources, the mapping and the split ratios
+ *
+ *   Input : name string - name of the merged dataset
+ *
+ *  Return : error - ErrInvalidMerge if settings are not valid, ErrDatasetNotFound
+ *			 if source is not exists
+ */
+func (options MergeOptions) Validate(name string) error {
+	if len(options.Sources) < 2 {
+		return fmt.Errorf("%w: at least two sources are needed", ErrInvalidMerge)
+	}
+
+	classes := map[string]bool{}
+	seen := map[string]bool{}
+	for _, source := range options.Sources {
+		if source == name || seen[source] {
+			return fmt.Errorf("%w: source '%v' is repeated or is the merged dataset", ErrInvalidMerge, source)
+		}
+		seen[source] = true
+
+		dataset, err := OpenDataset(source)
+		if err != nil {
+			return fmt.Errorf("source '%v': %w", source, err)
+		}
+		names, err := dataset.Classes()
+		if err != nil {
+			return fmt.Errorf("source '%v': %w", source, err)
+		}
+		for _, class := range names {
+			classes[class] = true
+		}
+	}
+
+	for class, merged := range options.Classes {
+		if !classes[class] {
+			return fmt.Errorf("%w: class '%v' of the mapping is not in the sources", ErrInvalidMerge, class)
+		}
+		if merged != strings.TrimSpace(merged) {
+			return fmt.Errorf("%w: class '%v' is mapped to the name with spaces around", ErrInvalidMerge, class)
+		}
+	}
+
+	if options.Split != nil {
+		if _, err := assignSplits(0, *options.Split); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMerge, err)
+		}
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : MergeDatasets
+ *
+ * Purpose : Copy images with labels of the sources into the dataset
+ *			 Classes of data.yaml are replaced by the merged classes
+ *
+ *   Input : ctx context.Context - context, cancelled context stops the merge
+ *			 dataset Dataset - merged dataset, normally just created
+ *			 options MergeOptions - sources and mapping
+ *			 progress BulkProgress - called after every image, can be nil
+ *
+ *  Return : MergeResult - merged classes and number of images
+ *			 error - ErrInvalidMerge if settings are not valid
+ */
+func MergeDatasets(ctx context.Context, dataset Dataset, options MergeOptions, progress BulkProgress) (MergeResult, error) {
+	result := MergeResult{MergeOptions: options, Names: []string{}, Mapping: map[string][]int{}, Locations: map[string]int{}}
+	if err := options.Validate(dataset.Name); err != nil {
+		return result, err
+	}
+
+	// Classes are unified by the name after the mapping
+	sources := make([]Dataset, len(options.Sources))
+	mappings := make([][]int, len(options.Sources))
+	ids := map[string]int{}
+	for index, name := range options.Sources {
+		sources[index], _ = OpenDataset(name)
+		names, err := sources[index].Classes()
+		if err != nil {
+			return result, err
+		}
+
+		mappings[index] = make([]int, len(names))
+		for class, className := range names {
+			if merged, found := options.Classes[className]; found {
+				className = merged
+			}
+			if className == "" {
+				mappings[index][class] = -1
+				continue
+			}
+			if _, found := ids[className]; !found {
+				ids[className] = len(result.Names)
+				result.Names = append(result.Names, className)
+			}
+			mappings[index][class] = ids[className]
+		}
+		result.Mapping[name] = mappings[index]
+	}
+	if err := dataset.SetClasses(result.Names); err != nil {
+		return result, err
+	}
+
+	images := []mergeImage{}
+	for index, source := range sources {
+		for _, location := range Locations {
+			files, err := source.ListImages(location)
+			if err != nil && !os.IsNotExist(err) {
+				return result, err
+			}
+			for _, file := range files {
+				images = append(images, mergeImage{source: index, location: location, name: file.Name})
+			}
+		}
+	}
+
+	// Split images of all sources are distributed again when the ratios are given
+	locations := make([]string, len(images))
+	splitImages := []int{}
+	for index, image := range images {
+		locations[index] = image.location
+		if image.location != LocationUploaded {
+			splitImages = append(splitImages, index)
+		}
+	}
+	if options.Split != nil {
+		splits, err := assignSplits(len(splitImages), *options.Split)
+		if err != nil {
+			return result, fmt.Errorf("%w: %v", ErrInvalidMerge, err)
+		}
+		for position, index := range splitImages {
+			locations[index] = splits[position]
+		}
+	}
+
+	// Names used in any location of the dataset, images with one name without
+	// extension would share the label file
+	used := map[string]bool{}
+	for _, location := range Locations {
+		files, _ := dataset.ListImages(location)
+		for _, file := range files {
+			used[LabelFileName(file.Name)] = true
+		}
+	}
+
+	for index, image := range images {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		name := image.name
+		if used[LabelFileName(name)] {
+			name = sources[image.source].Name + "_" + image.name
+			result.Renamed++
+		}
+		if used[LabelFileName(name)] || !IsValidName(name) {
+			name = dataset.freeImageName(strings.TrimSuffix(name, filepath.Ext(name)), filepath.Ext(name))
+		}
+		used[LabelFileName(name)] = true
+
+		labelled, dropped, err := dataset.mergeImage(sources[image.source], image, locations[index], name, mappings[image.source])
+		if err != nil {
+			return result, fmt.Errorf("image '%v' of '%v': %w", image.name, sources[image.source].Name, err)
+		}
+		result.Images++
+		result.Dropped += dropped
+		result.Locations[locations[index]]++
+		if labelled {
+			result.Labelled++
+		}
+		if progress != nil {
+			progress(index+1, len(images))
+		}
+	}
+
+	return result, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.mergeImage
+ *
+ * Purpose : Copy image of the source with the changed label ids, tags and review status
+ *
+ *   Input : source Dataset - source dataset
+ *			 image mergeImage - image of the source
+ *			 location string - location in the merged dataset
+ *			 name string - image name in the merged dataset
+ *			 mapping []int - new id of every class of the source, -1 for the dropped class
+ *
+ *  Return : bool - true if image has labels after the merge
+ *			 int - number of dropped boxes
+ *			 error - error if occur
+ */
+func (dataset Dataset) mergeImage(source Dataset, image mergeImage, location string, name string, mapping []int) (bool, int, error) {
+	sourceImage, _ := source.ImagePath(image.location, image.name)
+	targetImage, err := dataset.ImagePath(location, name)
+	if err != nil {
+		return false, 0, err
+	}
+
+	labelled, dropped := false, 0
+	sourceLabel, _ := source.LabelPath(image.location, image.name)
+	content, err := os.ReadFile(sourceLabel)
+	if err != nil && !os.IsNotExist(err) {
+		return false, 0, err
+	}
+	if len(content) > 0 {
+		changed, _, removed := remapLabelFile(content, func(class int) int {
+			if class < len(mapping) {
+				return mapping[class]
+			}
+			return -1
+		})
+		dropped = removed
+
+		// Label file without boxes left is not written, the image becomes a background
+		if len(bytes.TrimSpace(changed)) > 0 {
+			targetLabel, _ := dataset.LabelPath(location, name)
+			if err := writeFileAtomic(targetLabel, bytes.NewReader(changed)); err != nil {
+				return false, dropped, err
+			}
+			labelled = true
+		}
+	}
+
+	if err := copyFileAtomic(sourceImage, targetImage); err != nil {
+		return labelled, dropped, err
+	}
+	dataset.refreshCatalog(location, name)
+
+	if record, err := source.GetImageRecord(image.location, image.name); err == nil {
+		var reviewed *bool
+		if record.LabelStatus == LabelReviewed && labelled {
+			reviewed = &labelled
+		}
+		if len(record.Tags) > 0 || reviewed != nil {
+			dataset.UpdateImageRecord(location, name, record.Tags, reviewed)
+		}
+	}
+
+	return labelled, dropped, nil
+}
@@ -0,0 +1,156 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: merge_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the merge of the datasets
+
+	In the file
+		1. TestMergeDatasets - unified classes, changed label ids and renamed images
+		2. TestFreeImageName - free name is not shared with the label of another image
+	=============================================================================
+*/
+
+package core
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : TestMergeDatasets
+ *
+ * Purpose : Check classes are unified by name or by the mapping, label ids follow
+ *			 the merged classes and the image sharing the label file is renamed
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestMergeDatasets(t *testing.T) {
+	tests := []struct {
+		name    string
+		classes map[string]string
+		names   []string
+		mapping map[string][]int
+		labels  map[string]string // Label files of the merged dataset, empty for the image without labels
+		dropped int
+	}{
+		{
+			name:    "classes by name",
+			names:   []string{"car", "truck", "bike"},
+			mapping: map[string][]int{"site_a": {0, 1}, "site_b": {1, 0, 2}},
+			labels: map[string]string{
+				"site.jpg":        "1 0.5 0.5 0.2 0.2\n",
+				"site_b_site.png": "1 0.5 0.5 0.2 0.2\n",
+				"bike.png":        "2 0.5 0.5 0.2 0.2\n",
+			},
+		},
+		{
+			name:    "classes by mapping",
+			classes: map[string]string{"truck": "car", "bike": ""},
+			names:   []string{"car"},
+			mapping: map[string][]int{"site_a": {0, 0}, "site_b": {0, 0, -1}},
+			labels: map[string]string{
+				"site.jpg":        "0 0.5 0.5 0.2 0.2\n",
+				"site_b_site.png": "0 0.5 0.5 0.2 0.2\n",
+				"bike.png":        "",
+			},
+			dropped: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTestDatasets(t)
+			siteA := createTestDataset(t, "site_a", "car", "truck")
+			siteB := createTestDataset(t, "site_b", "truck", "car", "bike")
+			merged := createTestDataset(t, "merged")
+
+			// Images with one name without extension in both sources
+			writeTestImage(t, siteA, SplitTrain, "site.jpg", "1 0.5 0.5 0.2 0.2\n")
+			writeTestImage(t, siteB, SplitTrain, "site.png", "0 0.5 0.5 0.2 0.2\n")
+			writeTestImage(t, siteB, SplitValid, "bike.png", "2 0.5 0.5 0.2 0.2\n")
+
+			options := MergeOptions{Sources: []string{"site_a", "site_b"}, Classes: test.classes}
+			result, err := MergeDatasets(context.Background(), merged, options, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result.Names, test.names) || !reflect.DeepEqual(result.Mapping, test.mapping) {
+				t.Errorf("classes %v, mapping %v", result.Names, result.Mapping)
+			}
+			if result.Images != 3 || result.Renamed != 1 || result.Dropped != test.dropped {
+				t.Errorf("result %+v", result)
+			}
+			if classes, _ := merged.Classes(); !reflect.DeepEqual(classes, test.names) {
+				t.Errorf("classes of data.yaml %v", classes)
+			}
+
+			for name, label := range test.labels {
+				location := SplitTrain
+				if name == "bike.png" {
+					location = SplitValid
+				}
+				if _, err := merged.StatImage(location, name); err != nil {
+					t.Errorf("image '%v': %v", name, err)
+				}
+				labelPath, _ := merged.LabelPath(location, name)
+				content, err := os.ReadFile(labelPath)
+				if label == "" && !os.IsNotExist(err) {
+					t.Errorf("label of '%v' without boxes is written: %v", name, err)
+				}
+				if label != "" && string(content) != label {
+					t.Errorf("label of '%v' is '%v', expected '%v'", name, string(content), label)
+				}
+			}
+		})
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestFreeImageName
+ *
+ * Purpose : Check free name skips names used by the image with another extension
+ *			 or by the label file in any location
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestFreeImageName(t *testing.T) {
+	tests := []struct {
+		name     string
+		prepare  func(t *testing.T, dataset Dataset)
+		expected string
+	}{
+		{"free", func(t *testing.T, dataset Dataset) {}, "mosaic_1.jpg"},
+		{"same name", func(t *testing.T, dataset Dataset) {
+			writeTestImage(t, dataset, SplitTrain, "mosaic_1.jpg", "")
+		}, "mosaic_2.jpg"},
+		{"another extension", func(t *testing.T, dataset Dataset) {
+			writeTestImage(t, dataset, SplitValid, "mosaic_1.PNG", "")
+		}, "mosaic_2.jpg"},
+		{"label without image", func(t *testing.T, dataset Dataset) {
+			labelPath, _ := dataset.LabelPath(LocationUploaded, "mosaic_1.jpg")
+			os.WriteFile(labelPath, []byte("0 0.5 0.5 0.2 0.2\n"), 0644)
+		}, "mosaic_2.jpg"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTestDatasets(t)
+			dataset := createTestDataset(t, "cars", "car")
+			test.prepare(t, dataset)
+
+			if name := dataset.freeImageName("mosaic", ".jpg"); name != test.expected {
+				t.Errorf("free name '%v', expected '%v'", name, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: migrate.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Versions of the dataset folder layout and migrations between them
+
+	Layout version is kept in the dataset descriptor, CreateNewDataset makes
+	the latest layout. Older datasets are upgraded step by step, every step
+	only adds what is missed, so the interrupted migration is run again
+	safely. Version is written after every step once the descriptor exists,
+	the descriptor of the older dataset is written by the last step only.
+	Dataset is written to '.backups/<name>-layout<version>-<time>.zip' before
+	the first step, the backup has the format of the archive and is restored
+	from the '.archives' folder.
+
+	Layouts:
+		1. Splits and uploaded images folders with data.yaml
+		2. 'versions' and 'models' folders
+		3. Images catalog database
+		4. 'thumbnails' folder
+		5. Dataset descriptor
+
+	In the file
+		1. Dataset.Layout - layout version of the dataset
+		2. Dataset.MigrateLayout - upgrade the dataset to the latest layout
+		3. MigrateDatasets - upgrade all datasets
+	=============================================================================
+*/
+
+package core
+
+import (
+	"fmt"
+	"github.com/CoderSergiy/golib/logging"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Latest layout of the dataset folder
+const LayoutVersion = 5
+
+// Folder of the backups made before the migration
+const BackupsFolder = ".backups"
+
+// Step of the migration to the layout version
+type layoutMigration struct {
+	version     int
+	description string
+	run         func(dataset Dataset) error
+}
+
+// Migrations in the order of the layout versions
+var layoutMigrations = []layoutMigration{
+	{version: 1, description: "splits and uploaded images folders with data.yaml", run: migrateBaseFolders},
+	{version: 2, description: "versions and models folders", run: migrateVersionsFolders},
+	{version: 3, description: "images catalog database", run: migrateCatalog},
+	{version: 4, description: "thumbnails folder", run: migrateThumbnailsFolder},
+	{version: 5, description: "dataset descriptor", run: migrateDescriptor},
+}
+
+// Settings of the migration
+type MigrationOptions struct {
+	DryRun bool `json:"dry_run"` // Report the steps without doing them
+	Backup bool `json:"backup"`  // Zip the dataset before the first step
+}
+
+// Result of the dataset migration
+type MigrationResult struct {
+	Dataset string   `json:"dataset"`
+	From    int      `json:"from"`             // Layout before the migration
+	To      int      `json:"to"`               // Layout after the migration, the latest for the dry run
+	Steps   []string `json:"steps"`            // Done steps, or steps to do for the dry run
+	Backup  string   `json:"backup,omitempty"` // Path to the backup archive
+	Error   string   `json:"error,omitempty"`  // Reason why the migration stopped
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.Layout
+ *
+ * Purpose : Get layout version of the dataset from the descriptor
+ *
+ *   Input : Nothing
+ *
+ *  Return : int - layout version, 0 for the dataset without the descriptor
+ */
+func (dataset Dataset) Layout() int {
+	descriptor, err := dataset.Descriptor()
+	if err != nil {
+		return 0
+	}
+
+	return descriptor.Layout
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.MigrateLayout
+ *
+ * Purpose : Run the steps from the layout of the dataset to the latest one
+ *
+ *   Input : options MigrationOptions - dry run and backup
+ *
+ *  Return : MigrationResult - done steps and the reached layout
+ *			 error - ErrLayoutTooNew if dataset is made by the newer version,
+ *			 ErrInvalidDescriptor if descriptor exists but cannot be read,
+ *			 error of the step which stopped the migration
+ */
+func (dataset Dataset) MigrateLayout(options MigrationOptions) (MigrationResult, error) {
+	result := MigrationResult{Dataset: dataset.Name, Steps: []string{}}
+
+	// Broken descriptor is not the layout 0, it is reported before any backup is written
+	if _, err := os.Stat(dataset.DescriptorPath()); err == nil {
+		if _, err := dataset.Descriptor(); err != nil {
+			return result, err
+		}
+	}
+
+	result.From = dataset.Layout()
+	result.To = result.From
+	if result.From > LayoutVersion {
+		return result, fmt.Errorf("%w: layout %v, latest known is %v", ErrLayoutTooNew, result.From, LayoutVersion)
+	}
+
+	pending := []layoutMigration{}
+	for _, migration := range layoutMigrations {
+		if migration.version > result.From {
+			pending = append(pending, migration)
+		}
+	}
+	if len(pending) == 0 {
+		return result, nil
+	}
+
+	if options.DryRun {
+		for _, migration := range pending {
+			result.Steps = append(result.Steps, migration.description)
+		}
+		result.To = LayoutVersion
+		return result, nil
+	}
+
+	if options.Backup {
+		backup, err := dataset.backup(result.From)
+		if err != nil {
+			return result, fmt.Errorf("backup: %w", err)
+		}
+		result.Backup = backup
+	}
+
+	for _, migration := range pending {
+		if err := migration.run(dataset); err != nil {
+			return result, fmt.Errorf("layout %v, %v: %w", migration.version, migration.description, err)
+		}
+		result.Steps = append(result.Steps, migration.description)
+		result.To = migration.version
+
+		// Version is kept once the descriptor exists, the steps before it are run again
+		if err := dataset.setLayout(migration.version); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : MigrateDatasets
+ *
+ * Purpose : Upgrade all datasets to the latest layout
+ *			 Folder with the valid name is migrated when it has the descriptor or
+ *			 data.yaml, other folders are not datasets and are not changed
+ *			 Failed dataset is reported in the result, the rest are migrated
+ *
+ *   Input : options MigrationOptions - dry run and backup
+ *
+ *  Return : []MigrationResult - result of every dataset with the older layout
+ *			 error - error if datasets folder cannot be read
+ */
+func MigrateDatasets(options MigrationOptions) ([]MigrationResult, error) {
+	entries, err := os.ReadDir(DatasetsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []MigrationResult{}
+	for _, entry := range entries {
+		// Hidden folders of the archives, backups, clones and restores are not datasets
+		if !entry.IsDir() || !IsValidName(entry.Name()) {
+			continue
+		}
+		name := entry.Name()
+		dataset := Dataset{Name: name, Path: DatasetPath(name)}
+		if !dataset.isDatasetFolder() {
+			continue
+		}
+
+		result, err := dataset.MigrateLayout(options)
+		if err != nil {
+			result.Error = err.Error()
+			logging.Error_Log("Migration of the dataset '%v' stopped: '%v'", name, err)
+		}
+		if result.From != LayoutVersion || result.Error != "" {
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.setLayout
+ *
+ * Purpose : Write the layout version to the descriptor, updated time is kept
+ *			 Dataset without the descriptor gets it from the last step
+ *
+ *   Input : version int - reached layout version
+ *
+ *  Return : error - ErrInvalidDescriptor if descriptor cannot be read,
+ *			 nil if it is not exists yet
+ */
+func (dataset Dataset) setLayout(version int) error {
+	if _, err := os.Stat(dataset.DescriptorPath()); os.IsNotExist(err) {
+		return nil
+	}
+
+	descriptor, err := dataset.Descriptor()
+	if err != nil {
+		return err
+	}
+
+	descriptor.Layout = version
+	return writeDescriptor(dataset.Path, descriptor)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.backup
+ *
+ * Purpose : Write the dataset folder to the zip archive in the backups folder
+ *
+ *   Input : layout int - layout version of the dataset, part of the archive name
+ *
+ *  Return : string - path to the archive
+ *			 error - error if occur
+ */
+func (dataset Dataset) backup(layout int) (string, error) {
+	name := fmt.Sprintf("%v-layout%v-%v%v", dataset.Name, layout, time.Now().UTC().Format("20060102-150405"), archiveExtension)
+	backupPath := filepath.Join(DatasetsPath, BackupsFolder, name)
+	if err := os.MkdirAll(filepath.Dir(backupPath), os.ModePerm); err != nil {
+		return "", err
+	}
+
+	output, err := os.CreateTemp(filepath.Dir(backupPath), "."+name+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	// Catalog database is written to the archive closed
+	unlock := lockDatasetFolder(dataset)
+	defer unlock()
+	err = ZipFolder(dataset.Path, output)
+	if err == nil {
+		err = output.Sync()
+	}
+	output.Close()
+	if err == nil {
+		err = os.Rename(output.Name(), backupPath)
+	}
+	if err != nil {
+		os.Remove(output.Name())
+		return "", err
+	}
+	syncFolder(filepath.Dir(backupPath))
+
+	return backupPath, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : migrateBaseFolders
+ *
+ * Purpose : Create missed folders of the splits and uploaded images, data.yaml
+ *			 without classes when it is missed
+ *
+ *   Input : dataset Dataset - migrated dataset
+ *
+ *  Return : error - error if occur
+ */
+func migrateBaseFolders(dataset Dataset) error {
+	for _, location := range Locations {
+		for _, folder := range []string{ImagesFolder, LabelsFolder} {
+			path, _ := dataset.LocationPath(location)
+			if err := os.MkdirAll(filepath.Join(path, folder), os.ModePerm); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := os.Stat(dataset.DataFilePath()); os.IsNotExist(err) {
+		return os.WriteFile(dataset.DataFilePath(), generateDataFileContent(), 0644)
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : migrateVersionsFolders
+ *
+ * Purpose : Create missed 'versions' and 'models' folders
+ *
+ *   Input : dataset Dataset - migrated dataset
+ *
+ *  Return : error - error if occur
+ */
+func migrateVersionsFolders(dataset Dataset) error {
+	for _, folder := range []string{VersionsFolder, ModelsFolder} {
+		if err := os.MkdirAll(filepath.Join(dataset.Path, folder), os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : migrateCatalog
+ *
+ * Purpose : Create the catalog database with records of all images
+ *
+ *   Input : dataset Dataset - migrated dataset
+ *
+ *  Return : error - ErrCatalogLocked if catalog is used by another process
+ */
+func migrateCatalog(dataset Dataset) error {
+	_, err := dataset.SyncCatalog(false)
+	return err
+}
+
+/****************************************************************************************
+ *
+ * Function : migrateThumbnailsFolder
+ *
+ * Purpose : Create missed 'thumbnails' folder, thumbnails are made when requested
+ *
+ *   Input : dataset Dataset - migrated dataset
+ *
+ *  Return : error - error if occur
+ */
+func migrateThumbnailsFolder(dataset Dataset) error {
+	return os.MkdirAll(filepath.Join(dataset.Path, ThumbnailsFolder), os.ModePerm)
+}
+
+/****************************************************************************************
+ *
+ * Function : migrateDescriptor
+ *
+ * Purpose : Write the descriptor to the dataset made before it, created time
+ *			 is taken from the data.yaml. Only writer of the missed descriptor
+ *
+ *   Input : dataset Dataset - migrated dataset
+ *
+ *  Return : error - ErrInvalidDescriptor if existing descriptor is not valid
+ */
+func migrateDescriptor(dataset Dataset) error {
+	if _, err := os.Stat(dataset.DescriptorPath()); err == nil {
+		_, err = dataset.Descriptor()
+		return err
+	}
+
+	info, err := os.Stat(dataset.DataFilePath())
+	if err != nil {
+		return err
+	}
+
+	descriptor := Descriptor{Task: TaskDetect, Tags: []string{}, Created: info.ModTime().UTC(), Updated: info.ModTime().UTC()}
+	if err := writeDescriptor(dataset.Path, descriptor); err != nil {
+		return err
+	}
+
+	logging.Info_Log("Descriptor is written to the dataset '%v' created before", dataset.Name)
+	return nil
+}
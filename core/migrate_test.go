@@ -0,0 +1,142 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: migrate_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the layout migrations
+
+	In the file
+		1. TestMigrateLayout - steps from every layout to the latest one
+		2. TestMigrateDatasets - only datasets are migrated, broken ones get no backup
+	=============================================================================
+*/
+
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : TestMigrateLayout
+ *
+ * Purpose : Check the dry run lists the pending steps and the migration reaches
+ *			 the latest layout with one backup
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestMigrateLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout int
+		steps  int
+		err    error
+	}{
+		{"layout 1", 1, 4, nil},
+		{"layout 3", 3, 2, nil},
+		{"latest layout", LayoutVersion, 0, nil},
+		{"newer layout", LayoutVersion + 1, 0, ErrLayoutTooNew},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := useTestDatasets(t)
+			dataset := createTestDataset(t, "cars", "car")
+			if err := dataset.setLayout(test.layout); err != nil {
+				t.Fatal(err)
+			}
+
+			planned, err := dataset.MigrateLayout(MigrationOptions{DryRun: true})
+			if !errors.Is(err, test.err) || len(planned.Steps) != test.steps {
+				t.Fatalf("dry run %+v: %v", planned, err)
+			}
+			if dataset.Layout() != test.layout {
+				t.Errorf("dry run changed the layout to %v", dataset.Layout())
+			}
+			if test.err != nil {
+				return
+			}
+
+			result, err := dataset.MigrateLayout(MigrationOptions{Backup: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.From != test.layout || result.To != LayoutVersion || len(result.Steps) != test.steps || dataset.Layout() != LayoutVersion {
+				t.Errorf("migration %+v, layout %v", result, dataset.Layout())
+			}
+			// Backup is written only when there are steps to do
+			expected := 0
+			if test.steps > 0 {
+				expected = 1
+			}
+			backups, _ := os.ReadDir(filepath.Join(root, BackupsFolder))
+			if (result.Backup != "") != (expected == 1) || len(backups) != expected {
+				t.Errorf("backup '%v', %v files", result.Backup, len(backups))
+			}
+		})
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestMigrateDatasets
+ *
+ * Purpose : Check the folder with data.yaml is migrated with the backup, other
+ *			 folders are not changed and the broken descriptor gets no backup
+ *			 when the migration is repeated
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestMigrateDatasets(t *testing.T) {
+	root := useTestDatasets(t)
+	createTestDataset(t, "cars", "car")
+	os.MkdirAll(filepath.Join(root, "legacy", DatasetFolder), os.ModePerm)
+	os.WriteFile(filepath.Join(root, "legacy", DatasetFolder, DataFileName), []byte("names: ['car']\n"), 0644)
+	os.MkdirAll(filepath.Join(root, "notes"), os.ModePerm)
+	os.MkdirAll(filepath.Join(root, "broken"), os.ModePerm)
+	os.WriteFile(filepath.Join(root, "broken", DescriptorFileName), []byte("{"), 0644)
+
+	for run := 1; run <= 2; run++ {
+		results, err := MigrateDatasets(MigrationOptions{Backup: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		byName := map[string]MigrationResult{}
+		for _, result := range results {
+			byName[result.Dataset] = result
+		}
+		if _, found := byName["cars"]; found {
+			t.Errorf("run %v: dataset of the latest layout is reported", run)
+		}
+		if _, found := byName["notes"]; found {
+			t.Errorf("run %v: folder without the dataset is migrated", run)
+		}
+		if broken, found := byName["broken"]; !found || broken.Error == "" || broken.Backup != "" {
+			t.Errorf("run %v: broken descriptor %+v", run, broken)
+		}
+		if legacy, found := byName["legacy"]; run == 1 && (!found || legacy.Error != "" || legacy.To != LayoutVersion) {
+			t.Errorf("run %v: legacy %+v", run, legacy)
+		}
+	}
+
+	// One backup of the legacy dataset, nothing written to the other folders
+	backups, _ := os.ReadDir(filepath.Join(root, BackupsFolder))
+	if len(backups) != 1 {
+		t.Errorf("%v backups, expected 1", len(backups))
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, "notes")); len(entries) != 0 {
+		t.Errorf("folder without the dataset has %v entries", len(entries))
+	}
+	if content, _ := os.ReadFile(filepath.Join(root, "broken", DescriptorFileName)); string(content) != "{" {
+		t.Errorf("broken descriptor is changed to '%v'", string(content))
+	}
+}
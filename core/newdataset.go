@@ -5,6 +5,9 @@
 	Source : github.com/CoderSergiy/yolov8-dataset/core
 	Purpose: Methods to create a new dataset
 
+	Descriptor is written the last, so the folder is listed as the dataset
+	only when all folders and files are created.
+
 	=============================================================================
 */
 
@@ -13,6 +16,7 @@ package core
 import (
 	"github.com/CoderSergiy/golib/tools"
 	"os"
+	"time"
 )
 
 /****************************************************************************************
@@ -22,10 +26,14 @@ import (
  * Purpose : Create required files for the new dataset
  *
  *   Input : path string - path on drive where to create the dataset folder with required files
+ *			 descriptor Descriptor - description, task, owner and tags of the dataset
  *
- *  Return : error - error if occur
+ *  Return : error - ErrInvalidDescriptor if descriptor is not valid
  */
-func CreateNewDataset(path string) error {
+func CreateNewDataset(path string, descriptor Descriptor) error {
+	if err := descriptor.Validate(); err != nil {
+		return err
+	}
 
 	if err := os.MkdirAll(tools.EnsureSlashInEnd(path)+"dataset/test/images", os.ModePerm); err != nil {
 		return err
@@ -69,7 +77,15 @@ func CreateNewDataset(path string) error {
 		return err
 	}
 
-	return nil
+	// Create folder for thumbnails
+	if err := os.MkdirAll(tools.EnsureSlashInEnd(path)+ThumbnailsFolder, os.ModePerm); err != nil {
+		return err
+	}
+
+	descriptor.Created = time.Now().UTC()
+	descriptor.Layout = LayoutVersion
+	descriptor.Updated = descriptor.Created
+	return writeDescriptor(path, descriptor)
 }
 
 /****************************************************************************************
@@ -83,11 +99,11 @@ func CreateNewDataset(path string) error {
  *  Return : []byte - file content
  */
 func generateDataFileContent() []byte {
-	return []byte("train: ../train/images\n" +
-		"val: ../valid/images\n" +
-		"test: ../test/images\n" +
-		"\n" +
-		"nc: 0\n" +
-		"names: []\n" +
-		"\n")
+	dataFile := DataFile{
+		Train: []string{"../train/images"},
+		Val:   []string{"../valid/images"},
+		Test:  []string{"../test/images"},
+		Names: []string{}}
+
+	return dataFile.Bytes()
 }
@@ -0,0 +1,405 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: pipeline.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Upload pipeline of the images
+
+	Every image is streamed to the temporary file, synced and renamed into
+	place. Catalog record with hashes and dimensions and the thumbnail are
+	made by the pool of processing workers, next image of the request is
+	received while the previous one is processed. Request is stored
+	completely or not at all, images of the failed request are removed.
+
+	In the file
+		1. StartProcessing - start the pool of processing workers
+		2. SaveImages - store images of the request and wait for processing
+		3. ProcessImages - process images already stored in the location
+		4. MultipartImages - source of the images from the multipart form
+	=============================================================================
+*/
+
+package core
+
+import (
+	"fmt"
+	"github.com/CoderSergiy/golib/logging"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// Default number of images processed at the same time
+var DefaultProcessWorkers = runtime.NumCPU()
+
+// Images waiting for the free worker, storing of the next image waits when queue is full
+const processQueueSize = 64
+
+// Source of the images, returns io.EOF when there are no more images
+type ImageSource func() (string, io.Reader, error)
+
+// Images of one request processed by the workers
+type imageBatch struct {
+	dataset  Dataset
+	location string
+	wait     sync.WaitGroup
+	mutex    sync.Mutex
+	err      error // First error of the batch
+}
+
+// Image waiting for the worker
+type processTask struct {
+	batch *imageBatch
+	name  string
+}
+
+// Queue of the processing workers, images are processed by the caller when workers are not started
+var processQueue chan processTask
+
+/****************************************************************************************
+ *
+ * Function : StartProcessing
+ *
+ * Purpose : Start the pool of processing workers, called once on the start
+ *
+ *   Input : workers int - number of images processed at the same time
+ *
+ *  Return : Nothing
+ */
+func StartProcessing(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	processQueue = make(chan processTask, processQueueSize)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for task := range processQueue {
+				task.batch.done(task.name, task.batch.dataset.processImage(task.batch.location, task.name))
+			}
+		}()
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.SaveImages
+ *
+ * Purpose : Store every image of the source and wait until all are processed
+ *			 Request is stored completely or not at all, images stored before
+ *			 the failed one are removed
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 next ImageSource - images of the request
+ *
+ *  Return : []ImageFile - stored images
+ *			 error - first error, ErrNoImages if source has no images,
+ *			 ErrImageExists if location has the image with the same name
+ */
+func (dataset Dataset) SaveImages(location string, next ImageSource) ([]ImageFile, error) {
+	if !IsLocation(location) {
+		return nil, ErrInvalidLocation
+	}
+
+	batch := &imageBatch{dataset: dataset, location: location}
+	names := []string{}
+	for {
+		name, source, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			if err = dataset.writeImage(location, name, source); err != nil {
+				err = fmt.Errorf("image '%v': %w", name, err)
+			}
+		}
+		if err != nil {
+			batch.wait.Wait()
+			dataset.removeImages(location, names)
+			return nil, err
+		}
+
+		names = append(names, name)
+		batch.add(name)
+	}
+	if len(names) == 0 {
+		return nil, ErrNoImages
+	}
+
+	if err := batch.result(); err != nil {
+		dataset.removeImages(location, names)
+		return nil, err
+	}
+
+	images := []ImageFile{}
+	for _, name := range names {
+		image, err := dataset.StatImage(location, name)
+		if err != nil {
+			dataset.removeImages(location, names)
+			return nil, fmt.Errorf("image '%v': %w", name, err)
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.removeImages
+ *
+ * Purpose : Remove images stored by the failed request with their catalog records
+ *			 and thumbnails
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 names []string - image file names
+ *
+ *  Return : Nothing
+ */
+func (dataset Dataset) removeImages(location string, names []string) {
+	for _, name := range names {
+		imagePath, _ := dataset.ImagePath(location, name)
+		if err := os.Remove(imagePath); err != nil && !os.IsNotExist(err) {
+			logging.Error_Log("Image '%v' of the failed upload is not removed: '%v'", imagePath, err)
+		}
+	}
+	dataset.refreshCatalog(location, names...)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ProcessImages
+ *
+ * Purpose : Update catalog records and thumbnails of the stored images by the workers
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 names ...string - image file names
+ *
+ *  Return : error - first error of the images
+ */
+func (dataset Dataset) ProcessImages(location string, names ...string) error {
+	batch := &imageBatch{dataset: dataset, location: location}
+	for _, name := range names {
+		batch.add(name)
+	}
+
+	return batch.result()
+}
+
+/****************************************************************************************
+ *
+ * Function : MultipartImages
+ *
+ * Purpose : Read files of the form field part by part without buffering
+ *
+ *   Input : reader *multipart.Reader - reader of the request body
+ *			 field string - form field with the images
+ *
+ *  Return : ImageSource - images of the field, errors of the body are ErrInvalidForm
+ */
+func MultipartImages(reader *multipart.Reader, field string) ImageSource {
+	var previous *multipart.Part
+	return func() (string, io.Reader, error) {
+		if previous != nil {
+			previous.Close()
+		}
+
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return "", nil, io.EOF
+			}
+			if err != nil {
+				return "", nil, fmt.Errorf("%w: %v", ErrInvalidForm, err)
+			}
+			if part.FormName() != field || part.FileName() == "" {
+				part.Close()
+				continue
+			}
+
+			previous = part
+			return filepath.Base(part.FileName()), formReader{part}, nil
+		}
+	}
+}
+
+// Reader of the form part, connection errors are not errors of the drive
+type formReader struct {
+	source io.Reader
+}
+
+/****************************************************************************************
+ *
+ * Function : formReader.Read
+ *
+ * Purpose : Read the part, errors are wrapped by ErrInvalidForm
+ *
+ *   Input : buffer []byte - buffer to fill
+ *
+ *  Return : int - read bytes
+ *			 error - io.EOF or ErrInvalidForm
+ */
+func (reader formReader) Read(buffer []byte) (int, error) {
+	length, err := reader.source.Read(buffer)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("%w: %v", ErrInvalidForm, err)
+	}
+
+	return length, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.writeImage
+ *
+ * Purpose : Stream the image to the location, larger than MaxUploadSize is rejected
+ *			 Existing image is not replaced, its label would stay with the new image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *			 source io.Reader - image content
+ *
+ *  Return : error - ErrImageExists if location has the image with the same name
+ */
+func (dataset Dataset) writeImage(location string, name string, source io.Reader) error {
+	imagePath, err := dataset.ImagePath(location, name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(imagePath); err == nil {
+		return ErrImageExists
+	}
+
+	tempPath, err := writeTempFile(imagePath, &sizeLimitReader{source: source, left: MaxUploadSize})
+	if err != nil {
+		return err
+	}
+	// Image with the same name could be stored while the image was received
+	if err := publishFile(tempPath, imagePath); err != nil {
+		if os.IsExist(err) {
+			return ErrImageExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Reader failing when the limit is exceeded
+type sizeLimitReader struct {
+	source io.Reader
+	left   int64
+}
+
+/****************************************************************************************
+ *
+ * Function : sizeLimitReader.Read
+ *
+ * Purpose : Read the source, ErrUploadTooLarge after the limit
+ *
+ *   Input : buffer []byte - buffer to fill
+ *
+ *  Return : int - read bytes
+ *			 error - error if occur
+ */
+func (reader *sizeLimitReader) Read(buffer []byte) (int, error) {
+	if reader.left < 0 {
+		return 0, ErrUploadTooLarge
+	}
+	if int64(len(buffer)) > reader.left+1 {
+		buffer = buffer[:reader.left+1]
+	}
+
+	length, err := reader.source.Read(buffer)
+	reader.left -= int64(length)
+	if reader.left < 0 {
+		return length, ErrUploadTooLarge
+	}
+
+	return length, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.processImage
+ *
+ * Purpose : Update catalog record and thumbnail of the stored image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) processImage(location string, name string) error {
+	catalog, err := dataset.Catalog()
+	if err != nil {
+		return err
+	}
+	if err := dataset.refreshRecord(catalog, location, name, location); err != nil {
+		return err
+	}
+
+	return dataset.writeThumbnail(location, name)
+}
+
+/****************************************************************************************
+ *
+ * Function : imageBatch.add
+ *
+ * Purpose : Queue the image to the workers, processed at once if workers are not started
+ *
+ *   Input : name string - image file name
+ *
+ *  Return : Nothing
+ */
+func (batch *imageBatch) add(name string) {
+	batch.wait.Add(1)
+	if processQueue == nil {
+		batch.done(name, batch.dataset.processImage(batch.location, name))
+		return
+	}
+
+	processQueue <- processTask{batch: batch, name: name}
+}
+
+/****************************************************************************************
+ *
+ * Function : imageBatch.done
+ *
+ * Purpose : Keep the first error of the batch
+ *
+ *   Input : name string - image file name
+ *			 err error - result of the processing
+ *
+ *  Return : Nothing
+ */
+func (batch *imageBatch) done(name string, err error) {
+	if err != nil {
+		batch.mutex.Lock()
+		if batch.err == nil {
+			batch.err = fmt.Errorf("image '%v': %w", name, err)
+		}
+		batch.mutex.Unlock()
+	}
+
+	batch.wait.Done()
+}
+
+/****************************************************************************************
+ *
+ * Function : imageBatch.result
+ *
+ * Purpose : Wait until all images of the batch are processed
+ *
+ *  Return : error - first error of the batch
+ */
+func (batch *imageBatch) result() error {
+	batch.wait.Wait()
+
+	batch.mutex.Lock()
+	defer batch.mutex.Unlock()
+	return batch.err
+}
@@ -0,0 +1,132 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: pipeline_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the images stored by the upload request
+
+	In the file
+		1. testImages - source of the request images
+		2. TestSaveImages - request stored completely or not at all
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Image of the test request, broken image fails while it is read
+type testFile struct {
+	name   string
+	broken bool
+}
+
+/****************************************************************************************
+ *
+ * Function : testImages
+ *
+ * Purpose : Make the source of the request with the files
+ *
+ *   Input : files []testFile - files of the request
+ *
+ *  Return : ImageSource - images of the request
+ */
+func testImages(files []testFile) ImageSource {
+	return func() (string, io.Reader, error) {
+		if len(files) == 0 {
+			return "", nil, io.EOF
+		}
+		file := files[0]
+		files = files[1:]
+
+		var source io.Reader = bytes.NewReader(testImage())
+		if file.broken {
+			source = io.MultiReader(source, brokenReader{})
+		}
+		return file.name, source, nil
+	}
+}
+
+// Reader of the lost connection
+type brokenReader struct{}
+
+/****************************************************************************************
+ *
+ * Function : brokenReader.Read
+ *
+ * Purpose : Fail as the lost connection
+ *
+ *   Input : buffer []byte - buffer to fill
+ *
+ *  Return : int - 0
+ *			 error - ErrInvalidForm
+ */
+func (brokenReader) Read(buffer []byte) (int, error) {
+	return 0, ErrInvalidForm
+}
+
+/****************************************************************************************
+ *
+ * Function : TestSaveImages
+ *
+ * Purpose : Check images of the request are stored together, failed request removes
+ *			 the stored images and keeps the image stored before
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestSaveImages(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []testFile
+		err    error
+		stored []string
+	}{
+		{"stored", []testFile{{name: "a.png"}, {name: "b.png"}}, nil, []string{"a.png", "b.png", "old.png"}},
+		{"stored image name", []testFile{{name: "a.png"}, {name: "old.png"}}, ErrImageExists, []string{"old.png"}},
+		{"same name twice", []testFile{{name: "a.png"}, {name: "a.png"}}, ErrImageExists, []string{"old.png"}},
+		{"broken image", []testFile{{name: "a.png"}, {name: "b.png", broken: true}}, ErrInvalidForm, []string{"old.png"}},
+		{"no images", []testFile{}, ErrNoImages, []string{"old.png"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTestDatasets(t)
+			dataset := createTestDataset(t, "cars", "car")
+			writeTestImage(t, dataset, LocationUploaded, "old.png", "0 0.5 0.5 0.2 0.2\n")
+
+			images, err := dataset.SaveImages(LocationUploaded, testImages(test.files))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("error %v, expected %v", err, test.err)
+			}
+			if err == nil && len(images) != len(test.files) {
+				t.Errorf("stored %+v", images)
+			}
+
+			// Only images remain, temporary files are removed
+			path, _ := dataset.LocationPath(LocationUploaded)
+			entries, _ := os.ReadDir(filepath.Join(path, ImagesFolder))
+			names := []string{}
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, test.stored) {
+				t.Errorf("location has %v, expected %v", names, test.stored)
+			}
+			if labels, err := dataset.ReadLabels(LocationUploaded, "old.png"); err != nil || len(labels) != 1 {
+				t.Errorf("label of the stored image %+v: %v", labels, err)
+			}
+		})
+	}
+}
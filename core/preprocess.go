@@ -0,0 +1,495 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: preprocess.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Preprocessing of the images of the version
+
+	Every image of the version is decoded, turned by the EXIF orientation,
+	resized, converted to grayscale if asked and encoded again: png stays
+	png, other formats become jpeg. Labels are drawn on the image as the
+	browser shows it, so they are kept by the orientation and moved only by
+	the letterbox border.
+
+	Resize modes:
+		stretch   - exactly size x size, the proportions are changed
+		fit       - longest side is the size, the proportions are kept
+		letterbox - fit and centred on size x size with the grey border
+
+	In the file
+		1. PreprocessOptions.Resolve - check the settings and fill the defaults
+		2. preprocessImage - orient, resize and convert the image
+		3. readOrientation - EXIF orientation of the jpeg file
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Resize modes of the preprocessing
+const ResizeStretch = "stretch"
+const ResizeFit = "fit"
+const ResizeLetterbox = "letterbox"
+
+// Largest side of the preprocessed image
+const MaxPreprocessSize = 8192
+
+// Quality of the preprocessed and augmented jpeg images
+const preprocessQuality = 95
+
+// Settings of the preprocessing
+type PreprocessOptions struct {
+	AutoOrient bool   `json:"auto_orient"`      // Turn the image by its EXIF orientation
+	Size       int    `json:"size,omitempty"`   // Target size in pixels, 0 keeps the size
+	Resize     string `json:"resize,omitempty"` // 'stretch', 'fit' or 'letterbox' (default) when size is set
+	Grayscale  bool   `json:"grayscale"`        // Convert to grayscale
+}
+
+// Result of the preprocessing in the version manifest
+type PreprocessRecipe struct {
+	PreprocessOptions
+	Images   int `json:"images"`   // Preprocessed images of all splits
+	Oriented int `json:"oriented"` // Images turned by the EXIF orientation
+	Skipped  int `json:"skipped"`  // Images which cannot be decoded, copied as is
+}
+
+/****************************************************************************************
+ *
+ * Function : PreprocessOptions.Resolve
+ *
+ * Purpose : Check the settings and fill the default resize mode
+ *
+ *  Return : PreprocessOptions - settings with the resize mode
+ *			 error - ErrInvalidPreprocess if settings are not valid
+ */
+func (options PreprocessOptions) Resolve() (PreprocessOptions, error) {
+	if options.Size < 0 || options.Size > MaxPreprocessSize {
+		return options, fmt.Errorf("%w: size must be from 0 to %v", ErrInvalidPreprocess, MaxPreprocessSize)
+	}
+
+	if options.Size == 0 {
+		if options.Resize != "" {
+			return options, fmt.Errorf("%w: resize needs size", ErrInvalidPreprocess)
+		}
+		return options, nil
+	}
+
+	switch options.Resize {
+	case "":
+		options.Resize = ResizeLetterbox
+	case ResizeStretch, ResizeFit, ResizeLetterbox:
+	default:
+		return options, fmt.Errorf("%w: resize '%v' is not known, use '%v', '%v' or '%v'", ErrInvalidPreprocess, options.Resize, ResizeStretch, ResizeFit, ResizeLetterbox)
+	}
+
+	return options, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.preprocessSplit
+ *
+ * Purpose : Write preprocessed images and moved labels of the split to the version folder
+ *
+ *   Input : split string - split name
+ *			 target string - path to the split folder in the version
+ *			 options PreprocessOptions - resolved settings
+ *			 recipe *PreprocessRecipe - counters of the version
+ *
+ *  Return : LocationStats - written images, labelled images and boxes
+ *			 error - error if occur
+ */
+func (dataset Dataset) preprocessSplit(split string, target string, options PreprocessOptions, recipe *PreprocessRecipe) (LocationStats, error) {
+	stats := LocationStats{}
+
+	if err := os.MkdirAll(filepath.Join(target, ImagesFolder), os.ModePerm); err != nil {
+		return stats, err
+	}
+	if err := os.MkdirAll(filepath.Join(target, LabelsFolder), os.ModePerm); err != nil {
+		return stats, err
+	}
+
+	images, err := dataset.ListImages(split)
+	if err != nil {
+		return stats, err
+	}
+
+	for _, imageFile := range images {
+		labels := []Label{}
+		if imageFile.Labelled {
+			if labels, err = dataset.ReadLabels(split, imageFile.Name); err != nil {
+				return stats, fmt.Errorf("%v/%v: %w", split, imageFile.Name, err)
+			}
+		}
+
+		imagePath, _ := dataset.ImagePath(split, imageFile.Name)
+		name := imageFile.Name
+		picture, transform, oriented, err := preprocessImage(imagePath, options)
+		if err == nil {
+			if transform != nil {
+				labels, _ = moveLabels(labels, transform, 0)
+			}
+			name = encodedName(imageFile.Name)
+			err = writeImageFile(filepath.Join(target, ImagesFolder, name), picture)
+			if err != nil {
+				return stats, err
+			}
+			recipe.Images++
+			if oriented {
+				recipe.Oriented++
+			}
+		} else {
+			// Image which cannot be decoded is copied as is
+			if err := copyFile(imagePath, filepath.Join(target, ImagesFolder, name)); err != nil {
+				return stats, err
+			}
+			recipe.Skipped++
+		}
+		stats.Images++
+
+		if len(labels) == 0 {
+			continue
+		}
+		labelPath := filepath.Join(target, LabelsFolder, LabelFileName(name))
+		if err := os.WriteFile(labelPath, FormatLabels(labels), 0644); err != nil {
+			return stats, err
+		}
+		stats.Labelled++
+		stats.Boxes += len(labels)
+	}
+
+	return stats, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : preprocessImage
+ *
+ * Purpose : Decode the image, turn it by the EXIF orientation, resize and convert
+ *
+ *   Input : path string - path to the image
+ *			 options PreprocessOptions - resolved settings
+ *
+ *  Return : image.Image - preprocessed image, *image.Gray for grayscale
+ *			 pointTransform - change of the labels by the letterbox, nil if labels are kept
+ *			 bool - true if image is turned by the orientation
+ *			 error - error if image cannot be decoded
+ */
+func preprocessImage(path string, options PreprocessOptions) (image.Image, pointTransform, bool, error) {
+	source, err := decodeImage(path)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	picture := toRGBA(source)
+
+	oriented := false
+	if options.AutoOrient {
+		if orientation := readOrientation(path); orientation > 1 {
+			picture = orientImage(picture, orientation)
+			oriented = true
+		}
+	}
+
+	var transform pointTransform
+	if options.Size > 0 {
+		width, height := options.Size, options.Size
+		if options.Resize != ResizeStretch {
+			width, height = fitSize(picture.Rect.Dx(), picture.Rect.Dy(), options.Size)
+		}
+		picture = resizeImage(picture, width, height)
+
+		if options.Resize == ResizeLetterbox {
+			canvas := image.NewRGBA(image.Rect(0, 0, options.Size, options.Size))
+			draw.Draw(canvas, canvas.Rect, &image.Uniform{C: augmentFill}, image.Point{}, draw.Src)
+			left, top := (options.Size-width)/2, (options.Size-height)/2
+			draw.Draw(canvas, image.Rect(left, top, left+width, top+height), picture, image.Point{}, draw.Src)
+			picture = canvas
+
+			size := float64(options.Size)
+			transform = func(x float64, y float64) (float64, float64) {
+				return (x*float64(width) + float64(left)) / size, (y*float64(height) + float64(top)) / size
+			}
+		}
+	}
+
+	if options.Grayscale {
+		gray := image.NewGray(picture.Rect)
+		draw.Draw(gray, gray.Rect, picture, image.Point{}, draw.Src)
+		return gray, transform, oriented, nil
+	}
+
+	return picture, transform, oriented, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : fitSize
+ *
+ * Purpose : Get size of the image with the longest side equal to the size
+ *
+ *   Input : width, height int - image size
+ *			 size int - longest side
+ *
+ *  Return : int, int - width and height, at least 1
+ */
+func fitSize(width int, height int, size int) (int, int) {
+	targetWidth, targetHeight := size, height*size/width
+	if height > width {
+		targetWidth, targetHeight = width*size/height, size
+	}
+	if targetWidth < 1 {
+		targetWidth = 1
+	}
+	if targetHeight < 1 {
+		targetHeight = 1
+	}
+
+	return targetWidth, targetHeight
+}
+
+/****************************************************************************************
+ *
+ * Function : resizeImage
+ *
+ * Purpose : Resize by the average of the source pixels, nearest pixel when enlarged
+ *
+ *   Input : picture *image.RGBA - image from (0, 0)
+ *			 width, height int - new size
+ *
+ *  Return : *image.RGBA - resized image
+ */
+func resizeImage(picture *image.RGBA, width int, height int) *image.RGBA {
+	sourceWidth, sourceHeight := picture.Rect.Dx(), picture.Rect.Dy()
+	if width == sourceWidth && height == sourceHeight {
+		return picture
+	}
+
+	target := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		top, bottom := y*sourceHeight/height, (y+1)*sourceHeight/height
+		if bottom <= top {
+			bottom = top + 1
+		}
+		for x := 0; x < width; x++ {
+			left, right := x*sourceWidth/width, (x+1)*sourceWidth/width
+			if right <= left {
+				right = left + 1
+			}
+
+			var sum [4]int
+			for sourceY := top; sourceY < bottom; sourceY++ {
+				offset := sourceY*picture.Stride + left*4
+				for sourceX := left; sourceX < right; sourceX++ {
+					for channel := 0; channel < 4; channel++ {
+						sum[channel] += int(picture.Pix[offset+channel])
+					}
+					offset += 4
+				}
+			}
+
+			count := (bottom - top) * (right - left)
+			offset := y*target.Stride + x*4
+			for channel := 0; channel < 4; channel++ {
+				target.Pix[offset+channel] = uint8(sum[channel] / count)
+			}
+		}
+	}
+
+	return target
+}
+
+/****************************************************************************************
+ *
+ * Function : orientImage
+ *
+ * Purpose : Turn the image as the EXIF orientation says
+ *
+ *   Input : picture *image.RGBA - image from (0, 0)
+ *			 orientation int - EXIF orientation from 2 to 8
+ *
+ *  Return : *image.RGBA - image as it is shown
+ */
+func orientImage(picture *image.RGBA, orientation int) *image.RGBA {
+	switch orientation {
+	case 2:
+		return flipImage(picture, true)
+	case 3:
+		return rotateImage(rotateImage(picture))
+	case 4:
+		return flipImage(picture, false)
+	case 5:
+		return flipImage(rotateImage(picture), true)
+	case 6:
+		return rotateImage(picture)
+	case 7:
+		return flipImage(rotateImage(picture), false)
+	case 8:
+		return rotateImage(rotateImage(rotateImage(picture)))
+	}
+
+	return picture
+}
+
+/****************************************************************************************
+ *
+ * Function : readOrientation
+ *
+ * Purpose : Read orientation tag of the EXIF block of the jpeg file
+ *
+ *   Input : path string - path to the image
+ *
+ *  Return : int - orientation from 1 to 8, 1 if file has no orientation
+ */
+func readOrientation(path string) int {
+	imageFile, err := os.Open(path)
+	if err != nil {
+		return 1
+	}
+	defer imageFile.Close()
+
+	// Segments before the image data: marker, length and content
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(imageFile, header[:2]); err != nil || header[0] != 0xFF || header[1] != 0xD8 {
+		return 1
+	}
+	for {
+		if _, err := io.ReadFull(imageFile, header); err != nil || header[0] != 0xFF {
+			return 1
+		}
+		marker, length := header[1], int(binary.BigEndian.Uint16(header[2:]))
+		if marker == 0xDA || length < 2 {
+			return 1
+		}
+
+		content := make([]byte, length-2)
+		if _, err := io.ReadFull(imageFile, content); err != nil {
+			return 1
+		}
+		if marker == 0xE1 && bytes.HasPrefix(content, []byte("Exif\x00\x00")) {
+			return exifOrientation(content[6:])
+		}
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : exifOrientation
+ *
+ * Purpose : Find orientation tag in the first IFD of the TIFF block
+ *
+ *   Input : tiff []byte - TIFF header and IFDs
+ *
+ *  Return : int - orientation from 1 to 8, 1 if not found
+ */
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[offset:]))
+	for index := 0; index < entries; index++ {
+		entry := offset + 2 + index*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 1
+		}
+	}
+
+	return 1
+}
+
+/****************************************************************************************
+ *
+ * Function : encodedName
+ *
+ * Purpose : Get name of the encoded image, png stays png, others become jpeg
+ *
+ *   Input : name string - image file name
+ *
+ *  Return : string - file name with '.png' or '.jpg' extension
+ */
+func encodedName(name string) string {
+	extension := strings.ToLower(filepath.Ext(name))
+	if extension == ".png" || extension == ".jpg" {
+		return name
+	}
+
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".jpg"
+}
+
+/****************************************************************************************
+ *
+ * Function : writeImageFile
+ *
+ * Purpose : Encode the image by the file extension, png or jpeg
+ *
+ *   Input : path string - path to the new file
+ *			 picture image.Image - image
+ *
+ *  Return : error - error if occur
+ */
+func writeImageFile(path string, picture image.Image) error {
+	imageFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = encodeImage(imageFile, path, picture)
+	if closeErr := imageFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+/****************************************************************************************
+ *
+ * Function : encodeImage
+ *
+ * Purpose : Encode the image as png or jpeg by the extension of the name
+ *
+ *   Input : writer io.Writer - output of the image
+ *			 name string - file name or path
+ *			 picture image.Image - image
+ *
+ *  Return : error - error if occur
+ */
+func encodeImage(writer io.Writer, name string, picture image.Image) error {
+	if strings.ToLower(filepath.Ext(name)) == ".png" {
+		return png.Encode(writer, picture)
+	}
+
+	return jpeg.Encode(writer, picture, &jpeg.Options{Quality: preprocessQuality})
+}
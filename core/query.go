@@ -0,0 +1,247 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: query.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Filter and sort images records of the catalog
+
+	In the file
+		1. ImageQuery - filters, sorting and page of the images
+		2. ImageQuery.Match - check record by the filters
+		3. sortRecords - sort records by the field
+	=============================================================================
+*/
+
+package core
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Location to query images from all locations
+const LocationAll = "all"
+
+// Fields to sort images
+const SortName = "name"
+const SortUploaded = "uploaded"
+const SortSize = "size"
+const SortBoxes = "boxes"
+
+// Fields allowed to sort images
+var SortFields = []string{SortName, SortUploaded, SortSize, SortBoxes}
+
+// Filters, sorting and page of the images query, empty fields are not used
+type ImageQuery struct {
+	Location     string    // 'uploaded', one of the splits or 'all'
+	LabelStatus  string    // 'unlabelled', 'labelled' or 'reviewed'
+	Labelled     *bool     // Image has or has not labels
+	Class        *int      // Class present in the labels
+	Tag          string    // Tag of the image
+	Search       string    // Part of the file name, case insensitive
+	MinWidth     int       // Minimum width in pixels
+	MaxWidth     int       // Maximum width in pixels
+	MinHeight    int       // Minimum height in pixels
+	MaxHeight    int       // Maximum height in pixels
+	UploadedFrom time.Time // Uploaded at or after
+	UploadedTo   time.Time // Uploaded before
+	Sort         string    // One of SortFields, name by default
+	Descending   bool      // Reverse sorting order
+	Offset       int       // Number of images to skip
+	Limit        int       // Maximum number of images, 0 for all
+}
+
+/****************************************************************************************
+ *
+ * Function : ImageQuery.Validate
+ *
+ * Purpose : Check values of the query
+ *
+ *   Input : Nothing
+ *
+ *  Return : error - ErrInvalidQuery or ErrInvalidLocation if query is not valid
+ */
+func (query ImageQuery) Validate() error {
+	if query.Location != LocationAll && !IsLocation(query.Location) {
+		return ErrInvalidLocation
+	}
+
+	switch query.LabelStatus {
+	case "", LabelUnlabelled, LabelLabelled, LabelReviewed:
+	default:
+		return ErrInvalidQuery
+	}
+
+	if query.Sort != "" {
+		known := false
+		for _, field := range SortFields {
+			known = known || query.Sort == field
+		}
+		if !known {
+			return ErrInvalidQuery
+		}
+	}
+
+	if query.Class != nil && *query.Class < 0 {
+		return ErrInvalidQuery
+	}
+	if query.MinWidth < 0 || query.MaxWidth < 0 || query.MinHeight < 0 || query.MaxHeight < 0 || query.Offset < 0 || query.Limit < 0 {
+		return ErrInvalidQuery
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : ImageQuery.locations
+ *
+ * Purpose : Get locations to read records from
+ *
+ *   Input : Nothing
+ *
+ *  Return : []string - locations
+ */
+func (query ImageQuery) locations() []string {
+	if query.Location == LocationAll {
+		return Locations
+	}
+
+	return []string{query.Location}
+}
+
+/****************************************************************************************
+ *
+ * Function : ImageQuery.Match
+ *
+ * Purpose : Check if image record matches all filters of the query
+ *
+ *   Input : record ImageRecord - image record
+ *
+ *  Return : bool - true if record matches
+ */
+func (query ImageQuery) Match(record ImageRecord) bool {
+	if query.LabelStatus != "" && record.LabelStatus != query.LabelStatus {
+		return false
+	}
+
+	if query.Labelled != nil && record.Labelled != *query.Labelled {
+		return false
+	}
+
+	if query.Class != nil {
+		found := false
+		for _, class := range record.Classes {
+			found = found || class == *query.Class
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if query.Tag != "" {
+		found := false
+		for _, tag := range record.Tags {
+			found = found || tag == query.Tag
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if query.Search != "" && !strings.Contains(strings.ToLower(record.Name), strings.ToLower(query.Search)) {
+		return false
+	}
+
+	if (query.MinWidth > 0 && record.Width < query.MinWidth) || (query.MaxWidth > 0 && record.Width > query.MaxWidth) {
+		return false
+	}
+	if (query.MinHeight > 0 && record.Height < query.MinHeight) || (query.MaxHeight > 0 && record.Height > query.MaxHeight) {
+		return false
+	}
+
+	if !query.UploadedFrom.IsZero() && record.Uploaded.Before(query.UploadedFrom) {
+		return false
+	}
+	if !query.UploadedTo.IsZero() && !record.Uploaded.Before(query.UploadedTo) {
+		return false
+	}
+
+	return true
+}
+
+/****************************************************************************************
+ *
+ * Function : ImageQuery.page
+ *
+ * Purpose : Filter, sort and cut the page of the records
+ *
+ *   Input : records []ImageRecord - all records of the locations sorted by the name
+ *
+ *  Return : []ImageRecord - records of the page
+ *			 int - total number of the matched records
+ */
+func (query ImageQuery) page(records []ImageRecord) ([]ImageRecord, int) {
+	matched := []ImageRecord{}
+	for _, record := range records {
+		if query.Match(record) {
+			matched = append(matched, record)
+		}
+	}
+
+	sortRecords(matched, query.Sort, query.Descending)
+
+	total := len(matched)
+	start := query.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if query.Limit > 0 && start+query.Limit < total {
+		end = start + query.Limit
+	}
+
+	return matched[start:end], total
+}
+
+/****************************************************************************************
+ *
+ * Function : sortRecords
+ *
+ * Purpose : Sort records by the field, records with the same value are sorted by name
+ *
+ *   Input : records []ImageRecord - records to sort
+ *			 field string - one of SortFields
+ *			 descending bool - reverse order
+ *
+ *  Return : Nothing
+ */
+func sortRecords(records []ImageRecord, field string, descending bool) {
+	sort.SliceStable(records, func(i, j int) bool {
+		first, second := records[i], records[j]
+		if descending {
+			first, second = second, first
+		}
+
+		switch field {
+		case SortUploaded:
+			if !first.Uploaded.Equal(second.Uploaded) {
+				return first.Uploaded.Before(second.Uploaded)
+			}
+		case SortSize:
+			if first.Size != second.Size {
+				return first.Size < second.Size
+			}
+		case SortBoxes:
+			if first.Boxes != second.Boxes {
+				return first.Boxes < second.Boxes
+			}
+		}
+
+		if first.Name != second.Name {
+			return first.Name < second.Name
+		}
+		return first.Location < second.Location
+	})
+}
@@ -0,0 +1,116 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: split.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Distribute uploaded images between train, valid and test splits
+
+	=============================================================================
+*/
+
+package core
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Options to split uploaded images
+type SplitOptions struct {
+	Train             float64 `json:"train"`
+	Valid             float64 `json:"valid"`
+	Test              float64 `json:"test"`
+	Seed              int64   `json:"seed"`
+	IncludeUnlabelled bool    `json:"include_unlabelled"`
+}
+
+// Default split ratios
+var DefaultSplitOptions = SplitOptions{Train: 0.7, Valid: 0.2, Test: 0.1}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.SplitUploaded
+ *
+ * Purpose : Move uploaded images with labels to the splits by the ratios
+ *			 Images are shuffled with the seed, so the same seed gives the same split
+ *
+ *   Input : options SplitOptions - ratios of the splits
+ *
+ *  Return : map[string]int - number of images moved to every split
+ *			 error - error if occur
+ */
+func (dataset Dataset) SplitUploaded(options SplitOptions) (map[string]int, error) {
+	moved := map[string]int{SplitTrain: 0, SplitValid: 0, SplitTest: 0}
+
+	images, err := dataset.ListImages(LocationUploaded)
+	if err != nil {
+		return moved, err
+	}
+
+	var names []string
+	for _, image := range images {
+		if image.Labelled || options.IncludeUnlabelled {
+			names = append(names, image.Name)
+		}
+	}
+
+	splits, err := assignSplits(len(names), options)
+	if err != nil {
+		return moved, err
+	}
+
+	for index, name := range names {
+		if err := dataset.MoveImage(LocationUploaded, splits[index], name); err != nil {
+			return moved, err
+		}
+		moved[splits[index]]++
+	}
+
+	return moved, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : assignSplits
+ *
+ * Purpose : Give the split to every image by the ratios
+ *			 Images are shuffled with the seed, so the same seed gives the same split
+ *
+ *   Input : count int - number of images
+ *			 options SplitOptions - ratios of the splits
+ *
+ *  Return : []string - split of every image by its index
+ *			 error - error if ratios are not valid
+ */
+func assignSplits(count int, options SplitOptions) ([]string, error) {
+	total := options.Train + options.Valid + options.Test
+	if options.Train < 0 || options.Valid < 0 || options.Test < 0 || total <= 0 {
+		return nil, fmt.Errorf("split ratios must be positive")
+	}
+
+	order := make([]int, count)
+	for index := range order {
+		order[index] = index
+	}
+	random := rand.New(rand.NewSource(options.Seed))
+	random.Shuffle(count, func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+	trainCount := int(float64(count) * options.Train / total)
+	validCount := int(float64(count) * options.Valid / total)
+	if options.Test == 0 {
+		// Rounding leftovers go to the train split when test split is not used
+		trainCount = count - validCount
+	}
+
+	splits := make([]string, count)
+	for position, index := range order {
+		splits[index] = SplitTest
+		if position < trainCount {
+			splits[index] = SplitTrain
+		} else if position < trainCount+validCount {
+			splits[index] = SplitValid
+		}
+	}
+
+	return splits, nil
+}
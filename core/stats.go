@@ -0,0 +1,101 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: stats.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Calculate statistics of the dataset
+
+	=============================================================================
+*/
+
+package core
+
+// Statistics of the images location
+type LocationStats struct {
+	Images   int `json:"images"`
+	Labelled int `json:"labelled"`
+	Boxes    int `json:"boxes"`
+}
+
+// Statistics of the class
+type ClassStats struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Images int    `json:"images"`
+	Boxes  int    `json:"boxes"`
+}
+
+// Statistics of the dataset
+type Stats struct {
+	Images        int                      `json:"images"`
+	Labelled      int                      `json:"labelled"`
+	Boxes         int                      `json:"boxes"`
+	InvalidLabels int                      `json:"invalid_labels"`
+	Locations     map[string]LocationStats `json:"locations"`
+	Classes       []ClassStats             `json:"classes"`
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.Stats
+ *
+ * Purpose : Count images, labelled images and boxes per location and per class
+ *
+ *   Input : Nothing
+ *
+ *  Return : Stats - dataset statistics
+ *			 error - error if occur
+ */
+func (dataset Dataset) Stats() (Stats, error) {
+	stats := Stats{Locations: make(map[string]LocationStats), Classes: []ClassStats{}}
+
+	classes, err := dataset.Classes()
+	if err != nil {
+		return stats, err
+	}
+	for id, name := range classes {
+		stats.Classes = append(stats.Classes, ClassStats{Id: id, Name: name})
+	}
+
+	for _, location := range Locations {
+		images, err := dataset.ListImages(location)
+		if err != nil {
+			return stats, err
+		}
+
+		locationStats := LocationStats{Images: len(images)}
+		for _, image := range images {
+			if !image.Labelled {
+				continue
+			}
+
+			labels, err := dataset.ReadLabels(location, image.Name)
+			if err != nil {
+				stats.InvalidLabels++
+				continue
+			}
+
+			locationStats.Labelled++
+			locationStats.Boxes += len(labels)
+
+			imageClasses := make(map[int]bool)
+			for _, label := range labels {
+				for label.Class >= len(stats.Classes) {
+					stats.Classes = append(stats.Classes, ClassStats{Id: len(stats.Classes)})
+				}
+				stats.Classes[label.Class].Boxes++
+				imageClasses[label.Class] = true
+			}
+			for class := range imageClasses {
+				stats.Classes[class].Images++
+			}
+		}
+
+		stats.Locations[location] = locationStats
+		stats.Images += locationStats.Images
+		stats.Labelled += locationStats.Labelled
+		stats.Boxes += locationStats.Boxes
+	}
+
+	return stats, nil
+}
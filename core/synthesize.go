@@ -0,0 +1,603 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: synthesize.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Synthetic images with more objects of the rare classes
+
+	New images are written to the split of the dataset with their labels
+	and tagged 'synthetic' and the method, so they can be found and removed.
+
+	Methods:
+		mosaic     - four labelled images resized into the quarters of one
+		             square image, labels are moved and clipped by the quarter
+		copy_paste - objects of the chosen classes cut by the polygon or the
+		             box and pasted on the other images, existing objects are
+		             not covered by more than a quarter
+
+	In the file
+		1. SynthesizeOptions.Resolve - check the settings and fill the defaults
+		2. Synthesize - write synthetic images to the split
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Methods of the synthesis
+const SynthesizeMosaic = "mosaic"
+const SynthesizeCopyPaste = "copy_paste"
+
+// Tag of the synthetic images in the catalog
+const SyntheticTag = "synthetic"
+
+// Largest number of the images of one synthesis
+const MaxSynthesizeImages = 1000
+
+// Largest number of the objects pasted on one image
+const MaxPasteObjects = 20
+
+// Default side of the mosaic image
+const DefaultMosaicSize = 640
+
+// Default number of the objects pasted on one image
+const defaultPasteObjects = 3
+
+// Largest part of the existing box covered by the pasted object
+const pasteMaxCover = 0.25
+
+// Attempts to find the place of the pasted object
+const pasteAttempts = 10
+
+// Settings of the synthesis
+type SynthesizeOptions struct {
+	Method  string `json:"method"`            // 'mosaic' or 'copy_paste'
+	Count   int    `json:"count"`             // Number of the new images
+	Source  string `json:"source,omitempty"`  // Location of the source images, the target by default
+	Target  string `json:"target,omitempty"`  // Split of the new images, 'train' by default
+	Classes []int  `json:"classes,omitempty"` // Rare classes, copy_paste pastes only them, mosaic puts them in every image
+	Size    int    `json:"size,omitempty"`    // Side of the mosaic image
+	Objects int    `json:"objects,omitempty"` // Largest number of the objects pasted on one image
+	Seed    int64  `json:"seed"`              // Seed of the random choices
+}
+
+// Result of the synthesis
+type SynthesizeResult struct {
+	SynthesizeOptions
+	Images  int      `json:"images"`  // New images
+	Boxes   int      `json:"boxes"`   // Labels of the new images
+	Pasted  int      `json:"pasted"`  // Pasted objects
+	Dropped int      `json:"dropped"` // Labels left out of the mosaic quarters and objects without place
+	Names   []string `json:"names"`   // Names of the new images
+}
+
+// Source image with its labels
+type synthesizeSource struct {
+	name   string
+	labels []Label
+}
+
+// Object of the rare class on the source image
+type synthesizeObject struct {
+	source int
+	label  Label
+}
+
+/****************************************************************************************
+ *
+ * Function : SynthesizeOptions.Resolve
+ *
+ * Purpose : Check the settings and fill the defaults
+ *
+ *  Return : SynthesizeOptions - settings with the defaults
+ *			 error - ErrInvalidSynthesis if settings are not valid
+ */
+func (options SynthesizeOptions) Resolve() (SynthesizeOptions, error) {
+	if options.Method != SynthesizeMosaic && options.Method != SynthesizeCopyPaste {
+		return options, fmt.Errorf("%w: method '%v' is not known, use '%v' or '%v'", ErrInvalidSynthesis, options.Method, SynthesizeMosaic, SynthesizeCopyPaste)
+	}
+	if options.Count < 1 || options.Count > MaxSynthesizeImages {
+		return options, fmt.Errorf("%w: count must be from 1 to %v", ErrInvalidSynthesis, MaxSynthesizeImages)
+	}
+
+	if options.Target == "" {
+		options.Target = SplitTrain
+	}
+	if !IsLocation(options.Target) || options.Target == LocationUploaded {
+		return options, fmt.Errorf("%w: target '%v' is not a split", ErrInvalidSynthesis, options.Target)
+	}
+	if options.Source == "" {
+		options.Source = options.Target
+	}
+	if !IsLocation(options.Source) {
+		return options, fmt.Errorf("%w: source '%v' is not a location", ErrInvalidSynthesis, options.Source)
+	}
+
+	for _, class := range options.Classes {
+		if class < 0 {
+			return options, fmt.Errorf("%w: class %v is not valid", ErrInvalidSynthesis, class)
+		}
+	}
+	if options.Method == SynthesizeCopyPaste && len(options.Classes) == 0 {
+		return options, fmt.Errorf("%w: copy_paste needs classes", ErrInvalidSynthesis)
+	}
+
+	if options.Size == 0 {
+		options.Size = DefaultMosaicSize
+	}
+	if options.Size < 32 || options.Size > MaxPreprocessSize {
+		return options, fmt.Errorf("%w: size must be from 32 to %v", ErrInvalidSynthesis, MaxPreprocessSize)
+	}
+	if options.Objects == 0 {
+		options.Objects = defaultPasteObjects
+	}
+	if options.Objects < 1 || options.Objects > MaxPasteObjects {
+		return options, fmt.Errorf("%w: objects must be from 1 to %v", ErrInvalidSynthesis, MaxPasteObjects)
+	}
+
+	return options, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.Synthesize
+ *
+ * Purpose : Write synthetic images with labels to the target split
+ *			 Images of the source are taken by name, so the same seed gives the same images
+ *
+ *   Input : ctx context.Context - context, cancelled context stops the synthesis
+ *			 options SynthesizeOptions - settings of the synthesis
+ *			 progress BulkProgress - called after every image, can be nil
+ *
+ *  Return : SynthesizeResult - settings and new images
+ *			 error - ErrInvalidSynthesis if settings are not valid or there are no source objects
+ */
+func (dataset Dataset) Synthesize(ctx context.Context, options SynthesizeOptions, progress BulkProgress) (SynthesizeResult, error) {
+	options, err := options.Resolve()
+	if err != nil {
+		return SynthesizeResult{}, err
+	}
+	result := SynthesizeResult{SynthesizeOptions: options, Names: []string{}}
+
+	classes, err := dataset.Classes()
+	if err != nil {
+		return result, err
+	}
+	for _, class := range options.Classes {
+		if len(classes) > 0 && class >= len(classes) {
+			return result, fmt.Errorf("%w: class %v is not in the data file", ErrInvalidSynthesis, class)
+		}
+	}
+
+	sources, err := dataset.synthesizeSources(options.Source)
+	if err != nil {
+		return result, err
+	}
+
+	// Labelled images for the mosaic, images and objects of the rare classes
+	labelled, rare := []int{}, []int{}
+	objects := []synthesizeObject{}
+	for index, source := range sources {
+		if len(source.labels) > 0 {
+			labelled = append(labelled, index)
+		}
+		hasRare := false
+		for _, label := range source.labels {
+			if isClassOf(label.Class, options.Classes) {
+				objects = append(objects, synthesizeObject{source: index, label: label})
+				hasRare = true
+			}
+		}
+		if hasRare {
+			rare = append(rare, index)
+		}
+	}
+	if len(labelled) == 0 {
+		return result, fmt.Errorf("%w: '%v' has no labelled images", ErrInvalidSynthesis, options.Source)
+	}
+	if len(options.Classes) > 0 && len(objects) == 0 {
+		return result, fmt.Errorf("%w: '%v' has no objects of the classes", ErrInvalidSynthesis, options.Source)
+	}
+
+	random := rand.New(rand.NewSource(options.Seed))
+	for number := 0; number < options.Count; number++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var picture *image.RGBA
+		var labels []Label
+		var name string
+		if options.Method == SynthesizeMosaic {
+			tiles := make([]synthesizeSource, 4)
+			for index := range tiles {
+				pool := labelled
+				if len(rare) > 0 && (index == 0 || random.Intn(2) == 0) {
+					pool = rare
+				}
+				tiles[index] = sources[pool[random.Intn(len(pool))]]
+			}
+			random.Shuffle(len(tiles), func(i, j int) { tiles[i], tiles[j] = tiles[j], tiles[i] })
+
+			var dropped int
+			picture, labels, dropped = dataset.mosaicImage(options.Source, tiles, options.Size, random)
+			result.Dropped += dropped
+			name = dataset.freeImageName(SynthesizeMosaic, ".jpg")
+		} else {
+			background := sources[random.Intn(len(sources))]
+			imagePath, _ := dataset.ImagePath(options.Source, background.name)
+			decoded, err := decodeImage(imagePath)
+			if err != nil {
+				return result, fmt.Errorf("image '%v': %w", background.name, err)
+			}
+			picture = toRGBA(decoded)
+
+			labels = append([]Label{}, background.labels...)
+			donors := map[string]*image.RGBA{}
+			for count := 1 + random.Intn(options.Objects); count > 0; count-- {
+				object := objects[random.Intn(len(objects))]
+				donor, found := donors[sources[object.source].name]
+				if !found {
+					donorPath, _ := dataset.ImagePath(options.Source, sources[object.source].name)
+					decoded, err := decodeImage(donorPath)
+					if err != nil {
+						return result, fmt.Errorf("image '%v': %w", sources[object.source].name, err)
+					}
+					donor = toRGBA(decoded)
+					donors[sources[object.source].name] = donor
+				}
+
+				pasted, ok := pasteObject(picture, labels, donor, object.label, random)
+				if !ok {
+					result.Dropped++
+					continue
+				}
+				labels = append(labels, pasted)
+				result.Pasted++
+			}
+			extension := filepath.Ext(encodedName(background.name))
+			name = dataset.freeImageName(strings.TrimSuffix(background.name, filepath.Ext(background.name))+"_paste", extension)
+		}
+
+		if err := dataset.saveSynthetic(options.Target, name, picture, labels, options.Method); err != nil {
+			return result, err
+		}
+		result.Images++
+		result.Boxes += len(labels)
+		result.Names = append(result.Names, name)
+		if progress != nil {
+			progress(number+1, options.Count)
+		}
+	}
+
+	return result, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.synthesizeSources
+ *
+ * Purpose : Read names and labels of the source images sorted by name
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *
+ *  Return : []synthesizeSource - images with their labels
+ *			 error - ErrInvalidSynthesis if location has no images
+ */
+func (dataset Dataset) synthesizeSources(location string) ([]synthesizeSource, error) {
+	images, err := dataset.ListImages(location)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("%w: '%v' has no images", ErrInvalidSynthesis, location)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Name < images[j].Name })
+
+	sources := []synthesizeSource{}
+	for _, imageFile := range images {
+		labels := []Label{}
+		if imageFile.Labelled {
+			if labels, err = dataset.ReadLabels(location, imageFile.Name); err != nil {
+				return nil, fmt.Errorf("%v/%v: %w", location, imageFile.Name, err)
+			}
+		}
+		sources = append(sources, synthesizeSource{name: imageFile.Name, labels: labels})
+	}
+
+	return sources, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.mosaicImage
+ *
+ * Purpose : Put four images into the quarters around the random centre
+ *			 Every image is resized to cover its quarter and cropped at random
+ *
+ *   Input : location string - location of the images
+ *			 tiles []synthesizeSource - four images with labels
+ *			 size int - side of the mosaic
+ *			 random *rand.Rand - source of the random choices
+ *
+ *  Return : *image.RGBA - mosaic image, quarter of the image which cannot be decoded is grey
+ *			 []Label - labels moved to the mosaic
+ *			 int - number of dropped labels
+ */
+func (dataset Dataset) mosaicImage(location string, tiles []synthesizeSource, size int, random *rand.Rand) (*image.RGBA, []Label, int) {
+	canvas := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(canvas, canvas.Rect, &image.Uniform{C: augmentFill}, image.Point{}, draw.Src)
+
+	centerX, centerY := size/4+random.Intn(size/2+1), size/4+random.Intn(size/2+1)
+	quarters := []image.Rectangle{
+		image.Rect(0, 0, centerX, centerY), image.Rect(centerX, 0, size, centerY),
+		image.Rect(0, centerY, centerX, size), image.Rect(centerX, centerY, size, size)}
+
+	labels := []Label{}
+	dropped := 0
+	for index, quarter := range quarters {
+		imagePath, _ := dataset.ImagePath(location, tiles[index].name)
+		decoded, err := decodeImage(imagePath)
+		if err != nil {
+			dropped += len(tiles[index].labels)
+			continue
+		}
+		picture := toRGBA(decoded)
+
+		width, height := picture.Rect.Dx(), picture.Rect.Dy()
+		scale := math.Max(float64(quarter.Dx())/float64(width), float64(quarter.Dy())/float64(height))
+		scaledWidth, scaledHeight := int(math.Ceil(float64(width)*scale)), int(math.Ceil(float64(height)*scale))
+		picture = resizeImage(picture, scaledWidth, scaledHeight)
+		left, top := random.Intn(scaledWidth-quarter.Dx()+1), random.Intn(scaledHeight-quarter.Dy()+1)
+		draw.Draw(canvas, quarter, picture, image.Pt(left, top), draw.Src)
+
+		// Labels are clipped by the quarter and then moved to the mosaic
+		inQuarter, droppedLabels := moveLabels(tiles[index].labels, func(x float64, y float64) (float64, float64) {
+			return (x*float64(scaledWidth) - float64(left)) / float64(quarter.Dx()), (y*float64(scaledHeight) - float64(top)) / float64(quarter.Dy())
+		}, augmentMinVisible)
+		inMosaic, _ := moveLabels(inQuarter, func(x float64, y float64) (float64, float64) {
+			return (x*float64(quarter.Dx()) + float64(quarter.Min.X)) / float64(size), (y*float64(quarter.Dy()) + float64(quarter.Min.Y)) / float64(size)
+		}, 0)
+		labels = append(labels, inMosaic...)
+		dropped += droppedLabels
+	}
+
+	return canvas, labels, dropped
+}
+
+/****************************************************************************************
+ *
+ * Function : pasteObject
+ *
+ * Purpose : Cut the object from the donor by its polygon or box and paste it
+ *			 at the random place which does not cover the existing labels
+ *
+ *   Input : picture *image.RGBA - background image, changed in place
+ *			 labels []Label - labels of the background
+ *			 donor *image.RGBA - image of the object
+ *			 object Label - label of the object on the donor
+ *			 random *rand.Rand - source of the random choices
+ *
+ *  Return : Label - label of the pasted object
+ *			 bool - false if object is too small or there is no place for it
+ */
+func pasteObject(picture *image.RGBA, labels []Label, donor *image.RGBA, object Label, random *rand.Rand) (Label, bool) {
+	donorWidth, donorHeight := float64(donor.Rect.Dx()), float64(donor.Rect.Dy())
+	width, height := picture.Rect.Dx(), picture.Rect.Dy()
+
+	area := image.Rect(int(math.Floor((object.X-object.Width/2)*donorWidth)), int(math.Floor((object.Y-object.Height/2)*donorHeight)),
+		int(math.Ceil((object.X+object.Width/2)*donorWidth)), int(math.Ceil((object.Y+object.Height/2)*donorHeight))).Intersect(donor.Rect)
+	if area.Dx() < 2 || area.Dy() < 2 {
+		return Label{}, false
+	}
+
+	// Object keeps its part of the image area, changed by a quarter at random
+	scale := math.Sqrt(float64(width*height)/(donorWidth*donorHeight)) * (0.75 + random.Float64()*0.5)
+	scale = math.Min(scale, math.Min(float64(width)/float64(area.Dx()), float64(height)/float64(area.Dy())))
+	pasteWidth, pasteHeight := int(float64(area.Dx())*scale), int(float64(area.Dy())*scale)
+	if pasteWidth < 2 || pasteHeight < 2 {
+		return Label{}, false
+	}
+
+	for attempt := 0; attempt < pasteAttempts; attempt++ {
+		left, top := random.Intn(width-pasteWidth+1), random.Intn(height-pasteHeight+1)
+		place := Label{
+			X:      (float64(left) + float64(pasteWidth)/2) / float64(width),
+			Y:      (float64(top) + float64(pasteHeight)/2) / float64(height),
+			Width:  float64(pasteWidth) / float64(width),
+			Height: float64(pasteHeight) / float64(height)}
+		if coversLabels(place, labels) {
+			continue
+		}
+
+		for y := 0; y < pasteHeight; y++ {
+			sourceY := float64(area.Min.Y) + (float64(y)+0.5)/scale
+			for x := 0; x < pasteWidth; x++ {
+				sourceX := float64(area.Min.X) + (float64(x)+0.5)/scale
+				if len(object.Points) > 0 && !insidePolygon(object.Points, sourceX/donorWidth, sourceY/donorHeight) {
+					continue
+				}
+				picture.SetRGBA(left+x, top+y, donor.RGBAAt(int(sourceX), int(sourceY)))
+			}
+		}
+
+		moved, _ := moveLabels([]Label{object}, func(x float64, y float64) (float64, float64) {
+			return ((x*donorWidth-float64(area.Min.X))*scale + float64(left)) / float64(width), ((y*donorHeight-float64(area.Min.Y))*scale + float64(top)) / float64(height)
+		}, augmentMinVisible)
+		if len(moved) == 0 {
+			return Label{}, false
+		}
+		return moved[0], true
+	}
+
+	return Label{}, false
+}
+
+/****************************************************************************************
+ *
+ * Function : coversLabels
+ *
+ * Purpose : Check if the box covers too much of any label
+ *
+ *   Input : box Label - box of the pasted object
+ *			 labels []Label - existing labels
+ *
+ *  Return : bool - true if any label is covered by more than pasteMaxCover
+ */
+func coversLabels(box Label, labels []Label) bool {
+	for _, label := range labels {
+		overlapWidth := math.Min(box.X+box.Width/2, label.X+label.Width/2) - math.Max(box.X-box.Width/2, label.X-label.Width/2)
+		overlapHeight := math.Min(box.Y+box.Height/2, label.Y+label.Height/2) - math.Max(box.Y-box.Height/2, label.Y-label.Height/2)
+		if overlapWidth <= 0 || overlapHeight <= 0 {
+			continue
+		}
+		if overlapWidth*overlapHeight > pasteMaxCover*label.Width*label.Height {
+			return true
+		}
+	}
+
+	return false
+}
+
+/****************************************************************************************
+ *
+ * Function : insidePolygon
+ *
+ * Purpose : Check if the point is inside of the polygon by the even-odd rule
+ *
+ *   Input : points []float64 - polygon points x1, y1, ..., xn, yn
+ *			 x, y float64 - point
+ *
+ *  Return : bool - true if point is inside
+ */
+func insidePolygon(points []float64, x float64, y float64) bool {
+	inside := false
+	count := len(points) / 2
+	for i, j := 0, count-1; i < count; j, i = i, i+1 {
+		x1, y1, x2, y2 := points[2*i], points[2*i+1], points[2*j], points[2*j+1]
+		if (y1 > y) != (y2 > y) && x < (x2-x1)*(y-y1)/(y2-y1)+x1 {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+/****************************************************************************************
+ *
+ * Function : isClassOf
+ *
+ * Purpose : Check if the class is one of the classes
+ *
+ *   Input : class int - class of the label
+ *			 classes []int - chosen classes
+ *
+ *  Return : bool - true if class is chosen
+ */
+func isClassOf(class int, classes []int) bool {
+	for _, chosen := range classes {
+		if class == chosen {
+			return true
+		}
+	}
+
+	return false
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.freeImageName
+ *
+ * Purpose : Find name '<base>_<number><extension>' which is not used in any location,
+ *			 also by the image with another extension sharing the label file
+ *
+ *   Input : base string - beginning of the name
+ *			 extension string - file extension with the dot
+ *
+ *  Return : string - free image name
+ */
+func (dataset Dataset) freeImageName(base string, extension string) string {
+	for number := 1; ; number++ {
+		name := fmt.Sprintf("%v_%v%v", base, number, extension)
+		if !dataset.isStemUsed(name) {
+			return name
+		}
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.isStemUsed
+ *
+ * Purpose : Check if any location has the image or the label with the name
+ *			 without extension, such images share one label file
+ *
+ *   Input : name string - image file name
+ *
+ *  Return : bool - true if name without extension is used
+ */
+func (dataset Dataset) isStemUsed(name string) bool {
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	for _, location := range Locations {
+		labelPath, _ := dataset.LabelPath(location, name)
+		if _, err := os.Stat(labelPath); err == nil {
+			return true
+		}
+		for _, extension := range ImageExtensions {
+			for _, candidate := range []string{stem + extension, stem + strings.ToUpper(extension)} {
+				imagePath, _ := dataset.ImagePath(location, candidate)
+				if _, err := os.Stat(imagePath); err == nil {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.saveSynthetic
+ *
+ * Purpose : Store the synthetic image with labels and tag it
+ *
+ *   Input : location string - split of the image
+ *			 name string - image file name
+ *			 picture image.Image - image
+ *			 labels []Label - labels of the image
+ *			 method string - method of the synthesis, added to the tags
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) saveSynthetic(location string, name string, picture image.Image, labels []Label, method string) error {
+	content := bytes.Buffer{}
+	if err := encodeImage(&content, name, picture); err != nil {
+		return err
+	}
+	if _, err := dataset.SaveImage(location, name, &content); err != nil {
+		return err
+	}
+
+	if len(labels) > 0 {
+		if err := dataset.WriteLabels(location, name, labels); err != nil {
+			return err
+		}
+	}
+
+	_, err := dataset.UpdateImageRecord(location, name, []string{SyntheticTag, method}, nil)
+	return err
+}
@@ -0,0 +1,161 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: thumbnails.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Small copies of the images for the galleries
+
+	Thumbnail is stored as 'thumbnails/<location>/<image name>.jpg' and
+	made again when the image is newer than the thumbnail.
+
+	In the file
+		1. ThumbnailPath - path to the thumbnail of the image
+		2. Thumbnail - path to the fresh thumbnail, made if needed
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+)
+
+// Folder of the thumbnails inside the dataset
+const ThumbnailsFolder = "thumbnails"
+
+// Longest side of the thumbnail in pixels
+const ThumbnailSize = 320
+
+// Quality of the thumbnail jpeg
+const thumbnailQuality = 80
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ThumbnailPath
+ *
+ * Purpose : Get path to the thumbnail of the image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : string - path to the thumbnail
+ *			 error - error if name or location is not valid
+ */
+func (dataset Dataset) ThumbnailPath(location string, name string) (string, error) {
+	if _, err := dataset.ImagePath(location, name); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dataset.Path, ThumbnailsFolder, location, name+".jpg"), nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.Thumbnail
+ *
+ * Purpose : Get path to the thumbnail, made again if missing or older than the image
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : string - path to the thumbnail
+ *			 error - ErrImageNotFound, ErrNotImage if image cannot be decoded
+ */
+func (dataset Dataset) Thumbnail(location string, name string) (string, error) {
+	image, err := dataset.StatImage(location, name)
+	if err != nil {
+		return "", err
+	}
+
+	thumbnailPath, _ := dataset.ThumbnailPath(location, name)
+	if info, err := os.Stat(thumbnailPath); err == nil && !info.ModTime().Before(image.Modified) {
+		return thumbnailPath, nil
+	}
+
+	if err := dataset.writeThumbnail(location, name); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(thumbnailPath); err != nil {
+		return "", ErrNotImage
+	}
+
+	return thumbnailPath, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.writeThumbnail
+ *
+ * Purpose : Scale the image down and store it as jpeg
+ *			 Image which cannot be decoded has no thumbnail
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : error - error if thumbnail cannot be stored
+ */
+func (dataset Dataset) writeThumbnail(location string, name string) error {
+	imagePath, err := dataset.ImagePath(location, name)
+	if err != nil {
+		return err
+	}
+	thumbnailPath, _ := dataset.ThumbnailPath(location, name)
+
+	if _, err := os.Stat(imagePath); err != nil {
+		return err
+	}
+	decoded, err := decodeImage(imagePath)
+	if err != nil {
+		os.Remove(thumbnailPath)
+		return nil
+	}
+
+	content := bytes.Buffer{}
+	if err := jpeg.Encode(&content, scaleDown(decoded, ThumbnailSize), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(thumbnailPath, &content)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.removeThumbnail
+ *
+ * Purpose : Remove thumbnail of the image which is deleted or moved
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : Nothing
+ */
+func (dataset Dataset) removeThumbnail(location string, name string) {
+	if thumbnailPath, err := dataset.ThumbnailPath(location, name); err == nil {
+		os.Remove(thumbnailPath)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : scaleDown
+ *
+ * Purpose : Scale image to fit the size by the average of the source pixels
+ *
+ *   Input : source image.Image - decoded image
+ *			 size int - longest side of the result
+ *
+ *  Return : image.Image - scaled image, the source if it is already smaller
+ */
+func scaleDown(source image.Image, size int) image.Image {
+	bounds := source.Bounds()
+	if bounds.Dx() <= size && bounds.Dy() <= size {
+		return source
+	}
+
+	width, height := fitSize(bounds.Dx(), bounds.Dy(), size)
+	return resizeImage(toRGBA(source), width, height)
+}
@@ -0,0 +1,253 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: tiles.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Slicing of the large images of the version into tiles
+
+	Images larger than the tile are cut into overlapping tiles named
+	'<image>_tile_<left>_<top>', smaller images are kept as is. Labels are
+	clipped by the tile and dropped when less than the minimum visible part
+	of the box is left. Tiles without labels are dropped or kept at random
+	as the background images.
+
+	In the file
+		1. TileOptions.Resolve - check the settings and fill the defaults
+		2. tileSplit - cut images of the version split into tiles
+	=============================================================================
+*/
+
+package core
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Default side of the tile in pixels
+const DefaultTileSize = 640
+
+// Smallest side of the tile in pixels
+const minTileSize = 64
+
+// Largest part of the tile shared with the next one
+const maxTileOverlap = 0.9
+
+// Default part of the box left in the tile to keep the label
+const DefaultTileMinVisible = 0.25
+
+// Settings of the slicing, zero size and minimum visible part are replaced by the defaults
+type TileOptions struct {
+	Size       int     `json:"size,omitempty"`        // Side of the tile in pixels
+	Overlap    float64 `json:"overlap"`               // Part of the tile shared with the next one
+	MinVisible float64 `json:"min_visible,omitempty"` // Part of the box left in the tile to keep the label
+	Background float64 `json:"background"`            // Part of the tiles without labels kept as background
+	Seed       int64   `json:"seed"`                  // Seed of the choice of the background tiles
+}
+
+// Result of the slicing in the version manifest
+type TileRecipe struct {
+	TileOptions
+	Images  int `json:"images"`  // Images cut into tiles
+	Tiles   int `json:"tiles"`   // Written tiles with the background ones
+	Empty   int `json:"empty"`   // Tiles without labels kept as background
+	Skipped int `json:"skipped"` // Tiles without labels which are dropped
+	Dropped int `json:"dropped"` // Labels which are not kept by any tile
+
+	Undecoded int `json:"undecoded"` // Images which cannot be decoded and are kept full size
+}
+
+// Image which can give its part without copying
+type subImager interface {
+	SubImage(area image.Rectangle) image.Image
+}
+
+/****************************************************************************************
+ *
+ * Function : TileOptions.Resolve
+ *
+ * Purpose : Check the settings and fill the defaults
+ *
+ *  Return : TileOptions - settings with the defaults
+ *			 error - ErrInvalidTiling if settings are not valid
+ */
+func (options TileOptions) Resolve() (TileOptions, error) {
+	if options.Size == 0 {
+		options.Size = DefaultTileSize
+	}
+	if options.MinVisible == 0 {
+		options.MinVisible = DefaultTileMinVisible
+	}
+
+	switch {
+	case options.Size < minTileSize || options.Size > MaxPreprocessSize:
+		return options, fmt.Errorf("%w: size must be from %v to %v", ErrInvalidTiling, minTileSize, MaxPreprocessSize)
+	case options.Overlap < 0 || options.Overlap > maxTileOverlap:
+		return options, fmt.Errorf("%w: overlap must be from 0 to %v", ErrInvalidTiling, maxTileOverlap)
+	case options.MinVisible < 0 || options.MinVisible > 1:
+		return options, fmt.Errorf("%w: min_visible must be above 0 and up to 1", ErrInvalidTiling)
+	case options.Background < 0 || options.Background > 1:
+		return options, fmt.Errorf("%w: background must be from 0 to 1", ErrInvalidTiling)
+	}
+
+	return options, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : tileSplit
+ *
+ * Purpose : Replace images of the version split larger than the tile by their tiles
+ *			 Images are taken by name, so the same seed gives the same background tiles
+ *
+ *   Input : target string - path to the split folder in the version
+ *			 options TileOptions - resolved settings
+ *			 recipe *TileRecipe - counters of the version
+ *			 random *rand.Rand - choice of the background tiles
+ *
+ *  Return : LocationStats - images, labelled images and boxes of the split after slicing
+ *			 error - error if occur
+ */
+func tileSplit(target string, options TileOptions, recipe *TileRecipe, random *rand.Rand) (LocationStats, error) {
+	stats := LocationStats{}
+
+	entries, err := os.ReadDir(filepath.Join(target, ImagesFolder))
+	if err != nil {
+		return stats, err
+	}
+
+	count := func(labels []Label) {
+		stats.Images++
+		if len(labels) > 0 {
+			stats.Labelled++
+			stats.Boxes += len(labels)
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !IsImageFile(entry.Name()) {
+			continue
+		}
+		imagePath := filepath.Join(target, ImagesFolder, entry.Name())
+		labelPath := filepath.Join(target, LabelsFolder, LabelFileName(entry.Name()))
+
+		labels := []Label{}
+		content, err := os.ReadFile(labelPath)
+		if err == nil {
+			if labels, err = ParseLabels(content); err != nil {
+				return stats, fmt.Errorf("%v: %w", entry.Name(), err)
+			}
+		} else if !os.IsNotExist(err) {
+			return stats, err
+		}
+
+		// Image which cannot be decoded or fits the tile is kept as is, not decoded is reported
+		picture, err := decodeImage(imagePath)
+		if err != nil {
+			recipe.Undecoded++
+			count(labels)
+			continue
+		}
+		bounds := picture.Bounds()
+		width, height := bounds.Dx(), bounds.Dy()
+		if width <= options.Size && height <= options.Size {
+			count(labels)
+			continue
+		}
+		parts, ok := picture.(subImager)
+		if !ok {
+			parts = toRGBA(picture)
+			bounds = image.Rect(0, 0, width, height)
+		}
+
+		base := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		extension := filepath.Ext(encodedName(entry.Name()))
+		kept := make([]bool, len(labels))
+		for _, top := range tileOffsets(height, options.Size, options.Overlap) {
+			for _, left := range tileOffsets(width, options.Size, options.Overlap) {
+				area := image.Rect(left, top, left+options.Size, top+options.Size).Intersect(image.Rect(0, 0, width, height))
+
+				// Labels are moved one by one to know which of them are not kept by any tile
+				tileLabels := []Label{}
+				for index, label := range labels {
+					moved, _ := moveLabels([]Label{label}, func(x float64, y float64) (float64, float64) {
+						return (x*float64(width) - float64(area.Min.X)) / float64(area.Dx()), (y*float64(height) - float64(area.Min.Y)) / float64(area.Dy())
+					}, options.MinVisible)
+					if len(moved) > 0 {
+						kept[index] = true
+						tileLabels = append(tileLabels, moved...)
+					}
+				}
+
+				if len(tileLabels) == 0 {
+					if random.Float64() >= options.Background {
+						recipe.Skipped++
+						continue
+					}
+					recipe.Empty++
+				}
+
+				name := fmt.Sprintf("%v_tile_%v_%v%v", base, left, top, extension)
+				if err := writeImageFile(filepath.Join(target, ImagesFolder, name), parts.SubImage(area.Add(bounds.Min))); err != nil {
+					return stats, err
+				}
+				if len(tileLabels) > 0 {
+					if err := os.WriteFile(filepath.Join(target, LabelsFolder, LabelFileName(name)), FormatLabels(tileLabels), 0644); err != nil {
+						return stats, err
+					}
+				}
+				recipe.Tiles++
+				count(tileLabels)
+			}
+		}
+		for _, found := range kept {
+			if !found {
+				recipe.Dropped++
+			}
+		}
+
+		if err := os.Remove(imagePath); err != nil {
+			return stats, err
+		}
+		if err := os.Remove(labelPath); err != nil && !os.IsNotExist(err) {
+			return stats, err
+		}
+		recipe.Images++
+	}
+
+	return stats, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : tileOffsets
+ *
+ * Purpose : Get offsets of the tiles along the side, the last tile ends at the edge
+ *
+ *   Input : length int - side of the image
+ *			 size int - side of the tile
+ *			 overlap float64 - part of the tile shared with the next one
+ *
+ *  Return : []int - offsets of the tiles
+ */
+func tileOffsets(length int, size int, overlap float64) []int {
+	if length <= size {
+		return []int{0}
+	}
+
+	step := int(float64(size) * (1 - overlap))
+	if step < 1 {
+		step = 1
+	}
+
+	offsets := []int{}
+	for offset := 0; offset+size < length; offset += step {
+		offsets = append(offsets, offset)
+	}
+
+	return append(offsets, length-size)
+}
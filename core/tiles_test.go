@@ -0,0 +1,55 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: tiles_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the tiles placement
+
+	In the file
+		1. TestTileOffsets - offsets of the overlapping tiles along the side
+	=============================================================================
+*/
+
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+/****************************************************************************************
+ *
+ * Function : TestTileOffsets
+ *
+ * Purpose : Check tiles cover the side and the last tile ends at the edge
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestTileOffsets(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   int
+		size     int
+		overlap  float64
+		expected []int
+	}{
+		{"side smaller than the tile", 500, 640, 0.2, []int{0}},
+		{"side equal to the tile", 640, 640, 0.2, []int{0}},
+		{"no overlap", 1280, 640, 0, []int{0, 640}},
+		{"no overlap, last tile moved back", 1500, 640, 0, []int{0, 640, 860}},
+		{"overlap", 1600, 640, 0.25, []int{0, 480, 960}},
+		{"overlap, last tile moved back", 1700, 640, 0.25, []int{0, 480, 960, 1060}},
+		{"full overlap moves by one pixel", 4, 2, 1, []int{0, 1, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offsets := tileOffsets(test.length, test.size, test.overlap)
+			if !reflect.DeepEqual(offsets, test.expected) {
+				t.Errorf("offsets %v, expected %v", offsets, test.expected)
+			}
+		})
+	}
+}
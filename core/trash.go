@@ -0,0 +1,328 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: trash.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Trash of the dataset for the deleted images
+
+	Every deleted image is stored in 'trash/<id>' folder together with the
+	label file and 'item.json' with the place and catalog record of the image.
+
+	In the file
+		1. TrashImage, TrashImages - move images with labels to the trash
+		2. ListTrash - deleted images, the latest first
+		3. RestoreTrash - move image back to its location
+		4. DeleteTrash, PurgeTrash - delete images from the trash forever
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Trash folder inside the dataset and description of the deleted image
+const TrashFolder = "trash"
+const TrashItemFileName = "item.json"
+
+// Default time to keep deleted images
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// Deleted image
+type TrashItem struct {
+	Id       string       `json:"id"`
+	Name     string       `json:"name"`
+	Location string       `json:"location"`
+	Deleted  time.Time    `json:"deleted"`
+	Size     int64        `json:"size"`
+	Labelled bool         `json:"labelled"`
+	Record   *ImageRecord `json:"record,omitempty"` // Catalog record to restore tags and review
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.TrashPath
+ *
+ * Purpose : Get path to the trash folder or the deleted image folder
+ *
+ *   Input : id string - id of the deleted image, empty for the trash folder
+ *
+ *  Return : string - path on the drive
+ */
+func (dataset Dataset) TrashPath(id string) string {
+	return filepath.Join(dataset.Path, TrashFolder, id)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.TrashImage
+ *
+ * Purpose : Move image together with the label to the trash
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *
+ *  Return : TrashItem - deleted image
+ *			 error - error if occur
+ */
+func (dataset Dataset) TrashImage(location string, name string) (TrashItem, error) {
+	image, err := dataset.StatImage(location, name)
+	if err != nil {
+		return TrashItem{}, err
+	}
+
+	item := TrashItem{Name: name, Location: location, Deleted: time.Now(), Size: image.Size, Labelled: image.Labelled}
+	if record, err := dataset.GetImageRecord(location, name); err == nil {
+		item.Record = &record
+	}
+
+	// Id is unique and sorted by the time of deletion
+	item.Id = strconv.FormatInt(item.Deleted.UnixNano(), 36)
+	for suffix := 1; ; suffix++ {
+		if _, err := os.Stat(dataset.TrashPath(item.Id)); os.IsNotExist(err) {
+			break
+		}
+		item.Id = strconv.FormatInt(item.Deleted.UnixNano(), 36) + "-" + strconv.Itoa(suffix)
+	}
+	itemPath := dataset.TrashPath(item.Id)
+	if err := os.MkdirAll(itemPath, os.ModePerm); err != nil {
+		return TrashItem{}, err
+	}
+
+	content, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return TrashItem{}, err
+	}
+	if err := writeFileAtomic(filepath.Join(itemPath, TrashItemFileName), bytes.NewReader(content)); err != nil {
+		os.RemoveAll(itemPath)
+		return TrashItem{}, err
+	}
+
+	// Image is moved back if the label cannot follow it
+	imagePath, _ := dataset.ImagePath(location, name)
+	if err := os.Rename(imagePath, filepath.Join(itemPath, name)); err != nil {
+		os.RemoveAll(itemPath)
+		return TrashItem{}, err
+	}
+	labelPath, _ := dataset.LabelPath(location, name)
+	if err := os.Rename(labelPath, filepath.Join(itemPath, LabelFileName(name))); err != nil && !os.IsNotExist(err) {
+		if os.Rename(filepath.Join(itemPath, name), imagePath) == nil {
+			os.RemoveAll(itemPath)
+		}
+		return TrashItem{}, err
+	}
+	dataset.refreshCatalog(location, name)
+
+	return item, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.TrashImages
+ *
+ * Purpose : Move several images of the location to the trash
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 names []string - images file names
+ *
+ *  Return : BulkResult - number of deleted images and failed ones
+ */
+func (dataset Dataset) TrashImages(location string, names []string) BulkResult {
+	result := BulkResult{Failed: make(map[string]string)}
+
+	for _, name := range names {
+		if _, err := dataset.TrashImage(location, name); err != nil {
+			result.Failed[name] = err.Error()
+			continue
+		}
+		result.Done++
+	}
+
+	return result
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ListTrash
+ *
+ * Purpose : Get deleted images, the latest first
+ *
+ *   Input : Nothing
+ *
+ *  Return : []TrashItem - deleted images
+ *			 error - error if occur
+ */
+func (dataset Dataset) ListTrash() ([]TrashItem, error) {
+	items := []TrashItem{}
+
+	entries, err := os.ReadDir(dataset.TrashPath(""))
+	if os.IsNotExist(err) {
+		return items, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		item, err := dataset.readTrashItem(entry.Name())
+		if err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Deleted.After(items[j].Deleted)
+	})
+
+	return items, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.readTrashItem
+ *
+ * Purpose : Read description of the deleted image
+ *
+ *   Input : id string - id of the deleted image
+ *
+ *  Return : TrashItem - deleted image
+ *			 error - ErrImageNotFound if item is not in the trash
+ */
+func (dataset Dataset) readTrashItem(id string) (TrashItem, error) {
+	if !IsValidName(id) {
+		return TrashItem{}, ErrInvalidName
+	}
+
+	content, err := os.ReadFile(filepath.Join(dataset.TrashPath(id), TrashItemFileName))
+	if os.IsNotExist(err) {
+		return TrashItem{}, ErrImageNotFound
+	}
+	if err != nil {
+		return TrashItem{}, err
+	}
+
+	item := TrashItem{}
+	if err := json.Unmarshal(content, &item); err != nil {
+		return TrashItem{}, err
+	}
+	item.Id = id
+
+	return item, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.RestoreTrash
+ *
+ * Purpose : Move deleted image with the label back to its location
+ *
+ *   Input : id string - id of the deleted image
+ *
+ *  Return : ImageFile - restored image
+ *			 error - ErrImageExists if location has image with the same name
+ */
+func (dataset Dataset) RestoreTrash(id string) (ImageFile, error) {
+	item, err := dataset.readTrashItem(id)
+	if err != nil {
+		return ImageFile{}, err
+	}
+
+	imagePath, err := dataset.ImagePath(item.Location, item.Name)
+	if err != nil {
+		return ImageFile{}, err
+	}
+	if _, err := os.Stat(imagePath); err == nil {
+		return ImageFile{}, ErrImageExists
+	}
+
+	// Image is moved first and back to the trash if the label cannot follow it
+	itemPath := dataset.TrashPath(id)
+	if err := os.MkdirAll(filepath.Dir(imagePath), os.ModePerm); err != nil {
+		return ImageFile{}, err
+	}
+	if err := os.Rename(filepath.Join(itemPath, item.Name), imagePath); err != nil {
+		return ImageFile{}, err
+	}
+	labelPath, _ := dataset.LabelPath(item.Location, item.Name)
+	err = os.MkdirAll(filepath.Dir(labelPath), os.ModePerm)
+	if err == nil {
+		err = os.Rename(filepath.Join(itemPath, LabelFileName(item.Name)), labelPath)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		os.Rename(imagePath, filepath.Join(itemPath, item.Name))
+		return ImageFile{}, err
+	}
+
+	if err := os.RemoveAll(itemPath); err != nil {
+		return ImageFile{}, err
+	}
+
+	if item.Record != nil {
+		dataset.restoreCatalogRecord(*item.Record)
+	} else {
+		dataset.refreshCatalog(item.Location, item.Name)
+	}
+
+	return dataset.StatImage(item.Location, item.Name)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.DeleteTrash
+ *
+ * Purpose : Delete image from the trash forever
+ *
+ *   Input : id string - id of the deleted image
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) DeleteTrash(id string) error {
+	if _, err := dataset.readTrashItem(id); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dataset.TrashPath(id))
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.PurgeTrash
+ *
+ * Purpose : Delete images kept in the trash longer than retention time
+ *
+ *   Input : retention time.Duration - time to keep images, 0 to delete all
+ *
+ *  Return : int - number of deleted images
+ *			 error - error if occur
+ */
+func (dataset Dataset) PurgeTrash(retention time.Duration) (int, error) {
+	items, err := dataset.ListTrash()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	deadline := time.Now().Add(-retention)
+	for _, item := range items {
+		if item.Deleted.After(deadline) {
+			continue
+		}
+		if err := os.RemoveAll(dataset.TrashPath(item.Id)); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
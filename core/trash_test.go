@@ -0,0 +1,192 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: trash_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the images moved to the trash and restored
+
+	In the file
+		1. TestTrashRestore - image with the label and catalog record restored
+		   or kept in the trash when restore fails
+		2. TestPurgeTrash - only images kept longer than retention are deleted
+	=============================================================================
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/****************************************************************************************
+ *
+ * Function : TestTrashRestore
+ *
+ * Purpose : Check image goes to the trash with the label and comes back with the
+ *			 label, tags and review, failed restore leaves the image in the trash
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestTrashRestore(t *testing.T) {
+	tests := []struct {
+		name     string
+		label    string
+		tags     []string
+		reviewed bool
+		prepare  func(t *testing.T, dataset Dataset) // Change the location before the restore
+		err      error
+	}{
+		{name: "labelled image", label: "0 0.5 0.5 0.2 0.2\n", tags: []string{"night"}, reviewed: true},
+		{name: "unlabelled image", tags: []string{}},
+		{
+			name:  "image with the same name stored",
+			label: "0 0.5 0.5 0.2 0.2\n",
+			tags:  []string{},
+			prepare: func(t *testing.T, dataset Dataset) {
+				writeTestImage(t, dataset, SplitTrain, "a.png", "")
+			},
+			err: ErrImageExists,
+		},
+		{
+			name:  "label cannot be restored",
+			label: "0 0.5 0.5 0.2 0.2\n",
+			tags:  []string{},
+			prepare: func(t *testing.T, dataset Dataset) {
+				// Folder with the label name is not replaced by the label file
+				labelPath, _ := dataset.LabelPath(SplitTrain, "a.png")
+				os.MkdirAll(labelPath+"/blocked", os.ModePerm)
+			},
+			err: errors.New("any"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTestDatasets(t)
+			dataset := createTestDataset(t, "cars", "car")
+			writeTestImage(t, dataset, SplitTrain, "a.png", test.label)
+			dataset.SyncCatalog(false)
+			if _, err := dataset.UpdateImageRecord(SplitTrain, "a.png", test.tags, &test.reviewed); err != nil {
+				t.Fatal(err)
+			}
+
+			item, err := dataset.TrashImage(SplitTrain, "a.png")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := dataset.StatImage(SplitTrain, "a.png"); err != ErrImageNotFound {
+				t.Errorf("trashed image is in the location: %v", err)
+			}
+			if _, err := dataset.GetImageRecord(SplitTrain, "a.png"); err != ErrImageNotFound {
+				t.Errorf("trashed image is in the catalog: %v", err)
+			}
+			if items, _ := dataset.ListTrash(); len(items) != 1 || items[0].Id != item.Id || items[0].Labelled != (test.label != "") {
+				t.Fatalf("trash %+v", items)
+			}
+
+			if test.prepare != nil {
+				test.prepare(t, dataset)
+			}
+			image, err := dataset.RestoreTrash(item.Id)
+			if test.err != nil {
+				if err == nil || (test.err == ErrImageExists && err != ErrImageExists) {
+					t.Fatalf("restore error %v, expected %v", err, test.err)
+				}
+				// Image and label are kept in the trash to restore them later
+				restored, readErr := dataset.readTrashItem(item.Id)
+				if readErr != nil || restored.Id != item.Id {
+					t.Fatalf("trash item is lost: %v", readErr)
+				}
+				for _, file := range []string{item.Name, LabelFileName(item.Name)} {
+					if _, err := os.Stat(dataset.TrashPath(item.Id) + "/" + file); err != nil {
+						t.Errorf("trash has no '%v': %v", file, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if image.Labelled != (test.label != "") {
+				t.Errorf("restored image labelled %v", image.Labelled)
+			}
+			if labels, _ := dataset.ReadLabels(SplitTrain, "a.png"); (len(labels) == 1) != (test.label != "") {
+				t.Errorf("restored labels %+v", labels)
+			}
+			record, err := dataset.GetImageRecord(SplitTrain, "a.png")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(record.Tags, test.tags) || (record.LabelStatus == LabelReviewed) != test.reviewed {
+				t.Errorf("restored record tags %v, status %v", record.Tags, record.LabelStatus)
+			}
+			if _, err := os.Stat(dataset.TrashPath(item.Id)); !os.IsNotExist(err) {
+				t.Errorf("trash item is not removed: %v", err)
+			}
+		})
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : TestPurgeTrash
+ *
+ * Purpose : Check purge deletes images kept longer than retention and keeps others
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestPurgeTrash(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		purged    int
+		kept      int
+	}{
+		{"default retention", DefaultTrashRetention, 1, 1},
+		{"longer retention", 2 * DefaultTrashRetention, 0, 2},
+		{"delete all", 0, 2, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTestDatasets(t)
+			dataset := createTestDataset(t, "cars", "car")
+			writeTestImage(t, dataset, LocationUploaded, "old.png", "")
+			writeTestImage(t, dataset, LocationUploaded, "new.png", "")
+			old, _ := dataset.TrashImage(LocationUploaded, "old.png")
+			if _, err := dataset.TrashImage(LocationUploaded, "new.png"); err != nil {
+				t.Fatal(err)
+			}
+
+			// Image deleted after the default retention
+			old.Deleted = time.Now().Add(-DefaultTrashRetention - time.Hour)
+			content, _ := json.Marshal(old)
+			os.WriteFile(filepath.Join(dataset.TrashPath(old.Id), TrashItemFileName), content, 0644)
+
+			purged, err := dataset.PurgeTrash(test.retention)
+			if err != nil {
+				t.Fatal(err)
+			}
+			items, _ := dataset.ListTrash()
+			if purged != test.purged || len(items) != test.kept {
+				t.Errorf("purged %v, kept %v, expected %v and %v", purged, len(items), test.purged, test.kept)
+			}
+			for _, item := range items {
+				if test.purged == 1 && item.Name != "new.png" {
+					t.Errorf("kept '%v' deleted %v", item.Name, item.Deleted)
+				}
+			}
+		})
+	}
+}
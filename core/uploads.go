@@ -0,0 +1,481 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: uploads.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Resumable uploads of the images written by chunks
+
+	Every upload is stored in the 'uploads' folder of the dataset as
+	'<id>.part' with the received bytes and '<id>.json' with the offset.
+	Completed upload is moved to the image location, its description is
+	kept until expiration to answer the client which lost the last response.
+
+	Checksum is '<algorithm> <base64 digest>', algorithms md5, sha1 and sha256.
+
+	In the file
+		1. CreateUpload - start upload of the image with known size
+		2. GetUpload - upload with the received offset
+		3. WriteUpload - append chunk, complete the image at the end
+		4. DeleteUpload, PurgeUploads - remove cancelled or expired uploads
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Uploads folder inside the dataset
+const UploadsFolder = "uploads"
+
+// Biggest image accepted by the resumable upload
+const MaxUploadSize = 512 << 20
+
+// Time to keep the upload after the last chunk
+const UploadExpiration = 24 * time.Hour
+
+// Checksum algorithms of the chunks and the whole image
+var ChecksumAlgorithms = []string{"md5", "sha1", "sha256"}
+
+// Resumable upload of the image
+type Upload struct {
+	Id        string    `json:"id"`
+	Location  string    `json:"location"`
+	Name      string    `json:"name"`
+	Length    int64     `json:"length"`
+	Offset    int64     `json:"offset"`
+	Checksum  string    `json:"checksum,omitempty"` // Checksum of the whole image verified on completion
+	Metadata  string    `json:"metadata,omitempty"` // Metadata of the client as received
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+	Completed bool      `json:"completed"`
+}
+
+// Uploads receiving the chunk, the same upload cannot be written in parallel
+var uploadLocks = struct {
+	mutex sync.Mutex
+	busy  map[string]bool
+}{busy: make(map[string]bool)}
+
+/****************************************************************************************
+ *
+ * Function : Upload.Expires
+ *
+ * Purpose : Get time when the upload is removed
+ *
+ *   Input : Nothing
+ *
+ *  Return : time.Time - expiration time
+ */
+func (upload Upload) Expires() time.Time {
+	return upload.Updated.Add(UploadExpiration)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.CreateUpload
+ *
+ * Purpose : Start upload of the image, nothing is changed in the location until completion
+ *
+ *   Input : location string - 'uploaded' or one of the splits
+ *			 name string - image file name
+ *			 length int64 - size of the image
+ *			 checksum string - checksum of the whole image, can be empty
+ *			 metadata string - metadata of the client to keep
+ *
+ *  Return : Upload - created upload
+ *			 error - ErrImageExists, ErrInvalidUploadLength, ErrUploadTooLarge, ErrInvalidChecksum or name errors
+ */
+func (dataset Dataset) CreateUpload(location string, name string, length int64, checksum string, metadata string) (Upload, error) {
+	imagePath, err := dataset.ImagePath(location, name)
+	if err != nil {
+		return Upload{}, err
+	}
+	if _, err := os.Stat(imagePath); err == nil {
+		return Upload{}, ErrImageExists
+	}
+	if length <= 0 {
+		return Upload{}, ErrInvalidUploadLength
+	}
+	if length > MaxUploadSize {
+		return Upload{}, ErrUploadTooLarge
+	}
+	if checksum != "" {
+		if _, _, err := parseChecksum(checksum); err != nil {
+			return Upload{}, err
+		}
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return Upload{}, err
+	}
+
+	upload := Upload{
+		Id:       hex.EncodeToString(random),
+		Location: location,
+		Name:     name,
+		Length:   length,
+		Checksum: checksum,
+		Metadata: metadata,
+		Created:  time.Now(),
+		Updated:  time.Now()}
+
+	if err := os.MkdirAll(dataset.uploadPath(""), os.ModePerm); err != nil {
+		return Upload{}, err
+	}
+	part, err := os.Create(dataset.uploadPath(upload.Id + ".part"))
+	if err != nil {
+		return Upload{}, err
+	}
+	part.Close()
+
+	if err := dataset.saveUpload(upload); err != nil {
+		os.Remove(dataset.uploadPath(upload.Id + ".part"))
+		return Upload{}, err
+	}
+
+	return upload, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.GetUpload
+ *
+ * Purpose : Get upload with the received offset
+ *
+ *   Input : id string - upload id
+ *
+ *  Return : Upload - upload
+ *			 error - ErrUploadNotFound if upload is not exists or expired
+ */
+func (dataset Dataset) GetUpload(id string) (Upload, error) {
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		return Upload{}, ErrUploadNotFound
+	}
+
+	content, err := os.ReadFile(dataset.uploadPath(id + ".json"))
+	if os.IsNotExist(err) {
+		return Upload{}, ErrUploadNotFound
+	}
+	if err != nil {
+		return Upload{}, err
+	}
+
+	var upload Upload
+	if err := json.Unmarshal(content, &upload); err != nil {
+		return Upload{}, err
+	}
+	if time.Now().After(upload.Expires()) {
+		return Upload{}, ErrUploadNotFound
+	}
+
+	return upload, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.WriteUpload
+ *
+ * Purpose : Append chunk to the upload, received bytes are kept when the source fails
+ *			 Completed upload is checked by the checksum and moved to the image location
+ *
+ *   Input : id string - upload id
+ *			 offset int64 - offset of the chunk, must be equal to the received bytes
+ *			 source io.Reader - chunk
+ *			 checksum string - checksum of the chunk, can be empty
+ *
+ *  Return : Upload - upload with the new offset
+ *			 error - ErrUploadNotFound, ErrUploadOffset, ErrUploadLocked, ErrUploadTooLarge,
+ *					 ErrInvalidChecksum, ErrChecksumMismatch or the source error
+ */
+func (dataset Dataset) WriteUpload(id string, offset int64, source io.Reader, checksum string) (Upload, error) {
+	key := dataset.Name + "/" + id
+	uploadLocks.mutex.Lock()
+	if uploadLocks.busy[key] {
+		uploadLocks.mutex.Unlock()
+		return Upload{}, ErrUploadLocked
+	}
+	uploadLocks.busy[key] = true
+	uploadLocks.mutex.Unlock()
+
+	defer func() {
+		uploadLocks.mutex.Lock()
+		delete(uploadLocks.busy, key)
+		uploadLocks.mutex.Unlock()
+	}()
+
+	upload, err := dataset.GetUpload(id)
+	if err != nil {
+		return Upload{}, err
+	}
+	if offset != upload.Offset || (upload.Completed && offset != upload.Length) {
+		return upload, ErrUploadOffset
+	}
+	if upload.Completed {
+		return upload, nil
+	}
+
+	var chunkHash hash.Hash
+	var expected []byte
+	if checksum != "" {
+		if chunkHash, expected, err = parseChecksum(checksum); err != nil {
+			return upload, err
+		}
+	}
+
+	part, err := os.OpenFile(dataset.uploadPath(id+".part"), os.O_WRONLY, 0644)
+	if err != nil {
+		return upload, err
+	}
+	if _, err := part.Seek(offset, io.SeekStart); err != nil {
+		part.Close()
+		return upload, err
+	}
+
+	// One byte more than left is read to find the chunk bigger than the upload
+	var writer io.Writer = part
+	if chunkHash != nil {
+		writer = io.MultiWriter(part, chunkHash)
+	}
+	written, copyErr := io.Copy(writer, io.LimitReader(source, upload.Length-offset+1))
+
+	switch {
+	case copyErr == nil && offset+written > upload.Length:
+		copyErr = ErrUploadTooLarge
+	case copyErr == nil && chunkHash != nil && !bytes.Equal(chunkHash.Sum(nil), expected):
+		copyErr = ErrChecksumMismatch
+	}
+
+	// Not verified chunk is dropped, broken chunk without checksum keeps received bytes
+	if copyErr != nil && (chunkHash != nil || copyErr == ErrUploadTooLarge) {
+		written = 0
+	}
+	if err := part.Truncate(offset + written); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if err := part.Sync(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	part.Close()
+
+	upload.Offset = offset + written
+	upload.Updated = time.Now()
+	if upload.Offset == upload.Length && copyErr == nil {
+		if err := dataset.completeUpload(&upload); err != nil {
+			return upload, err
+		}
+	}
+	if err := dataset.saveUpload(upload); err != nil && copyErr == nil {
+		copyErr = err
+	}
+
+	return upload, copyErr
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.DeleteUpload
+ *
+ * Purpose : Remove the upload with received bytes
+ *
+ *   Input : id string - upload id
+ *
+ *  Return : error - ErrUploadNotFound if upload is not exists
+ */
+func (dataset Dataset) DeleteUpload(id string) error {
+	if _, err := dataset.GetUpload(id); err != nil {
+		return err
+	}
+
+	os.Remove(dataset.uploadPath(id + ".part"))
+	return os.Remove(dataset.uploadPath(id + ".json"))
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.PurgeUploads
+ *
+ * Purpose : Remove uploads without chunks for longer than expiration time
+ *
+ *   Input : Nothing
+ *
+ *  Return : int - number of removed uploads
+ *			 error - error if uploads folder cannot be read
+ */
+func (dataset Dataset) PurgeUploads() (int, error) {
+	entries, err := os.ReadDir(dataset.uploadPath(""))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		if id == entry.Name() {
+			continue
+		}
+		if _, err := dataset.GetUpload(id); err != ErrUploadNotFound {
+			continue
+		}
+		os.Remove(dataset.uploadPath(id + ".part"))
+		if os.Remove(dataset.uploadPath(id+".json")) == nil {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.completeUpload
+ *
+ * Purpose : Check checksum of the received image and move it to the location
+ *			 Upload with the wrong checksum, the existing image name or the image
+ *			 which cannot be processed is removed
+ *
+ *   Input : upload *Upload - upload with all bytes received
+ *
+ *  Return : error - ErrChecksumMismatch, ErrImageExists, processing error
+ *			 or error if image cannot be moved
+ */
+func (dataset Dataset) completeUpload(upload *Upload) error {
+	partPath := dataset.uploadPath(upload.Id + ".part")
+
+	if upload.Checksum != "" {
+		fileHash, expected, _ := parseChecksum(upload.Checksum)
+		part, err := os.Open(partPath)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fileHash, part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(fileHash.Sum(nil), expected) {
+			os.Remove(partPath)
+			os.Remove(dataset.uploadPath(upload.Id + ".json"))
+			return ErrChecksumMismatch
+		}
+	}
+
+	imagePath, err := dataset.ImagePath(upload.Location, upload.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(imagePath), os.ModePerm); err != nil {
+		return err
+	}
+	// Image with the same name could be stored while the upload was received
+	if err := publishFile(partPath, imagePath); err != nil {
+		os.Remove(dataset.uploadPath(upload.Id + ".json"))
+		if os.IsExist(err) {
+			return ErrImageExists
+		}
+		return err
+	}
+
+	// Image which cannot be processed is removed as the image of the form
+	if err := dataset.ProcessImages(upload.Location, upload.Name); err != nil {
+		dataset.removeImages(upload.Location, []string{upload.Name})
+		os.Remove(dataset.uploadPath(upload.Id + ".json"))
+		return err
+	}
+
+	upload.Completed = true
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.saveUpload
+ *
+ * Purpose : Store description of the upload
+ *
+ *   Input : upload Upload - upload
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) saveUpload(upload Upload) error {
+	content, err := json.MarshalIndent(upload, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(dataset.uploadPath(upload.Id+".json"), bytes.NewReader(content))
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.uploadPath
+ *
+ * Purpose : Get path to the file of the upload
+ *
+ *   Input : name string - file name, empty for the uploads folder
+ *
+ *  Return : string - path on the drive
+ */
+func (dataset Dataset) uploadPath(name string) string {
+	return filepath.Join(dataset.Path, UploadsFolder, name)
+}
+
+/****************************************************************************************
+ *
+ * Function : parseChecksum
+ *
+ * Purpose : Parse checksum in '<algorithm> <base64 digest>' format
+ *
+ *   Input : checksum string - checksum
+ *
+ *  Return : hash.Hash - new hash of the algorithm
+ *			 []byte - expected digest
+ *			 error - ErrInvalidChecksum if algorithm is not known or digest is not valid
+ */
+func parseChecksum(checksum string) (hash.Hash, []byte, error) {
+	parts := strings.SplitN(strings.TrimSpace(checksum), " ", 2)
+	if len(parts) != 2 {
+		return nil, nil, ErrInvalidChecksum
+	}
+
+	digest, err := base64.StdEncoding.DecodeString(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, nil, ErrInvalidChecksum
+	}
+
+	var checksumHash hash.Hash
+	switch strings.ToLower(parts[0]) {
+	case "md5":
+		checksumHash = md5.New()
+	case "sha1":
+		checksumHash = sha1.New()
+	case "sha256":
+		checksumHash = sha256.New()
+	default:
+		return nil, nil, ErrInvalidChecksum
+	}
+	if len(digest) != checksumHash.Size() {
+		return nil, nil, ErrInvalidChecksum
+	}
+
+	return checksumHash, digest, nil
+}
@@ -0,0 +1,144 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: uploads_test.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Tests of the resumable uploads
+
+	In the file
+		1. TestWriteUpload - chunks, completion and the rejected uploads
+	=============================================================================
+*/
+
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"testing"
+)
+
+// Any error of the image processing
+var errProcessing = errors.New("processing error")
+
+/****************************************************************************************
+ *
+ * Function : TestWriteUpload
+ *
+ * Purpose : Check image received by chunks is moved to the location once, upload with
+ *			 the wrong checksum, the stored name or the failed processing is removed
+ *			 without changing the location
+ *
+ *   Input : t *testing.T - test
+ *
+ *  Return : Nothing
+ */
+func TestWriteUpload(t *testing.T) {
+	image := testImage()
+	digest := sha256.Sum256(image)
+	checksum := "sha256 " + base64.StdEncoding.EncodeToString(digest[:])
+
+	tests := []struct {
+		name     string
+		content  []byte
+		checksum string
+		prepare  func(t *testing.T, dataset Dataset) // Change the location before the last chunk
+		err      error
+	}{
+		{name: "completed", content: image, checksum: checksum},
+		{name: "completed without checksum", content: image},
+		{name: "wrong checksum", content: image, checksum: "sha256 " + base64.StdEncoding.EncodeToString(make([]byte, 32)), err: ErrChecksumMismatch},
+		{
+			name:    "image stored while received",
+			content: image,
+			prepare: func(t *testing.T, dataset Dataset) {
+				writeTestImage(t, dataset, LocationUploaded, "a.png", "")
+			},
+			err: ErrImageExists,
+		},
+		{
+			name:    "image cannot be processed",
+			content: image,
+			prepare: func(t *testing.T, dataset Dataset) {
+				// Folder with the thumbnail name is not replaced by the thumbnail
+				thumbnailPath, _ := dataset.ThumbnailPath(LocationUploaded, "a.png")
+				os.MkdirAll(thumbnailPath+"/blocked", os.ModePerm)
+			},
+			err: errProcessing,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTestDatasets(t)
+			dataset := createTestDataset(t, "cars", "car")
+
+			upload, err := dataset.CreateUpload(LocationUploaded, "a.png", int64(len(test.content)), test.checksum, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Chunk with the wrong offset is refused, the first half is received
+			half := int64(len(test.content) / 2)
+			if _, err := dataset.WriteUpload(upload.Id, 1, bytes.NewReader(test.content), ""); err != ErrUploadOffset {
+				t.Errorf("chunk with the wrong offset: %v", err)
+			}
+			if upload, err = dataset.WriteUpload(upload.Id, 0, bytes.NewReader(test.content[:half]), ""); err != nil || upload.Offset != half || upload.Completed {
+				t.Fatalf("first chunk %+v: %v", upload, err)
+			}
+			if _, err := dataset.StatImage(LocationUploaded, "a.png"); err != ErrImageNotFound {
+				t.Errorf("image is stored before the last chunk: %v", err)
+			}
+
+			if test.prepare != nil {
+				test.prepare(t, dataset)
+			}
+			upload, err = dataset.WriteUpload(upload.Id, half, bytes.NewReader(test.content[half:]), "")
+			if test.err != nil {
+				if err == nil || (test.err != errProcessing && !errors.Is(err, test.err)) {
+					t.Fatalf("last chunk error %v, expected %v", err, test.err)
+				}
+				if _, err := dataset.GetUpload(upload.Id); err != ErrUploadNotFound {
+					t.Errorf("rejected upload is kept: %v", err)
+				}
+				if _, err := os.Stat(dataset.uploadPath(upload.Id + ".part")); !os.IsNotExist(err) {
+					t.Errorf("received bytes are kept: %v", err)
+				}
+				// Image stored by another request is not replaced
+				stored, statErr := dataset.StatImage(LocationUploaded, "a.png")
+				if test.err == ErrImageExists && (statErr != nil || stored.Size != int64(len(image))) {
+					t.Errorf("stored image is changed: %v", statErr)
+				}
+				if test.err != ErrImageExists && statErr != ErrImageNotFound {
+					t.Errorf("rejected image is in the location: %v", statErr)
+				}
+				if _, err := dataset.GetImageRecord(LocationUploaded, "a.png"); test.err == errProcessing && err != ErrImageNotFound {
+					t.Errorf("rejected image is in the catalog: %v", err)
+				}
+				return
+			}
+			if err != nil || !upload.Completed || upload.Offset != upload.Length {
+				t.Fatalf("last chunk %+v: %v", upload, err)
+			}
+
+			if _, err := os.Stat(dataset.uploadPath(upload.Id + ".part")); !os.IsNotExist(err) {
+				t.Errorf("received bytes are kept: %v", err)
+			}
+			stored, err := dataset.StatImage(LocationUploaded, "a.png")
+			if err != nil || stored.Size != int64(len(image)) {
+				t.Fatalf("stored image %+v: %v", stored, err)
+			}
+			if _, err := dataset.GetImageRecord(LocationUploaded, "a.png"); err != nil {
+				t.Errorf("completed image is not in the catalog: %v", err)
+			}
+
+			// Repeated last chunk of the lost response answers the completed upload
+			if repeated, err := dataset.WriteUpload(upload.Id, upload.Length, bytes.NewReader(nil), ""); err != nil || !repeated.Completed {
+				t.Errorf("repeated completion %+v: %v", repeated, err)
+			}
+		})
+	}
+}
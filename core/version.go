@@ -0,0 +1,496 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: version.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/core
+	Purpose: Dataset versions - frozen copies of the splits used for training
+
+	Version folder has the same structure as the 'dataset' folder and manifest:
+		versions/<name>/data.yaml
+		versions/<name>/train|valid|test/images|labels
+		versions/<name>/manifest.json
+
+	Images are preprocessed first if asked, see preprocess.go, and then cut
+	into tiles, see tiles.go. Augmented copies of the result are added to
+	the train split only, see augment.go
+	=============================================================================
+*/
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name of the manifest file in the version folder
+const ManifestFileName = "manifest.json"
+
+// Options to create a version
+type VersionOptions struct {
+	Name       string             `json:"name"`                 // Empty name gives the next 'v<number>'
+	Preprocess *PreprocessOptions `json:"preprocess,omitempty"` // Orientation, size and colour of all images
+	Tile       *TileOptions       `json:"tile,omitempty"`       // Slicing of the large images into tiles
+	Augment    *AugmentOptions    `json:"augment,omitempty"`    // Augmentation of the training images
+}
+
+// Manifest of the version
+type VersionManifest struct {
+	Name    string                   `json:"name"`
+	Created time.Time                `json:"created"`
+	Classes []string                 `json:"classes"`
+	Images  int                      `json:"images"`
+	Boxes   int                      `json:"boxes"`
+	Splits  map[string]LocationStats `json:"splits"`
+
+	Preprocessing *PreprocessRecipe `json:"preprocessing,omitempty"` // Settings and result of the preprocessing
+	Tiling        *TileRecipe       `json:"tiling,omitempty"`        // Settings and result of the slicing
+	Augmentation  *AugmentRecipe    `json:"augmentation,omitempty"`  // Recipe and result, the copies are counted in the train split
+}
+
+/****************************************************************************************
+ *
+ * Function : VersionOptions.Validate
+ *
+ * Purpose : Check preprocessing, tiling and augmentation options before the job is queued
+ *
+ *   Input : Nothing
+ *
+ *  Return : error - ErrInvalidPreprocess, ErrInvalidTiling or ErrInvalidAugmentation
+ */
+func (options VersionOptions) Validate() error {
+	if options.Preprocess != nil {
+		if _, err := options.Preprocess.Resolve(); err != nil {
+			return err
+		}
+	}
+	if options.Tile != nil {
+		if _, err := options.Tile.Resolve(); err != nil {
+			return err
+		}
+	}
+	if options.Augment != nil {
+		if _, err := options.Augment.Resolve(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.VersionPath
+ *
+ * Purpose : Get path to the version folder
+ *
+ *   Input : name string - version name
+ *
+ *  Return : string - path to the version
+ */
+func (dataset Dataset) VersionPath(name string) string {
+	return filepath.Join(dataset.Path, VersionsFolder, name)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ListVersions
+ *
+ * Purpose : Get manifests of all versions, sorted by creation time
+ *
+ *   Input : Nothing
+ *
+ *  Return : []VersionManifest - versions
+ *			 error - error if occur
+ */
+func (dataset Dataset) ListVersions() ([]VersionManifest, error) {
+	entries, err := os.ReadDir(filepath.Join(dataset.Path, VersionsFolder))
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []VersionManifest{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		manifest, err := dataset.ReadVersion(entry.Name())
+		if err != nil {
+			continue
+		}
+		versions = append(versions, manifest)
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Created.Before(versions[j].Created) })
+	return versions, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ReadVersion
+ *
+ * Purpose : Read manifest of the version
+ *
+ *   Input : name string - version name
+ *
+ *  Return : VersionManifest - manifest
+ *			 error - error if occur
+ */
+func (dataset Dataset) ReadVersion(name string) (VersionManifest, error) {
+	var manifest VersionManifest
+	if !IsValidName(name) {
+		return manifest, ErrInvalidName
+	}
+
+	content, err := os.ReadFile(filepath.Join(dataset.VersionPath(name), ManifestFileName))
+	if err != nil {
+		return manifest, err
+	}
+
+	err = json.Unmarshal(content, &manifest)
+	return manifest, err
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.CreateVersion
+ *
+ * Purpose : Copy splits and data.yaml to the new version folder and write manifest
+ *			 Version is built in the temporary folder and renamed when completed
+ *
+ *   Input : options VersionOptions - version options
+ *
+ *  Return : VersionManifest - manifest of the created version
+ *			 error - error if occur
+ */
+func (dataset Dataset) CreateVersion(options VersionOptions) (VersionManifest, error) {
+	name := options.Name
+	if name == "" {
+		name = dataset.nextVersionName()
+	}
+	if !IsValidName(name) {
+		return VersionManifest{}, ErrInvalidName
+	}
+
+	var preprocess *PreprocessOptions
+	if options.Preprocess != nil {
+		resolved, err := options.Preprocess.Resolve()
+		if err != nil {
+			return VersionManifest{}, err
+		}
+		preprocess = &resolved
+	}
+	var tile *TileOptions
+	if options.Tile != nil {
+		resolved, err := options.Tile.Resolve()
+		if err != nil {
+			return VersionManifest{}, err
+		}
+		tile = &resolved
+	}
+	var augment *AugmentOptions
+	if options.Augment != nil {
+		resolved, err := options.Augment.Resolve()
+		if err != nil {
+			return VersionManifest{}, err
+		}
+		augment = &resolved
+	}
+
+	versionPath := dataset.VersionPath(name)
+	if _, err := os.Stat(versionPath); err == nil {
+		return VersionManifest{}, fmt.Errorf("version '%v' already exists", name)
+	}
+
+	dataFile, err := dataset.ReadDataFile()
+	if err != nil {
+		return VersionManifest{}, err
+	}
+
+	tempPath := filepath.Join(dataset.Path, VersionsFolder, ".tmp-"+name)
+	os.RemoveAll(tempPath)
+	if err := os.MkdirAll(tempPath, os.ModePerm); err != nil {
+		return VersionManifest{}, err
+	}
+
+	manifest := VersionManifest{
+		Name:    name,
+		Created: time.Now().UTC(),
+		Classes: dataFile.Names,
+		Splits:  make(map[string]LocationStats)}
+
+	if preprocess != nil {
+		manifest.Preprocessing = &PreprocessRecipe{PreprocessOptions: *preprocess}
+	}
+	var tileRandom *rand.Rand
+	if tile != nil {
+		manifest.Tiling = &TileRecipe{TileOptions: *tile}
+		tileRandom = rand.New(rand.NewSource(tile.Seed))
+	}
+	for _, split := range Splits {
+		var splitStats LocationStats
+		if preprocess != nil {
+			splitStats, err = dataset.preprocessSplit(split, filepath.Join(tempPath, split), *preprocess, manifest.Preprocessing)
+		} else {
+			splitStats, err = dataset.copySplit(split, filepath.Join(tempPath, split))
+		}
+		if err == nil && tile != nil {
+			splitStats, err = tileSplit(filepath.Join(tempPath, split), *tile, manifest.Tiling, tileRandom)
+		}
+		if err != nil {
+			os.RemoveAll(tempPath)
+			return VersionManifest{}, err
+		}
+		manifest.Splits[split] = splitStats
+		manifest.Images += splitStats.Images
+		manifest.Boxes += splitStats.Boxes
+	}
+
+	if augment != nil {
+		recipe, err := augmentSplit(filepath.Join(tempPath, SplitTrain), *augment)
+		if err != nil {
+			os.RemoveAll(tempPath)
+			return VersionManifest{}, err
+		}
+		manifest.Augmentation = &recipe
+
+		trainStats := manifest.Splits[SplitTrain]
+		trainStats.Images += recipe.Images
+		trainStats.Boxes += recipe.Boxes
+		manifest.Splits[SplitTrain] = trainStats
+		manifest.Images += recipe.Images
+		manifest.Boxes += recipe.Boxes
+	}
+
+	if err := writeVersionFiles(tempPath, dataFile, manifest); err != nil {
+		os.RemoveAll(tempPath)
+		return VersionManifest{}, err
+	}
+
+	if err := os.Rename(tempPath, versionPath); err != nil {
+		os.RemoveAll(tempPath)
+		return VersionManifest{}, err
+	}
+
+	return manifest, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.ExportVersion
+ *
+ * Purpose : Write version folder as zip archive
+ *
+ *   Input : name string - version name
+ *			 writer io.Writer - where to write archive
+ *
+ *  Return : error - error if occur
+ */
+func (dataset Dataset) ExportVersion(name string, writer io.Writer) error {
+	if _, err := dataset.ReadVersion(name); err != nil {
+		return fmt.Errorf("version '%v' is not exists: %w", name, err)
+	}
+
+	return ZipFolder(dataset.VersionPath(name), writer)
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.copySplit
+ *
+ * Purpose : Copy images and labels of the split to the version folder
+ *
+ *   Input : split string - split name
+ *			 target string - path to the split folder in the version
+ *
+ *  Return : LocationStats - copied images, labelled images and boxes
+ *			 error - error if occur
+ */
+func (dataset Dataset) copySplit(split string, target string) (LocationStats, error) {
+	stats := LocationStats{}
+
+	if err := os.MkdirAll(filepath.Join(target, ImagesFolder), os.ModePerm); err != nil {
+		return stats, err
+	}
+	if err := os.MkdirAll(filepath.Join(target, LabelsFolder), os.ModePerm); err != nil {
+		return stats, err
+	}
+
+	images, err := dataset.ListImages(split)
+	if err != nil {
+		return stats, err
+	}
+
+	for _, image := range images {
+		imagePath, _ := dataset.ImagePath(split, image.Name)
+		if err := copyFile(imagePath, filepath.Join(target, ImagesFolder, image.Name)); err != nil {
+			return stats, err
+		}
+		stats.Images++
+
+		if !image.Labelled {
+			continue
+		}
+		labels, err := dataset.ReadLabels(split, image.Name)
+		if err != nil {
+			return stats, fmt.Errorf("%v/%v: %w", split, image.Name, err)
+		}
+		labelPath := filepath.Join(target, LabelsFolder, LabelFileName(image.Name))
+		if err := os.WriteFile(labelPath, FormatLabels(labels), 0644); err != nil {
+			return stats, err
+		}
+		stats.Labelled++
+		stats.Boxes += len(labels)
+	}
+
+	return stats, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : augmentSplit
+ *
+ * Purpose : Add augmented copies of every image of the split folder of the version
+ *			 Images are taken by name, so the same seed gives the same copies
+ *
+ *   Input : target string - path to the split folder in the version
+ *			 options AugmentOptions - resolved recipe
+ *
+ *  Return : AugmentRecipe - recipe with the number of copies and labels
+ *			 error - error if occur
+ */
+func augmentSplit(target string, options AugmentOptions) (AugmentRecipe, error) {
+	recipe := AugmentRecipe{AugmentOptions: options}
+
+	// Names are read before the copies are added, ReadDir returns them sorted
+	entries, err := os.ReadDir(filepath.Join(target, ImagesFolder))
+	if err != nil {
+		return recipe, err
+	}
+
+	random := rand.New(rand.NewSource(options.Seed))
+	for _, entry := range entries {
+		if entry.IsDir() || !IsImageFile(entry.Name()) {
+			continue
+		}
+		source, err := decodeImage(filepath.Join(target, ImagesFolder, entry.Name()))
+		if err != nil {
+			// Image which cannot be decoded has no copies
+			continue
+		}
+
+		labels := []Label{}
+		content, err := os.ReadFile(filepath.Join(target, LabelsFolder, LabelFileName(entry.Name())))
+		if err == nil {
+			if labels, err = ParseLabels(content); err != nil {
+				return recipe, fmt.Errorf("%v: %w", entry.Name(), err)
+			}
+		} else if !os.IsNotExist(err) {
+			return recipe, err
+		}
+
+		for number := 1; number <= options.Copies; number++ {
+			picture, copyLabels, dropped := augmentImage(source, labels, options, random)
+			if err := writeAugmentedImage(picture, copyLabels, entry.Name(), number, target); err != nil {
+				return recipe, err
+			}
+			recipe.Images++
+			recipe.Boxes += len(copyLabels)
+			recipe.Dropped += dropped
+		}
+		recipe.Source++
+	}
+
+	return recipe, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Dataset.nextVersionName
+ *
+ * Purpose : Find the next free 'v<number>' version name
+ *
+ *   Input : Nothing
+ *
+ *  Return : string - version name
+ */
+func (dataset Dataset) nextVersionName() string {
+	lastNumber := 0
+
+	entries, _ := os.ReadDir(filepath.Join(dataset.Path, VersionsFolder))
+	for _, entry := range entries {
+		if number, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), "v")); err == nil && number > lastNumber {
+			lastNumber = number
+		}
+	}
+
+	return "v" + strconv.Itoa(lastNumber+1)
+}
+
+/****************************************************************************************
+ *
+ * Function : writeVersionFiles
+ *
+ * Purpose : Write data.yaml and manifest.json to the version folder
+ *
+ *   Input : path string - version folder
+ *			 dataFile DataFile - dataset data file
+ *			 manifest VersionManifest - version manifest
+ *
+ *  Return : error - error if occur
+ */
+func writeVersionFiles(path string, dataFile DataFile, manifest VersionManifest) error {
+	dataFile.Path = ""
+	dataFile.Train = []string{"train/images"}
+	dataFile.Val = []string{"valid/images"}
+	dataFile.Test = []string{"test/images"}
+	if err := os.WriteFile(filepath.Join(path, DataFileName), dataFile.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(path, ManifestFileName), content, 0644)
+}
+
+/****************************************************************************************
+ *
+ * Function : copyFile
+ *
+ * Purpose : Copy file content to the new file
+ *
+ *   Input : source string - path to the source file
+ *			 target string - path to the new file
+ *
+ *  Return : error - error if occur
+ */
+func copyFile(source string, target string) error {
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	targetFile, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(targetFile, sourceFile); err != nil {
+		targetFile.Close()
+		return err
+	}
+
+	return targetFile.Close()
+}
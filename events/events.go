@@ -0,0 +1,239 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: events.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/events
+	Purpose: Live events of the datasets streamed to the browsers
+
+	Events with the message are kept in the short history of the dataset
+	to fill the activity feed of the new page, progress notifications
+	are only sent to the connected subscribers.
+
+	In the file
+		1. Publish - event of the activity feed, kept in the history
+		2. Notify - live notification, e.g. progress of the job
+		3. Subscribe - channel of the dataset events with the missed history
+		4. Unsubscribe - close the channel of the subscriber
+		5. ImagesUploaded, LabelsChanged - events of the dataset changes
+	=============================================================================
+*/
+
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Types of the events
+const TypeUpload = "upload"
+const TypeJob = "job"
+const TypeLabels = "labels"
+const TypeLint = "lint"
+
+// Number of events kept for every dataset
+const historySize = 50
+
+// Events buffered for the subscriber, slow subscriber misses newer events
+const subscriberBuffer = 64
+
+// Event of the dataset
+type Event struct {
+	Id      int64       `json:"id"`
+	Type    string      `json:"type"`
+	Dataset string      `json:"dataset"`
+	Time    time.Time   `json:"time"`
+	Message string      `json:"message,omitempty"` // Text of the activity feed, empty for notifications
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Data of the upload event
+type UploadData struct {
+	Location string   `json:"location"`
+	Images   []string `json:"images"`
+}
+
+// Data of the labels event, client is the id sent by the page in 'X-Client-Id' header
+type LabelsData struct {
+	Location string `json:"location"`
+	Image    string `json:"image"`
+	Boxes    int    `json:"boxes"`
+	Client   string `json:"client,omitempty"`
+}
+
+// Subscribers and history of the datasets
+var broker = struct {
+	mutex       sync.Mutex
+	last        int64
+	history     map[string][]Event
+	subscribers map[string]map[chan Event]bool
+}{history: make(map[string][]Event), subscribers: make(map[string]map[chan Event]bool)}
+
+func init() {
+	// Ids continue after the restart, browsers reconnect with the last received id
+	broker.last = time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+/****************************************************************************************
+ *
+ * Function : Publish
+ *
+ * Purpose : Send event of the activity feed and keep it in the history
+ *
+ *   Input : dataset string - dataset name
+ *			 eventType string - type of the event
+ *			 message string - text of the activity feed
+ *			 data interface{} - details of the event, stored as JSON
+ *
+ *  Return : Event - sent event
+ */
+func Publish(dataset string, eventType string, message string, data interface{}) Event {
+	return send(Event{Type: eventType, Dataset: dataset, Message: message, Data: data}, true)
+}
+
+/****************************************************************************************
+ *
+ * Function : Notify
+ *
+ * Purpose : Send live notification to the connected subscribers only
+ *
+ *   Input : dataset string - dataset name
+ *			 eventType string - type of the event
+ *			 data interface{} - details of the event, stored as JSON
+ *
+ *  Return : Event - sent event
+ */
+func Notify(dataset string, eventType string, data interface{}) Event {
+	return send(Event{Type: eventType, Dataset: dataset, Data: data}, false)
+}
+
+/****************************************************************************************
+ *
+ * Function : Subscribe
+ *
+ * Purpose : Get channel of the dataset events
+ *
+ *   Input : dataset string - dataset name
+ *			 lastId int64 - id of the last received event, history after it is returned
+ *
+ *  Return : chan Event - channel of the new events
+ *			 []Event - events of the history after lastId
+ */
+func Subscribe(dataset string, lastId int64) (chan Event, []Event) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	channel := make(chan Event, subscriberBuffer)
+	if broker.subscribers[dataset] == nil {
+		broker.subscribers[dataset] = make(map[chan Event]bool)
+	}
+	broker.subscribers[dataset][channel] = true
+
+	missed := []Event{}
+	for _, event := range broker.history[dataset] {
+		if event.Id > lastId {
+			missed = append(missed, event)
+		}
+	}
+
+	return channel, missed
+}
+
+/****************************************************************************************
+ *
+ * Function : Unsubscribe
+ *
+ * Purpose : Stop sending events to the channel and close it
+ *
+ *   Input : dataset string - dataset name
+ *			 channel chan Event - channel from Subscribe
+ *
+ *  Return : Nothing
+ */
+func Unsubscribe(dataset string, channel chan Event) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if broker.subscribers[dataset][channel] {
+		delete(broker.subscribers[dataset], channel)
+		close(channel)
+	}
+	if len(broker.subscribers[dataset]) == 0 {
+		delete(broker.subscribers, dataset)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : ImagesUploaded
+ *
+ * Purpose : Publish upload of the images
+ *
+ *   Input : dataset string - dataset name
+ *			 location string - location of the images
+ *			 images []string - names of the uploaded images
+ *
+ *  Return : Nothing
+ */
+func ImagesUploaded(dataset string, location string, images []string) {
+	message := fmt.Sprintf("Image '%v' uploaded to %v", images[0], location)
+	if len(images) > 1 {
+		message = fmt.Sprintf("%v images uploaded to %v", len(images), location)
+	}
+
+	Publish(dataset, TypeUpload, message, UploadData{Location: location, Images: images})
+}
+
+/****************************************************************************************
+ *
+ * Function : LabelsChanged
+ *
+ * Purpose : Publish new labels of the image
+ *
+ *   Input : dataset string - dataset name
+ *			 data LabelsData - image, number of boxes and the client
+ *
+ *  Return : Nothing
+ */
+func LabelsChanged(dataset string, data LabelsData) {
+	message := fmt.Sprintf("Labels of '%v/%v' changed, %v boxes", data.Location, data.Image, data.Boxes)
+	Publish(dataset, TypeLabels, message, data)
+}
+
+/****************************************************************************************
+ *
+ * Function : send
+ *
+ * Purpose : Number the event and send it to the subscribers of the dataset
+ *			 Publisher is never blocked, full channel misses the event
+ *
+ *   Input : event Event - event without id and time
+ *			 keep bool - keep event in the history
+ *
+ *  Return : Event - sent event
+ */
+func send(event Event, keep bool) Event {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.last++
+	event.Id, event.Time = broker.last, time.Now()
+
+	if keep {
+		history := append(broker.history[event.Dataset], event)
+		if len(history) > historySize {
+			history = history[len(history)-historySize:]
+		}
+		broker.history[event.Dataset] = history
+	}
+
+	for channel := range broker.subscribers[event.Dataset] {
+		select {
+		case channel <- event:
+		default:
+		}
+	}
+
+	return event
+}
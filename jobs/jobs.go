@@ -0,0 +1,668 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: jobs.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/jobs
+	Purpose: Queue of the background jobs for the long operations
+
+	Jobs are queued and run by the pool of workers started with Init.
+	Every job type has the registered handler, the job keeps parameters of the
+	handler, so the job can be retried or resumed after the server restart.
+	Records of the jobs are stored in the 'jobs' folder of the dataset.
+	Status changes and the progress are sent to the events of the dataset.
+
+	In the file
+		1. Register - handler of the job type
+		2. Init - load stored jobs and start the workers
+		3. Enqueue, Cancel, Retry - manage the jobs
+		4. Exclusive - dataset action which cannot run together with the jobs
+		5. Get, List, OutputFile - jobs with the progress
+		6. Job.Context, Job.Progress, Job.CreateOutput - used by the handler
+	=============================================================================
+*/
+
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/events"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Job statuses
+const StatusQueued = "queued"
+const StatusRunning = "running"
+const StatusDone = "done"
+const StatusFailed = "failed"
+const StatusCancelled = "cancelled"
+
+// Default number of jobs running at the same time
+const DefaultWorkers = 2
+
+// Number of finished jobs kept for every dataset
+const maxFinishedJobs = 100
+
+// How often the progress of the running job is sent to the events
+const progressNotifyInterval = 250 * time.Millisecond
+
+// How often the progress of the running job is stored
+const progressSaveInterval = time.Second
+
+// Errors of the jobs
+var ErrJobNotFound = errors.New("Job is not exists")
+var ErrNoOutput = errors.New("Job has no output file")
+var ErrUnknownType = errors.New("Job type is not known")
+var ErrJobFinished = errors.New("Job is already finished")
+var ErrJobNotRetryable = errors.New("Only failed or cancelled job can be retried")
+
+// Background job
+type Job struct {
+	Id       string          `json:"id"`
+	Type     string          `json:"type"`
+	Dataset  string          `json:"dataset"`
+	Status   string          `json:"status"`
+	Params   json.RawMessage `json:"params,omitempty"`
+	Done     int             `json:"done"`
+	Total    int             `json:"total"`
+	Error    string          `json:"error,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty"`
+	Output   string          `json:"output,omitempty"` // File name of the output, e.g. 'images.zip'
+	Attempts int             `json:"attempts"`
+	Created  time.Time       `json:"created"`
+	Started  time.Time       `json:"started"`
+	Finished time.Time       `json:"finished"`
+
+	ctx        context.Context
+	cancel     context.CancelFunc
+	lastSave   time.Time
+	lastNotify time.Time
+}
+
+// Handler of the job type
+type Handler struct {
+	Run       func(job *Job) (interface{}, error) // Work of the job, returns result
+	Resumable bool                                // Job can run again from the start after the server restart
+}
+
+// Jobs, queue, handlers and datasets with the running exclusive action
+var registry = struct {
+	mutex     sync.Mutex
+	wakeup    *sync.Cond
+	jobs      map[string]*Job
+	queue     []string
+	handlers  map[string]Handler
+	last      int64
+	exclusive map[string]bool
+}{jobs: make(map[string]*Job), handlers: make(map[string]Handler), exclusive: make(map[string]bool)}
+
+func init() {
+	registry.wakeup = sync.NewCond(&registry.mutex)
+}
+
+/****************************************************************************************
+ *
+ * Function : Register
+ *
+ * Purpose : Set handler of the job type, called from init of the package
+ *
+ *   Input : jobType string - type of the job
+ *			 handler Handler - handler
+ *
+ *  Return : Nothing
+ */
+func Register(jobType string, handler Handler) {
+	registry.mutex.Lock()
+	registry.handlers[jobType] = handler
+	registry.mutex.Unlock()
+}
+
+/****************************************************************************************
+ *
+ * Function : Init
+ *
+ * Purpose : Load stored jobs of all datasets and start the workers
+ *			 Queued jobs stay in the queue, running jobs are resumed
+ *			 or marked failed when their type cannot be resumed
+ *
+ *   Input : workers int - number of jobs running at the same time
+ *
+ *  Return : error - error if occur
+ */
+func Init(workers int) error {
+	loaded, err := loadJobs()
+	if err != nil {
+		return err
+	}
+
+	registry.mutex.Lock()
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].Created.Before(loaded[j].Created)
+	})
+	for _, job := range loaded {
+		registry.jobs[job.Id] = job
+		if id, err := strconv.ParseInt(job.Id, 36, 64); err == nil && id > registry.last {
+			registry.last = id
+		}
+
+		if job.Status == StatusRunning {
+			if registry.handlers[job.Type].Resumable {
+				logging.Info_Log("Job '%v' of type '%v' is resumed after the restart", job.Id, job.Type)
+				job.Status, job.Done = StatusQueued, 0
+			} else {
+				logging.Error_Log("Job '%v' of type '%v' is interrupted by the restart", job.Id, job.Type)
+				job.Status, job.Error, job.Finished = StatusFailed, "Interrupted by the server restart", time.Now()
+			}
+			saveJob(job)
+			publish(job)
+		}
+		if job.Status == StatusQueued {
+			registry.queue = append(registry.queue, job.Id)
+		}
+	}
+	queued := len(registry.queue)
+	registry.mutex.Unlock()
+
+	if workers < 1 {
+		workers = 1
+	}
+	for index := 0; index < workers; index++ {
+		go worker()
+	}
+
+	logging.Info_Log("Jobs started with [%v] workers, [%v] jobs loaded, [%v] queued", workers, len(loaded), queued)
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Enqueue
+ *
+ * Purpose : Create the job and put it to the queue
+ *
+ *   Input : jobType string - type of the job with registered handler
+ *			 dataset string - dataset name
+ *			 params interface{} - parameters of the handler, stored as JSON
+ *
+ *  Return : Job - queued job
+ *			 error - ErrUnknownType if type has no handler,
+ *					 core.ErrDatasetBusy while the dataset is renamed, archived or deleted
+ */
+func Enqueue(jobType string, dataset string, params interface{}) (Job, error) {
+	content, err := json.Marshal(params)
+	if err != nil {
+		return Job{}, err
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if _, found := registry.handlers[jobType]; !found {
+		return Job{}, ErrUnknownType
+	}
+	if registry.exclusive[dataset] {
+		return Job{}, core.ErrDatasetBusy
+	}
+
+	// Id is unique and sorted by the time of creation
+	id := time.Now().UnixNano()
+	if id <= registry.last {
+		id = registry.last + 1
+	}
+	registry.last = id
+
+	job := &Job{Id: strconv.FormatInt(id, 36), Type: jobType, Dataset: dataset, Status: StatusQueued, Params: content, Created: time.Now()}
+	if err := saveJob(job); err != nil {
+		return Job{}, err
+	}
+	registry.jobs[job.Id] = job
+	registry.queue = append(registry.queue, job.Id)
+	registry.wakeup.Signal()
+	publish(job)
+
+	logging.Info_Log("Job '%v' of type '%v' queued for dataset '%v'", job.Id, jobType, dataset)
+	return *job, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Cancel
+ *
+ * Purpose : Cancel queued or running job, running job stops when its handler
+ *			 checks the context
+ *
+ *   Input : id string - job id
+ *
+ *  Return : Job - job
+ *			 error - ErrJobNotFound or ErrJobFinished
+ */
+func Cancel(id string) (Job, error) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	job, found := registry.jobs[id]
+	if !found {
+		return Job{}, ErrJobNotFound
+	}
+
+	switch job.Status {
+	case StatusQueued:
+		job.Status, job.Finished = StatusCancelled, time.Now()
+		saveJob(job)
+		publish(job)
+	case StatusRunning:
+		job.cancel()
+	default:
+		return *job, ErrJobFinished
+	}
+
+	logging.Info_Log("Job '%v' of type '%v' cancelled", job.Id, job.Type)
+	return *job, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Retry
+ *
+ * Purpose : Put failed or cancelled job to the queue again
+ *
+ *   Input : id string - job id
+ *
+ *  Return : Job - queued job
+ *			 error - ErrJobNotFound, ErrJobNotRetryable or core.ErrDatasetBusy
+ */
+func Retry(id string) (Job, error) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	job, found := registry.jobs[id]
+	if !found {
+		return Job{}, ErrJobNotFound
+	}
+	if job.Status != StatusFailed && job.Status != StatusCancelled {
+		return *job, ErrJobNotRetryable
+	}
+	if registry.exclusive[job.Dataset] {
+		return *job, core.ErrDatasetBusy
+	}
+
+	removeOutput(job)
+	job.Status, job.Done, job.Total, job.Error, job.Result = StatusQueued, 0, 0, "", nil
+	job.Started, job.Finished = time.Time{}, time.Time{}
+	saveJob(job)
+	registry.queue = append(registry.queue, job.Id)
+	registry.wakeup.Signal()
+	publish(job)
+
+	logging.Info_Log("Job '%v' of type '%v' queued again", job.Id, job.Type)
+	return *job, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Exclusive
+ *
+ * Purpose : Run the action of the dataset, e.g. rename or delete, when the dataset
+ *			 has no queued or running jobs. New jobs of the dataset are refused
+ *			 until the action is finished
+ *
+ *   Input : dataset string - dataset name
+ *			 action func() error - action of the dataset
+ *
+ *  Return : error - core.ErrDatasetBusy if the dataset has active jobs,
+ *					 otherwise error of the action
+ */
+func Exclusive(dataset string, action func() error) error {
+	registry.mutex.Lock()
+	busy := registry.exclusive[dataset]
+	for _, job := range registry.jobs {
+		if job.Dataset == dataset && (job.Status == StatusQueued || job.Status == StatusRunning) {
+			busy = true
+		}
+	}
+	if busy {
+		registry.mutex.Unlock()
+		return core.ErrDatasetBusy
+	}
+	registry.exclusive[dataset] = true
+	registry.mutex.Unlock()
+
+	defer func() {
+		registry.mutex.Lock()
+		delete(registry.exclusive, dataset)
+		registry.mutex.Unlock()
+	}()
+
+	return action()
+}
+
+/****************************************************************************************
+ *
+ * Function : worker
+ *
+ * Purpose : Run jobs from the queue one by one
+ *
+ *   Input : Nothing
+ *
+ *  Return : Nothing, runs forever
+ */
+func worker() {
+	for {
+		registry.mutex.Lock()
+		for len(registry.queue) == 0 {
+			registry.wakeup.Wait()
+		}
+		job := registry.jobs[registry.queue[0]]
+		registry.queue = registry.queue[1:]
+		if job == nil || job.Status != StatusQueued {
+			registry.mutex.Unlock()
+			continue
+		}
+		handler := registry.handlers[job.Type]
+		job.ctx, job.cancel = context.WithCancel(context.Background())
+		job.Status, job.Started = StatusRunning, time.Now()
+		job.Attempts++
+		saveJob(job)
+		publish(job)
+		registry.mutex.Unlock()
+
+		run(job, handler)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : run
+ *
+ * Purpose : Run the handler and store its result in the job
+ *
+ *   Input : job *Job - running job
+ *			 handler Handler - handler of the job type
+ *
+ *  Return : Nothing
+ */
+func run(job *Job, handler Handler) {
+	logging.Info_Log("Job '%v' of type '%v' started for dataset '%v'", job.Id, job.Type, job.Dataset)
+
+	var result interface{}
+	var err error
+	if handler.Run == nil {
+		err = ErrUnknownType
+	} else {
+		result, err = runHandler(job, handler)
+	}
+
+	registry.mutex.Lock()
+	if result != nil {
+		job.Result, _ = json.Marshal(result)
+	}
+	job.Finished = time.Now()
+	switch {
+	case job.ctx.Err() != nil:
+		job.Status = StatusCancelled
+	case err != nil:
+		job.Status, job.Error = StatusFailed, err.Error()
+	default:
+		job.Status = StatusDone
+	}
+	job.cancel()
+	saveJob(job)
+	publish(job)
+	registry.mutex.Unlock()
+
+	if err != nil {
+		logging.Error_Log("Job '%v' of type '%v' finished with error: '%v'", job.Id, job.Type, err)
+	} else {
+		logging.Info_Log("Job '%v' of type '%v' finished in %v", job.Id, job.Type, job.Finished.Sub(job.Started))
+	}
+
+	dropFinished(job.Dataset)
+}
+
+/****************************************************************************************
+ *
+ * Function : publish
+ *
+ * Purpose : Publish changed status of the job to the events of its dataset
+ *			 Called with locked registry
+ *
+ *   Input : job *Job - job
+ *
+ *  Return : Nothing
+ */
+func publish(job *Job) {
+	message := fmt.Sprintf("Job '%v' %v", job.Type, job.Status)
+	switch job.Status {
+	case StatusRunning:
+		message = fmt.Sprintf("Job '%v' started", job.Type)
+	case StatusFailed:
+		message = fmt.Sprintf("Job '%v' failed: %v", job.Type, job.Error)
+	}
+
+	events.Publish(job.Dataset, events.TypeJob, message, *job)
+}
+
+/****************************************************************************************
+ *
+ * Function : runHandler
+ *
+ * Purpose : Run the handler, panic of the handler fails the job
+ *
+ *   Input : job *Job - running job
+ *			 handler Handler - handler of the job type
+ *
+ *  Return : interface{} - result of the handler
+ *			 error - error of the handler
+ */
+func runHandler(job *Job, handler Handler) (result interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Job panic: %v", recovered)
+		}
+	}()
+
+	return handler.Run(job)
+}
+
+/****************************************************************************************
+ *
+ * Function : Job.Context
+ *
+ * Purpose : Get context of the running job, it is cancelled by Cancel
+ *
+ *   Input : Nothing
+ *
+ *  Return : context.Context - context of the job
+ */
+func (job *Job) Context() context.Context {
+	return job.ctx
+}
+
+/****************************************************************************************
+ *
+ * Function : Job.Decode
+ *
+ * Purpose : Decode parameters of the job
+ *
+ *   Input : params interface{} - pointer to the parameters model
+ *
+ *  Return : error - error if occur
+ */
+func (job *Job) Decode(params interface{}) error {
+	return json.Unmarshal(job.Params, params)
+}
+
+/****************************************************************************************
+ *
+ * Function : Job.Progress
+ *
+ * Purpose : Set number of processed items, called by the handler
+ *
+ *   Input : done int - processed items
+ *			 total int - all items
+ *
+ *  Return : Nothing
+ */
+func (job *Job) Progress(done int, total int) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	job.Done, job.Total = done, total
+	if time.Since(job.lastSave) > progressSaveInterval {
+		saveJob(job)
+	}
+	if time.Since(job.lastNotify) > progressNotifyInterval || done == total {
+		job.lastNotify = time.Now()
+		events.Notify(job.Dataset, events.TypeJob, *job)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : Job.CreateOutput
+ *
+ * Purpose : Create output file of the job, called by the handler
+ *
+ *   Input : name string - file name for the download, e.g. 'images.zip'
+ *
+ *  Return : *os.File - file to write
+ *			 error - error if occur
+ */
+func (job *Job) CreateOutput(name string) (*os.File, error) {
+	path, err := outputPath(job.Dataset, job.Id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.mutex.Lock()
+	job.Output = name
+	saveJob(job)
+	registry.mutex.Unlock()
+
+	return file, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Get
+ *
+ * Purpose : Get job by the id
+ *
+ *   Input : id string - job id
+ *
+ *  Return : Job - copy of the job
+ *			 error - ErrJobNotFound if job is not exists
+ */
+func Get(id string) (Job, error) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	job, found := registry.jobs[id]
+	if !found {
+		return Job{}, ErrJobNotFound
+	}
+
+	return *job, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : OutputFile
+ *
+ * Purpose : Get output file of the finished job
+ *
+ *   Input : id string - job id
+ *
+ *  Return : string - path on the drive
+ *			 Job - copy of the job
+ *			 error - ErrJobNotFound or ErrNoOutput
+ */
+func OutputFile(id string) (string, Job, error) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	job, found := registry.jobs[id]
+	if !found {
+		return "", Job{}, ErrJobNotFound
+	}
+	if job.Status != StatusDone || job.Output == "" {
+		return "", *job, ErrNoOutput
+	}
+
+	path, err := outputPath(job.Dataset, job.Id, job.Output)
+	return path, *job, err
+}
+
+/****************************************************************************************
+ *
+ * Function : List
+ *
+ * Purpose : Get jobs of the dataset, the latest first
+ *
+ *   Input : dataset string - dataset name, empty for all jobs
+ *
+ *  Return : []Job - copies of the jobs
+ */
+func List(dataset string) []Job {
+	registry.mutex.Lock()
+	list := []Job{}
+	for _, job := range registry.jobs {
+		if dataset == "" || job.Dataset == dataset {
+			list = append(list, *job)
+		}
+	}
+	registry.mutex.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.After(list[j].Created)
+	})
+
+	return list
+}
+
+/****************************************************************************************
+ *
+ * Function : dropFinished
+ *
+ * Purpose : Remove the oldest finished jobs of the dataset with their files
+ *			 when there are more than maxFinishedJobs
+ *
+ *   Input : dataset string - dataset name
+ *
+ *  Return : Nothing
+ */
+func dropFinished(dataset string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	finished := []*Job{}
+	for _, job := range registry.jobs {
+		if job.Dataset == dataset && job.Status != StatusQueued && job.Status != StatusRunning {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].Finished.Before(finished[j].Finished)
+	})
+	for _, job := range finished[:len(finished)-maxFinishedJobs] {
+		removeJob(job)
+		delete(registry.jobs, job.Id)
+	}
+}
@@ -0,0 +1,197 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: store.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/jobs
+	Purpose: Records and output files of the jobs in the dataset folder
+
+	Every job is stored as 'jobs/<id>.json' in the folder of its dataset,
+	output file of the job is 'jobs/<id><extension>'.
+	=============================================================================
+*/
+
+package jobs
+
+import (
+	"encoding/json"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/****************************************************************************************
+ *
+ * Function : jobsPath
+ *
+ * Purpose : Get path to the jobs folder of the dataset
+ *
+ *   Input : dataset string - dataset name
+ *
+ *  Return : string - path on the drive
+ *			 error - core.ErrInvalidName if dataset name is not valid
+ */
+func jobsPath(dataset string) (string, error) {
+	if !core.IsValidName(dataset) {
+		return "", core.ErrInvalidName
+	}
+
+	return filepath.Join(core.DatasetPath(dataset), core.JobsFolder), nil
+}
+
+/****************************************************************************************
+ *
+ * Function : outputPath
+ *
+ * Purpose : Get path to the output file of the job
+ *
+ *   Input : dataset string - dataset name
+ *			 id string - job id
+ *			 name string - file name of the output, extension is used
+ *
+ *  Return : string - path on the drive
+ *			 error - error if occur
+ */
+func outputPath(dataset string, id string, name string) (string, error) {
+	path, err := jobsPath(dataset)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(path, id+filepath.Ext(name)), nil
+}
+
+/****************************************************************************************
+ *
+ * Function : saveJob
+ *
+ * Purpose : Write record of the job, called with the registry locked
+ *			 Record is written to the temporary file and renamed into place
+ *
+ *   Input : job *Job - job
+ *
+ *  Return : error - error if occur
+ */
+func saveJob(job *Job) error {
+	job.lastSave = time.Now()
+
+	path, err := jobsPath(job.Dataset)
+	if err != nil {
+		return err
+	}
+	// Folder of the deleted dataset is not created again
+	if _, err := os.Stat(core.DatasetPath(job.Dataset)); err != nil {
+		return core.ErrDatasetNotFound
+	}
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		logging.Error_Log("Cannot create jobs folder of '%v': '%v'", job.Dataset, err)
+		return err
+	}
+
+	content, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	recordPath := filepath.Join(path, job.Id+".json")
+	if err := os.WriteFile(recordPath+".tmp", content, 0644); err != nil {
+		logging.Error_Log("Cannot store job '%v': '%v'", job.Id, err)
+		return err
+	}
+
+	return os.Rename(recordPath+".tmp", recordPath)
+}
+
+/****************************************************************************************
+ *
+ * Function : loadJobs
+ *
+ * Purpose : Read records of the jobs of all datasets
+ *
+ *   Input : Nothing
+ *
+ *  Return : []*Job - jobs, broken records are skipped
+ *			 error - error if datasets cannot be listed
+ */
+func loadJobs() ([]*Job, error) {
+	names, err := core.ListDatasets()
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := []*Job{}
+	for _, dataset := range names {
+		path, err := jobsPath(dataset)
+		if err != nil {
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(path, entry.Name()))
+			if err != nil {
+				continue
+			}
+			job := &Job{}
+			if err := json.Unmarshal(content, job); err != nil {
+				logging.Error_Log("Cannot read job '%v' of '%v': '%v'", entry.Name(), dataset, err)
+				continue
+			}
+			// Dataset could be renamed
+			job.Dataset = dataset
+			loaded = append(loaded, job)
+		}
+	}
+
+	return loaded, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : removeOutput
+ *
+ * Purpose : Delete output file of the job
+ *
+ *   Input : job *Job - job
+ *
+ *  Return : Nothing
+ */
+func removeOutput(job *Job) {
+	if job.Output == "" {
+		return
+	}
+
+	if path, err := outputPath(job.Dataset, job.Id, job.Output); err == nil {
+		os.Remove(path)
+	}
+	job.Output = ""
+}
+
+/****************************************************************************************
+ *
+ * Function : removeJob
+ *
+ * Purpose : Delete record and output file of the job
+ *
+ *   Input : job *Job - job
+ *
+ *  Return : Nothing
+ */
+func removeJob(job *Job) {
+	removeOutput(job)
+
+	if path, err := jobsPath(job.Dataset); err == nil {
+		os.Remove(filepath.Join(path, job.Id+".json"))
+	}
+}
@@ -0,0 +1,327 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: tasks.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/jobs
+	Purpose: Job types of the dataset operations
+
+	In the file
+		1. Bulk actions - 'move', 'delete', 'tag', 'untag', 'clear_labels',
+		   'copy' and 'download' over the selected images
+		2. 'export' - zip archive of the dataset
+		3. 'version' - version of the dataset splits
+		4. 'catalog' - synchronise catalog, rebuild hashes all images again
+		5. 'import' - images with labels from the folder on the server
+		6. 'lint' - check the dataset, the report is published to the events
+		7. 'synthesize' - mosaic or copy-paste images of the rare classes
+		8. 'merge' - images of the source datasets copied into the new one
+		9. 'adopt' - images of the Ultralytics dataset copied into the new one
+	=============================================================================
+*/
+
+package jobs
+
+import (
+	"fmt"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/events"
+)
+
+// Job types besides the bulk actions
+const TypeExport = "export"
+const TypeVersion = "version"
+const TypeCatalog = "catalog"
+const TypeImport = "import"
+const TypeLint = "lint"
+const TypeSynthesize = "synthesize"
+const TypeMerge = "merge"
+const TypeAdopt = "adopt"
+
+// Parameters of the bulk action job
+type BulkParams struct {
+	Operation core.BulkOperation `json:"operation"`
+	Images    []core.ImageRef    `json:"images"`
+}
+
+// Parameters of the catalog job
+type CatalogParams struct {
+	Rebuild bool `json:"rebuild"`
+}
+
+// Parameters of the import job
+type ImportParams struct {
+	Folder string `json:"folder"` // Folder on the server
+	core.ImportOptions
+}
+
+func init() {
+	// Actions which give the same result when run again can be resumed
+	for _, action := range core.BulkActions {
+		resumable := action == core.BulkTag || action == core.BulkUntag || action == core.BulkClearLabels || action == core.BulkDownload
+		Register(action, Handler{Run: runBulk, Resumable: resumable})
+	}
+
+	Register(TypeExport, Handler{Run: runExport, Resumable: true})
+	Register(TypeVersion, Handler{Run: runVersion})
+	Register(TypeCatalog, Handler{Run: runCatalog, Resumable: true})
+	Register(TypeImport, Handler{Run: runImport})
+	Register(TypeLint, Handler{Run: runLint, Resumable: true})
+	Register(TypeSynthesize, Handler{Run: runSynthesize})
+	Register(TypeMerge, Handler{Run: runMerge})
+	Register(TypeAdopt, Handler{Run: runAdopt})
+}
+
+/****************************************************************************************
+ *
+ * Function : StartBulk
+ *
+ * Purpose : Queue the job of the bulk action
+ *
+ *   Input : dataset core.Dataset - dataset of the images
+ *			 operation core.BulkOperation - action with its arguments
+ *			 images []core.ImageRef - selected images
+ *
+ *  Return : Job - queued job
+ *			 error - error if action is not valid
+ */
+func StartBulk(dataset core.Dataset, operation core.BulkOperation, images []core.ImageRef) (Job, error) {
+	if err := operation.Validate(); err != nil {
+		return Job{}, err
+	}
+
+	return Enqueue(operation.Action, dataset.Name, BulkParams{Operation: operation, Images: images})
+}
+
+/****************************************************************************************
+ *
+ * Function : runBulk
+ *
+ * Purpose : Run the bulk action, download writes zip archive as the output file
+ *
+ *   Input : job *Job - running job
+ *
+ *  Return : interface{} - core.BulkResult
+ *			 error - error if occur
+ */
+func runBulk(job *Job) (interface{}, error) {
+	var params BulkParams
+	if err := job.Decode(&params); err != nil {
+		return nil, err
+	}
+	dataset, err := core.OpenDataset(job.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	job.Progress(0, len(params.Images))
+
+	if params.Operation.Action != core.BulkDownload {
+		return dataset.RunBulk(job.Context(), params.Operation, params.Images, job.Progress)
+	}
+
+	output, err := job.CreateOutput(dataset.Name + "-images.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer output.Close()
+
+	return dataset.ZipImages(job.Context(), params.Images, output, job.Progress)
+}
+
+/****************************************************************************************
+ *
+ * Function : runExport
+ *
+ * Purpose : Write zip archive of the dataset as the output file
+ *
+ *   Input : job *Job - running job
+ *
+ *  Return : interface{} - nothing
+ *			 error - error if occur
+ */
+func runExport(job *Job) (interface{}, error) {
+	dataset, err := core.OpenDataset(job.Dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := job.CreateOutput(dataset.Name + ".zip")
+	if err != nil {
+		return nil, err
+	}
+	defer output.Close()
+
+	return nil, dataset.Export(output)
+}
+
+/****************************************************************************************
+ *
+ * Function : runVersion
+ *
+ * Purpose : Create version of the dataset
+ *
+ *   Input : job *Job - running job
+ *
+ *  Return : interface{} - core.VersionManifest
+ *			 error - error if occur
+ */
+func runVersion(job *Job) (interface{}, error) {
+	var options core.VersionOptions
+	if err := job.Decode(&options); err != nil {
+		return nil, err
+	}
+	dataset, err := core.OpenDataset(job.Dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataset.CreateVersion(options)
+}
+
+/****************************************************************************************
+ *
+ * Function : runCatalog
+ *
+ * Purpose : Synchronise catalog of the dataset with the files
+ *
+ *   Input : job *Job - running job
+ *
+ *  Return : interface{} - core.CatalogResult
+ *			 error - error if occur
+ */
+func runCatalog(job *Job) (interface{}, error) {
+	var params CatalogParams
+	if err := job.Decode(&params); err != nil {
+		return nil, err
+	}
+	dataset, err := core.OpenDataset(job.Dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataset.SyncCatalog(params.Rebuild)
+}
+
+/****************************************************************************************
+ *
+ * Function : runImport
+ *
+ * Purpose : Import images with labels from the folder on the server
+ *
+ *   Input : job *Job - running job
+ *
+ *  Return : interface{} - core.ImportResult
+ *			 error - error if occur
+ */
+func runImport(job *Job) (interface{}, error) {
+	var params ImportParams
+	if err := job.Decode(&params); err != nil {
+		return nil, err
+	}
+	dataset, err := core.OpenDataset(job.Dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataset.ImportFolder(params.Folder, params.ImportOptions)
+}
+
+/****************************************************************************************
+ *
+ * Function : runLint
+ *
+ * Purpose : Check the dataset and publish the number of found issues
+ *
+ *   Input : job *Job - running job
+ *
+ *  Return : interface{} - core.LintReport
+ *			 error - error if occur
+ */
+func runLint(job *Job) (interface{}, error) {
+	dataset, err := core.OpenDataset(job.Dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := dataset.Lint()
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Lint found %v errors and %v warnings in %v images", report.Errors, report.Warnings, report.Images)
+	events.Publish(dataset.Name, events.TypeLint, message, report)
+
+	return report, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : runSynthesize
+ *
+ * Purpose : Write synthetic images of the rare classes to the split
+ *
+ *   Input : job *Job - running job
+ *
+ *  Return : interface{} - core.SynthesizeResult
+ *			 error - error if occur
+ */
+func runSynthesize(job *Job) (interface{}, error) {
+	var options core.SynthesizeOptions
+	if err := job.Decode(&options); err != nil {
+		return nil, err
+	}
+	dataset, err := core.OpenDataset(job.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	job.Progress(0, options.Count)
+
+	return dataset.Synthesize(job.Context(), options, job.Progress)
+}
+
+/****************************************************************************************
+ *
+ * Function : runMerge
+ *
+ * Purpose : Copy images of the source datasets into the dataset of the job
+ *
+ *   Input : job *Job - running job
+ *
+ *  Return : interface{} - core.MergeResult
+ *			 error - error if occur
+ */
+func runMerge(job *Job) (interface{}, error) {
+	var options core.MergeOptions
+	if err := job.Decode(&options); err != nil {
+		return nil, err
+	}
+	dataset, err := core.OpenDataset(job.Dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.MergeDatasets(job.Context(), dataset, options, job.Progress)
+}
+
+/****************************************************************************************
+ *
+ * Function : runAdopt
+ *
+ * Purpose : Copy images of the checked Ultralytics dataset into the new dataset
+ *
+ *   Input : job *Job - running job
+ *
+ *  Return : interface{} - core.AdoptResult
+ *			 error - error if occur
+ */
+func runAdopt(job *Job) (interface{}, error) {
+	var plan core.AdoptPlan
+	if err := job.Decode(&plan); err != nil {
+		return nil, err
+	}
+	dataset, err := core.OpenDataset(job.Dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.AdoptDataset(job.Context(), dataset, plan, job.Progress)
+}
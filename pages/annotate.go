@@ -25,7 +25,6 @@ import (
 	"github.com/CoderSergiy/golib/logging"
 	"github.com/CoderSergiy/golib/timelib"
 	"github.com/julienschmidt/httprouter"
-	"html/template"
 	"net/http"
 )
 
@@ -96,34 +95,14 @@ func RenderAnnotatePage(w http.ResponseWriter, r *http.Request, p httprouter.Par
 	w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
 
-	// Pointed all template files to render current page
-	parsedPage, errTemplate :=
-		template.New("index.gohtml").Funcs(funcPaginationMap).ParseFiles(
-			templatePath+"layouts/index.gohtml", // Must to be first in the list
-			templatePath+"layouts/logo.gohtml",
-			templatePath+"layouts/header.gohtml",
-			templatePath+"layouts/notifications.gohtml",
-			templatePath+"annotate/body.gohtml", // page body
-			templatePath+"layouts/pagination.gohtml",
-			templatePath+"layouts/menu.gohtml", // menu is using in body, so it shouls be after body.gohtml
-			templatePath+"layouts/footer.gohtml")
-
-	if errTemplate != nil {
-		logging.Error_Log("Error parse the files : '%v'", errTemplate)
-		http.Error(w, "404 not found.", http.StatusNotFound)
-		return
-	}
-
 	// Initialise model
 	model.Menu = "annotate"                 // Set active menu button
 	model.Title = datasetName + " Annotate" // Set title of the webpage
 	model.DatasetName = datasetName
 
 	// Render the page
-	err := parsedPage.Execute(w, &model) //ExecuteTemplate(w, templatePath+"layouts/index.gohtml", &model)//
-	if err != nil {
+	if err := renderPage(w, "annotate", &model); err != nil {
 		logging.Error_Log("Error render annotate page : '%v'", err)
-		http.Error(w, "404 not found.", http.StatusNotFound)
 		return
 	}
 }
@@ -0,0 +1,237 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: annotated.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/pages
+	Purpose: Gallery views of the annotated images and images which need annotation
+
+	Boxes are rendered on the server as the blocks positioned in percents
+	of the image size, so they follow the image when it is scaled.
+
+	Links:
+		1. /dataset/:datasetname/images/annotated
+		2. /dataset/:datasetname/images/annotated/:page/page
+		3. /dataset/:datasetname/images/unannotated
+		4. /dataset/:datasetname/images/unannotated/:page/page
+	=============================================================================
+*/
+
+package pages
+
+import (
+	"fmt"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/golib/timelib"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/julienschmidt/httprouter"
+	"math"
+	"net/http"
+)
+
+// Number of the boxes colors in the stylesheet
+const boxColors = 10
+
+// Image of the gallery view with its boxes
+type GalleryImageModel struct {
+	Image core.ImageRecord
+	Boxes []BoxModel
+}
+
+// Box on the image, position and size in percents of the image
+type BoxModel struct {
+	Class  int
+	Name   string
+	Left   float64
+	Top    float64
+	Width  float64
+	Height float64
+	Color  int
+}
+
+/****************************************************************************************
+ *
+ * Function : AnnotatedHandler
+ *
+ * Purpose : Render gallery of the images with labels and their boxes
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func AnnotatedHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	renderGalleryView(w, r, p, "annotated", "/images/annotated/", "annotated images", annotatedQuery())
+}
+
+/****************************************************************************************
+ *
+ * Function : UnannotatedHandler
+ *
+ * Purpose : Render queue of the images which need annotation
+ *			 Images reviewed as background are not in the queue
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func UnannotatedHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	renderGalleryView(w, r, p, "unannotated", "/images/unannotated/", "images need annotation, oldest uploads first", unannotatedQuery())
+}
+
+/****************************************************************************************
+ *
+ * Function : annotatedQuery
+ *
+ * Purpose : Get query of the images with labels in all locations
+ *
+ *   Input : Nothing
+ *
+ *  Return : core.ImageQuery - query of the annotated view
+ */
+func annotatedQuery() core.ImageQuery {
+	labelled := true
+	return core.ImageQuery{Location: core.LocationAll, Labelled: &labelled}
+}
+
+/****************************************************************************************
+ *
+ * Function : unannotatedQuery
+ *
+ * Purpose : Get query of the images without labels which are not reviewed
+ *
+ *   Input : Nothing
+ *
+ *  Return : core.ImageQuery - query of the unannotated view, oldest uploads first
+ */
+func unannotatedQuery() core.ImageQuery {
+	return core.ImageQuery{Location: core.LocationAll, LabelStatus: core.LabelUnlabelled, Sort: core.SortUploaded}
+}
+
+/****************************************************************************************
+ *
+ * Function : renderGalleryView
+ *
+ * Purpose : Render page of the gallery view
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *			 view string - active tab of the images page
+ *			 path string - path of the view inside the dataset, used for pagination
+ *			 title string - text after the number of images
+ *			 query core.ImageQuery - images of the view
+ *
+ *  Return : Nothing
+ */
+func renderGalleryView(w http.ResponseWriter, r *http.Request, p httprouter.Params, view string, path string, title string, query core.ImageQuery) {
+	ET := timelib.EventTimerConstructor()
+	logging.Info_Log("Render '%v' gallery view", view)
+
+	// Check if dataset folder existing
+	if !isDatasetExist(w, r, p) {
+		return
+	}
+
+	viewUrl := "/dataset/" + p.ByName("datasetname") + path
+	page := getRequestedPage(p)
+	if page < 1 {
+		http.Redirect(w, r, viewUrl+"1/page", http.StatusSeeOther)
+		return
+	}
+
+	dataset, err := core.OpenDataset(p.ByName("datasetname"))
+	if err != nil {
+		RedirectToPage(w, r, p, "/?errorMessage=Cannot%20open%20dataset", err.Error())
+		return
+	}
+
+	images, total, err := getGalleryImages(dataset.Name, query, maxImagesInGallery, page)
+	if err != nil {
+		RedirectToPage(w, r, p, "/?errorMessage=Cannot%20get%20files%20for%20'"+dataset.Name+"'", "cannot read images from the catalog")
+		return
+	}
+
+	lastPage := GetPaginationPages(total, maxImagesInGallery)
+	if page > lastPage && lastPage != 0 {
+		logging.Info_Log("Redirect to the first page, page %v more than possible %v", page, lastPage)
+		http.Redirect(w, r, viewUrl+"1/page", http.StatusSeeOther)
+		return
+	}
+
+	// Set model
+	model := ImagesModel{Tag: view}
+	model.UploadedPage = page
+	model.GalleryTotal = total
+	model.GalleryTitle = title
+	model.Split = query.Location
+	model.Classes, _ = dataset.Classes()
+	model.Pagination = getPaginationModel(page, total, maxImagesInGallery, viewUrl, "")
+
+	for _, image := range images {
+		galleryImage := GalleryImageModel{Image: image}
+		if image.Labelled {
+			galleryImage.Boxes = getBoxes(dataset, image, model.Classes)
+		}
+		model.GalleryImgs = append(model.GalleryImgs, galleryImage)
+	}
+
+	// Render the images page
+	RenderImagesPage(w, r, p, model)
+
+	logging.Info_Log("Finish render '%v' view for '%v' dataset page %v in %s", view, dataset.Name, page, ET.PrintTimerString())
+}
+
+/****************************************************************************************
+ *
+ * Function : getBoxes
+ *
+ * Purpose : Read labels of the image and convert them to the boxes in percents
+ *
+ *   Input : dataset core.Dataset - dataset
+ *			 image core.ImageRecord - image
+ *			 classes []string - classes names
+ *
+ *  Return : []BoxModel - boxes, empty if labels cannot be read
+ */
+func getBoxes(dataset core.Dataset, image core.ImageRecord, classes []string) []BoxModel {
+	labels, err := dataset.ReadLabels(image.Location, image.Name)
+	if err != nil {
+		logging.Error_Log("Cannot read labels of '%v/%v': '%v'", image.Location, image.Name, err)
+		return nil
+	}
+
+	boxes := []BoxModel{}
+	for _, label := range labels {
+		box := BoxModel{
+			Class:  label.Class,
+			Name:   fmt.Sprintf("%v", label.Class),
+			Left:   percent(label.X - label.Width/2),
+			Top:    percent(label.Y - label.Height/2),
+			Width:  percent(label.Width),
+			Height: percent(label.Height),
+			Color:  label.Class % boxColors}
+		if label.Class < len(classes) {
+			box.Name = classes[label.Class]
+		}
+		boxes = append(boxes, box)
+	}
+
+	return boxes
+}
+
+/****************************************************************************************
+ *
+ * Function : percent
+ *
+ * Purpose : Convert normalised coordinate to percents with two decimals
+ *
+ *   Input : value float64 - coordinate from 0 to 1
+ *
+ *  Return : float64 - percents
+ */
+func percent(value float64) float64 {
+	return math.Round(value*10000) / 100
+}
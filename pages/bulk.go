@@ -0,0 +1,154 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: bulk.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/pages
+	Purpose: Bulk actions of the gallery running as background jobs
+
+	Gallery form sends selected images as 'location/name' or 'scope=all'
+	with the view and its filters to act on all images of the view.
+
+	Links:
+		1. POST /dataset/:datasetname/bulk
+	=============================================================================
+*/
+
+package pages
+
+import (
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/jobs"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/****************************************************************************************
+ *
+ * Function : BulkActionHandler
+ *
+ * Purpose : Start the job of the bulk action and redirect to its page
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func BulkActionHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	// Check if dataset folder existing
+	if !isDatasetExist(w, r, p) {
+		return
+	}
+
+	dataset, err := core.OpenDataset(p.ByName("datasetname"))
+	if err != nil {
+		RedirectToPage(w, r, p, "/?errorMessage=Cannot%20open%20dataset", err.Error())
+		return
+	}
+
+	r.ParseForm()
+
+	// Return only to the pages of the same dataset
+	back := r.PostForm.Get("back")
+	if _, err := url.Parse(back); err != nil || !strings.HasPrefix(back, "/dataset/"+dataset.Name+"/") {
+		back = "/dataset/" + dataset.Name + "/uploaded"
+	}
+
+	operation := core.BulkOperation{
+		Action:  r.PostForm.Get("action"),
+		To:      r.PostForm.Get("to"),
+		Dataset: r.PostForm.Get("dataset"),
+		Tags:    strings.Split(r.PostForm.Get("tags"), ",")}
+
+	images := []core.ImageRef{}
+	if r.PostForm.Get("scope") == "all" {
+		values, _ := url.ParseQuery(r.PostForm.Get("filter"))
+		query, err := viewQuery(r.PostForm.Get("view"), r.PostForm.Get("split"), values)
+		if err == nil {
+			images, err = dataset.SelectImages(query)
+		}
+		if err != nil {
+			redirectWithError(w, r, back, "Cannot select images of the view: "+err.Error())
+			return
+		}
+	} else {
+		for _, value := range r.PostForm["image"] {
+			image, err := core.ParseImageRef(value)
+			if err != nil {
+				redirectWithError(w, r, back, "Image '"+value+"' is not valid")
+				return
+			}
+			images = append(images, image)
+		}
+	}
+
+	if len(images) == 0 {
+		redirectWithError(w, r, back, "Select images for the action")
+		return
+	}
+
+	job, err := jobs.StartBulk(dataset, operation, images)
+	if err != nil {
+		redirectWithError(w, r, back, "Action '"+operation.Action+"' cannot be started: "+err.Error())
+		return
+	}
+
+	logging.Info_Log("Bulk '%v' of [%v] images of '%v' started as job '%v'", operation.Action, len(images), dataset.Name, job.Id)
+	http.Redirect(w, r, "/dataset/"+dataset.Name+"/jobs/"+job.Id, http.StatusSeeOther)
+}
+
+/****************************************************************************************
+ *
+ * Function : viewQuery
+ *
+ * Purpose : Get query of all images of the gallery view
+ *
+ *   Input : view string - 'uploaded', 'annotated', 'unannotated' or 'split'
+ *			 split string - split of the 'split' view
+ *			 values url.Values - filters of the 'uploaded' view
+ *
+ *  Return : core.ImageQuery - query of the view
+ *			 error - core.ErrInvalidQuery if view is not known
+ */
+func viewQuery(view string, split string, values url.Values) (core.ImageQuery, error) {
+	switch view {
+	case "uploaded":
+		return GetImageFilter(values).Query(core.LocationUploaded)
+	case "annotated":
+		return annotatedQuery(), nil
+	case "unannotated":
+		return unannotatedQuery(), nil
+	case "split":
+		query := core.ImageQuery{Location: split}
+		return query, query.Validate()
+	}
+
+	return core.ImageQuery{}, core.ErrInvalidQuery
+}
+
+/****************************************************************************************
+ *
+ * Function : redirectWithError
+ *
+ * Purpose : Redirect to the page of the dataset with the error message
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 page string - page to redirect
+ *			 message string - error message
+ *
+ *  Return : Nothing
+ */
+func redirectWithError(w http.ResponseWriter, r *http.Request, page string, message string) {
+	logging.Error_Log("Redirect to '%v' with error: '%v'", page, message)
+
+	pageUrl, _ := url.Parse(page)
+	query := pageUrl.Query()
+	query.Set("errorMessage", message)
+	pageUrl.RawQuery = query.Encode()
+
+	http.Redirect(w, r, pageUrl.String(), http.StatusSeeOther)
+}
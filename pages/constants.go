@@ -7,14 +7,24 @@
 
 	In the file
 		1. Path to the main folers in the project
+		   Templates and statics paths are relative to the web files system
+		2. Sorting of the datasets on the index page
 	=============================================================================
 */
 
 package pages
 
-const staticsPath = "web/statics/"
-const templatePath = "web/templates/"
-const datsetsPath = "/datasets"
+const staticsPath = "statics/"
+const templatePath = "templates/"
 
 // Images page
 const maxImagesInGallery = 20
+
+// Sorting of the datasets cards on the index page
+const sortDatasetsName = "name"
+const sortDatasetsActivity = "activity"
+const sortDatasetsImages = "images"
+const sortDatasetsCreated = "created"
+
+// All sorting of the datasets cards, the first one is default
+var datasetsSorts = []string{sortDatasetsName, sortDatasetsActivity, sortDatasetsImages, sortDatasetsCreated}
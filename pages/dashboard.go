@@ -14,7 +14,6 @@ import (
 	"github.com/CoderSergiy/golib/logging"
 	"github.com/CoderSergiy/golib/timelib"
 	"github.com/julienschmidt/httprouter"
-	"html/template"
 	"net/http"
 )
 
@@ -52,16 +51,6 @@ func DashBoardHandler(w http.ResponseWriter, r *http.Request, p httprouter.Param
 	w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
 
-	// Pointed all template files to render current page
-	parsedPage := template.Must(template.ParseFiles(
-		templatePath+"layouts/index.gohtml", // Must to be first in the list
-		templatePath+"layouts/logo.gohtml",
-		templatePath+"layouts/header.gohtml",
-		templatePath+"layouts/notifications.gohtml",
-		templatePath+"dashboard/body.gohtml", // page body
-		templatePath+"layouts/menu.gohtml",   // menu is using in bodu, so it shouls be after body.gohtml
-		templatePath+"layouts/footer.gohtml"))
-
 	// Initialise model
 	datasetName := p.ByName("datasetname")
 	model := DashboardModel{Menu: "dashboard"} // Set active menu button
@@ -69,10 +58,8 @@ func DashBoardHandler(w http.ResponseWriter, r *http.Request, p httprouter.Param
 	model.DatasetName = datasetName
 
 	// Render the page
-	err := parsedPage.Execute(w, &model)
-	if err != nil {
+	if err := renderPage(w, "dashboard", &model); err != nil {
 		logging.Error_Log("Error render dashboard : '%v'", err)
-		http.Error(w, "404 not found.", http.StatusNotFound)
 		return
 	}
 
@@ -0,0 +1,104 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: errors.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/pages
+	Purpose: Http status and code of the errors returned by the core methods
+
+	Same mapping is used by the pages and by the API error object.
+
+	In the file
+		1. ErrorStatus - status and code of the core error
+	=============================================================================
+*/
+
+package pages
+
+import (
+	"errors"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"net/http"
+)
+
+// Status of the tus checksum extension for the chunk with the wrong checksum
+const StatusChecksumMismatch = 460
+
+/****************************************************************************************
+ *
+ * Function : ErrorStatus
+ *
+ * Purpose : Get http status and code of the error returned by the core methods
+ *
+ *   Input : err error - error from the core
+ *
+ *  Return : int - http status, 500 for the unknown errors
+ *			 string - error code of the API
+ */
+func ErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, core.ErrDatasetNotFound):
+		return http.StatusNotFound, "dataset_not_found"
+	case errors.Is(err, core.ErrImageNotFound):
+		return http.StatusNotFound, "image_not_found"
+	case errors.Is(err, core.ErrDatasetExists):
+		return http.StatusConflict, "dataset_exists"
+	case errors.Is(err, core.ErrArchiveNotFound):
+		return http.StatusNotFound, "archive_not_found"
+	case errors.Is(err, core.ErrArchiveExists):
+		return http.StatusConflict, "archive_exists"
+	case errors.Is(err, core.ErrImageExists):
+		return http.StatusConflict, "image_exists"
+	case errors.Is(err, core.ErrInvalidName):
+		return http.StatusBadRequest, "invalid_name"
+	case errors.Is(err, core.ErrInvalidLocation):
+		return http.StatusBadRequest, "invalid_location"
+	case errors.Is(err, core.ErrNotImage):
+		return http.StatusUnsupportedMediaType, "not_image"
+	case errors.Is(err, core.ErrInvalidLabel):
+		return http.StatusUnprocessableEntity, "invalid_label"
+	case errors.Is(err, core.ErrInvalidDataFile):
+		return http.StatusUnprocessableEntity, "invalid_data_file"
+	case errors.Is(err, core.ErrInvalidQuery):
+		return http.StatusBadRequest, "invalid_query"
+	case errors.Is(err, core.ErrCatalogLocked):
+		return http.StatusServiceUnavailable, "catalog_locked"
+	case errors.Is(err, core.ErrUploadNotFound):
+		return http.StatusNotFound, "upload_not_found"
+	case errors.Is(err, core.ErrUploadOffset):
+		return http.StatusConflict, "invalid_offset"
+	case errors.Is(err, core.ErrUploadLocked):
+		return http.StatusLocked, "upload_locked"
+	case errors.Is(err, core.ErrUploadTooLarge):
+		return http.StatusRequestEntityTooLarge, "upload_too_large"
+	case errors.Is(err, core.ErrInvalidUploadLength), errors.Is(err, core.ErrInvalidChecksum):
+		return http.StatusBadRequest, "invalid_upload"
+	case errors.Is(err, core.ErrChecksumMismatch):
+		return StatusChecksumMismatch, "checksum_mismatch"
+	case errors.Is(err, core.ErrNoImages):
+		return http.StatusBadRequest, "no_files"
+	case errors.Is(err, core.ErrInvalidForm):
+		return http.StatusBadRequest, "invalid_form"
+	case errors.Is(err, core.ErrInvalidClassOperation):
+		return http.StatusBadRequest, "invalid_class_operation"
+	case errors.Is(err, core.ErrInvalidMerge):
+		return http.StatusBadRequest, "invalid_merge"
+	case errors.Is(err, core.ErrInvalidAugmentation):
+		return http.StatusBadRequest, "invalid_augmentation"
+	case errors.Is(err, core.ErrInvalidPreprocess):
+		return http.StatusBadRequest, "invalid_preprocess"
+	case errors.Is(err, core.ErrInvalidSynthesis):
+		return http.StatusBadRequest, "invalid_synthesis"
+	case errors.Is(err, core.ErrInvalidTiling):
+		return http.StatusBadRequest, "invalid_tiling"
+	case errors.Is(err, core.ErrLayoutTooNew):
+		return http.StatusConflict, "layout_too_new"
+	case errors.Is(err, core.ErrDatasetBusy):
+		return http.StatusConflict, "dataset_busy"
+	case errors.Is(err, core.ErrInvalidDescriptor):
+		return http.StatusBadRequest, "invalid_descriptor"
+	case errors.Is(err, core.ErrInvalidAdoption):
+		return http.StatusBadRequest, "invalid_adoption"
+	}
+
+	return http.StatusInternalServerError, "internal_error"
+}
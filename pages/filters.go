@@ -0,0 +1,170 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: filters.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/pages
+	Purpose: Filters and sorting of the images gallery from the query parameters
+
+	Query parameters:
+		split - 'uploaded', 'train', 'valid', 'test' or 'all'
+		status - 'unlabelled', 'labelled' or 'reviewed'
+		class - class id present in the labels
+		tag - image tag
+		q - part of the file name
+		min_width, max_width, min_height, max_height - dimensions in pixels
+		from, to - upload date range as 'YYYY-MM-DD', both days included
+		sort - 'name', 'uploaded', 'size' or 'boxes'
+		order - 'asc' or 'desc'
+	=============================================================================
+*/
+
+package pages
+
+import (
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Date format of the upload date filters
+const filterDateFormat = "2006-01-02"
+
+// Filters of the images gallery as entered by the user
+type ImageFilterModel struct {
+	Split     string
+	Status    string
+	Class     string
+	Tag       string
+	Search    string
+	MinWidth  string
+	MaxWidth  string
+	MinHeight string
+	MaxHeight string
+	From      string
+	To        string
+	Sort      string
+	Order     string
+}
+
+/****************************************************************************************
+ *
+ * Function : GetImageFilter
+ *
+ * Purpose : Read filters from the query parameters
+ *
+ *   Input : values url.Values - query parameters
+ *
+ *  Return : ImageFilterModel - filters
+ */
+func GetImageFilter(values url.Values) ImageFilterModel {
+	return ImageFilterModel{
+		Split:     values.Get("split"),
+		Status:    values.Get("status"),
+		Class:     values.Get("class"),
+		Tag:       values.Get("tag"),
+		Search:    values.Get("q"),
+		MinWidth:  values.Get("min_width"),
+		MaxWidth:  values.Get("max_width"),
+		MinHeight: values.Get("min_height"),
+		MaxHeight: values.Get("max_height"),
+		From:      values.Get("from"),
+		To:        values.Get("to"),
+		Sort:      values.Get("sort"),
+		Order:     values.Get("order")}
+}
+
+/****************************************************************************************
+ *
+ * Function : ImageFilterModel.Values
+ *
+ * Purpose : Get not empty filters as query parameters
+ *
+ *   Input : Nothing
+ *
+ *  Return : url.Values - query parameters
+ */
+func (filter ImageFilterModel) Values() url.Values {
+	values := url.Values{}
+	parameters := map[string]string{
+		"split": filter.Split, "status": filter.Status, "class": filter.Class, "tag": filter.Tag, "q": filter.Search,
+		"min_width": filter.MinWidth, "max_width": filter.MaxWidth, "min_height": filter.MinHeight, "max_height": filter.MaxHeight,
+		"from": filter.From, "to": filter.To, "sort": filter.Sort, "order": filter.Order}
+
+	for name, value := range parameters {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+
+	return values
+}
+
+/****************************************************************************************
+ *
+ * Function : ImageFilterModel.Query
+ *
+ * Purpose : Convert filters to the images query
+ *
+ *   Input : location string - location used when split filter is empty
+ *
+ *  Return : core.ImageQuery - images query without the page
+ *			 error - core.ErrInvalidQuery if any filter has wrong value
+ */
+func (filter ImageFilterModel) Query(location string) (core.ImageQuery, error) {
+	query := core.ImageQuery{Location: location, LabelStatus: filter.Status, Tag: filter.Tag, Search: filter.Search, Sort: filter.Sort}
+	if filter.Split != "" {
+		query.Location = filter.Split
+	}
+
+	if filter.Class != "" {
+		class, err := strconv.Atoi(filter.Class)
+		if err != nil {
+			return query, core.ErrInvalidQuery
+		}
+		query.Class = &class
+	}
+
+	dimensions := []struct {
+		value  string
+		target *int
+	}{
+		{filter.MinWidth, &query.MinWidth}, {filter.MaxWidth, &query.MaxWidth},
+		{filter.MinHeight, &query.MinHeight}, {filter.MaxHeight, &query.MaxHeight}}
+	for _, dimension := range dimensions {
+		if dimension.value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(dimension.value)
+		if err != nil {
+			return query, core.ErrInvalidQuery
+		}
+		*dimension.target = number
+	}
+
+	if filter.From != "" {
+		from, err := time.ParseInLocation(filterDateFormat, filter.From, time.Local)
+		if err != nil {
+			return query, core.ErrInvalidQuery
+		}
+		query.UploadedFrom = from
+	}
+	if filter.To != "" {
+		to, err := time.ParseInLocation(filterDateFormat, filter.To, time.Local)
+		if err != nil {
+			return query, core.ErrInvalidQuery
+		}
+		// Last day is included
+		query.UploadedTo = to.AddDate(0, 0, 1)
+	}
+
+	switch filter.Order {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, core.ErrInvalidQuery
+	}
+
+	return query, query.Validate()
+}
@@ -9,29 +9,31 @@
 		1. ImagesHandler
 		2. UploadFilesHandler
 		3. UploadedHandler
+		4. ThumbnailHandler
 
 	Links:
 		1. /dataset/:datasetname/images
 		2. /dataset/:datasetname/uploaded
-		3. /dataset/:datasetname/uploaded/:page
-		4. /dataset/:datasetname/images/annotated
-		5. /dataset/:datasetname/images/annotated/:page
+		3. /dataset/:datasetname/uploaded/:page/page
+		4. /dataset/:datasetname/images/annotated, see annotated.go
+		5. /dataset/:datasetname/images/annotated/:page/page
+		6. /dataset/:datasetname/thumbnail/:location/:filename
 	=============================================================================
 */
 
 package pages
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/CoderSergiy/golib/logging"
 	"github.com/CoderSergiy/golib/timelib"
-	"github.com/CoderSergiy/golib/tools"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/events"
 	"github.com/julienschmidt/httprouter"
-	"html/template"
-	"io/ioutil"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
 )
 
 // Model to pass data to the html template
@@ -42,9 +44,25 @@ type ImagesModel struct {
 	ErrorMessage string
 	DatasetName  string
 
-	UploadedPage int64
-	UploadedImgs []string
+	UploadedPage  int64
+	UploadedImgs  []core.ImageRecord
+	UploadedTotal int64
 
+	GalleryImgs  []GalleryImageModel
+	GalleryTotal int64
+	GalleryTitle string
+
+	TrashItems []core.TrashItem
+	TrashTotal int64
+	BackUrl    string   // Page to return after the action
+	Datasets   []string // Other datasets to copy images
+
+	Split          string
+	LocationCounts map[string]int
+
+	Filter     ImageFilterModel
+	Classes    []string
+	SortFields []string
 	Pagination PaginationModel
 }
 
@@ -83,7 +101,8 @@ func ImagesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params)
  *
  * Function : UploadFilesHandler
  *
- * Purpose : Handler for the request to upload images
+ * Purpose : Handler for the request to upload images of the 'dataset_image' field
+ *			 Response is the list of stored images or the error with its status
  *
  *   Input : w http.ResponseWriter - output value
  *			 r *http.Request - request detials
@@ -100,46 +119,44 @@ func UploadFilesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Par
 		return
 	}
 
-	// Parse our multipart form, 10 << 20 specifies a maximum
-	// upload of 10 MB files.
-	r.ParseMultipartForm(10 << 20)
-
-	// FormFile returns the first file for the given key `dataset_image`
-	// it also returns the FileHeader so we can get the Filename,
-	// the Header and the size of the file
-	file, handler, err := r.FormFile("dataset_image")
+	dataset, err := core.OpenDataset(p.ByName("datasetname"))
 	if err != nil {
-		logging.Error_Log("Error Retrieving the File: '%s'", err)
+		logging.Error_Log("Error open dataset '%v': '%v'", p.ByName("datasetname"), err)
+		status, _ := ErrorStatus(err)
+		http.Error(w, err.Error(), status)
 		return
 	}
-	defer file.Close()
-
-	// Get dataset name
-	fileNamePath := tools.EnsureSlashInEnd(datsetsPath) + p.ByName("datasetname") + "/uploaded/images/" + handler.Filename
 
-	storeFile, err := os.Create(fileNamePath)
+	// Read the form part by part, every file is streamed to the disk without buffering
+	reader, err := r.MultipartReader()
 	if err != nil {
-		logging.Error_Log("Error when creating file: '%s' , error: '%s'", fileNamePath, err)
+		logging.Error_Log("Error reading the form: '%s'", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer storeFile.Close()
 
-	// read all of the contents of our uploaded file into a byte array
-	fileBytes, err := ioutil.ReadAll(file)
+	// Store files to the uploaded images, catalog and thumbnails are made by the workers
+	images, err := dataset.SaveImages(core.LocationUploaded, core.MultipartImages(reader, "dataset_image"))
 	if err != nil {
-		logging.Error_Log("Error reading sended file: '%s'", err)
+		logging.Error_Log("Error when storing files to '%v': '%s'", dataset.Name, err)
+		status, _ := ErrorStatus(err)
+		http.Error(w, err.Error(), status)
 		return
 	}
 
-	// write this byte array to our temporary file
-	storeFile.Write(fileBytes)
+	names := []string{}
+	for _, image := range images {
+		names = append(names, image.Name)
+		logging.Info_Log("File '%v' wirh size '%v'", image.Name, PrintFileSize(image.Size))
+	}
+	events.ImagesUploaded(dataset.Name, core.LocationUploaded, names)
 
-	// Response with file status to the client
+	// Response with the stored images to the client
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(images)
 
-	logging.Info_Log("File '%v' wirh size '%v'", handler.Filename, PrintFileSize(handler.Size))
-	logging.Info_Log("Successfully finish uploading file request in %s", ET.PrintTimerString())
+	logging.Info_Log("Successfully finish uploading [%v] files request in %s", len(images), ET.PrintTimerString())
 }
 
 /****************************************************************************************
@@ -169,15 +186,23 @@ func UploadedHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params
 		return
 	}
 
-	// Get uploaded Images from dataset
-	files, totalFiles, err := getFilesByPath(
-		tools.EnsureSlashInEnd(datsetsPath)+p.ByName("datasetname")+"/uploaded/images/",
-		p.ByName("datasetname"),
-		maxImagesInGallery,
-		getRequestedPage(p))
+	// Set model
+	model := ImagesModel{Tag: "uploaded", SortFields: core.SortFields}
+	model.UploadedPage = getRequestedPage(p)
+	model.Filter = GetImageFilter(r.URL.Query())
 
+	// Filters are dropped when they cannot be used
+	query, err := model.Filter.Query(core.LocationUploaded)
 	if err != nil {
-		RedirectToPage(w, r, p, "/?errorMessage=Cannot%20get%20files%20for%20'"+p.ByName("datasetname")+"'", "cannot read files from '/uploaded/images/' folder")
+		model.ErrorMessage = "Filters are not valid: " + err.Error()
+		model.Filter = ImageFilterModel{}
+		query, _ = model.Filter.Query(core.LocationUploaded)
+	}
+
+	// Get page of the images from the dataset catalog
+	files, totalFiles, err := getGalleryImages(p.ByName("datasetname"), query, maxImagesInGallery, getRequestedPage(p))
+	if err != nil {
+		RedirectToPage(w, r, p, "/?errorMessage=Cannot%20get%20files%20for%20'"+p.ByName("datasetname")+"'", "cannot read images from the catalog")
 		return
 	}
 
@@ -187,11 +212,12 @@ func UploadedHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params
 		return
 	}
 
-	// Set model
-	model := ImagesModel{Tag: "uploaded"}
-	model.UploadedPage = getRequestedPage(p)
+	if dataset, err := core.OpenDataset(p.ByName("datasetname")); err == nil {
+		model.Classes, _ = dataset.Classes()
+	}
 	model.UploadedImgs = files
-	model.Pagination = getPaginationModel(getRequestedPage(p), totalFiles, maxImagesInGallery, "/dataset/"+p.ByName("datasetname")+"/uploaded/")
+	model.UploadedTotal = totalFiles
+	model.Pagination = getPaginationModel(getRequestedPage(p), totalFiles, maxImagesInGallery, "/dataset/"+p.ByName("datasetname")+"/uploaded/", model.Filter.Values().Encode())
 
 	// Render the images page
 	RenderImagesPage(w, r, p, model)
@@ -222,36 +248,36 @@ func RenderImagesPage(w http.ResponseWriter, r *http.Request, p httprouter.Param
 	w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
 
-	// Pointed all template files to render current page
-	parsedPage, errTemplate :=
-		template.New("index.gohtml").Funcs(funcPaginationMap).ParseFiles(
-			templatePath+"layouts/index.gohtml", // Must to be first in the list
-			templatePath+"layouts/logo.gohtml",
-			templatePath+"layouts/header.gohtml",
-			templatePath+"layouts/notifications.gohtml",
-			templatePath+"images/body.gohtml", // page body
-			templatePath+"images/upload.gohtml",
-			templatePath+"images/uploaded.gohtml",
-			templatePath+"layouts/pagination.gohtml",
-			templatePath+"layouts/menu.gohtml", // menu is using in body, so it shouls be after body.gohtml
-			templatePath+"layouts/footer.gohtml")
-
-	if errTemplate != nil {
-		logging.Error_Log("Error parse the files : '%v'", errTemplate)
-		http.Error(w, "404 not found.", http.StatusNotFound)
-		return
-	}
-
 	// Initialise model
 	model.Menu = "images"                 // Set active menu button
 	model.Title = datasetName + " Images" // Set title of the webpage
 	model.DatasetName = datasetName
+	model.BackUrl = r.URL.RequestURI()
+
+	// Message of the redirected action, e.g. images failed to delete
+	if model.ErrorMessage == "" {
+		model.ErrorMessage = r.URL.Query().Get("errorMessage")
+	}
+
+	// Datasets to copy the selected images
+	if names, err := core.ListDatasets(); err == nil {
+		for _, name := range names {
+			if name != datasetName {
+				model.Datasets = append(model.Datasets, name)
+			}
+		}
+	}
+
+	// Number of images on the tabs
+	if dataset, err := core.OpenDataset(datasetName); err == nil {
+		if counts, err := dataset.CountImages(); err == nil {
+			model.LocationCounts = counts
+		}
+	}
 
 	// Render the page
-	err := parsedPage.Execute(w, &model) //ExecuteTemplate(w, templatePath+"layouts/index.gohtml", &model)//
-	if err != nil {
-		logging.Error_Log("Error render dashboard : '%v'", err)
-		http.Error(w, "404 not found.", http.StatusNotFound)
+	if err := renderPage(w, "images", &model); err != nil {
+		logging.Error_Log("Error render images page : '%v'", err)
 		return
 	}
 }
@@ -261,6 +287,7 @@ func RenderImagesPage(w http.ResponseWriter, r *http.Request, p httprouter.Param
  * Function : DownloadImageHandler
  *
  * Purpose : Handler for the 'Image download' request
+ *			 Path is '/<filename>' for uploaded images or '/<location>/<filename>'
  *
  *   Input : w http.ResponseWriter - output value
  *			 r *http.Request - request detials
@@ -269,7 +296,67 @@ func RenderImagesPage(w http.ResponseWriter, r *http.Request, p httprouter.Param
  *  Return : Nothing
  */
 func DownloadImageHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
-	http.ServeFile(w, r, tools.EnsureSlashInEnd(datsetsPath)+p.ByName("datasetname")+"/uploaded/images/"+p.ByName("filename"))
+	location, name := core.LocationUploaded, strings.TrimPrefix(p.ByName("filepath"), "/")
+	if parts := strings.Split(name, "/"); len(parts) == 2 {
+		location, name = parts[0], parts[1]
+	}
+
+	dataset, err := core.OpenDataset(p.ByName("datasetname"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Path is checked by the core, so it cannot leave the dataset
+	if _, err := dataset.StatImage(location, name); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	imagePath, _ := dataset.ImagePath(location, name)
+	http.ServeFile(w, r, imagePath)
+}
+
+/****************************************************************************************
+ *
+ * Function : ThumbnailHandler
+ *
+ * Purpose : Handler for the thumbnail of the image in the gallery
+ *			 Path is '/<location>/<filename>', image without thumbnail is sent as is
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func ThumbnailHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	parts := strings.Split(strings.TrimPrefix(p.ByName("filepath"), "/"), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	dataset, err := core.OpenDataset(p.ByName("datasetname"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	thumbnailPath, err := dataset.Thumbnail(parts[0], parts[1])
+	if err == core.ErrNotImage {
+		DownloadImageHandler(w, r, p)
+		return
+	}
+	if err != nil {
+		if err != core.ErrImageNotFound {
+			logging.Error_Log("Thumbnail of '%v/%v' is not available: '%v'", parts[0], parts[1], err)
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	http.ServeFile(w, r, thumbnailPath)
 }
 
 /****************************************************************************************
@@ -312,5 +399,5 @@ func PrintFileSize(fileSize int64) string {
 		return fmt.Sprintf("%vKB", fileSize/1024)
 	}
 
-	return fmt.Sprintf("%fBytes", fileSize)
+	return fmt.Sprintf("%vBytes", fileSize)
 }
@@ -8,6 +8,9 @@
 	In file:
 		1. IndexHandler
 		2. UploadFilesHandler
+		3. DatasetAdoptionHandler - adopt the Ultralytics dataset folder or zip archive
+		4. DatasetActionHandler - describe, clone, rename, archive and delete the dataset
+		5. ArchiveActionHandler - restore and delete the archive
 	=============================================================================
 */
 
@@ -21,16 +24,25 @@ import (
 	"github.com/CoderSergiy/golib/timelib"
 	"github.com/CoderSergiy/golib/tools"
 	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/jobs"
 	"github.com/julienschmidt/httprouter"
-	"html/template"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strings"
 )
 
 // Model to pass data to the html template
 type IndexModel struct {
 	Title        string
-	Directories  []string
+	Datasets     []core.DatasetSummary
+	Search       string   // Text to find in the name, description, owner, task or tags
+	Sort         string   // One of datasetsSorts
+	Sorts        []string // Sorting options of the cards
+	Locations    []string // Images locations in the order of the counts on the cards
+	Tasks        []string // Tasks of the descriptor form
+	Archives     []core.ArchiveInfo
 	ErrorMessage string
 }
 
@@ -49,8 +61,8 @@ type IndexModel struct {
 func IndexHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	logging.Info_Log("Get dataset list")
 
-	// Get list of the datasets
-	model, err := getDatasets()
+	// Get cards of the datasets found by the search
+	model, err := getDatasets(strings.TrimSpace(r.URL.Query().Get("q")), r.URL.Query().Get("sort"))
 	if err != nil {
 		logging.Error_Log("Error to get datasets list : '%v'", err)
 		http.Error(w, "404 not found.", http.StatusNotFound)
@@ -86,26 +98,33 @@ func DatasetCreationHandler(w http.ResponseWriter, r *http.Request, _ httprouter
 		return
 	}
 
+	// Descriptor is checked before the folder is created
+	descriptor := descriptorFromForm(r)
+	if err := descriptor.Validate(); err != nil {
+		renderIndexWithError(w, err.Error())
+		return
+	}
+
 	logging.Info_Log("Create a new dataset '%v'", newFolder)
-	fullPathToNewFolder := tools.EnsureSlashInEnd(datsetsPath) + newFolder
+	fullPathToNewFolder := tools.EnsureSlashInEnd(core.DatasetsPath) + newFolder
 
 	// Check if folder already exists
 	if success, _ := file.IsFolderExists(fullPathToNewFolder); success {
-		logging.Error_Log("Folder '%v' already exists", datsetsPath)
-		renderIndexWithError(w, fmt.Sprintf("Foler '%v' already exists"))
+		logging.Error_Log("Folder '%v' already exists", core.DatasetsPath)
+		renderIndexWithError(w, fmt.Sprintf("Foler '%v' already exists", newFolder))
 		return
 	}
 
 	// Create a new folder
 	if err := os.Mkdir(fullPathToNewFolder, os.ModePerm); err != nil {
 		logging.Error_Log("Error occur during folder creation: '%v'", err)
-		renderIndexWithError(w, fmt.Sprintf("Cannot create folder for the new dataset '%v'"))
+		renderIndexWithError(w, fmt.Sprintf("Cannot create folder for the new dataset '%v'", newFolder))
 		return
 	}
 
-	if err := core.CreateNewDataset(fullPathToNewFolder); err != nil {
+	if err := core.CreateNewDataset(fullPathToNewFolder, descriptor); err != nil {
 		logging.Error_Log("Error occur during creation all files/folders for : '%v'", err)
-		renderIndexWithError(w, fmt.Sprintf("Cannot create dataset '%v'"))
+		renderIndexWithError(w, fmt.Sprintf("Cannot create dataset '%v'", newFolder))
 		return
 	}
 
@@ -118,6 +137,172 @@ func DatasetCreationHandler(w http.ResponseWriter, r *http.Request, _ httprouter
 	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
 }
 
+/****************************************************************************************
+ *
+ * Function : DatasetAdoptionHandler
+ *
+ * Purpose : Check the Ultralytics dataset in the server folder or uploaded zip archive,
+ *			 create the dataset and queue the job copying images into it
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 _ httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func DatasetAdoptionHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	name := strings.TrimSpace(r.FormValue("dataset"))
+	descriptor := descriptorFromForm(r)
+	if err := descriptor.Validate(); err != nil {
+		renderIndexWithError(w, err.Error())
+		return
+	}
+
+	// Uploaded archive is used when it is chosen, otherwise the folder on the server
+	var plan core.AdoptPlan
+	archive, _, err := r.FormFile("archive")
+	if err == nil {
+		plan, err = core.PlanAdoptionArchive(archive)
+		archive.Close()
+	} else {
+		plan, err = core.PlanAdoption(strings.TrimSpace(r.FormValue("source")))
+	}
+	if r.MultipartForm != nil {
+		r.MultipartForm.RemoveAll()
+	}
+	if err != nil {
+		logging.Error_Log("Dataset '%v' cannot be adopted: '%v'", name, err)
+		renderIndexWithError(w, err.Error())
+		return
+	}
+
+	dataset, err := core.CreateDataset(name, descriptor)
+	if err != nil {
+		plan.Discard()
+		renderIndexWithError(w, fmt.Sprintf("Cannot create dataset '%v': %v", name, err))
+		return
+	}
+
+	job, err := jobs.Enqueue(jobs.TypeAdopt, dataset.Name, plan)
+	if err != nil {
+		plan.Discard()
+		renderIndexWithError(w, fmt.Sprintf("Cannot queue adoption of '%v': %v", name, err))
+		return
+	}
+
+	logging.Info_Log("Job '%v' adopts '%v' as dataset '%v'", job.Id, plan.Folder, dataset.Name)
+	http.Redirect(w, r, fmt.Sprintf("/dataset/%v/jobs/%v", url.PathEscape(dataset.Name), job.Id), http.StatusSeeOther)
+}
+
+/****************************************************************************************
+ *
+ * Function : DatasetActionHandler
+ *
+ * Purpose : Handle forms of the dataset on the index page
+ *			 'describe', 'clone', 'rename', 'archive' and 'delete', delete needs the typed name
+ *			 Rename, archive and delete are refused while the dataset has active jobs
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func DatasetActionHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	// Check if dataset folder existing
+	if !isDatasetExist(w, r, p) {
+		return
+	}
+
+	name := p.ByName("datasetname")
+	newName := strings.TrimSpace(r.FormValue("name"))
+
+	var err error
+	switch r.FormValue("action") {
+	case "describe":
+		var dataset core.Dataset
+		if dataset, err = core.OpenDataset(name); err == nil {
+			descriptor := descriptorFromForm(r)
+			descriptor.Cover = r.FormValue("cover")
+			unlock := core.LockDataset(name)
+			_, err = dataset.SetDescriptor(descriptor)
+			unlock()
+		}
+	case "clone":
+		_, err = core.CloneDataset(name, newName, r.FormValue("images_only") != "")
+	case "rename":
+		err = jobs.Exclusive(name, func() error {
+			_, err := core.RenameDataset(name, newName)
+			return err
+		})
+	case "archive":
+		err = jobs.Exclusive(name, func() error {
+			_, err := core.ArchiveDataset(name)
+			return err
+		})
+	case "delete":
+		if r.FormValue("confirm") != name {
+			err = fmt.Errorf("type the name '%v' to delete the dataset", name)
+			break
+		}
+		err = jobs.Exclusive(name, func() error {
+			return core.DeleteDataset(name)
+		})
+	default:
+		err = errors.New("unknown action")
+	}
+
+	if err != nil {
+		logging.Error_Log("Action '%v' of dataset '%v' failed: '%v'", r.FormValue("action"), name, err)
+		http.Redirect(w, r, "/?"+url.Values{"errorMessage": {fmt.Sprintf("Cannot %v '%v': %v", r.FormValue("action"), name, err)}}.Encode(), http.StatusSeeOther)
+		return
+	}
+
+	logging.Info_Log("Action '%v' of dataset '%v' done", r.FormValue("action"), name)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+/****************************************************************************************
+ *
+ * Function : ArchiveActionHandler
+ *
+ * Purpose : Handle forms of the archive on the index page
+ *			 'restore' extracts the archive with the optional new name, 'delete' removes it
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 _ httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func ArchiveActionHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	name := r.FormValue("archive")
+
+	var err error
+	switch r.FormValue("action") {
+	case "restore":
+		var dataset core.Dataset
+		if dataset, err = core.RestoreArchive(name, strings.TrimSpace(r.FormValue("name"))); err == nil {
+			logging.Info_Log("Archive '%v' restored to dataset '%v'", name, dataset.Name)
+			http.Redirect(w, r, fmt.Sprintf("/dataset/%v/dashboard", url.PathEscape(dataset.Name)), http.StatusSeeOther)
+			return
+		}
+	case "delete":
+		err = core.DeleteArchive(name)
+	default:
+		err = errors.New("unknown action")
+	}
+
+	if err != nil {
+		logging.Error_Log("Action '%v' of archive '%v' failed: '%v'", r.FormValue("action"), name, err)
+		http.Redirect(w, r, "/?"+url.Values{"errorMessage": {fmt.Sprintf("Cannot %v archive '%v': %v", r.FormValue("action"), name, err)}}.Encode(), http.StatusSeeOther)
+		return
+	}
+
+	logging.Info_Log("Archive '%v' deleted", name)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
 /****************************************************************************************
  *
  * Function : renderIndexWithError
@@ -130,10 +315,28 @@ func DatasetCreationHandler(w http.ResponseWriter, r *http.Request, _ httprouter
  *  Return : Nothing
  */
 func renderIndexWithError(w http.ResponseWriter, message string) {
-	model := IndexModel{ErrorMessage: message}
+	model := IndexModel{ErrorMessage: message, Sorts: datasetsSorts, Tasks: core.Tasks}
 	renderIndexPage(w, model)
 }
 
+/****************************************************************************************
+ *
+ * Function : descriptorFromForm
+ *
+ * Purpose : Get description, task, owner and comma separated tags from the form
+ *
+ *   Input : r *http.Request - request with the form
+ *
+ *  Return : core.Descriptor - descriptor, not validated
+ */
+func descriptorFromForm(r *http.Request) core.Descriptor {
+	return core.Descriptor{
+		Description: r.FormValue("description"),
+		Task:        r.FormValue("task"),
+		Owner:       r.FormValue("owner"),
+		Tags:        strings.Split(r.FormValue("tags"), ",")}
+}
+
 /****************************************************************************************
  *
  * Function : renderIndexPage
@@ -155,20 +358,9 @@ func renderIndexPage(w http.ResponseWriter, model IndexModel) {
 	w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
 
-	// Combined all template files to render current page
-	parsedPage := template.Must(template.ParseFiles(
-		templatePath+"layouts/index.gohtml", // Must to be first in the list
-		templatePath+"layouts/logo.gohtml",
-		templatePath+"layouts/header.gohtml",
-		templatePath+"layouts/footer.gohtml",
-		templatePath+"layouts/notifications.gohtml",
-		templatePath+"landingpage/body.gohtml")) // page definition
-
 	// Render the page
-	err := parsedPage.Execute(w, &model)
-	if err != nil {
-		logging.Error_Log("Error render dashboard : '%v'", err)
-		http.Error(w, "404 not found.", http.StatusNotFound)
+	if err := renderPage(w, "landingpage", &model); err != nil {
+		logging.Error_Log("Error render index page : '%v'", err)
 		return
 	}
 
@@ -179,36 +371,100 @@ func renderIndexPage(w http.ResponseWriter, model IndexModel) {
  *
  * Function : getDatasets
  *
- * Purpose : Render index page from templates
+ * Purpose : Get summaries of the datasets matched by the search in the requested order
  *
- *   Input : Nothing
+ *   Input : search string - text to find, empty for all datasets
+ *			 sortBy string - one of datasetsSorts, name if unknown
  *
  *  Return : IndexModel - model to render the current page
  *			 error - error if occur
  */
-func getDatasets() (IndexModel, error) {
-	model := IndexModel{Title: "Yolov8 Vision"}
+func getDatasets(search string, sortBy string) (IndexModel, error) {
+	model := IndexModel{Title: "Yolov8 Vision", Search: search, Sort: datasetsSorts[0], Sorts: datasetsSorts, Locations: core.Locations, Tasks: core.Tasks}
+	for _, known := range datasetsSorts {
+		if known == sortBy {
+			model.Sort = sortBy
+		}
+	}
 
 	// Check if folder already exists
-	if success, _ := file.IsFolderExists(datsetsPath); !success {
-		logging.Error_Log("Folder '%v' is not exists", datsetsPath)
+	if success, _ := file.IsFolderExists(core.DatasetsPath); !success {
+		logging.Error_Log("Folder '%v' is not exists", core.DatasetsPath)
 		return model, errors.New("Folder is not exists")
 	}
 
-	// Get all folders by the path
-	var directories []string
-	entries, err := os.ReadDir(datsetsPath)
+	// Get all datasets, folders without the descriptor are skipped
+	directories, err := core.ListDatasets()
 	if err != nil {
 		logging.Error_Log("%v", err)
 		return model, errors.New("There is no directories")
 	}
 
-	// Make array of folders
-	for _, e := range entries {
-		directories = append(directories, e.Name())
+	model.Datasets = []core.DatasetSummary{}
+	for _, name := range directories {
+		summary, err := core.Dataset{Name: name, Path: core.DatasetPath(name)}.Summary()
+		if err != nil {
+			logging.Error_Log("Cannot get summary of the dataset '%v': '%v'", name, err)
+		}
+		if matchDataset(summary, search) {
+			model.Datasets = append(model.Datasets, summary)
+		}
+	}
+	sortDatasets(model.Datasets, model.Sort)
+
+	if model.Archives, err = core.ListArchives(); err != nil {
+		logging.Error_Log("Cannot list archives: '%v'", err)
 	}
-	model.Directories = directories
 
 	logging.Info_Log("Folders [%v]: '%v'", len(directories), tools.Implode(directories))
 	return model, nil
 }
+
+/****************************************************************************************
+ *
+ * Function : matchDataset
+ *
+ * Purpose : Check if name, description, owner, task or one of tags has the text
+ *
+ *   Input : summary core.DatasetSummary - summary of the dataset
+ *			 search string - text to find, case insensitive
+ *
+ *  Return : bool - true if search is empty or found
+ */
+func matchDataset(summary core.DatasetSummary, search string) bool {
+	fields := append([]string{summary.Name, summary.Descriptor.Description, summary.Descriptor.Owner, summary.Descriptor.Task}, summary.Descriptor.Tags...)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), strings.ToLower(search)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/****************************************************************************************
+ *
+ * Function : sortDatasets
+ *
+ * Purpose : Sort cards by the name, or the newest and the largest first
+ *			 Datasets with the same value are sorted by the name
+ *
+ *   Input : datasets []core.DatasetSummary - summaries to sort
+ *			 sortBy string - one of datasetsSorts
+ *
+ *  Return : Nothing
+ */
+func sortDatasets(datasets []core.DatasetSummary, sortBy string) {
+	sort.SliceStable(datasets, func(i, j int) bool {
+		first, second := datasets[i], datasets[j]
+		switch {
+		case sortBy == sortDatasetsActivity && !first.LastActivity.Equal(second.LastActivity):
+			return first.LastActivity.After(second.LastActivity)
+		case sortBy == sortDatasetsImages && first.Images != second.Images:
+			return first.Images > second.Images
+		case sortBy == sortDatasetsCreated && !first.Descriptor.Created.Equal(second.Descriptor.Created):
+			return first.Descriptor.Created.After(second.Descriptor.Created)
+		}
+		return first.Name < second.Name
+	})
+}
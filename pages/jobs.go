@@ -0,0 +1,213 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: jobs.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/pages
+	Purpose: Pages of the background jobs of the dataset
+
+	Links:
+		1. /dataset/:datasetname/jobs
+		2. POST /dataset/:datasetname/jobs - queue 'export', 'catalog' or 'lint'
+		3. /dataset/:datasetname/jobs/:id
+		4. POST /dataset/:datasetname/jobs/:id/cancel
+		5. POST /dataset/:datasetname/jobs/:id/retry
+	=============================================================================
+*/
+
+package pages
+
+import (
+	"encoding/json"
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/jobs"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"time"
+)
+
+// Model of the jobs pages
+type JobModel struct {
+	Title        string
+	Menu         string
+	ErrorMessage string
+	DatasetName  string
+
+	Jobs       []jobs.Job
+	Job        jobs.Job
+	Result     *core.BulkResult // Result of the bulk action
+	ResultText string           // Result of other jobs as indented json
+	Loaded     time.Time        // Time of the page, older events of the job are ignored by the page
+}
+
+/****************************************************************************************
+ *
+ * Function : JobsHandler
+ *
+ * Purpose : Render page of the dataset jobs, the latest first
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func JobsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	// Check if dataset folder existing
+	if !isDatasetExist(w, r, p) {
+		return
+	}
+
+	model := JobModel{Menu: "jobs", Jobs: jobs.List(p.ByName("datasetname"))}
+	model.Title = p.ByName("datasetname") + " Jobs"
+	model.DatasetName = p.ByName("datasetname")
+	model.ErrorMessage = r.URL.Query().Get("errorMessage")
+
+	if err := renderPage(w, "jobs", &model); err != nil {
+		logging.Error_Log("Error render jobs page : '%v'", err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : JobCreateHandler
+ *
+ * Purpose : Queue the job without parameters and redirect to its page
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func JobCreateHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	// Check if dataset folder existing
+	if !isDatasetExist(w, r, p) {
+		return
+	}
+
+	jobsUrl := "/dataset/" + p.ByName("datasetname") + "/jobs"
+	jobType := r.PostFormValue("type")
+	if jobType != jobs.TypeExport && jobType != jobs.TypeCatalog && jobType != jobs.TypeLint {
+		redirectWithError(w, r, jobsUrl, "Job '"+jobType+"' cannot be started from the page")
+		return
+	}
+
+	job, err := jobs.Enqueue(jobType, p.ByName("datasetname"), struct{}{})
+	if err != nil {
+		redirectWithError(w, r, jobsUrl, "Job '"+jobType+"' cannot be started: "+err.Error())
+		return
+	}
+
+	logging.Info_Log("Job '%v' of type '%v' queued for '%v'", job.Id, job.Type, job.Dataset)
+	http.Redirect(w, r, jobsUrl+"/"+job.Id, http.StatusSeeOther)
+}
+
+/****************************************************************************************
+ *
+ * Function : JobHandler
+ *
+ * Purpose : Render page of the job with its progress and result
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func JobHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	// Check if dataset folder existing
+	if !isDatasetExist(w, r, p) {
+		return
+	}
+
+	job, err := jobs.Get(p.ByName("id"))
+	if err != nil || job.Dataset != p.ByName("datasetname") {
+		RedirectToPage(w, r, p, "/dataset/"+p.ByName("datasetname")+"/jobs?errorMessage=Job%20is%20not%20exists", "job not found")
+		return
+	}
+
+	model := JobModel{Menu: "jobs", Job: job, Loaded: time.Now()}
+	model.Title = p.ByName("datasetname") + " Job"
+	model.DatasetName = p.ByName("datasetname")
+	model.ErrorMessage = r.URL.Query().Get("errorMessage")
+
+	if len(job.Result) > 0 {
+		var result core.BulkResult
+		if isBulkJob(job.Type) && json.Unmarshal(job.Result, &result) == nil {
+			model.Result = &result
+		} else {
+			var value interface{}
+			json.Unmarshal(job.Result, &value)
+			text, _ := json.MarshalIndent(value, "", "  ")
+			model.ResultText = string(text)
+		}
+	}
+
+	if err := renderPage(w, "job", &model); err != nil {
+		logging.Error_Log("Error render job page : '%v'", err)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : JobActionHandler
+ *
+ * Purpose : Cancel or retry the job and return to its page
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func JobActionHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	// Check if dataset folder existing
+	if !isDatasetExist(w, r, p) {
+		return
+	}
+
+	jobUrl := "/dataset/" + p.ByName("datasetname") + "/jobs/" + p.ByName("id")
+	job, err := jobs.Get(p.ByName("id"))
+	if err != nil || job.Dataset != p.ByName("datasetname") {
+		redirectWithError(w, r, "/dataset/"+p.ByName("datasetname")+"/jobs", "Job is not exists")
+		return
+	}
+
+	switch p.ByName("action") {
+	case "cancel":
+		_, err = jobs.Cancel(job.Id)
+	case "retry":
+		_, err = jobs.Retry(job.Id)
+	default:
+		redirectWithError(w, r, jobUrl, "Action '"+p.ByName("action")+"' is not known")
+		return
+	}
+	if err != nil {
+		redirectWithError(w, r, jobUrl, "Job cannot be changed: "+err.Error())
+		return
+	}
+
+	logging.Info_Log("Job '%v' of '%v' action '%v'", job.Id, job.Dataset, p.ByName("action"))
+	http.Redirect(w, r, jobUrl, http.StatusSeeOther)
+}
+
+/****************************************************************************************
+ *
+ * Function : isBulkJob
+ *
+ * Purpose : Check if the job type is the bulk action
+ *
+ *   Input : jobType string - type of the job
+ *
+ *  Return : bool - true if the job is the bulk action
+ */
+func isBulkJob(jobType string) bool {
+	for _, action := range core.BulkActions {
+		if action == jobType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -12,15 +12,18 @@ package pages
 
 import (
 	"html/template"
+	"strconv"
+	"strings"
 )
 
-// Model to collect pagination data for the html template
+// Model to collect pagination data for the html template and API responses
 type PaginationModel struct {
-	Page           int64
-	ItemsPerPage   int64
-	Items          int64
-	LastPageNumber int64
-	Url            string
+	Page           int64  `json:"page"`
+	ItemsPerPage   int64  `json:"per_page"`
+	Items          int64  `json:"total_items"`
+	LastPageNumber int64  `json:"last_page"`
+	Url            string `json:"url"`
+	Query          string `json:"query,omitempty"` // Encoded filters kept in the page links
 }
 
 /****************************************************************************************
@@ -33,17 +36,19 @@ type PaginationModel struct {
  *			 totalItems int64 - total items to split in pages
  *			 itemsPerPage int64 - setted items per page
  *			 url string - url to create page button
+ *			 query string - encoded query parameters added to the page links
  *
  *  Return : PaginationModel
  */
 
-func getPaginationModel(page int64, totalItems int64, itemsPerPage int64, url string) PaginationModel {
+func getPaginationModel(page int64, totalItems int64, itemsPerPage int64, url string, query string) PaginationModel {
 
 	model := PaginationModel{Page: page,
 		Items:          totalItems,
 		ItemsPerPage:   itemsPerPage,
 		LastPageNumber: GetPaginationPages(totalItems, itemsPerPage),
-		Url:            url}
+		Url:            url,
+		Query:          query}
 
 	return model
 }
@@ -81,6 +86,7 @@ func GetPaginationPages(totalItems int64, itemsPerPage int64) int64 {
  *  Return : template.FuncMap
  */
 var funcPaginationMap template.FuncMap = template.FuncMap{
+	"fileSize": PrintFileSize,
 	"minus": func(a, b int64) int64 {
 		return a - b
 	},
@@ -102,6 +108,17 @@ var funcPaginationMap template.FuncMap = template.FuncMap{
 		}
 		return pages
 	},
+	"join": strings.Join,
+	"list": func(items ...string) []string {
+		return items
+	},
+	"pageLink": func(model PaginationModel, page int64) template.URL {
+		link := model.Url + strconv.FormatInt(page, 10) + "/page"
+		if model.Query != "" {
+			link += "?" + model.Query
+		}
+		return template.URL(link)
+	},
 	"pagesRangeDown": func(page int64, numberPages int64) []int64 {
 		var pages []int64
 		for number := page - numberPages; number < page; number++ {
@@ -0,0 +1,43 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: splits.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/pages
+	Purpose: Gallery of the dataset splits to inspect images used for training
+
+	Links:
+		1. /dataset/:datasetname/split/:split
+		2. /dataset/:datasetname/split/:split/:page/page
+		3. /dataset/:datasetname/download/:split/:filename - images of the split
+	=============================================================================
+*/
+
+package pages
+
+import (
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+/****************************************************************************************
+ *
+ * Function : SplitGalleryHandler
+ *
+ * Purpose : Render gallery of the 'train', 'valid' or 'test' split with the boxes
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func SplitGalleryHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	split := p.ByName("split")
+	if split == core.LocationUploaded || !core.IsLocation(split) {
+		http.Redirect(w, r, "/dataset/"+p.ByName("datasetname")+"/uploaded", http.StatusSeeOther)
+		return
+	}
+
+	renderGalleryView(w, r, p, "split", "/split/"+split+"/", "images in '"+split+"' split", core.ImageQuery{Location: split})
+}
@@ -0,0 +1,206 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: templates.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/pages
+	Purpose: Registry of the parsed html templates
+
+	In the file
+		1. InitTemplates - parse all pages once on the server start
+		2. renderPage - render page from the registry
+		3. watchTemplates - reload templates when files changed (dev mode)
+	=============================================================================
+*/
+
+package pages
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/CoderSergiy/golib/logging"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Layouts shared by all pages. 'index.gohtml' must to be first in the list
+var layoutTemplates = []string{
+	"layouts/index.gohtml",
+	"layouts/logo.gohtml",
+	"layouts/header.gohtml",
+	"layouts/notifications.gohtml",
+	"layouts/pagination.gohtml",
+	"layouts/menu.gohtml",
+	"layouts/activity.gohtml",
+	"layouts/footer.gohtml",
+}
+
+// Page specific templates, the key is the page name used by renderPage
+var pageTemplates = map[string][]string{
+	"landingpage": {"landingpage/body.gohtml"},
+	"dashboard":   {"dashboard/body.gohtml"},
+	"images":      {"images/body.gohtml", "images/upload.gohtml", "images/uploaded.gohtml", "images/gallery.gohtml", "images/trash.gohtml", "images/bulk.gohtml"},
+	"jobs":        {"jobs/jobs.gohtml"},
+	"job":         {"jobs/job.gohtml"},
+	"annotate":    {"annotate/body.gohtml"},
+}
+
+// How often to check template files for changes in reload mode
+const templatesWatchInterval = time.Second
+
+// Registry keeps parsed templates for every page
+type TemplateRegistry struct {
+	mutex sync.RWMutex
+	pages map[string]*template.Template
+}
+
+var templates TemplateRegistry
+
+/****************************************************************************************
+ *
+ * Function : InitTemplates
+ *
+ * Purpose : Parse all pages templates and keep them in the registry
+ *
+ *   Input : reload bool - watch template files and parse them again when changed
+ *
+ *  Return : error - error if occur
+ */
+func InitTemplates(reload bool) error {
+	if err := templates.load(); err != nil {
+		return err
+	}
+
+	if reload {
+		go watchTemplates(templatesWatchInterval)
+	}
+
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : TemplateRegistry.load
+ *
+ * Purpose : Parse templates for all pages and replace current ones
+ *			 Current templates stay in use if any page cannot be parsed
+ *
+ *   Input : Nothing
+ *
+ *  Return : error - error if occur
+ */
+func (registry *TemplateRegistry) load() error {
+	parsedPages := make(map[string]*template.Template)
+
+	for name, files := range pageTemplates {
+		var patterns []string
+		for _, file := range append(layoutTemplates, files...) {
+			patterns = append(patterns, templatePath+file)
+		}
+
+		parsedPage, err := template.New("index.gohtml").Funcs(funcPaginationMap).ParseFS(webFiles, patterns...)
+		if err != nil {
+			logging.Error_Log("Error parse templates for '%v' page: '%v'", name, err)
+			return err
+		}
+		parsedPages[name] = parsedPage
+	}
+
+	registry.mutex.Lock()
+	registry.pages = parsedPages
+	registry.mutex.Unlock()
+
+	logging.Info_Log("Parsed templates for [%v] pages", len(parsedPages))
+	return nil
+}
+
+/****************************************************************************************
+ *
+ * Function : renderPage
+ *
+ * Purpose : Render page from the registry to the response
+ *			 Page rendered into buffer first, so the error never sends half of the page
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 name string - page name
+ *			 model interface{} - model to render template
+ *
+ *  Return : error - error if occur
+ */
+func renderPage(w http.ResponseWriter, name string, model interface{}) error {
+	templates.mutex.RLock()
+	parsedPage, ok := templates.pages[name]
+	templates.mutex.RUnlock()
+
+	if !ok {
+		http.Error(w, "500 internal server error.", http.StatusInternalServerError)
+		return fmt.Errorf("template for '%v' page not found", name)
+	}
+
+	var page bytes.Buffer
+	if err := parsedPage.Execute(&page, model); err != nil {
+		http.Error(w, "500 internal server error.", http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err := page.WriteTo(w)
+	return err
+}
+
+/****************************************************************************************
+ *
+ * Function : watchTemplates
+ *
+ * Purpose : Check template files periodically and reload registry on any change
+ *
+ *   Input : interval time.Duration - how often to check files
+ *
+ *  Return : Nothing
+ */
+func watchTemplates(interval time.Duration) {
+	logging.Info_Log("Watching templates for changes every %v", interval)
+	lastChange := templatesLastChange()
+
+	for range time.Tick(interval) {
+		change := templatesLastChange()
+		if !change.After(lastChange) {
+			continue
+		}
+		lastChange = change
+
+		logging.Info_Log("Templates changed, reload them")
+		if err := templates.load(); err != nil {
+			logging.Error_Log("Keep previous templates, reload failed: '%v'", err)
+		}
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : templatesLastChange
+ *
+ * Purpose : Find the latest modification time between all template files
+ *
+ *   Input : Nothing
+ *
+ *  Return : time.Time - latest modification time
+ */
+func templatesLastChange() time.Time {
+	var lastChange time.Time
+
+	fs.WalkDir(webFiles, strings.TrimSuffix(templatePath, "/"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && info.ModTime().After(lastChange) {
+			lastChange = info.ModTime()
+		}
+		return nil
+	})
+
+	return lastChange
+}
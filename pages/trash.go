@@ -0,0 +1,161 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: trash.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/pages
+	Purpose: Trash tab of the images page
+
+	Links:
+		1. /dataset/:datasetname/trash
+		2. /dataset/:datasetname/trash/:page/page
+		3. POST /dataset/:datasetname/trash - restore, remove or purge
+	=============================================================================
+*/
+
+package pages
+
+import (
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/****************************************************************************************
+ *
+ * Function : TrashHandler
+ *
+ * Purpose : Render the page of deleted images, the latest first
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func TrashHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	// Check if dataset folder existing
+	if !isDatasetExist(w, r, p) {
+		return
+	}
+
+	trashUrl := "/dataset/" + p.ByName("datasetname") + "/trash/"
+	page := getRequestedPage(p)
+	if page < 1 {
+		http.Redirect(w, r, trashUrl+"1/page", http.StatusSeeOther)
+		return
+	}
+
+	dataset, err := core.OpenDataset(p.ByName("datasetname"))
+	if err != nil {
+		RedirectToPage(w, r, p, "/?errorMessage=Cannot%20open%20dataset", err.Error())
+		return
+	}
+
+	items, err := dataset.ListTrash()
+	if err != nil {
+		RedirectToPage(w, r, p, "/?errorMessage=Cannot%20read%20trash%20of%20'"+dataset.Name+"'", err.Error())
+		return
+	}
+
+	total := int64(len(items))
+	lastPage := GetPaginationPages(total, maxImagesInGallery)
+	if page > lastPage && lastPage != 0 {
+		http.Redirect(w, r, trashUrl+"1/page", http.StatusSeeOther)
+		return
+	}
+
+	start := (page - 1) * maxImagesInGallery
+	end := start + maxImagesInGallery
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	// Set model
+	model := ImagesModel{Tag: "trash"}
+	model.UploadedPage = page
+	model.TrashItems = items[start:end]
+	model.TrashTotal = total
+	model.Pagination = getPaginationModel(page, total, maxImagesInGallery, trashUrl, "")
+
+	// Render the images page
+	RenderImagesPage(w, r, p, model)
+}
+
+/****************************************************************************************
+ *
+ * Function : TrashActionHandler
+ *
+ * Purpose : Handle form of the trash tab
+ *			 'restore' and 'remove' are applied to the selected trash items,
+ *			 'purge' empties the trash
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 p httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func TrashActionHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	// Check if dataset folder existing
+	if !isDatasetExist(w, r, p) {
+		return
+	}
+
+	dataset, err := core.OpenDataset(p.ByName("datasetname"))
+	if err != nil {
+		RedirectToPage(w, r, p, "/?errorMessage=Cannot%20open%20dataset", err.Error())
+		return
+	}
+
+	r.ParseForm()
+
+	// Return only to the pages of the same dataset
+	back := r.PostForm.Get("back")
+	if _, err := url.Parse(back); err != nil || !strings.HasPrefix(back, "/dataset/"+dataset.Name+"/") {
+		back = "/dataset/" + dataset.Name + "/trash"
+	}
+
+	failed := map[string]string{}
+	switch r.PostForm.Get("action") {
+	case "restore":
+		for _, id := range r.PostForm["item"] {
+			if _, err := dataset.RestoreTrash(id); err != nil {
+				failed[id] = err.Error()
+			}
+		}
+	case "remove":
+		for _, id := range r.PostForm["item"] {
+			if err := dataset.DeleteTrash(id); err != nil {
+				failed[id] = err.Error()
+			}
+		}
+	case "purge":
+		if _, err := dataset.PurgeTrash(0); err != nil {
+			failed["trash"] = err.Error()
+		}
+	default:
+		failed["action"] = "unknown action"
+	}
+
+	// Message of the previous action is replaced by the current one
+	backUrl, _ := url.Parse(back)
+	query := backUrl.Query()
+	query.Del("errorMessage")
+	if len(failed) > 0 {
+		messages := []string{}
+		for name, reason := range failed {
+			messages = append(messages, name+": "+reason)
+		}
+		logging.Error_Log("Trash action '%v' of dataset '%v' failed for [%v] items: '%v'", r.PostForm.Get("action"), dataset.Name, len(failed), strings.Join(messages, "; "))
+		query.Set("errorMessage", strings.Join(messages, "; "))
+	}
+	backUrl.RawQuery = query.Encode()
+
+	http.Redirect(w, r, backUrl.String(), http.StatusSeeOther)
+}
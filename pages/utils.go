@@ -11,15 +11,97 @@
 package pages
 
 import (
-	"errors"
 	"github.com/CoderSergiy/golib/file"
 	"github.com/CoderSergiy/golib/logging"
 	"github.com/CoderSergiy/golib/tools"
+	"github.com/CoderSergiy/yolov8-dataset/core"
 	"github.com/julienschmidt/httprouter"
-	"io/ioutil"
+	"io/fs"
 	"net/http"
+	"strings"
 )
 
+// Files system with templates and statics folders, set on the server start
+var webFiles fs.FS
+
+/****************************************************************************************
+ *
+ * Function : SetWebFiles
+ *
+ * Purpose : Set files system to read templates and statics from
+ *
+ *   Input : files fs.FS - files system with 'templates' and 'statics' folders
+ *
+ *  Return : Nothing
+ */
+func SetWebFiles(files fs.FS) {
+	webFiles = files
+}
+
+/****************************************************************************************
+ *
+ * Function : Locked
+ *
+ * Purpose : Run the handler changing the dataset files with the shared lock of the
+ *			 dataset, archive, backup, rename and delete wait until it ends
+ *
+ *   Input : handler httprouter.Handle - handler with the 'datasetname' parameter
+ *
+ *  Return : httprouter.Handle - handler holding the lock
+ */
+func Locked(handler httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		unlock := core.LockDataset(p.ByName("datasetname"))
+		defer unlock()
+
+		handler(w, r, p)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : StaticsFileSystem
+ *
+ * Purpose : Get http file system for the assets handler
+ *
+ *   Input : Nothing
+ *
+ *  Return : http.FileSystem - statics folder as root
+ *			 error - error if occur
+ */
+func StaticsFileSystem() (http.FileSystem, error) {
+	statics, err := fs.Sub(webFiles, strings.TrimSuffix(staticsPath, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	return http.FS(statics), nil
+}
+
+/****************************************************************************************
+ *
+ * Function : FaviconHandler
+ *
+ * Purpose : Handler for the favicon request
+ *
+ *   Input : w http.ResponseWriter - output value
+ *			 r *http.Request - request detials
+ *			 _ httprouter.Params - parameter request
+ *
+ *  Return : Nothing
+ */
+func FaviconHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	favicon, err := fs.ReadFile(webFiles, staticsPath+"img/favicon.ico")
+	if err != nil {
+		logging.Error_Log("Cannot read favicon: '%v'", err)
+		http.Error(w, "404 not found.", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/x-icon")
+	w.Write(favicon)
+}
+
 /****************************************************************************************
  *
  * Function : isDatasetExist
@@ -36,10 +118,19 @@ import (
 func isDatasetExist(w http.ResponseWriter, r *http.Request, p httprouter.Params) bool {
 	// Get dataset name from the request parameters
 	datasetName := p.ByName("datasetname")
-	fullPathToNewFolder := tools.EnsureSlashInEnd(datsetsPath) + datasetName
+	fullPathToNewFolder := tools.EnsureSlashInEnd(core.DatasetsPath) + datasetName
 
 	// Firstly, check if folder exists
 	if success, _ := file.IsFolderExists(fullPathToNewFolder); !success {
+		// Pages of the renamed dataset are redirected to the new name
+		if current, renamed := core.RenamedDataset(datasetName); renamed {
+			redirect := *r.URL
+			redirect.Path = strings.Replace(r.URL.Path, "/dataset/"+datasetName, "/dataset/"+current, 1)
+			redirect.RawPath = ""
+			http.Redirect(w, r, redirect.String(), http.StatusTemporaryRedirect)
+			return false
+		}
+
 		logging.Error_Log("Folder '%v' not exists", fullPathToNewFolder)
 		// Redirect to the index again
 		http.Redirect(w, r, "/?errorMessage=Dataset%20'"+datasetName+"'%20folder%20not%20exist", http.StatusSeeOther)
@@ -51,48 +142,39 @@ func isDatasetExist(w http.ResponseWriter, r *http.Request, p httprouter.Params)
 
 /****************************************************************************************
  *
- * Function : getDatasets
+ * Function : getGalleryImages
  *
- * Purpose : Render index page from templates
+ * Purpose : Get page of the images matched by the query from the dataset catalog
  *
- *   Input : Nothing
+ *   Input : datasetName string - dataset name
+ *			 query core.ImageQuery - filters and sorting
+ *			 showPerPage int64 - images per page
+ *			 page int64 - page number
  *
- *  Return : IndexModel - model to render the current page
+ *  Return : []core.ImageRecord - images of the page
+ *			 int64 - total number of the matched images
  *			 error - error if occur
  */
-func getFilesByPath(path string, dataset string, showPerPage int64, page int64) ([]string, int64, error) {
-	logging.Info_Log("getFilesByPath")
-	var filesToPrint []string
-
-	// Check if folder exists
-	if success, _ := file.IsFolderExists(path); !success {
-		logging.Error_Log("Folder '%v' is not exists", path)
-		return filesToPrint, -1, errors.New("Folder is not exists")
-	}
-
-	logging.Info_Log("Path '%v'. Pagination page: %v", path, page)
-
-	files, err := ioutil.ReadDir(path)
+func getGalleryImages(datasetName string, query core.ImageQuery, showPerPage int64, page int64) ([]core.ImageRecord, int64, error) {
+	dataset, err := core.OpenDataset(datasetName)
 	if err != nil {
-		return filesToPrint, -1, err
+		return nil, -1, err
 	}
 
-	startImgIndex := (page - 1) * showPerPage
-	lastImgIndex := startImgIndex + showPerPage
-	for index, f := range files {
-		if int64(index) >= startImgIndex && int64(index) < lastImgIndex {
-			//filesToPrint = append(filesToPrint, "/dataset/"+dataset+"/download/"+f.Name())
-			filesToPrint = append(filesToPrint, f.Name())
-		}
+	query.Offset = int((page - 1) * showPerPage)
+	query.Limit = int(showPerPage)
+	records, total, err := dataset.QueryImages(query)
+	if err != nil {
+		return nil, -1, err
 	}
 
-	logging.Info_Log("Find [%v] files between index [%v] and [%v] from total [%v]", len(filesToPrint), startImgIndex, lastImgIndex, len(files))
-	return filesToPrint, int64(len(files)), nil
+	logging.Info_Log("Find [%v] images from index [%v] of total [%v]", len(records), query.Offset, total)
+	return records, int64(total), nil
 }
 
 func RedirectToPage(w http.ResponseWriter, r *http.Request, p httprouter.Params, path string, errorMessage string) {
-	pageToRedirect := "/dataset/"+p.ByName("datasetname")+"/uploaded/1"
+	pageToRedirect := "/dataset/" + p.ByName("datasetname") + "/uploaded/1"
 	logging.Info_Log("Redirect to '%v' as result of '%v'", pageToRedirect, errorMessage)
 	// Redirect to the index again
 	http.Redirect(w, r, pageToRedirect, http.StatusSeeOther)
-}
\ No newline at end of file
+}
@@ -6,52 +6,40 @@
 	Purpose: Server implementation to render project webpages
 			 Includes assets and favicon
 
+	Flags:
+		-dev - load templates and assets from the disk instead of the binary
+		-web - path to the 'web' folder used in dev mode
+		-reload - watch template files and reload them on change, used with -dev
+		-address - address to listen
+		-datasets - folder with all datasets
+
 	=============================================================================
 */
 
 package main
 
 import (
-	"github.com/CoderSergiy/yolov8-dataset/pages"
-	"github.com/julienschmidt/httprouter"
+	"flag"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/jobs"
+	"github.com/CoderSergiy/yolov8-dataset/server"
 	"log"
-	"net/http"
 )
 
-func faviconHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	http.ServeFile(w, r, "./web/statics/img/favicon.ico")
-}
-
 func main() {
-	router := httprouter.New()
-
-	// Assets files handler
-	router.GET("/favicon.ico", faviconHandler)
-	router.ServeFiles("/assets/*filepath", http.Dir("./web/statics/"))
-
-	// Dataset dashboard
-	router.GET("/dataset/:datasetname/", pages.DashBoardHandler) // Dashboard index page
-	router.GET("/dataset/:datasetname/dashboard", pages.DashBoardHandler)
-
-	// Dataset classes
-	//router.GET("/dataset/:name/classes/list", pages.DashBoardHandler)
-	//router.POST("/dataset/:name/classes/update", pages.DashBoardHandler)
-
-	// Images pages
-	router.GET("/dataset/:datasetname/images", pages.ImagesHandler)
-	router.GET("/dataset/:datasetname/uploaded", pages.UploadedHandler)
-	router.GET("/dataset/:datasetname/uploaded/:page/page", pages.UploadedHandler)
-	//router.GET("/dataset/:datasetname/images/annotated/:page", pages.UploadedHandler)
-	router.POST("/dataset/:datasetname/upload", pages.UploadFilesHandler)              // Handle 'file upload' request
-	router.GET("/dataset/:datasetname/download/:filename", pages.DownloadImageHandler) // Handle 'file download' request - when browser making a gallery
-
-	// Annotate pages
-	router.GET("/dataset/:datasetname/annotate", pages.AnnotateHandler)
-
-	// Landing page
-	router.GET("/", pages.IndexHandler)
-	router.POST("/create/dataset", pages.DatasetCreationHandler)
+	options := server.Options{}
+	flag.BoolVar(&options.Dev, "dev", false, "Load templates and assets from the disk for live editing")
+	flag.StringVar(&options.WebPath, "web", "web", "Path to the 'web' folder, used with -dev")
+	flag.BoolVar(&options.Reload, "reload", false, "Reload templates when files changed, used with -dev")
+	flag.StringVar(&options.Address, "address", ":8080", "Address to listen")
+	flag.StringVar(&core.DatasetsPath, "datasets", core.DatasetsPath, "Folder with all datasets")
+	flag.DurationVar(&options.TrashRetention, "trash-retention", core.DefaultTrashRetention, "Time to keep deleted images in the trash, 0 to keep forever")
+	flag.IntVar(&options.Workers, "workers", jobs.DefaultWorkers, "Number of background jobs running at the same time")
+	flag.IntVar(&options.ProcessWorkers, "process-workers", core.DefaultProcessWorkers, "Number of uploaded images processed at the same time")
+	flag.BoolVar(&options.Migrate, "migrate", true, "Upgrade datasets to the latest layout before the start")
+	flag.BoolVar(&options.MigrationBackup, "migrate-backup", true, "Zip every migrated dataset to the '"+core.BackupsFolder+"' folder first")
+	flag.Parse()
 
 	// Run server
-	log.Fatal(http.ListenAndServe(":8080", router))
+	log.Fatal(server.Run(options))
 }
@@ -0,0 +1,264 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: server.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/server
+	Purpose: Server implementation to render project webpages and API
+			 Includes assets and favicon
+
+	In the file
+		1. NewRouter - router with all pages, assets and API routes
+		2. Run - start the server
+		3. migrateDatasets - upgrade datasets to the latest layout
+		4. purgeTrash - delete images kept in the trash longer than retention time
+		5. purgeUploads - remove expired resumable uploads
+	=============================================================================
+*/
+
+package server
+
+import (
+	"github.com/CoderSergiy/golib/logging"
+	"github.com/CoderSergiy/yolov8-dataset/api"
+	"github.com/CoderSergiy/yolov8-dataset/core"
+	"github.com/CoderSergiy/yolov8-dataset/jobs"
+	"github.com/CoderSergiy/yolov8-dataset/pages"
+	"github.com/CoderSergiy/yolov8-dataset/web"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"time"
+)
+
+// How often to purge the trash and the expired uploads of the datasets
+const trashPurgeInterval = time.Hour
+const uploadsPurgeInterval = time.Hour
+
+// Server options
+type Options struct {
+	Address string // Address to listen, e.g. ':8080'
+	Dev     bool   // Load templates and assets from the disk
+	WebPath string // Path to the 'web' folder, used in dev mode
+	Reload  bool   // Reload templates when files changed, used in dev mode
+
+	TrashRetention time.Duration // Time to keep deleted images, 0 to keep them forever
+	Workers        int           // Number of background jobs running at the same time
+	ProcessWorkers int           // Number of uploaded images processed at the same time
+
+	Migrate         bool // Upgrade datasets to the latest layout before the start
+	MigrationBackup bool // Zip every migrated dataset to the backups folder first
+}
+
+/****************************************************************************************
+ *
+ * Function : NewRouter
+ *
+ * Purpose : Prepare templates and create router with all routes
+ *
+ *   Input : options Options - server options
+ *
+ *  Return : *httprouter.Router - router
+ *			 error - error if templates cannot be parsed
+ */
+func NewRouter(options Options) (*httprouter.Router, error) {
+	// Templates and assets are embedded in the binary, unless dev mode is set
+	pages.SetWebFiles(web.Files(options.Dev, options.WebPath))
+
+	// Parse all templates once, stop the server if any of them is broken
+	if err := pages.InitTemplates(options.Dev && options.Reload); err != nil {
+		return nil, err
+	}
+
+	statics, err := pages.StaticsFileSystem()
+	if err != nil {
+		return nil, err
+	}
+
+	router := httprouter.New()
+
+	// Assets files handler
+	router.GET("/favicon.ico", pages.FaviconHandler)
+	router.ServeFiles("/assets/*filepath", statics)
+
+	// Dataset dashboard
+	router.GET("/dataset/:datasetname/", pages.DashBoardHandler) // Dashboard index page
+	router.GET("/dataset/:datasetname/dashboard", pages.DashBoardHandler)
+
+	// Dataset classes
+	//router.GET("/dataset/:name/classes/list", pages.DashBoardHandler)
+	//router.POST("/dataset/:name/classes/update", pages.DashBoardHandler)
+
+	// Images pages
+	router.GET("/dataset/:datasetname/images", pages.ImagesHandler)
+	router.GET("/dataset/:datasetname/uploaded", pages.UploadedHandler)
+	router.GET("/dataset/:datasetname/uploaded/:page/page", pages.UploadedHandler)
+	router.GET("/dataset/:datasetname/images/annotated", pages.AnnotatedHandler)
+	router.GET("/dataset/:datasetname/images/annotated/:page/page", pages.AnnotatedHandler)
+	router.GET("/dataset/:datasetname/images/unannotated", pages.UnannotatedHandler)
+	router.GET("/dataset/:datasetname/images/unannotated/:page/page", pages.UnannotatedHandler)
+	router.POST("/dataset/:datasetname/upload", pages.Locked(pages.UploadFilesHandler)) // Handle 'file upload' request
+	router.GET("/dataset/:datasetname/download/*filepath", pages.DownloadImageHandler)  // Handle 'file download' request - when browser making a gallery
+	router.GET("/dataset/:datasetname/thumbnail/*filepath", pages.ThumbnailHandler)     // Small copy of the image for the gallery
+	router.GET("/dataset/:datasetname/split/:split", pages.SplitGalleryHandler)
+	router.GET("/dataset/:datasetname/split/:split/:page/page", pages.SplitGalleryHandler)
+	router.GET("/dataset/:datasetname/trash", pages.TrashHandler)
+	router.GET("/dataset/:datasetname/trash/:page/page", pages.TrashHandler)
+	router.POST("/dataset/:datasetname/trash", pages.Locked(pages.TrashActionHandler)) // Restore deleted images or empty the trash
+	router.POST("/dataset/:datasetname/bulk", pages.BulkActionHandler)                 // Start job of the bulk action over the gallery images
+	router.GET("/dataset/:datasetname/jobs", pages.JobsHandler)
+	router.POST("/dataset/:datasetname/jobs", pages.JobCreateHandler) // Queue export, catalog or lint
+	router.GET("/dataset/:datasetname/jobs/:id", pages.JobHandler)
+	router.POST("/dataset/:datasetname/jobs/:id/:action", pages.JobActionHandler) // Cancel or retry the job
+
+	// Annotate pages
+	router.GET("/dataset/:datasetname/annotate", pages.AnnotateHandler)
+
+	// JSON API
+	api.RegisterRoutes(router)
+
+	// Landing page
+	router.GET("/", pages.IndexHandler)
+	router.POST("/create/dataset", pages.DatasetCreationHandler)
+	router.POST("/adopt/dataset", pages.DatasetAdoptionHandler)             // Ultralytics dataset from the server folder or zip archive
+	router.POST("/dataset/:datasetname/manage", pages.DatasetActionHandler) // Describe, clone, rename, archive or delete the dataset
+	router.POST("/archives", pages.ArchiveActionHandler)                    // Restore or delete the archived dataset
+
+	return router, nil
+}
+
+/****************************************************************************************
+ *
+ * Function : Run
+ *
+ * Purpose : Start the server, returns only when server stopped
+ *
+ *   Input : options Options - server options
+ *
+ *  Return : error - reason why server stopped
+ */
+func Run(options Options) error {
+	router, err := NewRouter(options)
+	if err != nil {
+		return err
+	}
+
+	// Datasets are upgraded before the jobs are resumed on them
+	if options.Migrate {
+		migrateDatasets(options.MigrationBackup)
+	}
+
+	// Stored jobs are resumed before the server accepts new ones
+	if err := jobs.Init(options.Workers); err != nil {
+		return err
+	}
+	core.StartProcessing(options.ProcessWorkers)
+
+	if options.TrashRetention > 0 {
+		go purgeTrash(options.TrashRetention, trashPurgeInterval)
+	}
+	go purgeUploads(uploadsPurgeInterval)
+
+	logging.Info_Log("Server is listening on '%v'", options.Address)
+	return http.ListenAndServe(options.Address, router)
+}
+
+/****************************************************************************************
+ *
+ * Function : migrateDatasets
+ *
+ * Purpose : Upgrade datasets with the older layout, failed datasets are logged
+ *			 and the server is started with them as they are
+ *
+ *   Input : backup bool - zip every migrated dataset first
+ *
+ *  Return : Nothing
+ */
+func migrateDatasets(backup bool) {
+	results, err := core.MigrateDatasets(core.MigrationOptions{Backup: backup})
+	if err != nil {
+		logging.Error_Log("Cannot list datasets to migrate: '%v'", err)
+		return
+	}
+
+	for _, result := range results {
+		if result.Error == "" {
+			logging.Info_Log("Dataset '%v' migrated from layout [%v] to [%v], backup '%v'", result.Dataset, result.From, result.To, result.Backup)
+		}
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : purgeTrash
+ *
+ * Purpose : Periodically delete images kept in the trash of every dataset
+ *			 longer than retention time
+ *
+ *   Input : retention time.Duration - time to keep deleted images
+ *			 interval time.Duration - time between purges
+ *
+ *  Return : Nothing, runs forever
+ */
+func purgeTrash(retention time.Duration, interval time.Duration) {
+	for {
+		names, err := core.ListDatasets()
+		if err != nil {
+			logging.Error_Log("Cannot list datasets to purge the trash: '%v'", err)
+		}
+
+		for _, name := range names {
+			dataset, err := core.OpenDataset(name)
+			if err != nil {
+				continue
+			}
+			unlock := core.LockDataset(name)
+			purged, err := dataset.PurgeTrash(retention)
+			unlock()
+			if err != nil {
+				logging.Error_Log("Cannot purge trash of '%v': '%v'", name, err)
+			}
+			if purged > 0 {
+				logging.Info_Log("Purged [%v] images from the trash of '%v'", purged, name)
+			}
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+/****************************************************************************************
+ *
+ * Function : purgeUploads
+ *
+ * Purpose : Periodically remove resumable uploads of every dataset
+ *			 without chunks for longer than expiration time
+ *
+ *   Input : interval time.Duration - time between purges
+ *
+ *  Return : Nothing, runs forever
+ */
+func purgeUploads(interval time.Duration) {
+	for {
+		names, err := core.ListDatasets()
+		if err != nil {
+			logging.Error_Log("Cannot list datasets to purge the uploads: '%v'", err)
+		}
+
+		for _, name := range names {
+			dataset, err := core.OpenDataset(name)
+			if err != nil {
+				continue
+			}
+			unlock := core.LockDataset(name)
+			purged, err := dataset.PurgeUploads()
+			unlock()
+			if err != nil {
+				logging.Error_Log("Cannot purge uploads of '%v': '%v'", name, err)
+			}
+			if purged > 0 {
+				logging.Info_Log("Removed [%v] expired uploads of '%v'", purged, name)
+			}
+		}
+
+		time.Sleep(interval)
+	}
+}
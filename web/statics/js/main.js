@@ -0,0 +1,215 @@
+// Yolov8 dataset - page scripts
+(function () {
+	"use strict";
+
+	// Upload every selected file by chunks with the tus protocol, interrupted
+	// upload continues from the received offset, also after the page reload
+	var form = document.getElementById("upload-form");
+	if (form) {
+		var chunkSize = 4 * 1024 * 1024;
+		var maxRetries = 10;
+		var tusHeaders = function (headers) {
+			headers["Tus-Resumable"] = "1.0.0";
+			return headers;
+		};
+		var encode = function (text) {
+			return btoa(unescape(encodeURIComponent(text)));
+		};
+		var bufferBase64 = function (buffer) {
+			return btoa(String.fromCharCode.apply(null, new Uint8Array(buffer)));
+		};
+
+		// Checksum of the whole file is verified by the server on completion, needs secure context
+		var checksum = function (file) {
+			if (!window.crypto || !window.crypto.subtle) {
+				return Promise.resolve("");
+			}
+			return file.arrayBuffer().then(function (buffer) {
+				return window.crypto.subtle.digest("SHA-256", buffer);
+			}).then(function (digest) {
+				return "sha256 " + bufferBase64(digest);
+			});
+		};
+
+		var create = function (file) {
+			return checksum(file).then(function (sum) {
+				var metadata = "filename " + encode(file.name);
+				if (sum) {
+					metadata += ",checksum " + encode(sum);
+				}
+				return fetch(form.dataset.uploads, {
+					method: "POST",
+					headers: tusHeaders({ "Upload-Length": String(file.size), "Upload-Metadata": metadata })
+				});
+			}).then(function (response) {
+				if (!response.ok) {
+					return response.json().then(function (body) {
+						throw new Error(body.error ? body.error.message : response.status);
+					});
+				}
+				return response.headers.get("Location");
+			});
+		};
+
+		// Offset of the stored upload, null when the upload is not known
+		var offsetOf = function (url) {
+			return fetch(url, { method: "HEAD", headers: tusHeaders({}) }).then(function (response) {
+				return response.ok ? Number(response.headers.get("Upload-Offset")) : null;
+			});
+		};
+
+		var upload = function (file, item) {
+			var key = "upload:" + form.dataset.uploads + ":" + file.name + ":" + file.size + ":" + file.lastModified;
+			var url = window.localStorage.getItem(key);
+			var retries = 0;
+			var show = function (text, className) {
+				item.textContent = file.name + " - " + text;
+				item.className = className || "";
+			};
+
+			var send = function (offset) {
+				show(Math.floor(offset * 100 / file.size) + "%");
+				if (offset >= file.size) {
+					window.localStorage.removeItem(key);
+					show("done", "done");
+					return;
+				}
+				fetch(url, {
+					method: "PATCH",
+					headers: tusHeaders({ "Content-Type": "application/offset+octet-stream", "Upload-Offset": String(offset) }),
+					body: file.slice(offset, offset + chunkSize)
+				}).then(function (response) {
+					if (response.status === 460 || response.status === 404) {
+						window.localStorage.removeItem(key);
+						show(response.status === 460 ? "failed (checksum)" : "failed (upload expired)", "failed");
+						return;
+					}
+					// Image with the same name was stored while the upload was received
+					if (response.status === 409) {
+						return response.json().then(function (body) {
+							if (!body.error || body.error.code !== "image_exists") {
+								throw new Error(response.status);
+							}
+							window.localStorage.removeItem(key);
+							show("failed (" + body.error.message + ")", "failed");
+						});
+					}
+					if (!response.ok) {
+						throw new Error(response.status);
+					}
+					retries = 0;
+					send(Number(response.headers.get("Upload-Offset")));
+				}).catch(resume);
+			};
+
+			// Connection is lost, continue from the offset received by the server
+			var resume = function () {
+				if (++retries > maxRetries) {
+					show("failed, select the file again to continue", "failed");
+					return;
+				}
+				show("connection lost, retry " + retries);
+				setTimeout(function () {
+					offsetOf(url).then(function (offset) {
+						if (offset === null) {
+							throw new Error("upload is not known");
+						}
+						send(offset);
+					}).catch(resume);
+				}, 1000 * retries);
+			};
+
+			var start = url ? offsetOf(url) : Promise.resolve(null);
+			start.then(function (offset) {
+				if (offset !== null) {
+					return send(offset);
+				}
+				show("starting");
+				return create(file).then(function (location) {
+					url = location;
+					window.localStorage.setItem(key, url);
+					send(0);
+				});
+			}).catch(function (error) {
+				show("failed (" + error.message + ")", "failed");
+			});
+		};
+
+		form.addEventListener("submit", function (event) {
+			event.preventDefault();
+			var input = form.querySelector("input[type=file]");
+			var status = document.getElementById("upload-status");
+			Array.prototype.forEach.call(input.files, function (file) {
+				var item = document.createElement("li");
+				status.appendChild(item);
+				upload(file, item);
+			});
+		});
+	}
+
+	// Live events of the dataset: activity feed and progress of the job
+	var activity = document.getElementById("activity");
+	if (activity && window.EventSource) {
+		var feed = activity.querySelector(".activity-feed");
+		var count = activity.querySelector(".activity-count");
+		var job = document.getElementById("job-progress");
+		var unread = 0;
+		var maxItems = 50;
+
+		var addItem = function (event) {
+			if (!event.message) {
+				return;
+			}
+			var empty = feed.querySelector(".muted");
+			if (empty) {
+				feed.removeChild(empty);
+			}
+			var item = document.createElement("li");
+			var time = document.createElement("time");
+			time.textContent = new Date(event.time).toLocaleTimeString();
+			item.appendChild(time);
+			item.appendChild(document.createTextNode(event.message));
+			if (event.type === "lint" && event.data.errors > 0 || event.type === "job" && event.data.status === "failed") {
+				item.className = "failed";
+			}
+			feed.insertBefore(item, feed.firstChild);
+			while (feed.children.length > maxItems) {
+				feed.removeChild(feed.lastChild);
+			}
+			if (!activity.open) {
+				unread++;
+				count.textContent = "(" + unread + ")";
+			}
+		};
+
+		// Progress bar follows the job, finished job reloads the page to show the result
+		// History of the events is sent first, events before the page are skipped
+		var updateJob = function (state, time) {
+			if (!job || state.id !== job.dataset.job || new Date(time) < new Date(job.dataset.loaded)) {
+				return;
+			}
+			var active = state.status === "queued" || state.status === "running";
+			job.querySelector("progress").max = state.total;
+			job.querySelector("progress").value = state.done;
+			job.querySelector(".job-status").textContent = state.status + ", " + state.done + " of " + state.total;
+			if (!active && job.dataset.status !== state.status) {
+				window.location.reload();
+			}
+		};
+
+		var source = new EventSource("/api/v1/datasets/" + activity.dataset.dataset + "/events");
+		["upload", "labels", "lint", "job"].forEach(function (type) {
+			source.addEventListener(type, function (message) {
+				var event = JSON.parse(message.data);
+				addItem(event);
+				if (type === "job") {
+					updateJob(event.data, event.time);
+				}
+			});
+		});
+		activity.addEventListener("toggle", function () {
+			unread = 0;
+			count.textContent = "";
+		});
+	}
+})();
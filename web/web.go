@@ -0,0 +1,42 @@
+/*	==========================================================================
+	Yolov8 dataset
+	Filename: web.go
+	Owner: Sergiy Safronov
+	Source : github.com/CoderSergiy/yolov8-dataset/web
+	Purpose: Keep html templates and static assets inside the binary
+
+	In the file
+		1. Embedded 'templates' and 'statics' folders
+		2. Files - choose embedded or on-disk files
+	=============================================================================
+*/
+
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+)
+
+//go:embed templates statics
+var embeddedFiles embed.FS
+
+/****************************************************************************************
+ *
+ * Function : Files
+ *
+ * Purpose : Get file system with 'templates' and 'statics' folders
+ *
+ *   Input : dev bool - true to load files from the disk for the live editing
+ *			 path string - path on the disk to the 'web' folder, used in dev mode only
+ *
+ *  Return : fs.FS - file system to read templates and assets from
+ */
+func Files(dev bool, path string) fs.FS {
+	if dev {
+		return os.DirFS(path)
+	}
+
+	return embeddedFiles
+}